@@ -0,0 +1,480 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+const productColumns = `
+	id,
+	uuid,
+	sku,
+	name,
+	price,
+	original_price,
+	category,
+	stock_count,
+	specs,
+	created_at,
+	updated_at,
+	ready_for_sale,
+	full_desc,
+	reserved_count,
+	short_desc,
+	slug
+`
+
+const variantColumns = `
+	id,
+	product_id,
+	name,
+	stock_count,
+	reserved_count,
+	sku,
+	created_at,
+	updated_at,
+	price,
+	uuid
+`
+
+// ProductDAO is the write path for products shared by staff facing tools,
+// so the same business rules apply whether a product is edited from the bot or the admin API.
+type ProductDAO struct {
+	db *sqlx.DB
+}
+
+type ProductDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewProductDAO(p ProductDAOParams) *ProductDAO {
+	return &ProductDAO{db: p.DB}
+}
+
+// GetProductBySKU retrieves a product by its SKU regardless of ready_for_sale
+func (dao *ProductDAO) GetProductBySKU(ctx context.Context, sku string) (*db.Product, error) {
+	return getProduct(ctx, dao.db, `sku = $1`, sku)
+}
+
+// GetProductByID retrieves a product by its id regardless of ready_for_sale
+func (dao *ProductDAO) GetProductByID(ctx context.Context, id int64) (*db.Product, error) {
+	return getProduct(ctx, dao.db, `id = $1`, id)
+}
+
+// GetProductByUUID retrieves a product by its uuid regardless of ready_for_sale
+func (dao *ProductDAO) GetProductByUUID(ctx context.Context, uuid string) (*db.Product, error) {
+	return getProduct(ctx, dao.db, `uuid = $1`, uuid)
+}
+
+// GetProductDetail retrieves a product with its specs and variants
+func (dao *ProductDAO) GetProductDetail(ctx context.Context, id int64) (*ProductDetail, error) {
+	product, err := dao.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]Spec, 0)
+	specsQuery := `
+		SELECT spec_name as name, spec_value as value
+		FROM product_specs
+		WHERE product_id = $1
+		ORDER BY sort_order, id
+	`
+	if err := dao.db.SelectContext(ctx, &specs, specsQuery, id); err != nil {
+		return nil, fmt.Errorf("failed to load specs of product %d: %w", id, err)
+	}
+
+	variants := make([]db.ProductVariant, 0)
+	variantsQuery := fmt.Sprintf(`SELECT %s FROM product_variants WHERE product_id = $1 ORDER BY id`, variantColumns)
+	if err := dao.db.SelectContext(ctx, &variants, variantsQuery, id); err != nil {
+		return nil, fmt.Errorf("failed to load variants of product %d: %w", id, err)
+	}
+
+	return &ProductDetail{
+		Product:  product,
+		Specs:    specs,
+		Variants: variants,
+	}, nil
+}
+
+// CreateProduct creates the product with its specs, a generated uuid and a slug from its name
+func (dao *ProductDAO) CreateProduct(ctx context.Context, params CreateProductParams) (*db.Product, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		if err := checkSKU(ctx, tx, params.SKU); err != nil {
+			return nil, err
+		}
+
+		slug, err := uniqueSlug(ctx, tx, params.Name, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		query := fmt.Sprintf(`
+			INSERT INTO products (
+				uuid,
+				sku,
+				name,
+				slug,
+				category,
+				price,
+				original_price,
+				short_desc,
+				full_desc,
+				ready_for_sale
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING %s
+		`, productColumns)
+
+		var product db.Product
+		if err := tx.GetContext(
+			ctx,
+			&product,
+			query,
+			uuid.NewString(),
+			params.SKU,
+			params.Name,
+			slug,
+			params.Category,
+			params.Price,
+			params.OriginalPrice,
+			params.ShortDesc,
+			params.FullDesc,
+			params.ReadyForSale,
+		); err != nil {
+			return nil, fmt.Errorf("failed to create product %s: %w", params.SKU, err)
+		}
+
+		if err := replaceSpecs(ctx, tx, &product, params.Specs); err != nil {
+			return nil, err
+		}
+
+		return &product, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*db.Product), nil
+}
+
+// UpdateProduct validates params against the current product and applies the non-nil fields.
+// A new name gives the product a new slug.
+func (dao *ProductDAO) UpdateProduct(ctx context.Context, id int64, params UpdateProductParams) (*db.Product, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		current, err := getProduct(ctx, tx, `id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := params.Validate(current); err != nil {
+			return nil, err
+		}
+
+		var slug *string
+		if params.Name != nil && *params.Name != current.Name {
+			s, err := uniqueSlug(ctx, tx, *params.Name, id)
+			if err != nil {
+				return nil, err
+			}
+			slug = &s
+		}
+
+		query := fmt.Sprintf(`
+			UPDATE products
+			SET
+				name = COALESCE($2, name),
+				category = COALESCE($3, category),
+				price = COALESCE($4, price),
+				original_price = COALESCE($5, original_price),
+				short_desc = COALESCE($6, short_desc),
+				full_desc = COALESCE($7, full_desc),
+				ready_for_sale = COALESCE($8, ready_for_sale),
+				slug = COALESCE($9, slug),
+				updated_at = NOW()
+			WHERE id = $1
+			RETURNING %s
+		`, productColumns)
+
+		var product db.Product
+		if err := tx.GetContext(
+			ctx,
+			&product,
+			query,
+			id,
+			params.Name,
+			params.Category,
+			params.Price,
+			params.OriginalPrice,
+			params.ShortDesc,
+			params.FullDesc,
+			params.ReadyForSale,
+			slug,
+		); err != nil {
+			return nil, fmt.Errorf("failed to update product %d: %w", id, err)
+		}
+
+		if params.Specs != nil {
+			if err := replaceSpecs(ctx, tx, &product, params.Specs); err != nil {
+				return nil, err
+			}
+		}
+
+		return &product, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*db.Product), nil
+}
+
+// DeleteProduct deletes a product that was never ordered together with its specs, variants
+// and image links. The images themselves are left to the image GC script.
+func (dao *ProductDAO) DeleteProduct(ctx context.Context, id int64) error {
+	_, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		if _, err := getProduct(ctx, tx, `id = $1 FOR UPDATE`, id); err != nil {
+			return nil, err
+		}
+
+		var ordered bool
+		orderedQuery := `SELECT EXISTS (SELECT 1 FROM order_items WHERE product_id = $1)`
+		if err := tx.GetContext(ctx, &ordered, orderedQuery, id); err != nil {
+			return nil, fmt.Errorf("failed to check orders of product %d: %w", id, err)
+		}
+		if ordered {
+			return nil, ErrHasOrders
+		}
+
+		unlinkQuery := `
+			DELETE FROM image_entities
+			WHERE (entity_type = 'product' AND entity_id = $1)
+			OR (entity_type = 'product_variant' AND entity_id IN (SELECT id FROM product_variants WHERE product_id = $1))
+		`
+		if _, err := tx.ExecContext(ctx, unlinkQuery, id); err != nil {
+			return nil, fmt.Errorf("failed to unlink images of product %d: %w", id, err)
+		}
+
+		// Specs and variants cascade.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM products WHERE id = $1`, id); err != nil {
+			return nil, fmt.Errorf("failed to delete product %d: %w", id, err)
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+// GetVariantBySKU retrieves a variant of the product by its SKU
+func (dao *ProductDAO) GetVariantBySKU(ctx context.Context, productID int64, sku string) (*db.ProductVariant, error) {
+	return getVariant(ctx, dao.db, `product_id = $1 AND sku = $2`, productID, sku)
+}
+
+// CreateVariant adds a variant with a generated uuid to the product
+func (dao *ProductDAO) CreateVariant(ctx context.Context, productID int64, params CreateVariantParams) (*db.ProductVariant, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		if _, err := getProduct(ctx, tx, `id = $1 FOR UPDATE`, productID); err != nil {
+			return nil, err
+		}
+
+		if err := checkSKU(ctx, tx, params.SKU); err != nil {
+			return nil, err
+		}
+
+		query := fmt.Sprintf(`
+			INSERT INTO product_variants (product_id, uuid, name, sku, price)
+			VALUES ($1, $2, $3, $4, COALESCE($5, (SELECT price FROM products WHERE id = $1)))
+			RETURNING %s
+		`, variantColumns)
+
+		var variant db.ProductVariant
+		if err := tx.GetContext(
+			ctx,
+			&variant,
+			query,
+			productID,
+			uuid.NewString(),
+			params.Name,
+			params.SKU,
+			params.Price,
+		); err != nil {
+			return nil, fmt.Errorf("failed to create product variant %s: %w", params.SKU, err)
+		}
+
+		if err := SyncParentStockTx(ctx, tx, productID); err != nil {
+			return nil, err
+		}
+
+		return &variant, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*db.ProductVariant), nil
+}
+
+// UpdateVariant applies the non-nil fields of params to the variant
+func (dao *ProductDAO) UpdateVariant(ctx context.Context, id int64, params UpdateVariantParams) (*db.ProductVariant, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE product_variants
+		SET
+			name = COALESCE($2, name),
+			price = COALESCE($3, price),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING %s
+	`, variantColumns)
+
+	var variant db.ProductVariant
+	if err := dao.db.GetContext(ctx, &variant, query, id, params.Name, params.Price); err != nil {
+		return nil, fmt.Errorf("failed to update product variant %d: %w", id, err)
+	}
+
+	return &variant, nil
+}
+
+// DeleteVariant deletes a variant that was never ordered with its image links, the product
+// stock is synced to its remaining variants
+func (dao *ProductDAO) DeleteVariant(ctx context.Context, id int64) error {
+	_, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		variant, err := getVariant(ctx, tx, `id = $1 FOR UPDATE`, id)
+		if err != nil {
+			return nil, err
+		}
+
+		var ordered bool
+		orderedQuery := `SELECT EXISTS (SELECT 1 FROM order_items WHERE variant_id = $1)`
+		if err := tx.GetContext(ctx, &ordered, orderedQuery, id); err != nil {
+			return nil, fmt.Errorf("failed to check orders of product variant %d: %w", id, err)
+		}
+		if ordered {
+			return nil, ErrHasOrders
+		}
+
+		unlinkQuery := `DELETE FROM image_entities WHERE entity_type = 'product_variant' AND entity_id = $1`
+		if _, err := tx.ExecContext(ctx, unlinkQuery, id); err != nil {
+			return nil, fmt.Errorf("failed to unlink images of product variant %d: %w", id, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM product_variants WHERE id = $1`, id); err != nil {
+			return nil, fmt.Errorf("failed to delete product variant %d: %w", id, err)
+		}
+
+		return nil, SyncParentStockTx(ctx, tx, variant.ProductID)
+	})
+	return err
+}
+
+func getProduct(ctx context.Context, q sqlx.QueryerContext, where string, args ...any) (*db.Product, error) {
+	query := fmt.Sprintf(`SELECT %s FROM products WHERE %s`, productColumns, where)
+
+	var product db.Product
+	if err := sqlx.GetContext(ctx, q, &product, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func getVariant(ctx context.Context, q sqlx.QueryerContext, where string, args ...any) (*db.ProductVariant, error) {
+	query := fmt.Sprintf(`SELECT %s FROM product_variants WHERE %s`, variantColumns, where)
+
+	var variant db.ProductVariant
+	if err := sqlx.GetContext(ctx, q, &variant, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
+
+// checkSKU returns ErrSKUTaken when a product or variant already uses sku
+func checkSKU(ctx context.Context, q sqlx.QueryerContext, sku string) error {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM products WHERE sku = $1) OR
+			EXISTS (SELECT 1 FROM product_variants WHERE sku = $1)
+	`
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, q, &exists, query, sku); err != nil {
+		return fmt.Errorf("failed to check sku %s: %w", sku, err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrSKUTaken, sku)
+	}
+
+	return nil
+}
+
+// replaceSpecs writes specs to product_specs and products.specs
+func replaceSpecs(ctx context.Context, tx *sqlx.Tx, product *db.Product, specs []Spec) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_specs WHERE product_id = $1`, product.ID); err != nil {
+		return fmt.Errorf("failed to clear product specs: %w", err)
+	}
+
+	specQuery := `
+		INSERT INTO product_specs (product_id, spec_name, spec_value, sort_order)
+		VALUES ($1, $2, $3, $4)
+	`
+	entries := make([]specJSON, len(specs))
+	for i, spec := range specs {
+		if _, err := tx.ExecContext(ctx, specQuery, product.ID, spec.Name, spec.Value, i); err != nil {
+			return fmt.Errorf("failed to create product spec: %w", err)
+		}
+		entries[i] = specJSON{SpecName: spec.Name, SpecValue: spec.Value}
+	}
+
+	specsJSON, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE products SET specs = $2 WHERE id = $1`, product.ID, specsJSON); err != nil {
+		return fmt.Errorf("failed to update product specs: %w", err)
+	}
+	product.Specs = specsJSON
+
+	return nil
+}
+
+// SyncParentStockTx sets the stock of a product to the sum of its variants, same as the sheet
+// sync does. Every change to a variant's stock_count goes along with it in the same transaction.
+func SyncParentStockTx(ctx context.Context, tx *sqlx.Tx, productID int64) error {
+	query := `
+		UPDATE products
+		SET
+			stock_count = (
+				SELECT COALESCE(SUM(stock_count), 0)
+				FROM product_variants
+				WHERE product_id = $1
+			),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, productID); err != nil {
+		return fmt.Errorf("failed to sync parent product stock: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package importer
+
+import "strings"
+
+// Columns of an import file, named after the fields the Google Sheet sync reads
+// (api/_inngest/sync-products and sync-product-variants) so a sheet exported with a header
+// row can be imported as is. category, specs, full_desc, original_price and images aren't in
+// the sheet. Rows with parent_sku set are variants of that product, the others are products.
+// stock_count sets the stock while stock_adjust_count adds to it, a row has one or the other.
+const (
+	ColumnParentSKU        = "parent_sku"
+	ColumnSKU              = "sku"
+	ColumnName             = "name"
+	ColumnReadyForSale     = "ready_for_sale"
+	ColumnShortDesc        = "short_desc"
+	ColumnFullDesc         = "full_desc"
+	ColumnStockCount       = "stock_count"
+	ColumnStockAdjustCount = "stock_adjust_count"
+	ColumnPrice            = "price"
+	ColumnOriginalPrice    = "original_price"
+	ColumnCategory         = "category"
+	ColumnSpecs            = "specs"
+	ColumnImages           = "images"
+)
+
+// Columns are the columns an import file may have, in order
+var Columns = []string{
+	ColumnParentSKU,
+	ColumnSKU,
+	ColumnName,
+	ColumnReadyForSale,
+	ColumnShortDesc,
+	ColumnFullDesc,
+	ColumnStockCount,
+	ColumnStockAdjustCount,
+	ColumnPrice,
+	ColumnOriginalPrice,
+	ColumnCategory,
+	ColumnSpecs,
+	ColumnImages,
+}
+
+// ExportColumns is the header written by Export. Exports hold the stock itself, so importing
+// one back sets the stock rather than adding to it.
+var ExportColumns = []string{
+	ColumnParentSKU,
+	ColumnSKU,
+	ColumnName,
+	ColumnReadyForSale,
+	ColumnShortDesc,
+	ColumnFullDesc,
+	ColumnStockCount,
+	ColumnPrice,
+	ColumnOriginalPrice,
+	ColumnCategory,
+	ColumnSpecs,
+	ColumnImages,
+}
+
+// header maps column names to their index in a row. Names are matched case-insensitively
+// and spaces or dashes count as underscores, so "Parent SKU" is parent_sku.
+type header map[string]int
+
+func parseHeader(record []string) header {
+	h := make(header)
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+		if _, seen := h[name]; !seen && name != "" {
+			h[name] = i
+		}
+	}
+	return h
+}
+
+func (h header) has(column string) bool {
+	_, ok := h[column]
+	return ok
+}
+
+// value returns the trimmed cell of column, empty when the file has no such column or the
+// row is shorter than the header
+func (h header) value(record []string, column string) string {
+	i, ok := h[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,369 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+// adjustment_type of inventory_adjustments
+const (
+	adjustmentSet       = "set"
+	adjustmentIncrement = "increment"
+)
+
+// ImportDAO compares parsed files against the catalog and applies them
+type ImportDAO struct {
+	db *sqlx.DB
+}
+
+type ImportDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewImportDAO(p ImportDAOParams) *ImportDAO {
+	return &ImportDAO{db: p.DB}
+}
+
+// Plan is the dry run of the batch, nothing is written
+func (dao *ImportDAO) Plan(ctx context.Context, batch *Batch) (*Plan, error) {
+	return plan(ctx, dao.db, batch, false)
+}
+
+// Apply plans the batch again inside one transaction, with the rows it touches locked, and
+// writes the valid rows. The catalog may have changed since the dry run, so the returned
+// plan is what was actually applied.
+func (dao *ImportDAO) Apply(ctx context.Context, batch *Batch, actor Actor) (*Plan, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		p, err := plan(ctx, tx, batch, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := write(ctx, tx, p, actor); err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*Plan), nil
+}
+
+func write(ctx context.Context, tx *sqlx.Tx, p *Plan, actor Actor) error {
+	productIDs := make(map[string]int64)
+
+	for _, change := range p.Products {
+		id, err := writeProduct(ctx, tx, change, actor)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", change.Row.Line, err)
+		}
+		productIDs[change.Row.SKU] = id
+	}
+
+	parents := make(map[int64]bool)
+	for _, change := range p.Variants {
+		if change.ProductID == 0 {
+			change.ProductID = productIDs[change.Row.ParentSKU]
+		}
+
+		if err := writeVariant(ctx, tx, change, actor); err != nil {
+			return fmt.Errorf("line %d: %w", change.Row.Line, err)
+		}
+		parents[change.ProductID] = true
+	}
+
+	for productID := range parents {
+		if err := catalog.SyncParentStockTx(ctx, tx, productID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeProduct(ctx context.Context, tx *sqlx.Tx, change ProductChange, actor Actor) (int64, error) {
+	row := change.Row
+
+	var res stockResult
+
+	if change.IsNew() {
+		query := `
+			INSERT INTO products (
+				sku,
+				name,
+				category,
+				price,
+				original_price,
+				short_desc,
+				full_desc,
+				ready_for_sale,
+				stock_count
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, false), COALESCE($9, $10))
+			RETURNING id, stock_count, 0 AS previous_stock
+		`
+		if err := tx.GetContext(
+			ctx,
+			&res,
+			query,
+			row.SKU,
+			row.Name,
+			row.Category,
+			row.Price,
+			row.OriginalPrice,
+			row.ShortDesc,
+			row.FullDesc,
+			row.ReadyForSale,
+			row.StockCount,
+			row.StockAdjustCount,
+		); err != nil {
+			return 0, fmt.Errorf("failed to create product %s: %w", row.SKU, err)
+		}
+	} else {
+		// Joining the row to itself returns the stock from before the update.
+		query := `
+			UPDATE products p
+			SET
+				name = COALESCE($2, p.name),
+				category = COALESCE($3, p.category),
+				price = COALESCE($4, p.price),
+				original_price = COALESCE($5, p.original_price),
+				short_desc = COALESCE($6, p.short_desc),
+				full_desc = COALESCE($7, p.full_desc),
+				ready_for_sale = COALESCE($8, p.ready_for_sale),
+				stock_count = COALESCE($9, p.stock_count + $10),
+				updated_at = NOW()
+			FROM products previous
+			WHERE p.id = $1 AND previous.id = p.id
+			RETURNING p.id, p.stock_count, previous.stock_count AS previous_stock
+		`
+		if err := tx.GetContext(
+			ctx,
+			&res,
+			query,
+			change.ID,
+			row.Name,
+			row.Category,
+			row.Price,
+			row.OriginalPrice,
+			row.ShortDesc,
+			row.FullDesc,
+			row.ReadyForSale,
+			row.StockCount,
+			row.StockAdjustCount,
+		); err != nil {
+			return 0, fmt.Errorf("failed to update product %s: %w", row.SKU, err)
+		}
+	}
+
+	if row.Specs != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM product_specs WHERE product_id = $1`, res.ID); err != nil {
+			return 0, fmt.Errorf("failed to clear product specs: %w", err)
+		}
+
+		specQuery := `
+			INSERT INTO product_specs (product_id, spec_name, spec_value, sort_order)
+			VALUES ($1, $2, $3, $4)
+		`
+		for i, spec := range row.Specs {
+			if _, err := tx.ExecContext(ctx, specQuery, res.ID, spec.Name, spec.Value, i); err != nil {
+				return 0, fmt.Errorf("failed to create product spec: %w", err)
+			}
+		}
+	}
+
+	if row.Images != nil {
+		if err := replaceImages(ctx, tx, db.EntityTypeProduct, res.ID, row.SKU, row.Images); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := logStockChange(ctx, tx, db.EntityTypeProduct, row.SKU, row.StockCount, res, actor); err != nil {
+		return 0, err
+	}
+
+	return res.ID, nil
+}
+
+func writeVariant(ctx context.Context, tx *sqlx.Tx, change VariantChange, actor Actor) error {
+	row := change.Row
+
+	var res stockResult
+
+	if change.IsNew() {
+		// Without a price the variant sells at the parent's price.
+		query := `
+			INSERT INTO product_variants (product_id, name, sku, price, stock_count)
+			VALUES ($1, $2, $3, COALESCE($4, (SELECT price FROM products WHERE id = $1)), COALESCE($5, $6))
+			RETURNING id, stock_count, 0 AS previous_stock
+		`
+		if err := tx.GetContext(
+			ctx,
+			&res,
+			query,
+			change.ProductID,
+			row.Name,
+			row.SKU,
+			row.Price,
+			row.StockCount,
+			row.StockAdjustCount,
+		); err != nil {
+			return fmt.Errorf("failed to create product variant %s: %w", row.SKU, err)
+		}
+	} else {
+		query := `
+			UPDATE product_variants pv
+			SET
+				name = COALESCE($2, pv.name),
+				price = COALESCE($3, pv.price),
+				stock_count = COALESCE($4, pv.stock_count + $5),
+				updated_at = NOW()
+			FROM product_variants previous
+			WHERE pv.id = $1 AND previous.id = pv.id
+			RETURNING pv.id, pv.stock_count, previous.stock_count AS previous_stock
+		`
+		if err := tx.GetContext(
+			ctx,
+			&res,
+			query,
+			change.ID,
+			row.Name,
+			row.Price,
+			row.StockCount,
+			row.StockAdjustCount,
+		); err != nil {
+			return fmt.Errorf("failed to update product variant %s: %w", row.SKU, err)
+		}
+	}
+
+	if row.Images != nil {
+		if err := replaceImages(ctx, tx, db.EntityTypeProductVariant, res.ID, row.SKU, row.Images); err != nil {
+			return err
+		}
+	}
+
+	return logStockChange(ctx, tx, db.EntityTypeProductVariant, row.SKU, row.StockCount, res, actor)
+}
+
+// stockResult is a written product or variant with its stock before and after the write
+type stockResult struct {
+	ID            int64 `json:"id"`
+	StockCount    int32 `json:"stock_count"`
+	PreviousStock int32 `json:"previous_stock"`
+}
+
+// logStockChange records the stock a row changed, as a set when the row had a stock_count
+// and as an increment when it had an adjustment
+func logStockChange(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	entityType db.EntityType,
+	sku string,
+	stockCount *int32,
+	res stockResult,
+	actor Actor,
+) error {
+	if res.StockCount == res.PreviousStock {
+		return nil
+	}
+
+	if stockCount != nil {
+		return logAdjustment(ctx, tx, entityType, res.ID, sku, adjustmentSet, res.StockCount, res, actor)
+	}
+	return logAdjustment(ctx, tx, entityType, res.ID, sku, adjustmentIncrement, res.StockCount-res.PreviousStock, res, actor)
+}
+
+// replaceImages links the entity to the image URLs in order, the first one primary. Images
+// already stored under a URL are reused, so importing an export adds no images rows.
+func replaceImages(ctx context.Context, tx *sqlx.Tx, entityType db.EntityType, entityID int64, sku string, urls []string) error {
+	deleteQuery := `DELETE FROM image_entities WHERE entity_type = $1 AND entity_id = $2`
+	if _, err := tx.ExecContext(ctx, deleteQuery, entityType, entityID); err != nil {
+		return fmt.Errorf("failed to clear images of %s: %w", sku, err)
+	}
+
+	findQuery := `SELECT id FROM images WHERE url = $1 ORDER BY id LIMIT 1`
+	insertQuery := `INSERT INTO images (url) VALUES ($1) RETURNING id`
+	linkQuery := `
+		INSERT INTO image_entities (entity_id, image_id, alt_text, is_primary, sort_order, entity_type)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	altText := imageingest.AltText(imageingest.Entity{Type: entityType, ID: entityID, SKU: sku})
+
+	for i, url := range urls {
+		var imageID int64
+		err := tx.GetContext(ctx, &imageID, findQuery, url)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.GetContext(ctx, &imageID, insertQuery, url)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store image %s: %w", url, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, linkQuery, entityID, imageID, altText, i == 0, i, entityType); err != nil {
+			return fmt.Errorf("failed to link image %s to %s: %w", url, sku, err)
+		}
+	}
+
+	return nil
+}
+
+// logAdjustment records a stock change in inventory_adjustments, like /stock does
+func logAdjustment(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	entityType db.EntityType,
+	entityID int64,
+	sku string,
+	adjustmentType string,
+	quantity int32,
+	res stockResult,
+	actor Actor,
+) error {
+	query := `
+		INSERT INTO inventory_adjustments (
+			entity_type,
+			entity_id,
+			sku,
+			adjustment_type,
+			quantity,
+			previous_stock,
+			new_stock,
+			source,
+			actor_telegram_id,
+			actor_username
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9::bigint, 0), NULLIF($10, ''))
+	`
+	if _, err := tx.ExecContext(
+		ctx,
+		query,
+		entityType,
+		entityID,
+		sku,
+		adjustmentType,
+		quantity,
+		res.PreviousStock,
+		res.StockCount,
+		actor.Source,
+		actor.TelegramID,
+		actor.Username,
+	); err != nil {
+		return fmt.Errorf("failed to record inventory adjustment: %w", err)
+	}
+
+	return nil
+}
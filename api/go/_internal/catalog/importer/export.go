@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Export returns the whole catalog as records under the ExportColumns header, each product
+// followed by its variants. Importing them back leaves the catalog as it is.
+func (dao *ImportDAO) Export(ctx context.Context) ([][]string, error) {
+	var products []existingProduct
+	productQuery := `
+		SELECT
+			p.id,
+			p.sku,
+			p.name,
+			p.category,
+			p.price::float8 AS price,
+			p.original_price::float8 AS original_price,
+			p.short_desc,
+			p.full_desc,
+			p.ready_for_sale,
+			p.stock_count,
+			p.reserved_count,
+			0 AS variant_count
+		FROM products p
+		ORDER BY p.sku
+	`
+	if err := sqlx.SelectContext(ctx, dao.db, &products, productQuery); err != nil {
+		return nil, fmt.Errorf("failed to load products: %w", err)
+	}
+
+	var variants []existingVariant
+	variantQuery := `
+		SELECT
+			pv.id,
+			pv.product_id,
+			p.sku AS parent_sku,
+			pv.sku,
+			pv.name,
+			pv.price::float8 AS price,
+			pv.stock_count,
+			pv.reserved_count
+		FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id
+		ORDER BY pv.product_id, pv.id
+	`
+	if err := sqlx.SelectContext(ctx, dao.db, &variants, variantQuery); err != nil {
+		return nil, fmt.Errorf("failed to load product variants: %w", err)
+	}
+
+	productIDs := make([]int64, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+	}
+	variantIDs := make([]int64, 0, len(variants))
+	variantsOf := make(map[int64][]existingVariant)
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+		variantsOf[v.ProductID] = append(variantsOf[v.ProductID], v)
+	}
+
+	specs, err := loadSpecs(ctx, dao.db, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productImages, err := loadImages(ctx, dao.db, db.EntityTypeProduct, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	variantImages, err := loadImages(ctx, dao.db, db.EntityTypeProductVariant, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([][]string, 0, 1+len(products)+len(variants))
+	records = append(records, ExportColumns)
+
+	for _, p := range products {
+		records = append(records, exportRecord(map[string]string{
+			ColumnSKU:           p.SKU,
+			ColumnName:          p.Name,
+			ColumnReadyForSale:  formatFlag(p.ReadyForSale),
+			ColumnShortDesc:     p.ShortDesc.String,
+			ColumnFullDesc:      p.FullDesc.String,
+			ColumnStockCount:    formatStock(p.StockCount),
+			ColumnPrice:         formatPrice(p.Price),
+			ColumnOriginalPrice: formatOptionalPrice(p.OriginalPrice),
+			ColumnCategory:      p.Category.String,
+			ColumnSpecs:         FormatSpecs(specs[p.ID]),
+			ColumnImages:        FormatImages(productImages[p.ID]),
+		}))
+
+		for _, v := range variantsOf[p.ID] {
+			records = append(records, exportRecord(map[string]string{
+				ColumnParentSKU:  p.SKU,
+				ColumnSKU:        v.SKU,
+				ColumnName:       v.Name,
+				ColumnStockCount: formatStock(v.StockCount),
+				ColumnPrice:      formatPrice(v.Price),
+				ColumnImages:     FormatImages(variantImages[v.ID]),
+			}))
+		}
+	}
+
+	return records, nil
+}
+
+// exportRecord lays the cells out in ExportColumns order, missing columns are blank
+func exportRecord(cells map[string]string) []string {
+	record := make([]string, len(ExportColumns))
+	for i, column := range ExportColumns {
+		record[i] = cells[column]
+	}
+	return record
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("only .csv and .xlsx files can be imported")
+	ErrEmptyFile         = errors.New("file has no header row")
+	ErrMissingColumn     = errors.New("missing column")
+	ErrTooManyRows       = fmt.Errorf("file has more than %d rows", MaxRows)
+)
+
+// Row errors, the row is left out of the import and reported with the error
+var (
+	ErrMissingSKU          = errors.New("sku is required")
+	ErrInvalidSKU          = errors.New("sku must not contain spaces or exceed 100 characters")
+	ErrDuplicateSKU        = errors.New("sku appears more than once in the file")
+	ErrMissingName         = errors.New("name is required for new products and variants")
+	ErrMissingPrice        = errors.New("price is required for new products")
+	ErrInvalidPrice        = errors.New("price must be a number greater than 0")
+	ErrInvalidReadyForSale = errors.New("ready_for_sale must be Y or N")
+	ErrInvalidStockAdjust  = errors.New("stock_adjust_count must be a whole number")
+	ErrInvalidStockCount   = errors.New("stock_count must be a whole number of at least 0")
+	ErrStockConflict       = errors.New("stock_count and stock_adjust_count can't both be set")
+	ErrInvalidImage        = errors.New("images must be http or https URLs")
+	ErrInvalidSpec         = errors.New(`specs must be "name: value" entries`)
+	ErrTooLong             = errors.New("value is longer than the column allows")
+	ErrParentNotFound      = errors.New("parent product not found")
+	ErrParentInvalid       = errors.New("parent product row is invalid")
+	ErrSKUIsVariant        = errors.New("sku belongs to a product variant")
+	ErrSKUIsProduct        = errors.New("sku belongs to a product")
+	ErrParentMismatch      = errors.New("variant belongs to another product")
+	ErrStockFromVariants   = errors.New("stock of a product with variants is the sum of its variants")
+	ErrStockBelowReserved  = errors.New("stock would drop below zero or the reserved count")
+)
+
+// MaxRows caps the data rows of a file, a whole import runs in one transaction
+const MaxRows = 2000
+
+// Spec is a "name: value" product spec
+type Spec struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ProductRow is a product parsed from a file. Nil fields were left blank and keep their
+// current value on existing products. Specs and Images, when given, replace the product's.
+type ProductRow struct {
+	Line             int      `json:"line"`
+	SKU              string   `json:"sku"`
+	Name             *string  `json:"name,omitempty"`
+	Category         *string  `json:"category,omitempty"`
+	Price            *float64 `json:"price,omitempty"`
+	OriginalPrice    *float64 `json:"original_price,omitempty"`
+	ShortDesc        *string  `json:"short_desc,omitempty"`
+	FullDesc         *string  `json:"full_desc,omitempty"`
+	ReadyForSale     *bool    `json:"ready_for_sale,omitempty"`
+	StockCount       *int32   `json:"stock_count,omitempty"`
+	StockAdjustCount int32    `json:"stock_adjust_count"`
+	Specs            []Spec   `json:"specs,omitempty"`
+	Images           []string `json:"images,omitempty"`
+}
+
+// VariantRow is a product variant parsed from a file. A blank or zero price means the parent's
+// price for new variants, as in the sheet sync. Images, when given, replace the variant's.
+type VariantRow struct {
+	Line             int      `json:"line"`
+	ParentSKU        string   `json:"parent_sku"`
+	SKU              string   `json:"sku"`
+	Name             *string  `json:"name,omitempty"`
+	Price            *float64 `json:"price,omitempty"`
+	StockCount       *int32   `json:"stock_count,omitempty"`
+	StockAdjustCount int32    `json:"stock_adjust_count"`
+	Images           []string `json:"images,omitempty"`
+}
+
+// InvalidRow is a row left out of the import
+type InvalidRow struct {
+	Line int
+	SKU  string
+	Err  error
+}
+
+// Batch is a parsed file. Products and Variants passed the checks that don't need the
+// database; Batch is what gets stored between the dry run and the confirmation.
+type Batch struct {
+	Products []ProductRow `json:"products"`
+	Variants []VariantRow `json:"variants"`
+	Invalid  []InvalidRow `json:"-"`
+}
+
+// FieldChange is a field of an existing product or variant the import changes
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// ProductChange is a product row compared against the catalog. ID is 0 for new products.
+type ProductChange struct {
+	Row     ProductRow
+	ID      int64
+	Changes []FieldChange
+}
+
+func (c ProductChange) IsNew() bool {
+	return c.ID == 0
+}
+
+// VariantChange is a variant row compared against the catalog. ID is 0 for new variants and
+// ProductID is 0 when the parent is created by the same import.
+type VariantChange struct {
+	Row       VariantRow
+	ID        int64
+	ProductID int64
+	Changes   []FieldChange
+}
+
+func (c VariantChange) IsNew() bool {
+	return c.ID == 0
+}
+
+// Plan is the dry run of a batch: what would be created, what would change and which rows
+// are invalid. Existing rows without changes are counted in Unchanged.
+type Plan struct {
+	Products  []ProductChange
+	Variants  []VariantChange
+	Invalid   []InvalidRow
+	Unchanged int
+}
+
+// Counts returns how many products and variants the plan creates and updates
+func (p *Plan) Counts() (created, updated int) {
+	for _, c := range p.Products {
+		if c.IsNew() {
+			created++
+		} else {
+			updated++
+		}
+	}
+	for _, c := range p.Variants {
+		if c.IsNew() {
+			created++
+		} else {
+			updated++
+		}
+	}
+	return created, updated
+}
+
+// Empty reports whether applying the plan would write nothing
+func (p *Plan) Empty() bool {
+	return len(p.Products) == 0 && len(p.Variants) == 0
+}
+
+// Actor is who runs the import, recorded with the stock changes in inventory_adjustments
+type Actor struct {
+	Source     string
+	TelegramID int64
+	Username   string
+}
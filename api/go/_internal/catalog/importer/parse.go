@@ -0,0 +1,323 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column sizes of products, product_variants and product_specs
+const (
+	maxSKULength       = 100
+	maxNameLength      = 255
+	maxCategoryLength  = 100
+	maxSpecNameLength  = 100
+	maxSpecValueLength = 255
+)
+
+// Parse turns the records of a file into a batch. The first non-empty record is the header;
+// it must have sku and name columns. Line numbers are the 1-based record positions, the row
+// numbers a spreadsheet shows.
+func Parse(records [][]string) (*Batch, error) {
+	start := 0
+	for start < len(records) && blank(records[start]) {
+		start++
+	}
+	if start == len(records) {
+		return nil, ErrEmptyFile
+	}
+
+	h := parseHeader(records[start])
+	for _, column := range []string{ColumnSKU, ColumnName} {
+		if !h.has(column) {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, column)
+		}
+	}
+
+	rows := records[start+1:]
+	if len(rows) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+
+	batch := &Batch{
+		Products: []ProductRow{},
+		Variants: []VariantRow{},
+	}
+	seen := make(map[string]bool)
+
+	for i, record := range rows {
+		if blank(record) {
+			continue
+		}
+		line := start + i + 2
+		sku := h.value(record, ColumnSKU)
+
+		if err := validateSKU(sku); err != nil {
+			batch.Invalid = append(batch.Invalid, InvalidRow{Line: line, SKU: sku, Err: err})
+			continue
+		}
+		if seen[sku] {
+			batch.Invalid = append(batch.Invalid, InvalidRow{Line: line, SKU: sku, Err: ErrDuplicateSKU})
+			continue
+		}
+		seen[sku] = true
+
+		if h.value(record, ColumnParentSKU) != "" {
+			row, err := parseVariantRow(h, record, line)
+			if err != nil {
+				batch.Invalid = append(batch.Invalid, InvalidRow{Line: line, SKU: sku, Err: err})
+				continue
+			}
+			batch.Variants = append(batch.Variants, *row)
+			continue
+		}
+
+		row, err := parseProductRow(h, record, line)
+		if err != nil {
+			batch.Invalid = append(batch.Invalid, InvalidRow{Line: line, SKU: sku, Err: err})
+			continue
+		}
+		batch.Products = append(batch.Products, *row)
+	}
+
+	return batch, nil
+}
+
+func parseProductRow(h header, record []string, line int) (*ProductRow, error) {
+	row := &ProductRow{
+		Line:      line,
+		SKU:       h.value(record, ColumnSKU),
+		Name:      optional(h.value(record, ColumnName)),
+		Category:  optional(h.value(record, ColumnCategory)),
+		ShortDesc: optional(h.value(record, ColumnShortDesc)),
+		FullDesc:  optional(h.value(record, ColumnFullDesc)),
+	}
+
+	if tooLong(row.Name, maxNameLength) || tooLong(row.Category, maxCategoryLength) {
+		return nil, ErrTooLong
+	}
+
+	price, err := parsePrice(h.value(record, ColumnPrice))
+	if err != nil {
+		return nil, err
+	}
+	if price != nil && *price == 0 {
+		return nil, ErrInvalidPrice
+	}
+	row.Price = price
+
+	originalPrice, err := parsePrice(h.value(record, ColumnOriginalPrice))
+	if err != nil {
+		return nil, err
+	}
+	if originalPrice != nil && *originalPrice == 0 {
+		return nil, ErrInvalidPrice
+	}
+	row.OriginalPrice = originalPrice
+
+	if row.ReadyForSale, err = parseReadyForSale(h.value(record, ColumnReadyForSale)); err != nil {
+		return nil, err
+	}
+
+	if row.StockCount, row.StockAdjustCount, err = parseStock(h, record); err != nil {
+		return nil, err
+	}
+
+	if cell := h.value(record, ColumnSpecs); cell != "" {
+		if row.Specs, err = ParseSpecs(cell); err != nil {
+			return nil, err
+		}
+	}
+
+	if row.Images, err = parseImages(h.value(record, ColumnImages)); err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+func parseVariantRow(h header, record []string, line int) (*VariantRow, error) {
+	row := &VariantRow{
+		Line:      line,
+		ParentSKU: h.value(record, ColumnParentSKU),
+		SKU:       h.value(record, ColumnSKU),
+		Name:      optional(h.value(record, ColumnName)),
+	}
+
+	if err := validateSKU(row.ParentSKU); err != nil {
+		return nil, err
+	}
+
+	if tooLong(row.Name, maxNameLength) {
+		return nil, ErrTooLong
+	}
+
+	price, err := parsePrice(h.value(record, ColumnPrice))
+	if err != nil {
+		return nil, err
+	}
+	// Zero falls back to the parent's price, same as leaving it blank.
+	if price != nil && *price > 0 {
+		row.Price = price
+	}
+
+	if row.StockCount, row.StockAdjustCount, err = parseStock(h, record); err != nil {
+		return nil, err
+	}
+
+	if row.Images, err = parseImages(h.value(record, ColumnImages)); err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+// ParseSpecs splits a specs cell into "name: value" entries separated by new lines or
+// semicolons. Half-width and full-width colons and semicolons are accepted.
+func ParseSpecs(cell string) ([]Spec, error) {
+	cell = strings.NewReplacer("；", "\n", ";", "\n", "：", ":").Replace(cell)
+
+	specs := []Spec{}
+	for _, entry := range strings.Split(cell, "\n") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, ErrInvalidSpec
+		}
+		if tooLong(&name, maxSpecNameLength) || tooLong(&value, maxSpecValueLength) {
+			return nil, ErrTooLong
+		}
+
+		specs = upsertSpec(specs, Spec{Name: name, Value: value})
+	}
+
+	return specs, nil
+}
+
+// upsertSpec replaces the spec with the same name, spec names are unique per product
+func upsertSpec(specs []Spec, spec Spec) []Spec {
+	for i := range specs {
+		if specs[i].Name == spec.Name {
+			specs[i] = spec
+			return specs
+		}
+	}
+	return append(specs, spec)
+}
+
+func validateSKU(sku string) error {
+	if sku == "" {
+		return ErrMissingSKU
+	}
+	if len(sku) > maxSKULength || strings.ContainsAny(sku, " \t\n") {
+		return ErrInvalidSKU
+	}
+	return nil
+}
+
+func parsePrice(cell string) (*float64, error) {
+	if cell == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.ParseFloat(strings.ReplaceAll(cell, ",", ""), 64)
+	if err != nil || price < 0 {
+		return nil, ErrInvalidPrice
+	}
+	return &price, nil
+}
+
+// parseReadyForSale reads the Y/N flag of the sheet. Blank keeps the current value.
+func parseReadyForSale(cell string) (*bool, error) {
+	switch strings.ToUpper(cell) {
+	case "":
+		return nil, nil
+	case "Y":
+		v := true
+		return &v, nil
+	case "N":
+		v := false
+		return &v, nil
+	default:
+		return nil, ErrInvalidReadyForSale
+	}
+}
+
+// parseStock reads the stock of a row, either the stock_count to set or the
+// stock_adjust_count to add
+func parseStock(h header, record []string) (*int32, int32, error) {
+	adjust, err := parseStockAdjust(h.value(record, ColumnStockAdjustCount))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cell := h.value(record, ColumnStockCount)
+	if cell == "" {
+		return nil, adjust, nil
+	}
+	if h.value(record, ColumnStockAdjustCount) != "" {
+		return nil, 0, ErrStockConflict
+	}
+
+	n, err := strconv.ParseInt(cell, 10, 32)
+	if err != nil || n < 0 {
+		return nil, 0, ErrInvalidStockCount
+	}
+	stock := int32(n)
+	return &stock, 0, nil
+}
+
+// parseImages splits an images cell into URLs separated by spaces or new lines. The first
+// image is the primary one.
+func parseImages(cell string) ([]string, error) {
+	if cell == "" {
+		return nil, nil
+	}
+
+	urls := strings.Fields(cell)
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, ErrInvalidImage
+		}
+	}
+	return urls, nil
+}
+
+func parseStockAdjust(cell string) (int32, error) {
+	if cell == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(cell, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidStockAdjust
+	}
+	return int32(n), nil
+}
+
+func tooLong(v *string, max int) bool {
+	return v != nil && utf8.RuneCountInString(*v) > max
+}
+
+func optional(cell string) *string {
+	if cell == "" {
+		return nil
+	}
+	return &cell
+}
+
+func blank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,303 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testHeader = []string{"parent_sku", "sku", "name", "price", "ready_for_sale", "stock_adjust_count", "specs"}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestParseRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  []string
+		wantErr error
+		product *ProductRow
+		variant *VariantRow
+	}{
+		{
+			name:    "missing sku",
+			record:  []string{"", "", "T-shirt", "499", "", "", ""},
+			wantErr: ErrMissingSKU,
+		},
+		{
+			name:    "sku with a space",
+			record:  []string{"", "TEE 01", "T-shirt", "499", "", "", ""},
+			wantErr: ErrInvalidSKU,
+		},
+		{
+			name:    "sku too long",
+			record:  []string{"", strings.Repeat("A", maxSKULength+1), "T-shirt", "", "", "", ""},
+			wantErr: ErrInvalidSKU,
+		},
+		{
+			name:    "price not a number",
+			record:  []string{"", "TEE", "T-shirt", "free", "", "", ""},
+			wantErr: ErrInvalidPrice,
+		},
+		{
+			name:    "negative price",
+			record:  []string{"", "TEE", "T-shirt", "-1", "", "", ""},
+			wantErr: ErrInvalidPrice,
+		},
+		{
+			name:    "zero product price",
+			record:  []string{"", "TEE", "T-shirt", "0", "", "", ""},
+			wantErr: ErrInvalidPrice,
+		},
+		{
+			name:    "ready_for_sale not Y or N",
+			record:  []string{"", "TEE", "T-shirt", "499", "yes", "", ""},
+			wantErr: ErrInvalidReadyForSale,
+		},
+		{
+			name:    "fractional stock adjustment",
+			record:  []string{"", "TEE", "T-shirt", "499", "", "1.5", ""},
+			wantErr: ErrInvalidStockAdjust,
+		},
+		{
+			name:    "spec without a value",
+			record:  []string{"", "TEE", "T-shirt", "499", "", "", "material"},
+			wantErr: ErrInvalidSpec,
+		},
+		{
+			name:    "name too long",
+			record:  []string{"", "TEE", strings.Repeat("長", maxNameLength+1), "499", "", "", ""},
+			wantErr: ErrTooLong,
+		},
+		{
+			name:    "variant with a bad parent sku",
+			record:  []string{"TEE 01", "TEE-M", "M", "", "", "", ""},
+			wantErr: ErrInvalidSKU,
+		},
+		{
+			name:   "product",
+			record: []string{"", "TEE", "T-shirt", "1,299", "n", "+5", "material：cotton；origin: TW"},
+			product: &ProductRow{
+				Line:             2,
+				SKU:              "TEE",
+				Name:             ptr("T-shirt"),
+				Price:            ptr(1299.0),
+				ReadyForSale:     ptr(false),
+				StockAdjustCount: 5,
+				Specs:            []Spec{{Name: "material", Value: "cotton"}, {Name: "origin", Value: "TW"}},
+			},
+		},
+		{
+			name:    "product with only a stock adjustment",
+			record:  []string{"", "TEE", "", "", "", "-3", ""},
+			product: &ProductRow{Line: 2, SKU: "TEE", StockAdjustCount: -3},
+		},
+		{
+			name:    "variant with a zero price uses the parent price",
+			record:  []string{"TEE", "TEE-M", "M", "0", "", "2", ""},
+			variant: &VariantRow{Line: 2, ParentSKU: "TEE", SKU: "TEE-M", Name: ptr("M"), StockAdjustCount: 2},
+		},
+		{
+			name:    "variant",
+			record:  []string{"TEE", "TEE-L", "L", "549", "", "", ""},
+			variant: &VariantRow{Line: 2, ParentSKU: "TEE", SKU: "TEE-L", Name: ptr("L"), Price: ptr(549.0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch, err := Parse([][]string{testHeader, tt.record})
+			require.NoError(t, err)
+
+			if tt.wantErr != nil {
+				require.Empty(t, batch.Products)
+				require.Empty(t, batch.Variants)
+				require.Len(t, batch.Invalid, 1)
+				require.Equal(t, 2, batch.Invalid[0].Line)
+				require.ErrorIs(t, batch.Invalid[0].Err, tt.wantErr)
+				return
+			}
+
+			require.Empty(t, batch.Invalid)
+			if tt.product != nil {
+				require.Equal(t, []ProductRow{*tt.product}, batch.Products)
+			}
+			if tt.variant != nil {
+				require.Equal(t, []VariantRow{*tt.variant}, batch.Variants)
+			}
+		})
+	}
+}
+
+func TestParseExportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  map[string]string
+		wantErr error
+		product *ProductRow
+		variant *VariantRow
+	}{
+		{
+			name:    "negative stock count",
+			record:  map[string]string{ColumnSKU: "TEE", ColumnStockCount: "-1"},
+			wantErr: ErrInvalidStockCount,
+		},
+		{
+			name:    "stock count and adjustment",
+			record:  map[string]string{ColumnSKU: "TEE", ColumnStockCount: "3", ColumnStockAdjustCount: "1"},
+			wantErr: ErrStockConflict,
+		},
+		{
+			name:    "zero original price",
+			record:  map[string]string{ColumnSKU: "TEE", ColumnOriginalPrice: "0"},
+			wantErr: ErrInvalidPrice,
+		},
+		{
+			name:    "image that isn't a url",
+			record:  map[string]string{ColumnSKU: "TEE", ColumnImages: "https://cdn.example.com/tee.jpg tee.jpg"},
+			wantErr: ErrInvalidImage,
+		},
+		{
+			name:    "variant image with another scheme",
+			record:  map[string]string{ColumnParentSKU: "TEE", ColumnSKU: "TEE-M", ColumnImages: "ftp://cdn.example.com/m.jpg"},
+			wantErr: ErrInvalidImage,
+		},
+		{
+			name: "product",
+			record: map[string]string{
+				ColumnSKU:           "TEE",
+				ColumnStockCount:    "0",
+				ColumnOriginalPrice: "599",
+				ColumnFullDesc:      "Soft cotton",
+				ColumnImages:        "https://cdn.example.com/tee-1.jpg\nhttps://cdn.example.com/tee-2.jpg",
+			},
+			product: &ProductRow{
+				Line:          2,
+				SKU:           "TEE",
+				OriginalPrice: ptr(599.0),
+				FullDesc:      ptr("Soft cotton"),
+				StockCount:    ptr[int32](0),
+				Images:        []string{"https://cdn.example.com/tee-1.jpg", "https://cdn.example.com/tee-2.jpg"},
+			},
+		},
+		{
+			name: "variant ignores the product columns",
+			record: map[string]string{
+				ColumnParentSKU:     "TEE",
+				ColumnSKU:           "TEE-M",
+				ColumnStockCount:    "7",
+				ColumnOriginalPrice: "599",
+				ColumnFullDesc:      "Soft cotton",
+				ColumnImages:        "https://cdn.example.com/tee-m.jpg",
+			},
+			variant: &VariantRow{
+				Line:       2,
+				ParentSKU:  "TEE",
+				SKU:        "TEE-M",
+				StockCount: ptr[int32](7),
+				Images:     []string{"https://cdn.example.com/tee-m.jpg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := make([]string, len(Columns))
+			for i, column := range Columns {
+				record[i] = tt.record[column]
+			}
+
+			batch, err := Parse([][]string{Columns, record})
+			require.NoError(t, err)
+
+			if tt.wantErr != nil {
+				require.Len(t, batch.Invalid, 1)
+				require.ErrorIs(t, batch.Invalid[0].Err, tt.wantErr)
+				return
+			}
+
+			require.Empty(t, batch.Invalid)
+			if tt.product != nil {
+				require.Equal(t, []ProductRow{*tt.product}, batch.Products)
+			}
+			if tt.variant != nil {
+				require.Equal(t, []VariantRow{*tt.variant}, batch.Variants)
+			}
+		})
+	}
+}
+
+func TestParseDuplicateSKU(t *testing.T) {
+	batch, err := Parse([][]string{
+		testHeader,
+		{"", "TEE", "T-shirt", "499", "", "", ""},
+		{"TEE", "TEE-M", "M", "", "", "", ""},
+		{"", "TEE", "T-shirt again", "599", "", "", ""},
+		{"TEE", "TEE-M", "M again", "", "", "", ""},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, batch.Products, 1)
+	require.Equal(t, "T-shirt", *batch.Products[0].Name)
+	require.Len(t, batch.Variants, 1)
+	require.Equal(t, "M", *batch.Variants[0].Name)
+
+	require.Equal(t, []InvalidRow{
+		{Line: 4, SKU: "TEE", Err: ErrDuplicateSKU},
+		{Line: 5, SKU: "TEE-M", Err: ErrDuplicateSKU},
+	}, batch.Invalid)
+}
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		wantErr error
+	}{
+		{
+			name:    "no records",
+			records: nil,
+			wantErr: ErrEmptyFile,
+		},
+		{
+			name:    "blank records only",
+			records: [][]string{{"", " "}, {}},
+			wantErr: ErrEmptyFile,
+		},
+		{
+			name:    "no name column",
+			records: [][]string{{"sku", "price"}, {"TEE", "499"}},
+			wantErr: ErrMissingColumn,
+		},
+		{
+			name:    "too many rows",
+			records: append([][]string{{"sku", "name"}}, make([][]string, MaxRows+1)...),
+			wantErr: ErrTooManyRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.records)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	// Blank records before the header and between rows keep the spreadsheet row numbers.
+	batch, err := Parse([][]string{
+		{"", ""},
+		{"Parent SKU", "SKU", "Name"},
+		{"", "TEE", "T-shirt"},
+		{"", ""},
+		{"TEE", "", "M"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, batch.Products, 1)
+	require.Equal(t, 3, batch.Products[0].Line)
+	require.Equal(t, []InvalidRow{{Line: 5, SKU: "", Err: ErrMissingSKU}}, batch.Invalid)
+}
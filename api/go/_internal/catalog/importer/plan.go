@@ -0,0 +1,476 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FieldStock names the stock change in FieldChange, the diff shows the resulting stock for
+// adjustments too
+const FieldStock = "stock_count"
+
+type existingProduct struct {
+	ID            int64           `json:"id"`
+	SKU           string          `json:"sku"`
+	Name          string          `json:"name"`
+	Category      sql.NullString  `json:"category"`
+	Price         float64         `json:"price"`
+	OriginalPrice sql.NullFloat64 `json:"original_price"`
+	ShortDesc     sql.NullString  `json:"short_desc"`
+	FullDesc      sql.NullString  `json:"full_desc"`
+	ReadyForSale  bool            `json:"ready_for_sale"`
+	StockCount    int32           `json:"stock_count"`
+	ReservedCount int32           `json:"reserved_count"`
+	VariantCount  int64           `json:"variant_count"`
+}
+
+type existingVariant struct {
+	ID            int64   `json:"id"`
+	ProductID     int64   `json:"product_id"`
+	ParentSKU     string  `json:"parent_sku"`
+	SKU           string  `json:"sku"`
+	Name          string  `json:"name"`
+	Price         float64 `json:"price"`
+	StockCount    int32   `json:"stock_count"`
+	ReservedCount int32   `json:"reserved_count"`
+}
+
+type existingSpec struct {
+	ProductID int64  `json:"product_id"`
+	SpecName  string `json:"spec_name"`
+	SpecValue string `json:"spec_value"`
+}
+
+type existingImage struct {
+	EntityID int64  `json:"entity_id"`
+	URL      string `json:"url"`
+}
+
+// snapshot is the part of the catalog a batch touches: products and variants by SKU, the
+// specs of those products and the image URLs of both by id
+type snapshot struct {
+	products      map[string]existingProduct
+	variants      map[string]existingVariant
+	specs         map[int64][]Spec
+	productImages map[int64][]string
+	variantImages map[int64][]string
+}
+
+// plan compares the batch against the catalog. With lock the products and variants read are
+// locked until the transaction q belongs to ends.
+func plan(ctx context.Context, q sqlx.QueryerContext, batch *Batch, lock bool) (*Plan, error) {
+	skus := make([]string, 0, len(batch.Products)+2*len(batch.Variants))
+	for _, row := range batch.Products {
+		skus = append(skus, row.SKU)
+	}
+	for _, row := range batch.Variants {
+		skus = append(skus, row.SKU, row.ParentSKU)
+	}
+
+	products, err := loadProducts(ctx, q, skus, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := loadVariants(ctx, q, skus, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]int64, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+	}
+	variantIDs := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+
+	specs, err := loadSpecs(ctx, q, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productImages, err := loadImages(ctx, q, db.EntityTypeProduct, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	variantImages, err := loadImages(ctx, q, db.EntityTypeProductVariant, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return compare(batch, snapshot{
+		products:      products,
+		variants:      variants,
+		specs:         specs,
+		productImages: productImages,
+		variantImages: variantImages,
+	}), nil
+}
+
+// compare works out what importing the batch over the snapshot would create and change, and
+// which rows can't be imported
+func compare(batch *Batch, catalog snapshot) *Plan {
+	products, variants := catalog.products, catalog.variants
+
+	// Products given variants by this file get their stock from them, like those that have
+	// variants already.
+	parents := make(map[string]bool)
+	for _, row := range batch.Variants {
+		parents[row.ParentSKU] = true
+	}
+
+	p := &Plan{
+		Products: []ProductChange{},
+		Variants: []VariantChange{},
+		Invalid:  slices.Clone(batch.Invalid),
+	}
+	invalid := func(line int, sku string, err error) {
+		p.Invalid = append(p.Invalid, InvalidRow{Line: line, SKU: sku, Err: err})
+	}
+
+	// SKUs of the product rows that can be imported, new or already in the catalog
+	validProducts := make(map[string]bool)
+
+	for _, row := range batch.Products {
+		if _, ok := variants[row.SKU]; ok {
+			invalid(row.Line, row.SKU, ErrSKUIsVariant)
+			continue
+		}
+
+		current, ok := products[row.SKU]
+
+		// The stock_count of a product with variants is their sum, as in exports, so it's
+		// left to them rather than rejected.
+		hasVariants := parents[row.SKU] || current.VariantCount > 0
+		if hasVariants {
+			row.StockCount = nil
+		}
+
+		if !ok {
+			switch {
+			case row.Name == nil:
+				invalid(row.Line, row.SKU, ErrMissingName)
+			case row.Price == nil:
+				invalid(row.Line, row.SKU, ErrMissingPrice)
+			case row.StockAdjustCount != 0 && hasVariants:
+				invalid(row.Line, row.SKU, ErrStockFromVariants)
+			case row.StockAdjustCount < 0:
+				invalid(row.Line, row.SKU, ErrStockBelowReserved)
+			default:
+				validProducts[row.SKU] = true
+				p.Products = append(p.Products, ProductChange{Row: row})
+			}
+			continue
+		}
+
+		if row.StockAdjustCount != 0 && hasVariants {
+			invalid(row.Line, row.SKU, ErrStockFromVariants)
+			continue
+		}
+
+		stock := stockAfter(current.StockCount, row.StockCount, row.StockAdjustCount)
+		if stock < 0 || stock < current.ReservedCount {
+			invalid(row.Line, row.SKU, ErrStockBelowReserved)
+			continue
+		}
+
+		validProducts[row.SKU] = true
+
+		changes := productChanges(current, catalog.specs[current.ID], catalog.productImages[current.ID], row)
+		if len(changes) == 0 {
+			p.Unchanged++
+			continue
+		}
+		p.Products = append(p.Products, ProductChange{Row: row, ID: current.ID, Changes: changes})
+	}
+
+	inFile := make(map[string]bool)
+	for _, row := range batch.Products {
+		inFile[row.SKU] = true
+	}
+
+	for _, row := range batch.Variants {
+		if _, ok := products[row.SKU]; ok {
+			invalid(row.Line, row.SKU, ErrSKUIsProduct)
+			continue
+		}
+
+		parent, parentExists := products[row.ParentSKU]
+		switch {
+		case inFile[row.ParentSKU] && !validProducts[row.ParentSKU]:
+			invalid(row.Line, row.SKU, ErrParentInvalid)
+			continue
+		case !inFile[row.ParentSKU] && !parentExists:
+			invalid(row.Line, row.SKU, ErrParentNotFound)
+			continue
+		}
+
+		change := VariantChange{Row: row, ProductID: parent.ID}
+
+		current, ok := variants[row.SKU]
+		if !ok {
+			switch {
+			case row.Name == nil:
+				invalid(row.Line, row.SKU, ErrMissingName)
+			case row.StockAdjustCount < 0:
+				invalid(row.Line, row.SKU, ErrStockBelowReserved)
+			default:
+				p.Variants = append(p.Variants, change)
+			}
+			continue
+		}
+
+		if current.ParentSKU != row.ParentSKU {
+			invalid(row.Line, row.SKU, ErrParentMismatch)
+			continue
+		}
+
+		stock := stockAfter(current.StockCount, row.StockCount, row.StockAdjustCount)
+		if stock < 0 || stock < current.ReservedCount {
+			invalid(row.Line, row.SKU, ErrStockBelowReserved)
+			continue
+		}
+
+		change.ID = current.ID
+		change.Changes = variantChanges(current, catalog.variantImages[current.ID], row)
+		if len(change.Changes) == 0 {
+			p.Unchanged++
+			continue
+		}
+		p.Variants = append(p.Variants, change)
+	}
+
+	slices.SortStableFunc(p.Invalid, func(a, b InvalidRow) int {
+		return a.Line - b.Line
+	})
+
+	return p
+}
+
+// stockAfter is the stock a row leaves: the stock_count it sets, otherwise the current stock
+// plus its adjustment
+func stockAfter(current int32, stockCount *int32, adjust int32) int32 {
+	if stockCount != nil {
+		return *stockCount
+	}
+	return current + adjust
+}
+
+func productChanges(current existingProduct, specs []Spec, images []string, row ProductRow) []FieldChange {
+	var changes []FieldChange
+	change := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	if row.Name != nil {
+		change(ColumnName, current.Name, *row.Name)
+	}
+	if row.Category != nil {
+		change(ColumnCategory, current.Category.String, *row.Category)
+	}
+	if row.Price != nil {
+		change(ColumnPrice, formatPrice(current.Price), formatPrice(*row.Price))
+	}
+	if row.OriginalPrice != nil {
+		change(ColumnOriginalPrice, formatOptionalPrice(current.OriginalPrice), formatPrice(*row.OriginalPrice))
+	}
+	if row.ShortDesc != nil {
+		change(ColumnShortDesc, current.ShortDesc.String, *row.ShortDesc)
+	}
+	if row.FullDesc != nil {
+		change(ColumnFullDesc, current.FullDesc.String, *row.FullDesc)
+	}
+	if row.ReadyForSale != nil {
+		change(ColumnReadyForSale, formatFlag(current.ReadyForSale), formatFlag(*row.ReadyForSale))
+	}
+	change(FieldStock, formatStock(current.StockCount), formatStock(stockAfter(current.StockCount, row.StockCount, row.StockAdjustCount)))
+	if row.Specs != nil {
+		change(ColumnSpecs, FormatSpecs(specs), FormatSpecs(row.Specs))
+	}
+	if row.Images != nil {
+		change(ColumnImages, FormatImages(images), FormatImages(row.Images))
+	}
+
+	return changes
+}
+
+func variantChanges(current existingVariant, images []string, row VariantRow) []FieldChange {
+	var changes []FieldChange
+	change := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	if row.Name != nil {
+		change(ColumnName, current.Name, *row.Name)
+	}
+	if row.Price != nil {
+		change(ColumnPrice, formatPrice(current.Price), formatPrice(*row.Price))
+	}
+	change(FieldStock, formatStock(current.StockCount), formatStock(stockAfter(current.StockCount, row.StockCount, row.StockAdjustCount)))
+	if row.Images != nil {
+		change(ColumnImages, FormatImages(images), FormatImages(row.Images))
+	}
+
+	return changes
+}
+
+// FormatSpecs writes specs the way the specs column holds them
+func FormatSpecs(specs []Spec) string {
+	entries := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		entries = append(entries, fmt.Sprintf("%s: %s", spec.Name, spec.Value))
+	}
+	return strings.Join(entries, "; ")
+}
+
+// FormatImages writes image URLs the way the images column holds them
+func FormatImages(urls []string) string {
+	return strings.Join(urls, " ")
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+func formatOptionalPrice(price sql.NullFloat64) string {
+	if !price.Valid {
+		return ""
+	}
+	return formatPrice(price.Float64)
+}
+
+func formatFlag(v bool) string {
+	if v {
+		return "Y"
+	}
+	return "N"
+}
+
+func formatStock(stock int32) string {
+	return strconv.FormatInt(int64(stock), 10)
+}
+
+func loadProducts(ctx context.Context, q sqlx.QueryerContext, skus []string, lock bool) (map[string]existingProduct, error) {
+	query := `
+		SELECT
+			p.id,
+			p.sku,
+			p.name,
+			p.category,
+			p.price::float8 AS price,
+			p.original_price::float8 AS original_price,
+			p.short_desc,
+			p.full_desc,
+			p.ready_for_sale,
+			p.stock_count,
+			p.reserved_count,
+			(
+				SELECT COUNT(*)
+				FROM product_variants pv
+				WHERE pv.product_id = p.id
+			) AS variant_count
+		FROM products p
+		WHERE p.sku = ANY($1)
+	`
+	if lock {
+		query += ` FOR UPDATE OF p`
+	}
+
+	var rows []existingProduct
+	if err := sqlx.SelectContext(ctx, q, &rows, query, skus); err != nil {
+		return nil, fmt.Errorf("failed to load products: %w", err)
+	}
+
+	products := make(map[string]existingProduct, len(rows))
+	for _, row := range rows {
+		products[row.SKU] = row
+	}
+	return products, nil
+}
+
+func loadVariants(ctx context.Context, q sqlx.QueryerContext, skus []string, lock bool) (map[string]existingVariant, error) {
+	query := `
+		SELECT
+			pv.id,
+			pv.product_id,
+			p.sku AS parent_sku,
+			pv.sku,
+			pv.name,
+			pv.price::float8 AS price,
+			pv.stock_count,
+			pv.reserved_count
+		FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id
+		WHERE pv.sku = ANY($1)
+	`
+	if lock {
+		query += ` FOR UPDATE OF pv`
+	}
+
+	var rows []existingVariant
+	if err := sqlx.SelectContext(ctx, q, &rows, query, skus); err != nil {
+		return nil, fmt.Errorf("failed to load product variants: %w", err)
+	}
+
+	variants := make(map[string]existingVariant, len(rows))
+	for _, row := range rows {
+		variants[row.SKU] = row
+	}
+	return variants, nil
+}
+
+func loadSpecs(ctx context.Context, q sqlx.QueryerContext, ids []int64) (map[int64][]Spec, error) {
+	query := `
+		SELECT product_id, spec_name, spec_value
+		FROM product_specs
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, sort_order, id
+	`
+
+	var rows []existingSpec
+	if err := sqlx.SelectContext(ctx, q, &rows, query, ids); err != nil {
+		return nil, fmt.Errorf("failed to load product specs: %w", err)
+	}
+
+	specs := make(map[int64][]Spec)
+	for _, row := range rows {
+		specs[row.ProductID] = append(specs[row.ProductID], Spec{Name: row.SpecName, Value: row.SpecValue})
+	}
+	return specs, nil
+}
+
+func loadImages(ctx context.Context, q sqlx.QueryerContext, entityType db.EntityType, ids []int64) (map[int64][]string, error) {
+	query := `
+		SELECT ie.entity_id, i.url
+		FROM image_entities ie
+		JOIN images i ON i.id = ie.image_id
+		WHERE ie.entity_type = $1 AND ie.entity_id = ANY($2)
+		ORDER BY ie.entity_id, ie.sort_order, ie.id
+	`
+
+	var rows []existingImage
+	if err := sqlx.SelectContext(ctx, q, &rows, query, entityType, ids); err != nil {
+		return nil, fmt.Errorf("failed to load images: %w", err)
+	}
+
+	images := make(map[int64][]string)
+	for _, row := range rows {
+		images[row.EntityID] = append(images[row.EntityID], row.URL)
+	}
+	return images, nil
+}
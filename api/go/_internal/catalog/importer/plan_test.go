@@ -0,0 +1,312 @@
+package importer
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCatalog has a product without variants, one with a variant and a product with specs and
+// images
+func testCatalog() snapshot {
+	return snapshot{
+		products: map[string]existingProduct{
+			"TEE": {
+				ID: 1, SKU: "TEE", Name: "T-shirt", Price: 499,
+				Category: sql.NullString{String: "Tops", Valid: true}, ReadyForSale: true,
+				StockCount: 10, ReservedCount: 2,
+			},
+			"HOODIE": {
+				ID: 2, SKU: "HOODIE", Name: "Hoodie", Price: 1280, ReadyForSale: true,
+				StockCount: 8, VariantCount: 1,
+			},
+			"BAG": {
+				ID: 3, SKU: "BAG", Name: "Tote bag", Price: 690,
+				OriginalPrice: sql.NullFloat64{Float64: 890, Valid: true},
+			},
+		},
+		variants: map[string]existingVariant{
+			"HOODIE-M": {
+				ID: 20, ProductID: 2, ParentSKU: "HOODIE", SKU: "HOODIE-M", Name: "M", Price: 1280,
+				StockCount: 8, ReservedCount: 3,
+			},
+		},
+		specs: map[int64][]Spec{
+			3: {{Name: "material", Value: "canvas"}},
+		},
+		productImages: map[int64][]string{
+			3: {"https://cdn.example.com/bag-1.jpg", "https://cdn.example.com/bag-2.jpg"},
+		},
+		variantImages: map[int64][]string{},
+	}
+}
+
+func TestComparePlan(t *testing.T) {
+	tests := []struct {
+		name          string
+		batch         Batch
+		wantInvalid   []InvalidRow
+		wantNew       []string
+		wantChanges   map[string][]FieldChange
+		wantUnchanged int
+	}{
+		{
+			name: "new product",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "CAP", Name: ptr("Cap"), Price: ptr(350.0), StockAdjustCount: 4},
+			}},
+			wantNew: []string{"CAP"},
+		},
+		{
+			name: "new product without a name or price",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "CAP", Price: ptr(350.0)},
+				{Line: 3, SKU: "SOCKS", Name: ptr("Socks")},
+			}},
+			wantInvalid: []InvalidRow{
+				{Line: 2, SKU: "CAP", Err: ErrMissingName},
+				{Line: 3, SKU: "SOCKS", Err: ErrMissingPrice},
+			},
+		},
+		{
+			name: "new product starting below zero",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "CAP", Name: ptr("Cap"), Price: ptr(350.0), StockAdjustCount: -1},
+			}},
+			wantInvalid: []InvalidRow{{Line: 2, SKU: "CAP", Err: ErrStockBelowReserved}},
+		},
+		{
+			name: "stock adjustment of an existing product",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "TEE", StockAdjustCount: 5},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"TEE": {{Field: FieldStock, From: "10", To: "15"}},
+			},
+		},
+		{
+			name: "stock adjustment down to the reserved count",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "TEE", StockAdjustCount: -8},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"TEE": {{Field: FieldStock, From: "10", To: "2"}},
+			},
+		},
+		{
+			name: "stock adjustment below the reserved count",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "TEE", StockAdjustCount: -9},
+			}},
+			wantInvalid: []InvalidRow{{Line: 2, SKU: "TEE", Err: ErrStockBelowReserved}},
+		},
+		{
+			name: "stock count of an existing product",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "TEE", StockCount: ptr[int32](4)},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"TEE": {{Field: FieldStock, From: "10", To: "4"}},
+			},
+		},
+		{
+			name: "stock count below the reserved count",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "TEE", StockCount: ptr[int32](1)},
+			}},
+			wantInvalid: []InvalidRow{{Line: 2, SKU: "TEE", Err: ErrStockBelowReserved}},
+		},
+		{
+			name: "stock count of a product with variants is left to them",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "HOODIE", StockCount: ptr[int32](99)},
+			}},
+			wantUnchanged: 1,
+		},
+		{
+			name: "stock count of a variant",
+			batch: Batch{Variants: []VariantRow{
+				{Line: 2, ParentSKU: "HOODIE", SKU: "HOODIE-M", StockCount: ptr[int32](3)},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"HOODIE-M": {{Field: FieldStock, From: "8", To: "3"}},
+			},
+		},
+		{
+			name: "stock adjustment of a product with variants",
+			batch: Batch{Products: []ProductRow{
+				{Line: 2, SKU: "HOODIE", StockAdjustCount: 1},
+			}},
+			wantInvalid: []InvalidRow{{Line: 2, SKU: "HOODIE", Err: ErrStockFromVariants}},
+		},
+		{
+			name: "stock adjustment of a product given variants by the file",
+			batch: Batch{
+				Products: []ProductRow{{Line: 2, SKU: "BAG", StockAdjustCount: 1}},
+				Variants: []VariantRow{{Line: 3, ParentSKU: "BAG", SKU: "BAG-RED", Name: ptr("Red")}},
+			},
+			wantInvalid: []InvalidRow{
+				{Line: 2, SKU: "BAG", Err: ErrStockFromVariants},
+				{Line: 3, SKU: "BAG-RED", Err: ErrParentInvalid},
+			},
+		},
+		{
+			name: "stock adjustment of a variant",
+			batch: Batch{Variants: []VariantRow{
+				{Line: 2, ParentSKU: "HOODIE", SKU: "HOODIE-M", StockAdjustCount: -5},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"HOODIE-M": {{Field: FieldStock, From: "8", To: "3"}},
+			},
+		},
+		{
+			name: "variant stock adjustment below the reserved count",
+			batch: Batch{Variants: []VariantRow{
+				{Line: 2, ParentSKU: "HOODIE", SKU: "HOODIE-M", StockAdjustCount: -6},
+			}},
+			wantInvalid: []InvalidRow{{Line: 2, SKU: "HOODIE-M", Err: ErrStockBelowReserved}},
+		},
+		{
+			name: "field changes",
+			batch: Batch{Products: []ProductRow{
+				{
+					Line: 2, SKU: "BAG", Name: ptr("Tote bag"), Price: ptr(790.0), ReadyForSale: ptr(true),
+					Specs: []Spec{{Name: "material", Value: "canvas"}, {Name: "size", Value: "L"}},
+				},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"BAG": {
+					{Field: ColumnPrice, From: "690", To: "790"},
+					{Field: ColumnReadyForSale, From: "N", To: "Y"},
+					{Field: ColumnSpecs, From: "material: canvas", To: "material: canvas; size: L"},
+				},
+			},
+		},
+		{
+			name: "original price, description and images",
+			batch: Batch{Products: []ProductRow{
+				{
+					Line: 2, SKU: "BAG", OriginalPrice: ptr(990.0), FullDesc: ptr("Canvas tote"),
+					Images: []string{"https://cdn.example.com/bag-2.jpg", "https://cdn.example.com/bag-1.jpg"},
+				},
+			}},
+			wantChanges: map[string][]FieldChange{
+				"BAG": {
+					{Field: ColumnOriginalPrice, From: "890", To: "990"},
+					{Field: ColumnFullDesc, From: "", To: "Canvas tote"},
+					{
+						Field: ColumnImages,
+						From:  "https://cdn.example.com/bag-1.jpg https://cdn.example.com/bag-2.jpg",
+						To:    "https://cdn.example.com/bag-2.jpg https://cdn.example.com/bag-1.jpg",
+					},
+				},
+			},
+		},
+		{
+			name: "unchanged rows",
+			batch: Batch{
+				Products: []ProductRow{
+					{Line: 2, SKU: "TEE", Name: ptr("T-shirt"), Price: ptr(499.0), StockCount: ptr[int32](10)},
+					{Line: 3, SKU: "BAG", Images: []string{"https://cdn.example.com/bag-1.jpg", "https://cdn.example.com/bag-2.jpg"}},
+				},
+				Variants: []VariantRow{{Line: 4, ParentSKU: "HOODIE", SKU: "HOODIE-M", Name: ptr("M")}},
+			},
+			wantUnchanged: 3,
+		},
+		{
+			name: "new variant of a product created by the file",
+			batch: Batch{
+				Products: []ProductRow{{Line: 2, SKU: "CAP", Name: ptr("Cap"), Price: ptr(350.0)}},
+				Variants: []VariantRow{{Line: 3, ParentSKU: "CAP", SKU: "CAP-BLUE", Name: ptr("Blue")}},
+			},
+			wantNew: []string{"CAP", "CAP-BLUE"},
+		},
+		{
+			name: "sku used by the other kind",
+			batch: Batch{
+				Products: []ProductRow{{Line: 2, SKU: "HOODIE-M", Name: ptr("M"), Price: ptr(100.0)}},
+				Variants: []VariantRow{{Line: 3, ParentSKU: "HOODIE", SKU: "TEE", Name: ptr("Tee")}},
+			},
+			wantInvalid: []InvalidRow{
+				{Line: 2, SKU: "HOODIE-M", Err: ErrSKUIsVariant},
+				{Line: 3, SKU: "TEE", Err: ErrSKUIsProduct},
+			},
+		},
+		{
+			name: "variant parents",
+			batch: Batch{Variants: []VariantRow{
+				{Line: 2, ParentSKU: "NOPE", SKU: "NOPE-M", Name: ptr("M")},
+				{Line: 3, ParentSKU: "TEE", SKU: "HOODIE-M", Name: ptr("M")},
+				{Line: 4, ParentSKU: "TEE", SKU: "TEE-S", StockAdjustCount: 2},
+			}},
+			wantInvalid: []InvalidRow{
+				{Line: 2, SKU: "NOPE-M", Err: ErrParentNotFound},
+				{Line: 3, SKU: "HOODIE-M", Err: ErrParentMismatch},
+				{Line: 4, SKU: "TEE-S", Err: ErrMissingName},
+			},
+		},
+		{
+			name: "rows rejected by parse are kept in line order",
+			batch: Batch{
+				Products: []ProductRow{{Line: 2, SKU: "CAP", Price: ptr(350.0)}},
+				Invalid:  []InvalidRow{{Line: 3, SKU: "CAP", Err: ErrDuplicateSKU}},
+			},
+			wantInvalid: []InvalidRow{
+				{Line: 2, SKU: "CAP", Err: ErrMissingName},
+				{Line: 3, SKU: "CAP", Err: ErrDuplicateSKU},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := compare(&tt.batch, testCatalog())
+
+			if tt.wantInvalid == nil {
+				tt.wantInvalid = []InvalidRow{}
+			}
+			if p.Invalid == nil {
+				p.Invalid = []InvalidRow{}
+			}
+			require.Equal(t, tt.wantInvalid, p.Invalid)
+			require.Equal(t, tt.wantUnchanged, p.Unchanged)
+
+			created := []string{}
+			changes := map[string][]FieldChange{}
+			for _, c := range p.Products {
+				if c.IsNew() {
+					created = append(created, c.Row.SKU)
+				} else {
+					changes[c.Row.SKU] = c.Changes
+				}
+			}
+			for _, c := range p.Variants {
+				if c.IsNew() {
+					created = append(created, c.Row.SKU)
+				} else {
+					changes[c.Row.SKU] = c.Changes
+				}
+			}
+
+			if tt.wantNew == nil {
+				tt.wantNew = []string{}
+			}
+			if tt.wantChanges == nil {
+				tt.wantChanges = map[string][]FieldChange{}
+			}
+			require.Equal(t, tt.wantNew, created)
+			require.Equal(t, tt.wantChanges, changes)
+		})
+	}
+}
+
+func TestComparePlanVariantOfExistingProduct(t *testing.T) {
+	p := compare(&Batch{Variants: []VariantRow{
+		{Line: 2, ParentSKU: "TEE", SKU: "TEE-M", Name: ptr("M")},
+	}}, testCatalog())
+
+	require.Len(t, p.Variants, 1)
+	require.True(t, p.Variants[0].IsNew())
+	require.EqualValues(t, 1, p.Variants[0].ProductID)
+}
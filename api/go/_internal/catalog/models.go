@@ -0,0 +1,187 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+var (
+	ErrEmptyName            = errors.New("product name must not be empty")
+	ErrInvalidPrice         = errors.New("product price must be greater than 0")
+	ErrInvalidOriginalPrice = errors.New("product original price must not be negative")
+	ErrNotSellable          = errors.New("product must have a name and a price before it can be put on sale")
+	ErrEmptySKU             = errors.New("sku must not be empty")
+	ErrSKUTaken             = errors.New("sku already exists")
+	ErrDuplicateSpec        = errors.New("spec names must be unique")
+	ErrEmptySpec            = errors.New("spec name and value must not be empty")
+	ErrHasOrders            = errors.New("product or variant has been ordered, take it off sale instead")
+)
+
+// Spec is a name and value pair of a product, e.g. 尺寸: 45 x 45 cm. Specs are kept in
+// product_specs and mirrored in products.specs, which the storefront reads.
+type Spec struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// specJSON is the shape of the entries of products.specs
+type specJSON struct {
+	SpecName  string `json:"spec_name"`
+	SpecValue string `json:"spec_value"`
+}
+
+func validateSpecs(specs []Spec) error {
+	names := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec.Name) == "" || strings.TrimSpace(spec.Value) == "" {
+			return ErrEmptySpec
+		}
+		if names[spec.Name] {
+			return fmt.Errorf("%w: %s", ErrDuplicateSpec, spec.Name)
+		}
+		names[spec.Name] = true
+	}
+	return nil
+}
+
+// ProductDetail is a product with its specs and variants
+type ProductDetail struct {
+	Product  *db.Product
+	Specs    []Spec
+	Variants []db.ProductVariant
+}
+
+// CreateProductParams holds the fields of a new product. Stock starts at 0, it changes through
+// inventory adjustments.
+type CreateProductParams struct {
+	SKU           string
+	Name          string
+	Category      *string
+	Price         float64
+	OriginalPrice *float64
+	ShortDesc     *string
+	FullDesc      *string
+	ReadyForSale  bool
+	Specs         []Spec
+}
+
+func (p CreateProductParams) Validate() error {
+	if strings.TrimSpace(p.SKU) == "" {
+		return ErrEmptySKU
+	}
+
+	if strings.TrimSpace(p.Name) == "" {
+		return ErrEmptyName
+	}
+
+	if p.Price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	if p.OriginalPrice != nil && *p.OriginalPrice < 0 {
+		return ErrInvalidOriginalPrice
+	}
+
+	return validateSpecs(p.Specs)
+}
+
+// UpdateProductParams holds the fields to change on a product. Nil fields are left untouched.
+type UpdateProductParams struct {
+	Name          *string
+	Category      *string
+	Price         *float64
+	OriginalPrice *float64
+	ShortDesc     *string
+	FullDesc      *string
+	ReadyForSale  *bool
+	// Specs replaces all the specs of the product when not nil, an empty slice removes them
+	Specs []Spec
+}
+
+// Validate checks the business rules shared by every caller that edits products
+// (telegram /edit and the admin API) against the product as it would look after the update.
+func (p UpdateProductParams) Validate(current *db.Product) error {
+	if p.Name != nil && strings.TrimSpace(*p.Name) == "" {
+		return ErrEmptyName
+	}
+
+	if p.Price != nil && *p.Price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	if p.OriginalPrice != nil && *p.OriginalPrice < 0 {
+		return ErrInvalidOriginalPrice
+	}
+
+	if err := validateSpecs(p.Specs); err != nil {
+		return err
+	}
+
+	readyForSale := current.ReadyForSale
+	if p.ReadyForSale != nil {
+		readyForSale = *p.ReadyForSale
+	}
+
+	if readyForSale {
+		name := current.Name
+		if p.Name != nil {
+			name = *p.Name
+		}
+
+		hasPrice := current.Price.Valid
+		if p.Price != nil {
+			hasPrice = true
+		}
+
+		if strings.TrimSpace(name) == "" || !hasPrice {
+			return ErrNotSellable
+		}
+	}
+
+	return nil
+}
+
+// CreateVariantParams holds the fields of a new variant. Without a price it sells at the
+// product's price. Stock starts at 0.
+type CreateVariantParams struct {
+	SKU   string
+	Name  string
+	Price *float64
+}
+
+func (p CreateVariantParams) Validate() error {
+	if strings.TrimSpace(p.SKU) == "" {
+		return ErrEmptySKU
+	}
+
+	if strings.TrimSpace(p.Name) == "" {
+		return ErrEmptyName
+	}
+
+	if p.Price != nil && *p.Price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	return nil
+}
+
+// UpdateVariantParams holds the fields to change on a variant. Nil fields are left untouched.
+type UpdateVariantParams struct {
+	Name  *string
+	Price *float64
+}
+
+func (p UpdateVariantParams) Validate() error {
+	if p.Name != nil && strings.TrimSpace(*p.Name) == "" {
+		return ErrEmptyName
+	}
+
+	if p.Price != nil && *p.Price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	slugWhitespace = regexp.MustCompile(`\s+`)
+	slugUnsafe     = regexp.MustCompile("[<>\"'`%{}|\\\\^\\[\\]\\x00-\\x1f\\x7f-\\x9f]")
+	slugHyphens    = regexp.MustCompile(`-+`)
+)
+
+// Slugify turns a product name into its URL slug the way the sheet sync does, Chinese
+// characters and emojis are kept
+func Slugify(name string) string {
+	slug := strings.TrimSpace(name)
+	slug = strings.ReplaceAll(slug, "/", "-")
+	slug = slugWhitespace.ReplaceAllString(slug, "-")
+	slug = slugUnsafe.ReplaceAllString(slug, "")
+	slug = slugHyphens.ReplaceAllString(slug, "-")
+	return strings.Trim(slug, "-")
+}
+
+// uniqueSlug returns the slug of name, suffixed with -1, -2, ... when another product than
+// excludeID already uses it
+func uniqueSlug(ctx context.Context, q sqlx.QueryerContext, name string, excludeID int64) (string, error) {
+	base := Slugify(name)
+
+	var taken []string
+	query := `
+		SELECT slug
+		FROM products
+		WHERE id <> $1 AND (slug = $2 OR slug LIKE $3)
+	`
+	if err := sqlx.SelectContext(ctx, q, &taken, query, excludeID, base, likePrefix(base+"-")+"%"); err != nil {
+		return "", fmt.Errorf("failed to check slug %s: %w", base, err)
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for i := 1; used[slug]; i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug, nil
+}
+
+// likePrefix escapes the LIKE wildcards of s
+func likePrefix(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,22 @@
+package catalog
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Cat Tree", "Cat-Tree"},
+		{"  貓跳台  大型 ", "貓跳台-大型"},
+		{"Bowl / Large", "Bowl-Large"},
+		{`"Kivy" <50%> 🐱`, "Kivy-50-🐱"},
+		{"--a--b--", "a-b"},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
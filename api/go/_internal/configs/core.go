@@ -26,14 +26,82 @@ type Config struct {
 	} `mapstructure:"db"`
 
 	Telegram struct {
-		BotToken string `mapstructure:"bot_token"`
+		BotToken      string `mapstructure:"bot_token"`
+		WebhookSecret string `mapstructure:"webhook_secret"`
+		StaffChatID   int64  `mapstructure:"staff_chat_id"`
+		ReportChatID  int64  `mapstructure:"report_chat_id"`
+		APIBaseURL    string `mapstructure:"api_base_url"`
+		// StaffUserIDs are the Telegram user IDs allowed to use the bot, comma separated in the env
+		StaffUserIDs []int64 `mapstructure:"staff_user_ids"`
+		// ChatLocale is the language of the messages pushed to the staff and report chats,
+		// which aren't addressed to a single user. Defaults to zh-TW.
+		ChatLocale string `mapstructure:"chat_locale"`
 	} `mapstructure:"telegram"`
 
+	Cron struct {
+		Secret string `mapstructure:"secret"`
+	} `mapstructure:"cron"`
+
+	Clerk struct {
+		// JWTKey is the PEM public key session tokens are signed with, from the API keys page
+		// of the Clerk dashboard
+		JWTKey string `mapstructure:"jwt_key"`
+		// Issuer is the Frontend API URL of the Clerk instance, the iss claim of its session tokens
+		Issuer string `mapstructure:"issuer"`
+		// AuthorizedParties are the origins of the frontends signing users in, one of them is the
+		// azp claim of a session token. Comma separated in the env.
+		AuthorizedParties []string `mapstructure:"authorized_parties"`
+		// WebhookSecret is the "whsec_..." signing secret of the Clerk webhook endpoint
+		WebhookSecret string `mapstructure:"webhook_secret"`
+	} `mapstructure:"clerk"`
+
 	Azure struct {
 		BlobStorageAccountName      string `mapstructure:"blob_storage_account_name"`
 		BlobStorageKey              string `mapstructure:"blob_storage_key"`
 		BlobStorageConnectionString string `mapstructure:"blob_storage_connection_string"`
 	} `mapstructure:"azure"`
+
+	Storage struct {
+		// Driver is the blob store of uploaded files, "azure", "local" or "s3"
+		Driver string `mapstructure:"driver"`
+
+		Local struct {
+			Root          string `mapstructure:"root"`
+			BaseURL       string `mapstructure:"base_url"`
+			SigningSecret string `mapstructure:"signing_secret"`
+		} `mapstructure:"local"`
+
+		S3 struct {
+			Endpoint        string `mapstructure:"endpoint"`
+			Region          string `mapstructure:"region"`
+			Bucket          string `mapstructure:"bucket"`
+			AccessKeyID     string `mapstructure:"access_key_id"`
+			SecretAccessKey string `mapstructure:"secret_access_key"`
+			UseSSL          bool   `mapstructure:"use_ssl"`
+			// PublicBaseURL serves the bucket publicly, e.g. a CDN or an R2 public domain.
+			// Defaults to <endpoint>/<bucket>.
+			PublicBaseURL string `mapstructure:"public_base_url"`
+		} `mapstructure:"s3"`
+	} `mapstructure:"storage"`
+
+	Payments struct {
+		// Provider takes the payments, "ecpay" or "fake"
+		Provider string `mapstructure:"provider"`
+		// ReturnURL receives the server-side payment result callback
+		ReturnURL string `mapstructure:"return_url"`
+		// ClientBackURL is where the shopper goes back to from the payment page, "{order_number}"
+		// is replaced by the order number
+		ClientBackURL string `mapstructure:"client_back_url"`
+
+		ECPay struct {
+			MerchantID string `mapstructure:"merchant_id"`
+			HashKey    string `mapstructure:"hash_key"`
+			HashIV     string `mapstructure:"hash_iv"`
+			// BaseURL is https://payment-stage.ecpay.com.tw for testing and
+			// https://payment.ecpay.com.tw in production
+			BaseURL string `mapstructure:"base_url"`
+		} `mapstructure:"ecpay"`
+	} `mapstructure:"payments"`
 }
 
 func NewConfig(vp *viper.Viper) (*Config, error) {
@@ -62,7 +130,40 @@ func NewViper() *viper.Viper {
 	vp.SetDefault("azure.blob_storage_key", "")
 	vp.SetDefault("azure.blob_storage_connection_string", "")
 
+	vp.SetDefault("storage.driver", "azure")
+	vp.SetDefault("storage.local.root", "./.storage")
+	vp.SetDefault("storage.local.base_url", "http://localhost:3008/v1/files")
+	vp.SetDefault("storage.local.signing_secret", "")
+	vp.SetDefault("storage.s3.endpoint", "")
+	vp.SetDefault("storage.s3.region", "")
+	vp.SetDefault("storage.s3.bucket", "")
+	vp.SetDefault("storage.s3.access_key_id", "")
+	vp.SetDefault("storage.s3.secret_access_key", "")
+	vp.SetDefault("storage.s3.use_ssl", true)
+	vp.SetDefault("storage.s3.public_base_url", "")
+
+	vp.SetDefault("payments.provider", "ecpay")
+	vp.SetDefault("payments.return_url", "")
+	vp.SetDefault("payments.client_back_url", "")
+	vp.SetDefault("payments.ecpay.merchant_id", "")
+	vp.SetDefault("payments.ecpay.hash_key", "")
+	vp.SetDefault("payments.ecpay.hash_iv", "")
+	vp.SetDefault("payments.ecpay.base_url", "https://payment-stage.ecpay.com.tw")
+
 	vp.SetDefault("telegram.bot_token", "")
+	vp.SetDefault("telegram.webhook_secret", "")
+	vp.SetDefault("telegram.staff_chat_id", 0)
+	vp.SetDefault("telegram.report_chat_id", 0)
+	vp.SetDefault("telegram.api_base_url", "")
+	vp.SetDefault("telegram.staff_user_ids", []int64{})
+	vp.SetDefault("telegram.chat_locale", "")
+
+	vp.SetDefault("cron.secret", "")
+
+	vp.SetDefault("clerk.jwt_key", "")
+	vp.SetDefault("clerk.issuer", "")
+	vp.SetDefault("clerk.authorized_parties", []string{})
+	vp.SetDefault("clerk.webhook_secret", "")
 
 	return vp
 }
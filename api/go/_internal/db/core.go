@@ -19,6 +19,7 @@ import (
 
 type Conn interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
@@ -51,12 +52,11 @@ func getPostgresqlDSN(cfg *configs.Config) string {
 	) + params
 }
 
-func NewSQLXPostgresDB(lc fx.Lifecycle, config *configs.Config) *sqlx.DB {
-	dsn := getPostgresqlDSN(config)
-
+// OpenPostgres connects sqlx to the database of the DSN, columns map to json tags
+func OpenPostgres(dsn string) (*sqlx.DB, error) {
 	pgxConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		panic(fmt.Errorf("failed to parse pgx config: %w", err))
+		return nil, fmt.Errorf("failed to parse pgx config: %w", err)
 	}
 
 	pgxConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
@@ -66,6 +66,15 @@ func NewSQLXPostgresDB(lc fx.Lifecycle, config *configs.Config) *sqlx.DB {
 	driver := sqlx.NewDb(sqlDB, "pgx")
 	driver.Mapper = reflectx.NewMapperFunc("json", strings.ToLower)
 
+	return driver, nil
+}
+
+func NewSQLXPostgresDB(lc fx.Lifecycle, config *configs.Config) *sqlx.DB {
+	driver, err := OpenPostgres(getPostgresqlDSN(config))
+	if err != nil {
+		panic(err)
+	}
+
 	lc.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error { return driver.Close() },
 	})
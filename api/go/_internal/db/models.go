@@ -228,6 +228,49 @@ func (ns NullPaymentStatus) Value() (driver.Value, error) {
 	return string(ns.PaymentStatus), nil
 }
 
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+func (e *RefundStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RefundStatus(s)
+	case string:
+		*e = RefundStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RefundStatus: %T", src)
+	}
+	return nil
+}
+
+type NullRefundStatus struct {
+	RefundStatus RefundStatus `json:"refund_status"`
+	Valid        bool         `json:"valid"` // Valid is true if RefundStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRefundStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.RefundStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RefundStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRefundStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RefundStatus), nil
+}
+
 type ShippingStatus string
 
 const (
@@ -335,10 +378,13 @@ type Address struct {
 }
 
 type Image struct {
-	ID        int64              `json:"id"`
-	Url       string             `json:"url"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID         int64              `json:"id"`
+	Url        string             `json:"url"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	Width      pgtype.Int4        `json:"width"`
+	Height     pgtype.Int4        `json:"height"`
+	Renditions []byte             `json:"renditions"`
 }
 
 type ImageEntity struct {
@@ -353,6 +399,34 @@ type ImageEntity struct {
 	EntityType EntityType         `json:"entity_type"`
 }
 
+type ImageProcessingJob struct {
+	ID            int64              `json:"id"`
+	ImageID       int64              `json:"image_id"`
+	UploadKey     string             `json:"upload_key"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	ProcessedAt   pgtype.Timestamptz `json:"processed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type InventoryAdjustment struct {
+	ID              int64              `json:"id"`
+	EntityType      EntityType         `json:"entity_type"`
+	EntityID        int64              `json:"entity_id"`
+	Sku             string             `json:"sku"`
+	AdjustmentType  string             `json:"adjustment_type"`
+	Quantity        int32              `json:"quantity"`
+	PreviousStock   int32              `json:"previous_stock"`
+	NewStock        int32              `json:"new_stock"`
+	Source          string             `json:"source"`
+	ActorTelegramID pgtype.Int8        `json:"actor_telegram_id"`
+	ActorUsername   pgtype.Text        `json:"actor_username"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
 type Order struct {
 	ID            int64              `json:"id"`
 	OrderNumber   int64              `json:"order_number"`
@@ -369,16 +443,41 @@ type Order struct {
 }
 
 type OrderItem struct {
-	ID        int64          `json:"id"`
-	OrderID   int64          `json:"order_id"`
-	ProductID int64          `json:"product_id"`
-	VariantID pgtype.Int8    `json:"variant_id"`
-	Name      string         `json:"name"`
-	ImageUrl  pgtype.Text    `json:"image_url"`
-	UnitPrice pgtype.Numeric `json:"unit_price"`
-	Quantity  int32          `json:"quantity"`
-	LineTotal pgtype.Numeric `json:"line_total"`
-	Metadata  []byte         `json:"metadata"`
+	ID                int64          `json:"id"`
+	OrderID           int64          `json:"order_id"`
+	ProductID         int64          `json:"product_id"`
+	VariantID         pgtype.Int8    `json:"variant_id"`
+	Name              string         `json:"name"`
+	ImageUrl          pgtype.Text    `json:"image_url"`
+	UnitPrice         pgtype.Numeric `json:"unit_price"`
+	Quantity          int32          `json:"quantity"`
+	LineTotal         pgtype.Numeric `json:"line_total"`
+	Metadata          []byte         `json:"metadata"`
+	CommittedQuantity int32          `json:"committed_quantity"`
+}
+
+type OrderNotification struct {
+	ID            int64              `json:"id"`
+	OrderID       int64              `json:"order_id"`
+	Kind          string             `json:"kind"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	SentAt        pgtype.Timestamptz `json:"sent_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type OrderStatusHistory struct {
+	ID         int64              `json:"id"`
+	OrderID    int64              `json:"order_id"`
+	FromStatus NullOrderStatus    `json:"from_status"`
+	ToStatus   OrderStatus        `json:"to_status"`
+	Actor      StatusActor        `json:"actor"`
+	ActorRef   pgtype.Text        `json:"actor_ref"`
+	Note       pgtype.Text        `json:"note"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
 type Payment struct {
@@ -394,6 +493,14 @@ type Payment struct {
 	RawResponse      []byte             `json:"raw_response"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	Reference        pgtype.Text        `json:"reference"`
+}
+
+type Permission struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type Product struct {
@@ -438,6 +545,44 @@ type ProductVariant struct {
 	Uuid          pgtype.Text        `json:"uuid"`
 }
 
+type Refund struct {
+	ID          int64              `json:"id"`
+	OrderID     int64              `json:"order_id"`
+	PaymentID   int64              `json:"payment_id"`
+	Status      RefundStatus       `json:"status"`
+	Currency    string             `json:"currency"`
+	Amount      pgtype.Numeric     `json:"amount"`
+	Restock     bool               `json:"restock"`
+	Reason      pgtype.Text        `json:"reason"`
+	ActorRef    pgtype.Text        `json:"actor_ref"`
+	RawResponse []byte             `json:"raw_response"`
+	RefundedAt  pgtype.Timestamptz `json:"refunded_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type RefundItem struct {
+	ID          int64          `json:"id"`
+	RefundID    int64          `json:"refund_id"`
+	OrderItemID int64          `json:"order_item_id"`
+	Quantity    int32          `json:"quantity"`
+	Amount      pgtype.Numeric `json:"amount"`
+}
+
+type Role struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type RolePermission struct {
+	RoleID       int64              `json:"role_id"`
+	PermissionID int64              `json:"permission_id"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
 type Shipment struct {
 	ID             int64              `json:"id"`
 	OrderID        int64              `json:"order_id"`
@@ -459,6 +604,13 @@ type Shipment struct {
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
 }
 
+type TelegramUserPreference struct {
+	UserID    int64              `json:"user_id"`
+	Locale    string             `json:"locale"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
 type User struct {
 	ID             int64              `json:"id"`
 	Name           string             `json:"name"`
@@ -470,6 +622,12 @@ type User struct {
 	AuthProviderID pgtype.Text        `json:"auth_provider_id"`
 }
 
+type UserRole struct {
+	UserID    int64              `json:"user_id"`
+	RoleID    int64              `json:"role_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type UserSession struct {
 	ID                     int64              `json:"id"`
 	ChatID                 int64              `json:"chat_id"`
@@ -480,4 +638,5 @@ type UserSession struct {
 	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
 	ExpiresAt              pgtype.Timestamptz `json:"expires_at"`
 	ExpectedReplyMessageID pgtype.Int8        `json:"expected_reply_message_id"`
+	Version                int32              `json:"version"`
 }
@@ -0,0 +1,113 @@
+package admin_orders
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// OrderActionHandler marks an order processing, shipped or canceled. The change goes
+// through orders.OrderDAO.TransitionStatus like the Telegram /order command, so the same
+// status rules apply and it lands in the status history.
+type OrderActionHandler struct {
+	dao       *orders.OrderDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewOrderActionHandler(p HandlerParams) *OrderActionHandler {
+	return &OrderActionHandler{
+		dao:       p.DAO,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *OrderActionHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermOrdersWrite)...).Post("/v1/admin/orders/{id}/{action}", h.Handle)
+}
+
+func (h *OrderActionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	action := chi.URLParam(r, "action")
+	to, ok := actionStatuses[action]
+	if !ok {
+		render.ChiErr(w, r, fmt.Errorf("unknown order action %q", action), InvalidOrderRequest,
+			render.WithStatusCode(http.StatusNotFound))
+		return
+	}
+
+	// The body is optional, only shipping needs one.
+	var req OrderActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		render.ChiErr(w, r, err, InvalidOrderRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		render.ChiErr(w, r, err, InvalidOrderRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	record, ok := findOrder(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	updated, err := h.dao.TransitionStatus(r.Context(), orders.TransitionParams{
+		OrderID:        record.ID,
+		To:             to,
+		Actor:          db.StatusActorStaff,
+		ActorRef:       actorRef(r),
+		Note:           req.Note,
+		TrackingNumber: req.TrackingNumber,
+	})
+
+	var transitionErr *orders.InvalidTransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		render.ChiErr(w, r, err, InvalidOrderTransition,
+			render.WithStatusCode(http.StatusConflict))
+		return
+	case errors.Is(err, orders.ErrTrackingNumberRequired):
+		render.ChiErr(w, r, err, TrackingNumberRequired,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	case err != nil:
+		h.logger.Errorw("Failed to update order status", "order_id", record.ID, "to", to, "error", err)
+		render.ChiErr(w, r, err, FailedToUpdateOrderStatus,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	h.logger.Infow(
+		"order status changed",
+		"order_number", updated.OrderNumber,
+		"status", updated.Status,
+		"actor_ref", actorRef(r),
+	)
+
+	record, ok = findOrder(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	render.ChiJSON(w, r, renderOrder(record))
+}
+
+var _ router.Handler = (*OrderActionHandler)(nil)
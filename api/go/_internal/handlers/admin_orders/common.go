@@ -0,0 +1,62 @@
+package admin_orders
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type HandlerParams struct {
+	fx.In
+
+	DAO    *orders.OrderDAO
+	Auth   *middlewares.Auth
+	Logger *zap.SugaredLogger
+}
+
+// findOrder loads the order of the {id} URL param, rendering the error when it fails
+func findOrder(w http.ResponseWriter, r *http.Request, dao *orders.OrderDAO, logger *zap.SugaredLogger) (*orders.OrderRecord, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		render.ChiErr(w, r, errors.New("invalid order id"), InvalidOrderRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return nil, false
+	}
+
+	record, err := dao.GetOrderRecord(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		render.ChiErr(w, r, errors.New("order not found"), OrderNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return nil, false
+	}
+	if err != nil {
+		logger.Errorw("Failed to load order", "order_id", id, "error", err)
+		render.ChiErr(w, r, err, FailedToLoadOrders,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return nil, false
+	}
+
+	return record, true
+}
+
+// actorRef identifies the staff member in the order status history
+func actorRef(r *http.Request) string {
+	user, ok := middlewares.UserFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	if user.Email.Valid {
+		return fmt.Sprintf("user:%d:%s", user.ID, user.Email.String)
+	}
+	return fmt.Sprintf("user:%d", user.ID)
+}
@@ -0,0 +1,17 @@
+package admin_orders
+
+const (
+	InvalidOrderRequest       = "INVALID_ORDER_REQUEST"
+	OrderNotFound             = "ORDER_NOT_FOUND"
+	InvalidOrderTransition    = "INVALID_ORDER_TRANSITION"
+	TrackingNumberRequired    = "TRACKING_NUMBER_REQUIRED"
+	FailedToLoadOrders        = "FAILED_TO_LOAD_ORDERS"
+	FailedToExportOrders      = "FAILED_TO_EXPORT_ORDERS"
+	FailedToUpdateOrderStatus = "FAILED_TO_UPDATE_ORDER_STATUS"
+	OrderNotRefundable        = "ORDER_NOT_REFUNDABLE"
+	InvalidRefundItem         = "INVALID_REFUND_ITEM"
+	NothingToRefund           = "NOTHING_TO_REFUND"
+	AlreadyRestocked          = "ALREADY_RESTOCKED"
+	RefundRejected            = "REFUND_REJECTED"
+	FailedToRefundOrder       = "FAILED_TO_REFUND_ORDER"
+)
@@ -0,0 +1,45 @@
+package admin_orders
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// GetOrderHandler shows an order with its items, payments, refunds, shipments, addresses and
+// status history
+type GetOrderHandler struct {
+	dao    *orders.OrderDAO
+	auth   *middlewares.Auth
+	logger *zap.SugaredLogger
+}
+
+func NewGetOrderHandler(p HandlerParams) *GetOrderHandler {
+	return &GetOrderHandler{
+		dao:    p.DAO,
+		auth:   p.Auth,
+		logger: p.Logger,
+	}
+}
+
+func (h *GetOrderHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermOrdersRead)...).Get("/v1/admin/orders/{id}", h.Handle)
+}
+
+func (h *GetOrderHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	record, ok := findOrder(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	render.ChiJSON(w, r, renderOrder(record))
+}
+
+var _ router.Handler = (*GetOrderHandler)(nil)
@@ -0,0 +1,52 @@
+package admin_orders
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	h := &ListOrdersHandler{validator: validator.New()}
+
+	r := httptest.NewRequest("GET", "/v1/admin/orders?status=paid&from=2025-06-01&to=2025-06-30&email=Buyer&order_number=1042&page=3&per_page=10", nil)
+	query, params, err := h.parseQuery(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, query.Page)
+	assert.Equal(t, 10, params.Limit)
+	assert.Equal(t, 20, params.Offset)
+	if assert.NotNil(t, params.Status) {
+		assert.Equal(t, db.OrderStatusPaid, *params.Status)
+	}
+	if assert.NotNil(t, params.OrderNumber) {
+		assert.EqualValues(t, 1042, *params.OrderNumber)
+	}
+	assert.Equal(t, "Buyer", params.Email)
+
+	// The range covers whole days in Taiwan time, to included.
+	require.NotNil(t, params.CreatedFrom)
+	require.NotNil(t, params.CreatedTo)
+	assert.True(t, params.CreatedFrom.Equal(time.Date(2025, 5, 31, 16, 0, 0, 0, time.UTC)), "from = %v", params.CreatedFrom)
+	assert.True(t, params.CreatedTo.Equal(time.Date(2025, 6, 30, 16, 0, 0, 0, time.UTC)), "to = %v", params.CreatedTo)
+
+	for _, bad := range []string{
+		"status=lost",
+		"from=2025-6-1",
+		"to=2025-02-30",
+		"per_page=500",
+		"page=0",
+		"order_number=abc",
+		"format=xlsx",
+	} {
+		r := httptest.NewRequest("GET", "/v1/admin/orders?"+bad, nil)
+		_, _, err := h.parseQuery(r)
+		assert.Error(t, err, bad)
+	}
+}
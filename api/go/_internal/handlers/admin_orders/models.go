@@ -0,0 +1,144 @@
+package admin_orders
+
+import (
+	"encoding/json"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+)
+
+// Actions map the action URL param to the status it moves the order to. Orders become
+// refunded through refunds, which return the money as well.
+const (
+	ActionProcess = "process"
+	ActionShip    = "ship"
+	ActionCancel  = "cancel"
+)
+
+var actionStatuses = map[string]db.OrderStatus{
+	ActionProcess: db.OrderStatusProcessing,
+	ActionShip:    db.OrderStatusShipped,
+	ActionCancel:  db.OrderStatusCanceled,
+}
+
+// ListOrdersQuery holds the query params of the order list. Dates are days in Taiwan time, to
+// is inclusive.
+type ListOrdersQuery struct {
+	Status      string `validate:"omitempty"`
+	From        string `validate:"omitempty,datetime=2006-01-02"`
+	To          string `validate:"omitempty,datetime=2006-01-02"`
+	Email       string `validate:"omitempty,max=255"`
+	OrderNumber string `validate:"omitempty,number"`
+	Format      string `validate:"omitempty,oneof=json csv"`
+	Page        int    `validate:"min=1"`
+	PerPage     int    `validate:"min=1,max=100"`
+}
+
+type OrderActionRequest struct {
+	// TrackingNumber is required to ship
+	TrackingNumber string `json:"tracking_number" validate:"omitempty,max=100"`
+	Note           string `json:"note" validate:"omitempty,max=1000"`
+}
+
+type RefundItemRequest struct {
+	OrderItemID int64 `json:"order_item_id" validate:"required"`
+	Quantity    int32 `json:"quantity" validate:"required,min=1"`
+}
+
+// RefundOrderRequest refunds the items, or everything not refunded yet without any
+type RefundOrderRequest struct {
+	Items   []RefundItemRequest `json:"items" validate:"omitempty,dive"`
+	Restock bool                `json:"restock"`
+	Reason  string              `json:"reason" validate:"omitempty,max=1000"`
+}
+
+// PaginationMeta represents pagination metadata
+type PaginationMeta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+type ListOrdersResponse struct {
+	Orders     []orders.OrderSummary `json:"orders"`
+	Pagination PaginationMeta        `json:"pagination"`
+}
+
+// The jsonb columns are []byte in the db models, the response types render them as JSON
+// instead of base64.
+
+type OrderItemResponse struct {
+	db.OrderItem
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+type PaymentResponse struct {
+	db.Payment
+	RawResponse json.RawMessage `json:"raw_response"`
+}
+
+type RefundResponse struct {
+	db.Refund
+	RawResponse json.RawMessage `json:"raw_response"`
+}
+
+type ShipmentResponse struct {
+	db.Shipment
+	DimensionsCm   json.RawMessage `json:"dimensions_cm"`
+	CarrierPayload json.RawMessage `json:"carrier_payload"`
+}
+
+type OrderResponse struct {
+	db.Order
+	Items        []OrderItemResponse     `json:"items"`
+	Payments     []PaymentResponse       `json:"payments"`
+	Refunds      []RefundResponse        `json:"refunds"`
+	RefundItems  []db.RefundItem         `json:"refund_items"`
+	Shipments    []ShipmentResponse      `json:"shipments"`
+	Addresses    []db.Address            `json:"addresses"`
+	History      []db.OrderStatusHistory `json:"history"`
+	NextStatuses []db.OrderStatus        `json:"next_statuses"`
+}
+
+func renderOrder(record *orders.OrderRecord) OrderResponse {
+	resp := OrderResponse{
+		Order:        record.Order,
+		Items:        make([]OrderItemResponse, 0, len(record.Items)),
+		Payments:     make([]PaymentResponse, 0, len(record.Payments)),
+		Refunds:      make([]RefundResponse, 0, len(record.Refunds)),
+		RefundItems:  record.RefundItems,
+		Shipments:    make([]ShipmentResponse, 0, len(record.Shipments)),
+		Addresses:    record.Addresses,
+		History:      record.History,
+		NextStatuses: orders.NextStatuses(record.Status),
+	}
+
+	for _, item := range record.Items {
+		resp.Items = append(resp.Items, OrderItemResponse{
+			OrderItem: item,
+			Metadata:  item.Metadata,
+		})
+	}
+	for _, payment := range record.Payments {
+		resp.Payments = append(resp.Payments, PaymentResponse{
+			Payment:     payment,
+			RawResponse: payment.RawResponse,
+		})
+	}
+	for _, refund := range record.Refunds {
+		resp.Refunds = append(resp.Refunds, RefundResponse{
+			Refund:      refund,
+			RawResponse: refund.RawResponse,
+		})
+	}
+	for _, shipment := range record.Shipments {
+		resp.Shipments = append(resp.Shipments, ShipmentResponse{
+			Shipment:       shipment,
+			DimensionsCm:   shipment.DimensionsCm,
+			CarrierPayload: shipment.CarrierPayload,
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,126 @@
+package admin_orders
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/payments"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// RefundOrderHandler returns all or some items of a paid order and their money through the
+// payment provider. The order becomes refunded with the refund returning its last items.
+type RefundOrderHandler struct {
+	dao       *orders.OrderDAO
+	payments  *payments.Service
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+type RefundOrderHandlerParams struct {
+	fx.In
+
+	DAO      *orders.OrderDAO
+	Payments *payments.Service
+	Auth     *middlewares.Auth
+	Logger   *zap.SugaredLogger
+}
+
+func NewRefundOrderHandler(p RefundOrderHandlerParams) *RefundOrderHandler {
+	return &RefundOrderHandler{
+		dao:       p.DAO,
+		payments:  p.Payments,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *RefundOrderHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermOrdersWrite)...).Post("/v1/admin/orders/{id}/refunds", h.Handle)
+}
+
+func (h *RefundOrderHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	// An empty body refunds the whole order without restocking.
+	var req RefundOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		render.ChiErr(w, r, err, InvalidOrderRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		render.ChiErr(w, r, err, InvalidOrderRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	record, ok := findOrder(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	items := make([]payments.RefundItemParams, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, payments.RefundItemParams{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	_, err := h.payments.Refund(r.Context(), payments.RefundOrderParams{
+		OrderID:  record.ID,
+		Items:    items,
+		Restock:  req.Restock,
+		Reason:   req.Reason,
+		ActorRef: actorRef(r),
+	})
+	switch {
+	case errors.Is(err, payments.ErrOrderNotRefundable):
+		render.ChiErr(w, r, err, OrderNotRefundable,
+			render.WithStatusCode(http.StatusConflict))
+		return
+	case errors.Is(err, payments.ErrNothingToRefund):
+		render.ChiErr(w, r, err, NothingToRefund,
+			render.WithStatusCode(http.StatusConflict))
+		return
+	case errors.Is(err, payments.ErrAlreadyRestocked):
+		render.ChiErr(w, r, err, AlreadyRestocked,
+			render.WithStatusCode(http.StatusConflict))
+		return
+	case errors.Is(err, payments.ErrInvalidRefundItem):
+		render.ChiErr(w, r, err, InvalidRefundItem,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	case errors.Is(err, payments.ErrRefundRejected):
+		render.ChiErr(w, r, err, RefundRejected,
+			render.WithStatusCode(http.StatusBadGateway))
+		return
+	case err != nil:
+		h.logger.Errorw("Failed to refund order", "order_id", record.ID, "error", err)
+		render.ChiErr(w, r, err, FailedToRefundOrder,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	record, ok = findOrder(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	render.ChiJSON(w, r, renderOrder(record),
+		render.WithStatusCode(http.StatusCreated))
+}
+
+var _ router.Handler = (*RefundOrderHandler)(nil)
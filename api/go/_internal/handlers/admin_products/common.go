@@ -0,0 +1,108 @@
+package admin_products
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type HandlerParams struct {
+	fx.In
+
+	DAO    *catalog.ProductDAO
+	Auth   *middlewares.Auth
+	Logger *zap.SugaredLogger
+}
+
+// findProduct loads the product of the {uuid} URL param, rendering the error when it fails
+func findProduct(w http.ResponseWriter, r *http.Request, dao *catalog.ProductDAO, logger *zap.SugaredLogger) (*catalog.ProductDetail, bool) {
+	product, err := dao.GetProductByUUID(r.Context(), chi.URLParam(r, "uuid"))
+	if err == nil {
+		var detail *catalog.ProductDetail
+		detail, err = dao.GetProductDetail(r.Context(), product.ID)
+		if err == nil {
+			return detail, true
+		}
+	}
+
+	renderCatalogErr(w, r, err, logger)
+	return nil, false
+}
+
+// findVariant loads the variant of the {sku} URL param under the product of {uuid}
+func findVariant(w http.ResponseWriter, r *http.Request, dao *catalog.ProductDAO, logger *zap.SugaredLogger) (*db.ProductVariant, bool) {
+	product, err := dao.GetProductByUUID(r.Context(), chi.URLParam(r, "uuid"))
+	if err != nil {
+		renderCatalogErr(w, r, err, logger)
+		return nil, false
+	}
+
+	variant, err := dao.GetVariantBySKU(r.Context(), product.ID, chi.URLParam(r, "sku"))
+	if errors.Is(err, sql.ErrNoRows) {
+		render.ChiErr(w, r, errors.New("product variant not found"), VariantNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return nil, false
+	}
+	if err != nil {
+		renderCatalogErr(w, r, err, logger)
+		return nil, false
+	}
+
+	return variant, true
+}
+
+// userID is the admin making the request, for the logs
+func userID(r *http.Request) int64 {
+	if user, ok := middlewares.UserFromContext(r.Context()); ok {
+		return user.ID
+	}
+	return 0
+}
+
+// renderProductDetail responds with the product as it is after a change
+func renderProductDetail(w http.ResponseWriter, r *http.Request, dao *catalog.ProductDAO, productID int64, logger *zap.SugaredLogger, opts ...func(*render.ChiResponse)) {
+	detail, err := dao.GetProductDetail(r.Context(), productID)
+	if err != nil {
+		renderCatalogErr(w, r, err, logger)
+		return
+	}
+
+	render.ChiJSON(w, r, renderProduct(detail), opts...)
+}
+
+// renderCatalogErr renders the errors of catalog.ProductDAO with their status
+func renderCatalogErr(w http.ResponseWriter, r *http.Request, err error, logger *zap.SugaredLogger) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		render.ChiErr(w, r, errors.New("product not found"), ProductNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+	case errors.Is(err, catalog.ErrSKUTaken):
+		render.ChiErr(w, r, err, SKUAlreadyExists,
+			render.WithStatusCode(http.StatusConflict))
+	case errors.Is(err, catalog.ErrHasOrders):
+		render.ChiErr(w, r, err, ProductHasOrders,
+			render.WithStatusCode(http.StatusConflict))
+	case errors.Is(err, catalog.ErrEmptySKU),
+		errors.Is(err, catalog.ErrEmptyName),
+		errors.Is(err, catalog.ErrInvalidPrice),
+		errors.Is(err, catalog.ErrInvalidOriginalPrice),
+		errors.Is(err, catalog.ErrNotSellable),
+		errors.Is(err, catalog.ErrEmptySpec),
+		errors.Is(err, catalog.ErrDuplicateSpec):
+		render.ChiErr(w, r, err, InvalidProductRequest,
+			render.WithStatusCode(http.StatusUnprocessableEntity))
+	default:
+		logger.Errorw("Failed to save product", "error", err)
+		render.ChiErr(w, r, err, SaveProductFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+	}
+}
@@ -0,0 +1,65 @@
+package admin_products
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// CreateProductHandler creates a product with its specs, off sale unless ready_for_sale is set
+type CreateProductHandler struct {
+	dao       *catalog.ProductDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewCreateProductHandler(p HandlerParams) *CreateProductHandler {
+	return &CreateProductHandler{
+		dao:       p.DAO,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *CreateProductHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermProductsWrite)...).Post("/v1/admin/products", h.Handle)
+}
+
+func (h *CreateProductHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	var req CreateProductRequest
+	if !render.DecodeJSON(w, r, h.validator, &req, InvalidProductRequest) {
+		return
+	}
+
+	product, err := h.dao.CreateProduct(r.Context(), catalog.CreateProductParams{
+		SKU:           req.SKU,
+		Name:          req.Name,
+		Category:      req.Category,
+		Price:         req.Price,
+		OriginalPrice: req.OriginalPrice,
+		ShortDesc:     req.ShortDesc,
+		FullDesc:      req.FullDesc,
+		ReadyForSale:  req.ReadyForSale,
+		Specs:         toSpecs(req.Specs),
+	})
+	if err != nil {
+		renderCatalogErr(w, r, err, h.logger)
+		return
+	}
+
+	h.logger.Infow("Product created", "sku", product.Sku, "uuid", product.Uuid, "user_id", userID(r))
+
+	renderProductDetail(w, r, h.dao, product.ID, h.logger, render.WithStatusCode(http.StatusCreated))
+}
+
+var _ router.Handler = (*CreateProductHandler)(nil)
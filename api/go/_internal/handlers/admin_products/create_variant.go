@@ -0,0 +1,64 @@
+package admin_products
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// CreateVariantHandler adds a variant to a product and responds with the product
+type CreateVariantHandler struct {
+	dao       *catalog.ProductDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewCreateVariantHandler(p HandlerParams) *CreateVariantHandler {
+	return &CreateVariantHandler{
+		dao:       p.DAO,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *CreateVariantHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermProductsWrite)...).Post("/v1/admin/products/{uuid}/variants", h.Handle)
+}
+
+func (h *CreateVariantHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	detail, ok := findProduct(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	var req CreateVariantRequest
+	if !render.DecodeJSON(w, r, h.validator, &req, InvalidProductRequest) {
+		return
+	}
+
+	variant, err := h.dao.CreateVariant(r.Context(), detail.Product.ID, catalog.CreateVariantParams{
+		SKU:   req.SKU,
+		Name:  req.Name,
+		Price: req.Price,
+	})
+	if err != nil {
+		renderCatalogErr(w, r, err, h.logger)
+		return
+	}
+
+	h.logger.Infow("Product variant created", "sku", variant.Sku, "product_sku", detail.Product.Sku, "user_id", userID(r))
+
+	renderProductDetail(w, r, h.dao, detail.Product.ID, h.logger, render.WithStatusCode(http.StatusCreated))
+}
+
+var _ router.Handler = (*CreateVariantHandler)(nil)
@@ -0,0 +1,55 @@
+package admin_products
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// DeleteProductHandler deletes a product that was never ordered, ordered products can only be
+// taken off sale
+type DeleteProductHandler struct {
+	dao    *catalog.ProductDAO
+	auth   *middlewares.Auth
+	logger *zap.SugaredLogger
+}
+
+func NewDeleteProductHandler(p HandlerParams) *DeleteProductHandler {
+	return &DeleteProductHandler{
+		dao:    p.DAO,
+		auth:   p.Auth,
+		logger: p.Logger,
+	}
+}
+
+func (h *DeleteProductHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermProductsWrite)...).Delete("/v1/admin/products/{uuid}", h.Handle)
+}
+
+func (h *DeleteProductHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	detail, ok := findProduct(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	if err := h.dao.DeleteProduct(r.Context(), detail.Product.ID); err != nil {
+		renderCatalogErr(w, r, err, h.logger)
+		return
+	}
+
+	h.logger.Infow("Product deleted", "sku", detail.Product.Sku, "user_id", userID(r))
+
+	render.ChiJSON(w, r, DeleteProductResponse{
+		UUID: detail.Product.Uuid,
+		SKU:  detail.Product.Sku,
+	})
+}
+
+var _ router.Handler = (*DeleteProductHandler)(nil)
@@ -0,0 +1,50 @@
+package admin_products
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// DeleteVariantHandler deletes a variant that was never ordered and responds with the product
+type DeleteVariantHandler struct {
+	dao    *catalog.ProductDAO
+	auth   *middlewares.Auth
+	logger *zap.SugaredLogger
+}
+
+func NewDeleteVariantHandler(p HandlerParams) *DeleteVariantHandler {
+	return &DeleteVariantHandler{
+		dao:    p.DAO,
+		auth:   p.Auth,
+		logger: p.Logger,
+	}
+}
+
+func (h *DeleteVariantHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermProductsWrite)...).Delete("/v1/admin/products/{uuid}/variants/{sku}", h.Handle)
+}
+
+func (h *DeleteVariantHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	variant, ok := findVariant(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	if err := h.dao.DeleteVariant(r.Context(), variant.ID); err != nil {
+		renderCatalogErr(w, r, err, h.logger)
+		return
+	}
+
+	h.logger.Infow("Product variant deleted", "sku", variant.Sku, "user_id", userID(r))
+
+	renderProductDetail(w, r, h.dao, variant.ProductID, h.logger)
+}
+
+var _ router.Handler = (*DeleteVariantHandler)(nil)
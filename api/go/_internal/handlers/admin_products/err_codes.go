@@ -0,0 +1,10 @@
+package admin_products
+
+const (
+	InvalidProductRequest = "INVALID_PRODUCT_REQUEST"
+	ProductNotFound       = "PRODUCT_NOT_FOUND"
+	VariantNotFound       = "VARIANT_NOT_FOUND"
+	SKUAlreadyExists      = "SKU_ALREADY_EXISTS"
+	ProductHasOrders      = "PRODUCT_HAS_ORDERS"
+	SaveProductFailed     = "SAVE_PRODUCT_FAILED"
+)
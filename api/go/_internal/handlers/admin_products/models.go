@@ -0,0 +1,130 @@
+package admin_products
+
+import (
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SpecRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Value string `json:"value" validate:"required,max=255"`
+}
+
+type CreateProductRequest struct {
+	SKU           string        `json:"sku" validate:"required,max=100"`
+	Name          string        `json:"name" validate:"required,max=255"`
+	Category      *string       `json:"category" validate:"omitempty,max=100"`
+	Price         float64       `json:"price" validate:"required,gt=0"`
+	OriginalPrice *float64      `json:"original_price" validate:"omitempty,gte=0"`
+	ShortDesc     *string       `json:"short_desc"`
+	FullDesc      *string       `json:"full_desc"`
+	ReadyForSale  bool          `json:"ready_for_sale"`
+	Specs         []SpecRequest `json:"specs" validate:"dive"`
+}
+
+// UpdateProductRequest changes the fields it carries. Specs replace all the specs of the
+// product when present, an empty list removes them.
+type UpdateProductRequest struct {
+	Name          *string       `json:"name" validate:"omitempty,min=1,max=255"`
+	Category      *string       `json:"category" validate:"omitempty,max=100"`
+	Price         *float64      `json:"price" validate:"omitempty,gt=0"`
+	OriginalPrice *float64      `json:"original_price" validate:"omitempty,gte=0"`
+	ShortDesc     *string       `json:"short_desc"`
+	FullDesc      *string       `json:"full_desc"`
+	ReadyForSale  *bool         `json:"ready_for_sale"`
+	Specs         []SpecRequest `json:"specs" validate:"omitempty,dive"`
+}
+
+// CreateVariantRequest adds a variant, without a price it sells at the product's price
+type CreateVariantRequest struct {
+	SKU   string   `json:"sku" validate:"required,max=100"`
+	Name  string   `json:"name" validate:"required,max=255"`
+	Price *float64 `json:"price" validate:"omitempty,gt=0"`
+}
+
+type UpdateVariantRequest struct {
+	Name  *string  `json:"name" validate:"omitempty,min=1,max=255"`
+	Price *float64 `json:"price" validate:"omitempty,gt=0"`
+}
+
+type ProductResponse struct {
+	UUID          string            `json:"uuid"`
+	SKU           string            `json:"sku"`
+	Slug          string            `json:"slug"`
+	Name          string            `json:"name"`
+	Category      pgtype.Text       `json:"category"`
+	Price         pgtype.Numeric    `json:"price"`
+	OriginalPrice pgtype.Numeric    `json:"original_price"`
+	ShortDesc     pgtype.Text       `json:"short_desc"`
+	FullDesc      pgtype.Text       `json:"full_desc"`
+	ReadyForSale  bool              `json:"ready_for_sale"`
+	StockCount    int32             `json:"stock_count"`
+	ReservedCount int32             `json:"reserved_count"`
+	Specs         []catalog.Spec    `json:"specs"`
+	Variants      []VariantResponse `json:"variants"`
+}
+
+type VariantResponse struct {
+	UUID          string         `json:"uuid"`
+	SKU           string         `json:"sku"`
+	Name          string         `json:"name"`
+	Price         pgtype.Numeric `json:"price"`
+	StockCount    int32          `json:"stock_count"`
+	ReservedCount int32          `json:"reserved_count"`
+}
+
+type DeleteProductResponse struct {
+	UUID string `json:"uuid"`
+	SKU  string `json:"sku"`
+}
+
+func renderProduct(detail *catalog.ProductDetail) ProductResponse {
+	variants := make([]VariantResponse, len(detail.Variants))
+	for i, variant := range detail.Variants {
+		variants[i] = renderVariant(variant)
+	}
+
+	product := detail.Product
+	return ProductResponse{
+		UUID:          product.Uuid,
+		SKU:           product.Sku,
+		Slug:          product.Slug.String,
+		Name:          product.Name,
+		Category:      product.Category,
+		Price:         product.Price,
+		OriginalPrice: product.OriginalPrice,
+		ShortDesc:     product.ShortDesc,
+		FullDesc:      product.FullDesc,
+		ReadyForSale:  product.ReadyForSale,
+		StockCount:    product.StockCount,
+		ReservedCount: product.ReservedCount,
+		Specs:         detail.Specs,
+		Variants:      variants,
+	}
+}
+
+func renderVariant(variant db.ProductVariant) VariantResponse {
+	return VariantResponse{
+		UUID:          variant.Uuid.String,
+		SKU:           variant.Sku,
+		Name:          variant.Name,
+		Price:         variant.Price,
+		StockCount:    variant.StockCount,
+		ReservedCount: variant.ReservedCount,
+	}
+}
+
+// toSpecs returns nil for nil so an update without specs leaves them untouched
+func toSpecs(specs []SpecRequest) []catalog.Spec {
+	if specs == nil {
+		return nil
+	}
+
+	res := make([]catalog.Spec, len(specs))
+	for i, spec := range specs {
+		res[i] = catalog.Spec{Name: spec.Name, Value: spec.Value}
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package admin_products
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// UpdateProductHandler changes the fields of a product, putting it on or off sale with
+// ready_for_sale
+type UpdateProductHandler struct {
+	dao       *catalog.ProductDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewUpdateProductHandler(p HandlerParams) *UpdateProductHandler {
+	return &UpdateProductHandler{
+		dao:       p.DAO,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *UpdateProductHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermProductsWrite)...).Patch("/v1/admin/products/{uuid}", h.Handle)
+}
+
+func (h *UpdateProductHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	detail, ok := findProduct(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	var req UpdateProductRequest
+	if !render.DecodeJSON(w, r, h.validator, &req, InvalidProductRequest) {
+		return
+	}
+
+	product, err := h.dao.UpdateProduct(r.Context(), detail.Product.ID, catalog.UpdateProductParams{
+		Name:          req.Name,
+		Category:      req.Category,
+		Price:         req.Price,
+		OriginalPrice: req.OriginalPrice,
+		ShortDesc:     req.ShortDesc,
+		FullDesc:      req.FullDesc,
+		ReadyForSale:  req.ReadyForSale,
+		Specs:         toSpecs(req.Specs),
+	})
+	if err != nil {
+		renderCatalogErr(w, r, err, h.logger)
+		return
+	}
+
+	h.logger.Infow("Product updated", "sku", product.Sku, "ready_for_sale", product.ReadyForSale, "user_id", userID(r))
+
+	renderProductDetail(w, r, h.dao, product.ID, h.logger)
+}
+
+var _ router.Handler = (*UpdateProductHandler)(nil)
@@ -0,0 +1,62 @@
+package admin_products
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// UpdateVariantHandler changes the name or price of a variant and responds with the product
+type UpdateVariantHandler struct {
+	dao       *catalog.ProductDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewUpdateVariantHandler(p HandlerParams) *UpdateVariantHandler {
+	return &UpdateVariantHandler{
+		dao:       p.DAO,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *UpdateVariantHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermProductsWrite)...).Patch("/v1/admin/products/{uuid}/variants/{sku}", h.Handle)
+}
+
+func (h *UpdateVariantHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	variant, ok := findVariant(w, r, h.dao, h.logger)
+	if !ok {
+		return
+	}
+
+	var req UpdateVariantRequest
+	if !render.DecodeJSON(w, r, h.validator, &req, InvalidProductRequest) {
+		return
+	}
+
+	if _, err := h.dao.UpdateVariant(r.Context(), variant.ID, catalog.UpdateVariantParams{
+		Name:  req.Name,
+		Price: req.Price,
+	}); err != nil {
+		renderCatalogErr(w, r, err, h.logger)
+		return
+	}
+
+	h.logger.Infow("Product variant updated", "sku", variant.Sku, "user_id", userID(r))
+
+	renderProductDetail(w, r, h.dao, variant.ProductID, h.logger)
+}
+
+var _ router.Handler = (*UpdateVariantHandler)(nil)
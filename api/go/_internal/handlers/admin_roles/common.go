@@ -0,0 +1,70 @@
+package admin_roles
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type HandlerParams struct {
+	fx.In
+
+	Roles  *rbac.RoleDAO
+	DB     db.Conn
+	Auth   *middlewares.Auth
+	Logger *zap.SugaredLogger
+}
+
+// findUserID returns the id of the user in the {id} URL param, rendering the error when the
+// user doesn't exist
+func findUserID(w http.ResponseWriter, r *http.Request, conn db.Conn, logger *zap.SugaredLogger) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		render.ChiErr(w, r, errors.New("invalid user id"), InvalidRolesRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return 0, false
+	}
+
+	var userID int64
+	err = conn.GetContext(r.Context(), &userID, `SELECT id FROM users WHERE id = $1 AND deleted_at IS NULL`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		render.ChiErr(w, r, errors.New("user not found"), UserNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return 0, false
+	}
+	if err != nil {
+		logger.Errorw("Failed to load user", "user_id", id, "error", err)
+		render.ChiErr(w, r, err, FailedToLoadRoles,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// renderUserRoles renders the current roles and permissions of the user
+func renderUserRoles(w http.ResponseWriter, r *http.Request, dao *rbac.RoleDAO, userID int64, logger *zap.SugaredLogger) {
+	roles, permissions, err := dao.UserAccess(r.Context(), userID)
+	if err != nil {
+		logger.Errorw("Failed to load roles of user", "user_id", userID, "error", err)
+		render.ChiErr(w, r, err, FailedToLoadRoles,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	render.ChiJSON(w, r, UserRolesResponse{
+		UserID:      userID,
+		Roles:       roles,
+		Permissions: permissions,
+	})
+}
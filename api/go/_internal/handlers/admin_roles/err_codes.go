@@ -0,0 +1,9 @@
+package admin_roles
+
+const (
+	InvalidRolesRequest = "INVALID_ROLES_REQUEST"
+	UserNotFound        = "USER_NOT_FOUND"
+	UnknownRole         = "UNKNOWN_ROLE"
+	FailedToLoadRoles   = "FAILED_TO_LOAD_ROLES"
+	FailedToSaveRoles   = "FAILED_TO_SAVE_ROLES"
+)
@@ -0,0 +1,45 @@
+package admin_roles
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// GetUserRolesHandler shows the roles of a user and the permissions they add up to
+type GetUserRolesHandler struct {
+	roles  *rbac.RoleDAO
+	db     db.Conn
+	auth   *middlewares.Auth
+	logger *zap.SugaredLogger
+}
+
+func NewGetUserRolesHandler(p HandlerParams) *GetUserRolesHandler {
+	return &GetUserRolesHandler{
+		roles:  p.Roles,
+		db:     p.DB,
+		auth:   p.Auth,
+		logger: p.Logger,
+	}
+}
+
+func (h *GetUserRolesHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermRolesWrite)...).Get("/v1/admin/users/{id}/roles", h.Handle)
+}
+
+func (h *GetUserRolesHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := findUserID(w, r, h.db, h.logger)
+	if !ok {
+		return
+	}
+
+	renderUserRoles(w, r, h.roles, userID, h.logger)
+}
+
+var _ router.Handler = (*GetUserRolesHandler)(nil)
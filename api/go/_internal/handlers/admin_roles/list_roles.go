@@ -0,0 +1,46 @@
+package admin_roles
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// ListRolesHandler lists the roles and the permissions they grant
+type ListRolesHandler struct {
+	roles  *rbac.RoleDAO
+	auth   *middlewares.Auth
+	logger *zap.SugaredLogger
+}
+
+func NewListRolesHandler(p HandlerParams) *ListRolesHandler {
+	return &ListRolesHandler{
+		roles:  p.Roles,
+		auth:   p.Auth,
+		logger: p.Logger,
+	}
+}
+
+func (h *ListRolesHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermRolesWrite)...).Get("/v1/admin/roles", h.Handle)
+}
+
+func (h *ListRolesHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roles.ListRoles(r.Context())
+	if err != nil {
+		h.logger.Errorw("Failed to list roles", "error", err)
+		render.ChiErr(w, r, err, FailedToLoadRoles,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	render.ChiJSON(w, r, ListRolesResponse{Roles: roles})
+}
+
+var _ router.Handler = (*ListRolesHandler)(nil)
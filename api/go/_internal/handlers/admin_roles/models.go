@@ -0,0 +1,17 @@
+package admin_roles
+
+import "github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,dive,required"`
+}
+
+type ListRolesResponse struct {
+	Roles []rbac.Role `json:"roles"`
+}
+
+type UserRolesResponse struct {
+	UserID      int64    `json:"user_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
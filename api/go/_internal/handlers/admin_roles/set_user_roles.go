@@ -0,0 +1,82 @@
+package admin_roles
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// SetUserRolesHandler replaces the roles of a user, an empty list revokes every role
+type SetUserRolesHandler struct {
+	roles     *rbac.RoleDAO
+	db        db.Conn
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewSetUserRolesHandler(p HandlerParams) *SetUserRolesHandler {
+	return &SetUserRolesHandler{
+		roles:     p.Roles,
+		db:        p.DB,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *SetUserRolesHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermRolesWrite)...).Put("/v1/admin/users/{id}/roles", h.Handle)
+}
+
+func (h *SetUserRolesHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	var req SetUserRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.ChiErr(w, r, err, InvalidRolesRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		render.ChiErr(w, r, err, InvalidRolesRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	userID, ok := findUserID(w, r, h.db, h.logger)
+	if !ok {
+		return
+	}
+
+	err := h.roles.SetUserRoles(r.Context(), userID, req.Roles)
+	if errors.Is(err, rbac.ErrUnknownRole) {
+		render.ChiErr(w, r, err, UnknownRole,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("Failed to set roles of user", "user_id", userID, "error", err)
+		render.ChiErr(w, r, err, FailedToSaveRoles,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	var actorID int64
+	if actor, ok := middlewares.UserFromContext(r.Context()); ok {
+		actorID = actor.ID
+	}
+	h.logger.Infow("User roles updated", "user_id", userID, "roles", req.Roles, "actor_id", actorID)
+
+	renderUserRoles(w, r, h.roles, userID, h.logger)
+}
+
+var _ router.Handler = (*SetUserRolesHandler)(nil)
@@ -0,0 +1,7 @@
+package files
+
+const (
+	InvalidFileKey   = "INVALID_FILE_KEY"
+	InvalidSignature = "INVALID_SIGNATURE"
+	UploadFileFailed = "UPLOAD_FILE_FAILED"
+)
@@ -0,0 +1,105 @@
+package files
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// maxUploadBytes caps uploads through signed URLs
+const maxUploadBytes = 20 << 20
+
+// FileHandler serves the files of the local blob store in development: GET downloads a file,
+// PUT uploads one through a signed URL. It answers 404 for any other store and in production.
+type FileHandler struct {
+	store  storage.BlobStore
+	cfg    *configs.Config
+	logger *zap.SugaredLogger
+}
+
+type FileHandlerParams struct {
+	fx.In
+
+	Store  storage.BlobStore
+	Config *configs.Config
+	Logger *zap.SugaredLogger
+}
+
+func NewFileHandler(p FileHandlerParams) *FileHandler {
+	return &FileHandler{
+		store:  p.Store,
+		cfg:    p.Config,
+		logger: p.Logger,
+	}
+}
+
+func (h *FileHandler) RegisterRoutes(r *chi.Mux) {
+	r.Get("/v1/files/*", h.Handle)
+	r.Put("/v1/files/*", h.Handle)
+}
+
+func (h *FileHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	local, ok := h.store.(*storage.LocalStore)
+	if !ok || h.cfg.ENV == configs.Production {
+		http.NotFound(w, r)
+		return
+	}
+
+	key := chi.URLParam(r, "*")
+	filePath, err := local.Path(key)
+	if err != nil {
+		render.ChiErr(
+			w, r,
+			err,
+			InvalidFileKey,
+			render.WithStatusCode(http.StatusBadRequest),
+		)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		http.ServeFile(w, r, filePath)
+		return
+	}
+
+	if err := local.VerifySignature(http.MethodPut, key, r.URL.Query(), time.Now()); err != nil {
+		render.ChiErr(
+			w, r,
+			err,
+			InvalidSignature,
+			render.WithStatusCode(http.StatusForbidden),
+		)
+		return
+	}
+
+	url, err := local.Upload(r.Context(), key, http.MaxBytesReader(w, r.Body, maxUploadBytes), r.Header.Get("Content-Type"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			statusCode = http.StatusRequestEntityTooLarge
+		}
+
+		h.logger.Errorw("Failed to store uploaded file", "key", key, "error", err)
+		render.ChiErr(
+			w, r,
+			err,
+			UploadFileFailed,
+			render.WithStatusCode(statusCode),
+		)
+		return
+	}
+
+	render.ChiJSON(w, r, map[string]string{"url": url}, render.WithStatusCode(http.StatusCreated))
+}
+
+var _ router.Handler = (*FileHandler)(nil)
@@ -0,0 +1,68 @@
+package images
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// AttachImageHandler links an existing image to another entity, typically a product image to
+// one of its variants, without uploading it again
+type AttachImageHandler struct {
+	gallery   *GalleryDAO
+	images    *imageingest.ImageDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewAttachImageHandler(p GalleryHandlerParams) *AttachImageHandler {
+	return &AttachImageHandler{
+		gallery:   p.Gallery,
+		images:    p.Images,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *AttachImageHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermImagesWrite)...).Post("/v1/admin/images/{id}/entities", h.Handle)
+}
+
+func (h *AttachImageHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	imageID, ok := imageIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req AttachImageRequest
+	if !render.DecodeJSON(w, r, h.validator, &req, InvalidGalleryRequest) {
+		return
+	}
+
+	entity, ok := resolveEntity(ctx, w, r, h.images, req.EntityRef, h.logger)
+	if !ok {
+		return
+	}
+
+	image, err := h.gallery.Attach(ctx, imageID, entity, req.AltText)
+	if err != nil {
+		renderGalleryErr(w, r, err, h.logger)
+		return
+	}
+
+	render.ChiJSON(w, r, image, render.WithStatusCode(http.StatusCreated))
+}
+
+var _ router.Handler = (*AttachImageHandler)(nil)
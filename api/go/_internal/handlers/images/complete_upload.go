@@ -0,0 +1,184 @@
+package images
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// CompleteUploadHandler checks an image uploaded through a signed URL and records it after the
+// existing images of the product or variant. The image is served from the uploaded file until
+// UploadProcessor replaces it with the processed one and its renditions.
+type CompleteUploadHandler struct {
+	dao       *imageingest.ImageDAO
+	store     storage.BlobStore
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+type CompleteUploadHandlerParams struct {
+	fx.In
+
+	DAO    *imageingest.ImageDAO
+	Store  storage.BlobStore
+	Auth   *middlewares.Auth
+	Logger *zap.SugaredLogger
+}
+
+func NewCompleteUploadHandler(p CompleteUploadHandlerParams) *CompleteUploadHandler {
+	return &CompleteUploadHandler{
+		dao:       p.DAO,
+		store:     p.Store,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *CompleteUploadHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermImagesWrite)...).Post("/v1/admin/images/uploads/complete", h.Handle)
+}
+
+func (h *CompleteUploadHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CompleteUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.ChiErr(w, r, err, InvalidUploadRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		render.ChiErr(w, r, err, InvalidUploadRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	// Keys are issued under the SKU, anything else wasn't issued for this entity.
+	if !strings.HasPrefix(req.Key, req.SKU+"/") || path.Dir(req.Key) != req.SKU {
+		render.ChiErr(w, r, fmt.Errorf("key %s is not an upload of %s", req.Key, req.SKU), InvalidUploadRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	entity, err := h.dao.FindEntity(ctx, req.EntityType, req.SKU)
+	if errors.Is(err, imageingest.ErrEntityNotFound) {
+		render.ChiErr(w, r, err, EntityNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		h.fail(w, r, "Failed to find entity for upload", req.Key, err)
+		return
+	}
+
+	info, err := h.store.Stat(ctx, req.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		render.ChiErr(w, r, err, UploadNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		h.fail(w, r, "Failed to stat uploaded file", req.Key, err)
+		return
+	}
+
+	head, err := h.sniff(r, req.Key)
+	if err != nil {
+		h.fail(w, r, "Failed to download uploaded file", req.Key, err)
+		return
+	}
+
+	if err := validateUpload(req.Key, info, head); err != nil {
+		h.reject(w, r, req.Key, err)
+		return
+	}
+
+	upload, err := h.dao.CreateUpload(ctx, entity, h.store.PublicURL(req.Key), req.Key)
+	if errors.Is(err, imageingest.ErrUploadRecorded) {
+		render.ChiErr(w, r, err, UploadAlreadyCompleted,
+			render.WithStatusCode(http.StatusConflict))
+		return
+	}
+	if err != nil {
+		h.fail(w, r, "Failed to record uploaded image", req.Key, err)
+		return
+	}
+
+	render.ChiJSON(w, r, CompleteUploadResponse{
+		ID:        upload.ImageID,
+		URL:       h.store.PublicURL(req.Key),
+		SortOrder: upload.SortOrder,
+		IsPrimary: upload.SortOrder == 0,
+		Status:    imageingest.ProcessingStatusPending,
+	}, render.WithStatusCode(http.StatusAccepted))
+}
+
+// sniff reads the first bytes of the uploaded file, enough to detect its content type
+func (h *CompleteUploadHandler) sniff(r *http.Request, key string) ([]byte, error) {
+	body, err := h.store.Download(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(io.LimitReader(body, sniffLen))
+}
+
+// reject deletes an upload failing the checks
+func (h *CompleteUploadHandler) reject(w http.ResponseWriter, r *http.Request, key string, err error) {
+	if deleteErr := h.store.Delete(r.Context(), key); deleteErr != nil {
+		h.logger.Warnw("Failed to delete invalid upload", "key", key, "error", deleteErr)
+	}
+	render.ChiErr(w, r, err, InvalidUpload,
+		render.WithStatusCode(http.StatusUnprocessableEntity))
+}
+
+func (h *CompleteUploadHandler) fail(w http.ResponseWriter, r *http.Request, msg, key string, err error) {
+	h.logger.Errorw(msg, "key", key, "error", err)
+	render.ChiErr(w, r, err, CompleteUploadFailed,
+		render.WithStatusCode(http.StatusInternalServerError))
+}
+
+// validateUpload checks the stored file against what upload URLs are issued for. The content
+// type the client sent is checked against the one sniffed from the head of the content too.
+func validateUpload(key string, info storage.FileInfo, head []byte) error {
+	if info.Size <= 0 || info.Size > MaxUploadBytes {
+		return fmt.Errorf("image must be between 1 byte and %d MB, got %d bytes", MaxUploadBytes>>20, info.Size)
+	}
+
+	contentType, _, _ := strings.Cut(info.ContentType, ";")
+	contentType = strings.TrimSpace(contentType)
+	ext, ok := uploadExtensions[contentType]
+	if !ok {
+		return fmt.Errorf("unsupported content type %q", info.ContentType)
+	}
+	if path.Ext(key) != ext {
+		return fmt.Errorf("content type %s doesn't match %s", contentType, path.Base(key))
+	}
+
+	if sniffed := http.DetectContentType(head); sniffed != contentType {
+		return fmt.Errorf("content of %s is %s, not %s", path.Base(key), sniffed, contentType)
+	}
+
+	return nil
+}
+
+var _ router.Handler = (*CompleteUploadHandler)(nil)
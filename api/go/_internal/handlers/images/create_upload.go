@@ -0,0 +1,115 @@
+package images
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// CreateUploadHandler issues short-lived signed URLs so admins upload images straight to the
+// blob store, without going through the function and its body and duration limits
+type CreateUploadHandler struct {
+	dao       *imageingest.ImageDAO
+	store     storage.BlobStore
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+type CreateUploadHandlerParams struct {
+	fx.In
+
+	DAO    *imageingest.ImageDAO
+	Store  storage.BlobStore
+	Auth   *middlewares.Auth
+	Logger *zap.SugaredLogger
+}
+
+func NewCreateUploadHandler(p CreateUploadHandlerParams) *CreateUploadHandler {
+	return &CreateUploadHandler{
+		dao:       p.DAO,
+		store:     p.Store,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *CreateUploadHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermImagesWrite)...).Post("/v1/admin/images/uploads", h.Handle)
+}
+
+func (h *CreateUploadHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.ChiErr(w, r, err, InvalidUploadRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		render.ChiErr(w, r, err, InvalidUploadRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	entity, err := h.dao.FindEntity(ctx, req.EntityType, req.SKU)
+	if errors.Is(err, imageingest.ErrEntityNotFound) {
+		render.ChiErr(w, r, err, EntityNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("Failed to find entity for upload", "entity_type", req.EntityType, "sku", req.SKU, "error", err)
+		render.ChiErr(w, r, err, CreateUploadFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	id, err := gonanoid.New(12)
+	if err != nil {
+		render.ChiErr(w, r, err, CreateUploadFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	// Same layout as the upload script, so cleaning a SKU removes these files too.
+	key := entity.SKU + "/" + id + uploadExtensions[req.ContentType]
+	expiresAt := time.Now().Add(UploadURLExpiry)
+
+	uploadURL, err := h.store.SignedURL(ctx, key, storage.SignedURLOptions{
+		Method: http.MethodPut,
+		Expiry: UploadURLExpiry,
+	})
+	if err != nil {
+		h.logger.Errorw("Failed to sign upload URL", "key", key, "error", err)
+		render.ChiErr(w, r, err, CreateUploadFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	render.ChiJSON(w, r, CreateUploadResponse{
+		Key:       key,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers:   storage.UploadHeaders(h.store, req.ContentType),
+		ExpiresAt: expiresAt,
+	}, render.WithStatusCode(http.StatusCreated))
+}
+
+var _ router.Handler = (*CreateUploadHandler)(nil)
@@ -0,0 +1,104 @@
+package images
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DeleteImageHandler removes an image from a product or variant. Once no entity uses the image
+// its row and files, renditions included, are deleted too.
+type DeleteImageHandler struct {
+	gallery   *GalleryDAO
+	images    *imageingest.ImageDAO
+	store     storage.BlobStore
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+type DeleteImageHandlerParams struct {
+	fx.In
+
+	Gallery *GalleryDAO
+	Images  *imageingest.ImageDAO
+	Store   storage.BlobStore
+	Auth    *middlewares.Auth
+	Logger  *zap.SugaredLogger
+}
+
+func NewDeleteImageHandler(p DeleteImageHandlerParams) *DeleteImageHandler {
+	return &DeleteImageHandler{
+		gallery:   p.Gallery,
+		images:    p.Images,
+		store:     p.Store,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *DeleteImageHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermImagesWrite)...).Delete("/v1/admin/images/{id}", h.Handle)
+}
+
+func (h *DeleteImageHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	imageID, ok := imageIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	ref := entityRefFromQuery(r)
+	if err := h.validator.Struct(ref); err != nil {
+		render.ChiErr(w, r, err, InvalidGalleryRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	entity, ok := resolveEntity(ctx, w, r, h.images, ref, h.logger)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.gallery.Detach(ctx, entity, imageID)
+	if err != nil {
+		renderGalleryErr(w, r, err, h.logger)
+		return
+	}
+
+	// The rows are gone already, files left behind are picked up by the image GC.
+	if deleted != nil {
+		for _, url := range imageURLs(deleted) {
+			key, ok := storage.KeyFromURL(h.store, url)
+			if !ok {
+				h.logger.Warnw("Image is not served by the blob store, leaving it", "url", url)
+				continue
+			}
+			if err := h.store.Delete(ctx, key); err != nil {
+				h.logger.Warnw("Failed to delete image file", "key", key, "error", err)
+			}
+		}
+	}
+
+	images, err := h.gallery.ListImages(ctx, entity)
+	if err != nil {
+		renderGalleryErr(w, r, err, h.logger)
+		return
+	}
+
+	render.ChiJSON(w, r, GalleryResponse{Images: images})
+}
+
+var _ router.Handler = (*DeleteImageHandler)(nil)
@@ -0,0 +1,18 @@
+package images
+
+const (
+	InvalidUploadRequest   = "INVALID_UPLOAD_REQUEST"
+	EntityNotFound         = "ENTITY_NOT_FOUND"
+	CreateUploadFailed     = "CREATE_UPLOAD_FAILED"
+	UploadNotFound         = "UPLOAD_NOT_FOUND"
+	InvalidUpload          = "INVALID_UPLOAD"
+	CompleteUploadFailed   = "COMPLETE_UPLOAD_FAILED"
+	UploadAlreadyCompleted = "UPLOAD_ALREADY_COMPLETED"
+	InvalidGalleryRequest  = "INVALID_GALLERY_REQUEST"
+	ImageNotFound          = "IMAGE_NOT_FOUND"
+	ImageNotLinked         = "IMAGE_NOT_LINKED"
+	ImageAlreadyLinked     = "IMAGE_ALREADY_LINKED"
+	IncompleteImageOrder   = "INCOMPLETE_IMAGE_ORDER"
+	UpdateGalleryFailed    = "UPDATE_GALLERY_FAILED"
+	ProcessUploadsFailed   = "PROCESS_UPLOADS_FAILED"
+)
@@ -0,0 +1,72 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// entityRefFromQuery reads the entity of GET and DELETE requests, which have no body
+func entityRefFromQuery(r *http.Request) EntityRef {
+	return EntityRef{
+		EntityType: db.EntityType(r.URL.Query().Get("entity_type")),
+		SKU:        r.URL.Query().Get("sku"),
+	}
+}
+
+func imageIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	imageID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || imageID <= 0 {
+		render.ChiErr(w, r, errors.New("image id must be a positive integer"), InvalidGalleryRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return 0, false
+	}
+	return imageID, true
+}
+
+// resolveEntity finds the product or variant of ref, rendering the error when it fails
+func resolveEntity(ctx context.Context, w http.ResponseWriter, r *http.Request, dao *imageingest.ImageDAO, ref EntityRef, logger *zap.SugaredLogger) (imageingest.Entity, bool) {
+	entity, err := dao.FindEntity(ctx, ref.EntityType, ref.SKU)
+	if errors.Is(err, imageingest.ErrEntityNotFound) {
+		render.ChiErr(w, r, err, EntityNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return imageingest.Entity{}, false
+	}
+	if err != nil {
+		logger.Errorw("Failed to find entity", "entity_type", ref.EntityType, "sku", ref.SKU, "error", err)
+		render.ChiErr(w, r, err, UpdateGalleryFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return imageingest.Entity{}, false
+	}
+	return entity, true
+}
+
+// renderGalleryErr renders the errors of GalleryDAO with their status
+func renderGalleryErr(w http.ResponseWriter, r *http.Request, err error, logger *zap.SugaredLogger) {
+	switch {
+	case errors.Is(err, ErrImageNotFound):
+		render.ChiErr(w, r, err, ImageNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+	case errors.Is(err, ErrImageNotLinked):
+		render.ChiErr(w, r, err, ImageNotLinked,
+			render.WithStatusCode(http.StatusNotFound))
+	case errors.Is(err, ErrImageAlreadyLinked):
+		render.ChiErr(w, r, err, ImageAlreadyLinked,
+			render.WithStatusCode(http.StatusConflict))
+	case errors.Is(err, ErrIncompleteOrder):
+		render.ChiErr(w, r, err, IncompleteImageOrder,
+			render.WithStatusCode(http.StatusUnprocessableEntity))
+	default:
+		logger.Errorw("Failed to update gallery", "error", err)
+		render.ChiErr(w, r, err, UpdateGalleryFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+	}
+}
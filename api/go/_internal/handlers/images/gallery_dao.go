@@ -0,0 +1,307 @@
+package images
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+var (
+	ErrImageNotFound      = errors.New("image not found")
+	ErrImageNotLinked     = errors.New("image is not an image of the entity")
+	ErrImageAlreadyLinked = errors.New("image is already an image of the entity")
+	ErrIncompleteOrder    = errors.New("order must list every image of the entity exactly once")
+)
+
+// GalleryDAO manages the images of products and variants through image_entities
+type GalleryDAO struct {
+	db *sqlx.DB
+}
+
+type GalleryDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewGalleryDAO(p GalleryDAOParams) *GalleryDAO {
+	return &GalleryDAO{db: p.DB}
+}
+
+// ListImages returns the images of the entity in display order
+func (dao *GalleryDAO) ListImages(ctx context.Context, entity imageingest.Entity) ([]GalleryImage, error) {
+	query := `
+		SELECT
+			i.id,
+			i.url,
+			i.width,
+			i.height,
+			i.renditions,
+			COALESCE(ie.alt_text, '') as alt_text,
+			ie.is_primary,
+			COALESCE(ie.sort_order, 0) as sort_order
+		FROM image_entities ie
+		JOIN images i ON ie.image_id = i.id
+		WHERE ie.entity_id = $1 AND ie.entity_type = $2
+		ORDER BY ie.sort_order, ie.id
+	`
+
+	images := make([]GalleryImage, 0)
+	if err := dao.db.SelectContext(ctx, &images, query, entity.ID, entity.Type); err != nil {
+		return nil, fmt.Errorf("failed to list images of %s: %w", entity.SKU, err)
+	}
+	return images, nil
+}
+
+// Reorder sets the sort order of the entity's images to their position in imageIDs, which has
+// to list all of them
+func (dao *GalleryDAO) Reorder(ctx context.Context, entity imageingest.Entity, imageIDs []int64) error {
+	_, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var current []int64
+		if err := tx.SelectContext(
+			ctx,
+			&current,
+			`SELECT image_id FROM image_entities WHERE entity_id = $1 AND entity_type = $2 FOR UPDATE`,
+			entity.ID,
+			entity.Type,
+		); err != nil {
+			return nil, fmt.Errorf("failed to lock images of %s: %w", entity.SKU, err)
+		}
+
+		requested := slices.Clone(imageIDs)
+		slices.Sort(current)
+		slices.Sort(requested)
+		if !slices.Equal(current, requested) {
+			return nil, ErrIncompleteOrder
+		}
+
+		updateQuery := `
+			UPDATE image_entities
+			SET sort_order = $1, updated_at = NOW()
+			WHERE entity_id = $2 AND entity_type = $3 AND image_id = $4
+		`
+		for sortOrder, imageID := range imageIDs {
+			if _, err := tx.ExecContext(ctx, updateQuery, sortOrder, entity.ID, entity.Type, imageID); err != nil {
+				return nil, fmt.Errorf("failed to reorder image %d: %w", imageID, err)
+			}
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+// SetPrimary makes the image the entity's primary one, unsetting the previous primary first as
+// image_entities_one_primary_idx allows a single one
+func (dao *GalleryDAO) SetPrimary(ctx context.Context, entity imageingest.Entity, imageID int64) error {
+	_, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		if err := lockLink(ctx, tx, entity, imageID); err != nil {
+			return nil, err
+		}
+
+		unsetQuery := `
+			UPDATE image_entities
+			SET is_primary = false, updated_at = NOW()
+			WHERE entity_id = $1 AND entity_type = $2 AND is_primary AND image_id <> $3
+		`
+		if _, err := tx.ExecContext(ctx, unsetQuery, entity.ID, entity.Type, imageID); err != nil {
+			return nil, fmt.Errorf("failed to unset primary image: %w", err)
+		}
+
+		setQuery := `
+			UPDATE image_entities
+			SET is_primary = true, updated_at = NOW()
+			WHERE entity_id = $1 AND entity_type = $2 AND image_id = $3
+		`
+		if _, err := tx.ExecContext(ctx, setQuery, entity.ID, entity.Type, imageID); err != nil {
+			return nil, fmt.Errorf("failed to set primary image: %w", err)
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+func (dao *GalleryDAO) UpdateAltText(ctx context.Context, entity imageingest.Entity, imageID int64, altText string) error {
+	query := `
+		UPDATE image_entities
+		SET alt_text = $1, updated_at = NOW()
+		WHERE entity_id = $2 AND entity_type = $3 AND image_id = $4
+	`
+	res, err := dao.db.ExecContext(ctx, query, altText, entity.ID, entity.Type, imageID)
+	if err != nil {
+		return fmt.Errorf("failed to update alt text: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrImageNotLinked
+	}
+	return nil
+}
+
+// Attach links an existing image to the entity after its other images. It becomes the primary
+// image when the entity has none.
+func (dao *GalleryDAO) Attach(ctx context.Context, imageID int64, entity imageingest.Entity, altText string) (GalleryImage, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var image GalleryImage
+		imageQuery := `SELECT id, url, width, height, renditions FROM images WHERE id = $1`
+		err := tx.GetContext(ctx, &image, imageQuery, imageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImageNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find image %d: %w", imageID, err)
+		}
+
+		var state struct {
+			Linked     bool `json:"linked"`
+			HasPrimary bool `json:"has_primary"`
+			SortOrder  int  `json:"sort_order"`
+		}
+		stateQuery := `
+			SELECT
+				COALESCE(BOOL_OR(image_id = $3), false) as linked,
+				COALESCE(BOOL_OR(is_primary), false) as has_primary,
+				COALESCE(MAX(sort_order) + 1, 0) as sort_order
+			FROM image_entities
+			WHERE entity_id = $1 AND entity_type = $2
+		`
+		if err := tx.GetContext(ctx, &state, stateQuery, entity.ID, entity.Type, imageID); err != nil {
+			return nil, fmt.Errorf("failed to read images of %s: %w", entity.SKU, err)
+		}
+		if state.Linked {
+			return nil, ErrImageAlreadyLinked
+		}
+
+		image.AltText = altText
+		image.IsPrimary = !state.HasPrimary
+		image.SortOrder = state.SortOrder
+
+		insertQuery := `
+			INSERT INTO image_entities (entity_id, image_id, alt_text, is_primary, sort_order, entity_type)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`
+		if _, err := tx.ExecContext(
+			ctx,
+			insertQuery,
+			entity.ID,
+			imageID,
+			altText,
+			image.IsPrimary,
+			image.SortOrder,
+			entity.Type,
+		); err != nil {
+			return nil, fmt.Errorf("failed to attach image %d: %w", imageID, err)
+		}
+
+		return image, nil
+	})
+	if err != nil {
+		return GalleryImage{}, err
+	}
+
+	return res.(GalleryImage), nil
+}
+
+// Detach removes the image from the entity, promoting the next image when it was the primary
+// one. An image left without entities is deleted and returned so its files can be removed.
+func (dao *GalleryDAO) Detach(ctx context.Context, entity imageingest.Entity, imageID int64) (*db.Image, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var wasPrimary bool
+		deleteQuery := `
+			DELETE FROM image_entities
+			WHERE entity_id = $1 AND entity_type = $2 AND image_id = $3
+			RETURNING is_primary
+		`
+		err := tx.GetContext(ctx, &wasPrimary, deleteQuery, entity.ID, entity.Type, imageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImageNotLinked
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to detach image %d: %w", imageID, err)
+		}
+
+		if wasPrimary {
+			promoteQuery := `
+				UPDATE image_entities
+				SET is_primary = true, updated_at = NOW()
+				WHERE id = (
+					SELECT id
+					FROM image_entities
+					WHERE entity_id = $1 AND entity_type = $2
+					ORDER BY sort_order, id
+					LIMIT 1
+				)
+			`
+			if _, err := tx.ExecContext(ctx, promoteQuery, entity.ID, entity.Type); err != nil {
+				return nil, fmt.Errorf("failed to promote next primary image: %w", err)
+			}
+		}
+
+		var image db.Image
+		orphanQuery := `
+			DELETE FROM images
+			WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM image_entities WHERE image_id = $1)
+			RETURNING id, url, created_at, updated_at, width, height, renditions
+		`
+		err = tx.GetContext(ctx, &image, orphanQuery, imageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return (*db.Image)(nil), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete image %d: %w", imageID, err)
+		}
+
+		return &image, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*db.Image), nil
+}
+
+// lockLink locks the entity's link to the image, ErrImageNotLinked if there is none
+func lockLink(ctx context.Context, tx *sqlx.Tx, entity imageingest.Entity, imageID int64) error {
+	var id int64
+	query := `
+		SELECT id
+		FROM image_entities
+		WHERE entity_id = $1 AND entity_type = $2 AND image_id = $3
+		FOR UPDATE
+	`
+	err := tx.GetContext(ctx, &id, query, entity.ID, entity.Type, imageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrImageNotLinked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock image %d: %w", imageID, err)
+	}
+	return nil
+}
+
+// imageURLs returns the URLs of the image's files, the original and its renditions
+func imageURLs(image *db.Image) []string {
+	urls := []string{image.Url}
+
+	var renditions imageproc.Renditions
+	if err := renditions.Scan(image.Renditions); err == nil {
+		for _, rendition := range renditions {
+			urls = append(urls, rendition.URL)
+		}
+	}
+	return urls
+}
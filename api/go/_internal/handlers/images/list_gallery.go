@@ -0,0 +1,74 @@
+package images
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ListGalleryHandler lists the images of a product or variant with their gallery settings
+type ListGalleryHandler struct {
+	gallery   *GalleryDAO
+	images    *imageingest.ImageDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+type GalleryHandlerParams struct {
+	fx.In
+
+	Gallery *GalleryDAO
+	Images  *imageingest.ImageDAO
+	Auth    *middlewares.Auth
+	Logger  *zap.SugaredLogger
+}
+
+func NewListGalleryHandler(p GalleryHandlerParams) *ListGalleryHandler {
+	return &ListGalleryHandler{
+		gallery:   p.Gallery,
+		images:    p.Images,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *ListGalleryHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermImagesWrite)...).Get("/v1/admin/images", h.Handle)
+}
+
+func (h *ListGalleryHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ref := entityRefFromQuery(r)
+	if err := h.validator.Struct(ref); err != nil {
+		render.ChiErr(w, r, err, InvalidGalleryRequest,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	entity, ok := resolveEntity(ctx, w, r, h.images, ref, h.logger)
+	if !ok {
+		return
+	}
+
+	images, err := h.gallery.ListImages(ctx, entity)
+	if err != nil {
+		renderGalleryErr(w, r, err, h.logger)
+		return
+	}
+
+	render.ChiJSON(w, r, GalleryResponse{Images: images})
+}
+
+var _ router.Handler = (*ListGalleryHandler)(nil)
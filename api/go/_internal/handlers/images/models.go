@@ -0,0 +1,98 @@
+package images
+
+import (
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// MaxUploadBytes caps images uploaded through signed URLs
+	MaxUploadBytes = 10 << 20
+	// UploadURLExpiry is how long a signed upload URL stays valid
+	UploadURLExpiry = 15 * time.Minute
+	// sniffLen is how much of an upload is read to detect its content type
+	sniffLen = 512
+)
+
+// uploadExtensions are the accepted content types and the extension of their files
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// CreateUploadRequest asks for a URL to upload an image of the product or variant with the SKU
+type CreateUploadRequest struct {
+	EntityType  db.EntityType `json:"entity_type" validate:"required,oneof=product product_variant"`
+	SKU         string        `json:"sku" validate:"required"`
+	ContentType string        `json:"content_type" validate:"required,oneof=image/jpeg image/png image/webp"`
+	Size        int64         `json:"size" validate:"required,gt=0,lte=10485760"`
+}
+
+// CreateUploadResponse is where and how to PUT the image, then complete the upload with Key
+type CreateUploadResponse struct {
+	Key       string            `json:"key"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// CompleteUploadRequest records an image uploaded through a signed URL
+type CompleteUploadRequest struct {
+	EntityType db.EntityType `json:"entity_type" validate:"required,oneof=product product_variant"`
+	SKU        string        `json:"sku" validate:"required"`
+	Key        string        `json:"key" validate:"required"`
+}
+
+// CompleteUploadResponse is the recorded image, served from the uploaded file while its
+// renditions are generated
+type CompleteUploadResponse struct {
+	ID        int64  `json:"id"`
+	URL       string `json:"url"`
+	SortOrder int    `json:"sort_order"`
+	IsPrimary bool   `json:"is_primary"`
+	Status    string `json:"status"`
+}
+
+// EntityRef is the product or variant a gallery request is about
+type EntityRef struct {
+	EntityType db.EntityType `json:"entity_type" validate:"required,oneof=product product_variant"`
+	SKU        string        `json:"sku" validate:"required"`
+}
+
+// GalleryImage is an image as linked to a product or variant
+type GalleryImage struct {
+	ID         int64                `json:"id"`
+	URL        string               `json:"url"`
+	Width      pgtype.Int4          `json:"width"`
+	Height     pgtype.Int4          `json:"height"`
+	Renditions imageproc.Renditions `json:"renditions"`
+	AltText    string               `json:"alt_text"`
+	IsPrimary  bool                 `json:"is_primary"`
+	SortOrder  int                  `json:"sort_order"`
+}
+
+type GalleryResponse struct {
+	Images []GalleryImage `json:"images"`
+}
+
+// ReorderImagesRequest lists every image of the entity in the new order
+type ReorderImagesRequest struct {
+	EntityRef
+	ImageIDs []int64 `json:"image_ids" validate:"required,min=1,dive,gt=0"`
+}
+
+type UpdateImageRequest struct {
+	EntityRef
+	AltText string `json:"alt_text" validate:"max=255"`
+}
+
+// AttachImageRequest links an image of a product to one of its variants, or any other entity
+type AttachImageRequest struct {
+	EntityRef
+	AltText string `json:"alt_text" validate:"max=255"`
+}
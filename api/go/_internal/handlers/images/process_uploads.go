@@ -0,0 +1,56 @@
+package images
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ProcessUploadsHandler is hit by Vercel Cron to generate the renditions of completed uploads
+type ProcessUploadsHandler struct {
+	config    *configs.Config
+	processor *UploadProcessor
+	logger    *zap.SugaredLogger
+}
+
+type ProcessUploadsHandlerParams struct {
+	fx.In
+
+	Config    *configs.Config
+	Processor *UploadProcessor
+	Logger    *zap.SugaredLogger
+}
+
+func NewProcessUploadsHandler(p ProcessUploadsHandlerParams) *ProcessUploadsHandler {
+	return &ProcessUploadsHandler{
+		config:    p.Config,
+		processor: p.Processor,
+		logger:    p.Logger,
+	}
+}
+
+func (h *ProcessUploadsHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.CronSecret(h.config)).Get("/v1/cron/image-processing", h.Handle)
+}
+
+func (h *ProcessUploadsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	result, err := h.processor.Process(r.Context())
+	if err != nil {
+		h.logger.Errorw("Failed to process uploads", "error", err)
+		render.ChiErr(w, r, err, ProcessUploadsFailed,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	h.logger.Infow("Processed uploads", "processed", result.Processed, "discarded", result.Discarded, "failed", result.Failed)
+	render.ChiJSON(w, r, result)
+}
+
+var _ router.Handler = (*ProcessUploadsHandler)(nil)
@@ -0,0 +1,72 @@
+package images
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// UpdateImageHandler edits the alt text of an image of a product or variant
+type UpdateImageHandler struct {
+	gallery   *GalleryDAO
+	images    *imageingest.ImageDAO
+	auth      *middlewares.Auth
+	validator *validator.Validate
+	logger    *zap.SugaredLogger
+}
+
+func NewUpdateImageHandler(p GalleryHandlerParams) *UpdateImageHandler {
+	return &UpdateImageHandler{
+		gallery:   p.Gallery,
+		images:    p.Images,
+		auth:      p.Auth,
+		validator: validator.New(),
+		logger:    p.Logger,
+	}
+}
+
+func (h *UpdateImageHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.WithPermission(h.auth, rbac.PermImagesWrite)...).Patch("/v1/admin/images/{id}", h.Handle)
+}
+
+func (h *UpdateImageHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	imageID, ok := imageIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateImageRequest
+	if !render.DecodeJSON(w, r, h.validator, &req, InvalidGalleryRequest) {
+		return
+	}
+
+	entity, ok := resolveEntity(ctx, w, r, h.images, req.EntityRef, h.logger)
+	if !ok {
+		return
+	}
+
+	if err := h.gallery.UpdateAltText(ctx, entity, imageID, req.AltText); err != nil {
+		renderGalleryErr(w, r, err, h.logger)
+		return
+	}
+
+	images, err := h.gallery.ListImages(ctx, entity)
+	if err != nil {
+		renderGalleryErr(w, r, err, h.logger)
+		return
+	}
+
+	render.ChiJSON(w, r, GalleryResponse{Images: images})
+}
+
+var _ router.Handler = (*UpdateImageHandler)(nil)
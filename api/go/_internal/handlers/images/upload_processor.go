@@ -0,0 +1,168 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// processingBudget is how long a run keeps claiming uploads, below the function's maxDuration
+	// in vercel.json so the upload in progress can finish
+	processingBudget      = 40 * time.Second
+	processingLease       = 5 * time.Minute
+	processingMaxAttempts = 5
+	processingMaxBackoff  = time.Hour
+)
+
+// UploadProcessor generates the renditions of uploads recorded by CompleteUploadHandler, one
+// upload at a time. The processed file and its renditions replace the upload, which is deleted.
+// Uploads that aren't images the processing accepts are removed from the gallery, other failures
+// are retried with exponential backoff.
+type UploadProcessor struct {
+	dao      *imageingest.ImageDAO
+	ingester *imageingest.Ingester
+	store    storage.BlobStore
+	logger   *zap.SugaredLogger
+}
+
+type UploadProcessorParams struct {
+	fx.In
+
+	DAO      *imageingest.ImageDAO
+	Ingester *imageingest.Ingester
+	Store    storage.BlobStore
+	Logger   *zap.SugaredLogger
+}
+
+func NewUploadProcessor(p UploadProcessorParams) *UploadProcessor {
+	return &UploadProcessor{
+		dao:      p.DAO,
+		ingester: p.Ingester,
+		store:    p.Store,
+		logger:   p.Logger,
+	}
+}
+
+type ProcessResult struct {
+	Processed int `json:"processed"`
+	Discarded int `json:"discarded"`
+	Failed    int `json:"failed"`
+}
+
+// Process works through the due uploads until none is left or the budget is spent
+func (p *UploadProcessor) Process(ctx context.Context) (*ProcessResult, error) {
+	deadline := time.Now().Add(processingBudget)
+
+	result := &ProcessResult{}
+	for time.Now().Before(deadline) {
+		jobs, err := p.dao.ClaimDueProcessingJobs(ctx, 1, processingLease)
+		if err != nil {
+			return result, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+
+		job := jobs[0]
+		err = p.process(ctx, job)
+		switch {
+		case err == nil:
+			result.Processed++
+
+		case isUnprocessable(err):
+			result.Discarded++
+			p.logger.Warnw("Discarding unprocessable upload", "image_id", job.ImageID, "key", job.UploadKey, "error", err)
+
+			if err := p.dao.DiscardUpload(ctx, job.ImageID); err != nil {
+				return result, fmt.Errorf("failed to discard image %d: %w", job.ImageID, err)
+			}
+			if err := p.store.Delete(ctx, job.UploadKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				p.logger.Warnw("Failed to delete unprocessable upload", "key", job.UploadKey, "error", err)
+			}
+
+		default:
+			result.Failed++
+
+			var nextAttemptAt *time.Time
+			if job.Attempts < processingMaxAttempts {
+				next := time.Now().Add(processingBackoff(int(job.Attempts)))
+				nextAttemptAt = &next
+			}
+
+			p.logger.Errorw(
+				"Failed to process upload",
+				"job_id", job.ID,
+				"image_id", job.ImageID,
+				"key", job.UploadKey,
+				"attempts", job.Attempts,
+				"will_retry", nextAttemptAt != nil,
+				"error", err,
+			)
+
+			if err := p.dao.MarkProcessingJobFailed(ctx, job.ID, err.Error(), nextAttemptAt); err != nil {
+				return result, fmt.Errorf("failed to mark image processing job %d as failed: %w", job.ID, err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// process stores the processed upload next to it, keys are "<sku>/<nanoid><ext>". Files stored
+// by a run failing halfway are picked up by scripts/image_gc as no images row points to them.
+func (p *UploadProcessor) process(ctx context.Context, job db.ImageProcessingJob) error {
+	body, err := p.store.Download(ctx, job.UploadKey)
+	if err != nil {
+		return fmt.Errorf("failed to download upload: %w", err)
+	}
+	defer body.Close()
+
+	image, err := p.ingester.StoreImage(ctx, path.Dir(job.UploadKey), io.LimitReader(body, MaxUploadBytes))
+	if err != nil {
+		return err
+	}
+
+	if err := p.dao.CompleteProcessingJob(ctx, job, image); err != nil {
+		return err
+	}
+
+	if err := p.store.Delete(ctx, job.UploadKey); err != nil {
+		p.logger.Warnw("Failed to delete processed upload", "key", job.UploadKey, "error", err)
+	}
+
+	return nil
+}
+
+// isUnprocessable tells errors retrying won't fix: the upload is gone or isn't an image
+// the processing accepts
+func isUnprocessable(err error) bool {
+	return errors.Is(err, storage.ErrNotFound) ||
+		errors.Is(err, imageproc.ErrUnsupportedImage) ||
+		errors.Is(err, imageproc.ErrImageTooLarge)
+}
+
+// processingBackoff returns 1m, 2m, 4m ... capped at processingMaxBackoff for the given attempt
+// number
+func processingBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	d := time.Minute << (attempts - 1)
+	if d <= 0 || d > processingMaxBackoff {
+		return processingMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,9 @@
+package payment_webhooks
+
+const (
+	UnknownPaymentProvider  = "UNKNOWN_PAYMENT_PROVIDER"
+	InvalidPaymentSignature = "INVALID_PAYMENT_SIGNATURE"
+	InvalidPaymentCallback  = "INVALID_PAYMENT_CALLBACK"
+	PaymentNotFound         = "PAYMENT_NOT_FOUND"
+	FailedToApplyPayment    = "FAILED_TO_APPLY_PAYMENT"
+)
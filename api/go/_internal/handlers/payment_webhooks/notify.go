@@ -0,0 +1,120 @@
+package payment_webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/payments"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// NotifyHandler receives the payment results providers post, e.g. to the ECPay ReturnURL.
+// Providers retry until they get their acknowledgement, so anything that should be retried
+// fails with a 5xx and everything else is acknowledged.
+type NotifyHandler struct {
+	provider payments.Provider
+	dao      *payments.PaymentDAO
+	logger   *zap.SugaredLogger
+}
+
+type NotifyHandlerParams struct {
+	fx.In
+
+	Provider payments.Provider
+	DAO      *payments.PaymentDAO
+	Logger   *zap.SugaredLogger
+}
+
+func NewNotifyHandler(p NotifyHandlerParams) *NotifyHandler {
+	return &NotifyHandler{
+		provider: p.Provider,
+		dao:      p.DAO,
+		logger:   p.Logger,
+	}
+}
+
+func (h *NotifyHandler) RegisterRoutes(r *chi.Mux) {
+	r.Post("/v1/webhooks/payments/{provider}", h.Handle)
+}
+
+func (h *NotifyHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if name := chi.URLParam(r, "provider"); name != h.provider.Name() {
+		render.ChiErr(w, r, fmt.Errorf("payment provider %q is not enabled", name), UnknownPaymentProvider,
+			render.WithStatusCode(http.StatusNotFound))
+		return
+	}
+
+	result, err := h.provider.VerifyCallback(ctx, r)
+	if errors.Is(err, payments.ErrInvalidSignature) {
+		h.logger.Warnw("Rejected payment callback with an invalid signature", "provider", h.provider.Name(), "error", err)
+		render.ChiErr(w, r, err, InvalidPaymentSignature,
+			render.WithStatusCode(http.StatusUnauthorized))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("Failed to read payment callback", "provider", h.provider.Name(), "error", err)
+		render.ChiErr(w, r, err, InvalidPaymentCallback,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	logger := h.logger.With("provider", h.provider.Name(), "reference", result.Reference, "status", result.Status)
+
+	if result.Simulated {
+		logger.Warnw("Ignoring simulated payment", "amount", result.Amount)
+		h.ack(w, result)
+		return
+	}
+
+	outcome, err := h.dao.ApplyResult(ctx, h.provider.Name(), result)
+	if errors.Is(err, payments.ErrPaymentNotFound) {
+		logger.Errorw("Payment callback for an unknown payment", "error", err)
+		render.ChiErr(w, r, err, PaymentNotFound,
+			render.WithStatusCode(http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		logger.Errorw("Failed to apply payment result", "error", err)
+		render.ChiErr(w, r, err, FailedToApplyPayment,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	switch {
+	case outcome.Duplicate:
+		logger.Infow("Payment result already applied", "payment_status", outcome.Payment.Status)
+	case outcome.Review != "":
+		logger.Errorw("Payment needs review", "payment_id", outcome.Payment.ID, "reason", outcome.Review)
+	case outcome.OrderPaid:
+		logger.Infow("Order paid", "payment_id", outcome.Payment.ID, "order_id", outcome.Payment.OrderID)
+	default:
+		logger.Infow("Payment updated", "payment_id", outcome.Payment.ID)
+	}
+	for _, shortage := range outcome.Shortages {
+		logger.Errorw("Paid order has more items than stock",
+			"order_id", outcome.Payment.OrderID,
+			"sku", shortage.SKU,
+			"ordered", shortage.Ordered,
+			"in_stock", shortage.InStock,
+		)
+	}
+
+	h.ack(w, result)
+}
+
+// ack answers with the plain text body the provider expects, e.g. "1|OK" for ECPay
+func (h *NotifyHandler) ack(w http.ResponseWriter, result *payments.Result) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(result.Ack))
+}
+
+var _ router.Handler = (*NotifyHandler)(nil)
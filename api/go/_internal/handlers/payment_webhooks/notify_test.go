@@ -0,0 +1,42 @@
+package payment_webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/payments"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNotifyRejectsBeforeTouchingPayments(t *testing.T) {
+	provider := payments.NewFakeProvider()
+	h := NewNotifyHandler(NotifyHandlerParams{
+		Provider: provider,
+		Logger:   zap.NewNop().Sugar(),
+	})
+	mux := chi.NewMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"disabled provider", "/v1/webhooks/payments/ecpay", http.StatusNotFound},
+		{"unknown reference", "/v1/webhooks/payments/fake", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r, err := provider.Callback(tt.target, "K1001XYZ", db.PaymentStatusCaptured, 1280)
+		require.NoError(t, err, tt.name)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		assert.Equal(t, tt.want, w.Code, "%s: body %s", tt.name, w.Body.String())
+	}
+}
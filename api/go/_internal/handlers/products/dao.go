@@ -31,7 +31,10 @@ func (dao *ProductDAO) GetProducts(ctx context.Context, page, perPage int) ([]*P
 			p.stock_count,
 			p.short_desc,
 			COALESCE(variant_count.count, 0) as variant_count,
-			img.url as primary_image_url
+			img.url as primary_image_url,
+			img.width as primary_image_width,
+			img.height as primary_image_height,
+			img.renditions as primary_image_renditions
 		FROM products p
 		LEFT JOIN (
 			SELECT
@@ -43,7 +46,10 @@ func (dao *ProductDAO) GetProducts(ctx context.Context, page, perPage int) ([]*P
 		LEFT JOIN (
 			SELECT DISTINCT ON (ie.entity_id)
 				ie.entity_id,
-				i.url
+				i.url,
+				i.width,
+				i.height,
+				i.renditions
 			FROM image_entities ie
 			JOIN images i ON ie.image_id = i.id
 			WHERE ie.entity_type = 'product' AND ie.is_primary = true
@@ -79,6 +85,9 @@ func (dao *ProductDAO) GetProducts(ctx context.Context, page, perPage int) ([]*P
 			&product.ShortDesc,
 			&variantCount, // This was missing - now properly populated
 			&product.PrimaryImageURL,
+			&product.PrimaryImageWidth,
+			&product.PrimaryImageHeight,
+			&product.PrimaryImageRenditions,
 		)
 		if err != nil {
 			return nil, err
@@ -132,6 +141,9 @@ func (dao *ProductDAO) GetProductByUUID(ctx context.Context, uuid string) (*Prod
 	imagesQuery := `
 		SELECT
 			i.url,
+			i.width,
+			i.height,
+			i.renditions,
 			ie.is_primary,
 			COALESCE(ie.sort_order, 0) as sort_order
 		FROM image_entities ie
@@ -159,12 +171,18 @@ func (dao *ProductDAO) GetProductByUUID(ctx context.Context, uuid string) (*Prod
 			pv.uuid,
 			pv.created_at,
 			pv.updated_at,
-			COALESCE(img.url, '') as image_url
+			COALESCE(img.url, '') as image_url,
+			img.width as image_width,
+			img.height as image_height,
+			COALESCE(img.renditions, '[]'::jsonb) as image_renditions
 		FROM product_variants pv
 		LEFT JOIN (
 			SELECT DISTINCT ON (ie.entity_id)
 				ie.entity_id,
-				i.url
+				i.url,
+				i.width,
+				i.height,
+				i.renditions
 			FROM image_entities ie
 			JOIN images i ON ie.image_id = i.id
 			WHERE ie.entity_type = 'product_variant' AND ie.is_primary = true
@@ -221,12 +239,18 @@ func (dao *ProductDAO) GetProductVariantsByUUID(ctx context.Context, productUUID
 			pv.uuid,
 			pv.created_at,
 			pv.updated_at,
-			COALESCE(img.url, '') as image_url
+			COALESCE(img.url, '') as image_url,
+			img.width as image_width,
+			img.height as image_height,
+			COALESCE(img.renditions, '[]'::jsonb) as image_renditions
 		FROM product_variants pv
 		LEFT JOIN (
 			SELECT DISTINCT ON (ie.entity_id)
 				ie.entity_id,
-				i.url
+				i.url,
+				i.width,
+				i.height,
+				i.renditions
 			FROM image_entities ie
 			JOIN images i ON ie.image_id = i.id
 			WHERE ie.entity_type = 'product_variant' AND ie.is_primary = true
@@ -275,6 +299,9 @@ func (dao *ProductDAO) GetHotSellingProducts(ctx context.Context) ([]*Product, e
 				COALESCE(ps.total_sold, 0) as sales_count,
 				COALESCE(variant_count.count, 0) as variant_count,
 				img.url as primary_image_url,
+				img.width as primary_image_width,
+				img.height as primary_image_height,
+				img.renditions as primary_image_renditions,
 				CASE
 					WHEN ps.total_sold > 0 THEN 1
 					ELSE 2
@@ -291,7 +318,10 @@ func (dao *ProductDAO) GetHotSellingProducts(ctx context.Context) ([]*Product, e
 			LEFT JOIN (
 				SELECT DISTINCT ON (ie.entity_id)
 					ie.entity_id,
-					i.url
+					i.url,
+					i.width,
+					i.height,
+					i.renditions
 				FROM image_entities ie
 				JOIN images i ON ie.image_id = i.id
 				WHERE ie.entity_type = 'product' AND ie.is_primary = true
@@ -310,7 +340,10 @@ func (dao *ProductDAO) GetHotSellingProducts(ctx context.Context) ([]*Product, e
 			stock_count,
 			short_desc,
 			variant_count,
-			primary_image_url
+			primary_image_url,
+			primary_image_width,
+			primary_image_height,
+			primary_image_renditions
 		FROM ranked_products
 		ORDER BY
 			sort_priority ASC,
@@ -344,6 +377,9 @@ func (dao *ProductDAO) GetHotSellingProducts(ctx context.Context) ([]*Product, e
 			&product.ShortDesc,
 			&variantCount,
 			&product.PrimaryImageURL,
+			&product.PrimaryImageWidth,
+			&product.PrimaryImageHeight,
+			&product.PrimaryImageRenditions,
 		)
 		if err != nil {
 			return nil, err
@@ -4,15 +4,19 @@ import (
 	"encoding/json"
 
 	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // ProductListResponse represents a single product in the list
 type Product struct {
 	db.Product
-	PrimaryImageURL pgtype.Text `json:"primary_image_url"`
-	HasVariant      bool        `json:"has_variant"`
-	VariantCount    int64       `json:"variant_count"`
+	PrimaryImageURL        pgtype.Text          `json:"primary_image_url"`
+	PrimaryImageWidth      pgtype.Int4          `json:"primary_image_width"`
+	PrimaryImageHeight     pgtype.Int4          `json:"primary_image_height"`
+	PrimaryImageRenditions imageproc.Renditions `json:"primary_image_renditions"`
+	HasVariant             bool                 `json:"has_variant"`
+	VariantCount           int64                `json:"variant_count"`
 }
 
 // ProductDetailResponse represents the complete product detail API response
@@ -33,8 +37,25 @@ type ProductDetailResponse struct {
 
 // ProductImageResponse represents a product image in the API response
 type ProductImageResponse struct {
-	URL       string `json:"url"`
-	IsPrimary bool   `json:"is_primary"`
+	ImageResponse
+	IsPrimary bool `json:"is_primary"`
+}
+
+// ImageResponse is an image with its renditions, ready for <img srcset> and <picture> sources.
+// SrcSet holds the JPEG/PNG renditions and the original, Sources the WebP renditions. Photos
+// usually have no WebP renditions, see imageproc.Process, so Sources is often empty.
+type ImageResponse struct {
+	URL     string                `json:"url"`
+	Width   int32                 `json:"width,omitempty"`
+	Height  int32                 `json:"height,omitempty"`
+	SrcSet  string                `json:"srcset"`
+	Sources []ImageSourceResponse `json:"sources"`
+}
+
+// ImageSourceResponse is a <source> of a <picture>
+type ImageSourceResponse struct {
+	Type   string `json:"type"`
+	SrcSet string `json:"srcset"`
 }
 
 // ProductSpecResponse represents a product specification
@@ -55,6 +76,7 @@ type ProductVariantResponse struct {
 	SKU        string         `json:"sku"`
 	StockCount int32          `json:"stock_count"`
 	ImageURL   string         `json:"image_url"`
+	Image      *ImageResponse `json:"image"`
 	Price      pgtype.Numeric `json:"price"`
 	UUID       string         `json:"uuid"`
 }
@@ -74,15 +96,21 @@ type ProductDetail struct {
 
 // ProductImageWithEntity represents a product image with entity metadata
 type ProductImageWithEntity struct {
-	URL       string `json:"url"`
-	IsPrimary bool   `json:"is_primary"`
-	SortOrder int    `json:"sort_order"`
+	URL        string               `json:"url"`
+	Width      pgtype.Int4          `json:"width"`
+	Height     pgtype.Int4          `json:"height"`
+	Renditions imageproc.Renditions `json:"renditions"`
+	IsPrimary  bool                 `json:"is_primary"`
+	SortOrder  int                  `json:"sort_order"`
 }
 
 // ProductVariantWithImage represents a product variant with its primary image
 type ProductVariantWithImage struct {
 	db.ProductVariant
-	ImageURL pgtype.Text `json:"image_url"`
+	ImageURL        pgtype.Text          `json:"image_url"`
+	ImageWidth      pgtype.Int4          `json:"image_width"`
+	ImageHeight     pgtype.Int4          `json:"image_height"`
+	ImageRenditions imageproc.Renditions `json:"image_renditions"`
 }
 
 // ParseSpecs parses the JSONB specs column into structured data
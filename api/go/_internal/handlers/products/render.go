@@ -1,6 +1,10 @@
 package products
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -21,6 +25,7 @@ type ProductResponse struct {
 	ShortDesc       pgtype.Text    `json:"short_desc"`
 	VariantCount    int64          `json:"variant_count"`
 	PrimaryImageURL pgtype.Text    `json:"primary_image_url"`
+	PrimaryImage    *ImageResponse `json:"primary_image"`
 	HasVariant      bool           `json:"has_variant"`
 }
 
@@ -28,6 +33,17 @@ func renderProductList(products []*Product) *ProductListAPIResponse {
 	productResponses := make([]*ProductResponse, len(products))
 
 	for i, product := range products {
+		var primaryImage *ImageResponse
+		if product.PrimaryImageURL.Valid {
+			image := renderImage(
+				product.PrimaryImageURL.String,
+				product.PrimaryImageWidth,
+				product.PrimaryImageHeight,
+				product.PrimaryImageRenditions,
+			)
+			primaryImage = &image
+		}
+
 		productResponses[i] = &ProductResponse{
 			UUID:            product.Uuid,
 			SKU:             product.Sku,
@@ -39,6 +55,7 @@ func renderProductList(products []*Product) *ProductListAPIResponse {
 			ShortDesc:       product.ShortDesc,
 			VariantCount:    product.VariantCount,
 			PrimaryImageURL: product.PrimaryImageURL,
+			PrimaryImage:    primaryImage,
 			HasVariant:      product.HasVariant,
 		}
 	}
@@ -62,8 +79,8 @@ func renderProductDetail(productDetail *ProductDetail) *ProductDetailResponse {
 	images := make([]ProductImageResponse, len(productDetail.Images))
 	for i, image := range productDetail.Images {
 		images[i] = ProductImageResponse{
-			URL:       image.URL,
-			IsPrimary: image.IsPrimary,
+			ImageResponse: renderImage(image.URL, image.Width, image.Height, image.Renditions),
+			IsPrimary:     image.IsPrimary,
 		}
 	}
 
@@ -75,6 +92,7 @@ func renderProductDetail(productDetail *ProductDetail) *ProductDetailResponse {
 			SKU:        variant.Sku,
 			StockCount: variant.StockCount,
 			ImageURL:   variant.ImageURL.String,
+			Image:      renderVariantImage(variant),
 			Price:      variant.Price,
 			UUID:       variant.Uuid.String,
 		}
@@ -106,6 +124,7 @@ func renderProductVariantsList(variants []ProductVariantWithImage) *ProductVaria
 			SKU:        variant.Sku,
 			StockCount: variant.StockCount,
 			ImageURL:   variant.ImageURL.String,
+			Image:      renderVariantImage(variant),
 			Price:      variant.Price,
 			UUID:       variant.Uuid.String,
 		})
@@ -115,3 +134,44 @@ func renderProductVariantsList(variants []ProductVariantWithImage) *ProductVaria
 		Variants: variantResponses,
 	}
 }
+
+func renderVariantImage(variant ProductVariantWithImage) *ImageResponse {
+	if variant.ImageURL.String == "" {
+		return nil
+	}
+
+	image := renderImage(variant.ImageURL.String, variant.ImageWidth, variant.ImageHeight, variant.ImageRenditions)
+	return &image
+}
+
+// renderImage builds the srcset of the image. Images uploaded before renditions existed have
+// no size, their srcset is just the URL.
+func renderImage(url string, width, height pgtype.Int4, renditions imageproc.Renditions) ImageResponse {
+	image := ImageResponse{
+		URL:     url,
+		Width:   width.Int32,
+		Height:  height.Int32,
+		SrcSet:  url,
+		Sources: make([]ImageSourceResponse, 0),
+	}
+
+	for _, format := range renditions.Formats() {
+		srcSet := renditions.SrcSet(format)
+		if format == imageproc.FormatWebP {
+			image.Sources = append(image.Sources, ImageSourceResponse{
+				Type:   imageproc.ContentType(format),
+				SrcSet: srcSet,
+			})
+			continue
+		}
+
+		// The original is the widest candidate of the fallback.
+		entries := []string{srcSet}
+		if width.Valid {
+			entries = append(entries, fmt.Sprintf("%s %dw", url, width.Int32))
+		}
+		image.SrcSet = strings.Join(entries, ", ")
+	}
+
+	return image
+}
@@ -0,0 +1,413 @@
+package telegram_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	add_product "github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/add_product"
+	telegramfx "github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/telegramtest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+// fakeProducts is an in-memory add_product.ProductRepository that records the saved products
+type fakeProducts struct {
+	mu    sync.Mutex
+	taken map[string]bool
+	saved []add_product.AddProductSessionState
+}
+
+func newFakeProducts() *fakeProducts {
+	return &fakeProducts{taken: make(map[string]bool)}
+}
+
+func (f *fakeProducts) SKUExists(ctx context.Context, sku string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.taken[sku], nil
+}
+
+func (f *fakeProducts) SaveProduct(ctx context.Context, state *add_product.AddProductSessionState) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	skus := []string{state.Product.SKU}
+	for _, v := range state.Variants {
+		skus = append(skus, v.SKU(state.Product.SKU))
+	}
+	for _, sku := range skus {
+		if f.taken[sku] {
+			return 0, fmt.Errorf("%w: %s", add_product.ErrSKUTaken, sku)
+		}
+	}
+	for _, sku := range skus {
+		f.taken[sku] = true
+	}
+
+	f.saved = append(f.saved, *state)
+	return int64(len(f.saved)), nil
+}
+
+// product returns the saved product with the SKU
+func (f *fakeProducts) product(sku string) (add_product.AddProductSessionState, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, state := range f.saved {
+		if state.Product.SKU == sku {
+			return state, true
+		}
+	}
+	return add_product.AddProductSessionState{}, false
+}
+
+// AddProductTestSuite drives the /add wizard end to end: updates go through TelegramHandler
+// and the bot talks to a fake Bot API. Sessions and products are kept in memory, so the
+// scenarios need no database.
+type AddProductTestSuite struct {
+	suite.Suite
+
+	bot      *telegramtest.Server
+	app      *fx.App
+	handler  *telegram.TelegramHandler
+	store    *telegramtest.Store
+	products *fakeProducts
+
+	user *tgbotapi.User
+	loc  i18n.Localizer
+	sku  string
+}
+
+func (s *AddProductTestSuite) SetupSuite() {
+	s.bot = telegramtest.NewServer()
+}
+
+func (s *AddProductTestSuite) TearDownSuite() {
+	s.bot.Close()
+}
+
+// SetupTest builds the bot on fresh fakes, so no scenario sees another's sessions or products
+func (s *AddProductTestSuite) SetupTest() {
+	s.bot.Reset()
+	s.store = telegramtest.NewStore()
+	s.products = newFakeProducts()
+
+	id := 9_000_000_000 + rand.Int64N(1_000_000_000)
+	s.user = telegramtest.User(id, "zh-TW")
+	s.loc = i18n.For("zh-TW")
+	s.sku = fmt.Sprintf("TEST-%d", id)
+
+	s.app = fx.New(
+		fx.NopLogger,
+		logger.TagLogger("telegram-test"),
+		appfx.CoreConfigOptions,
+		telegramfx.CoreBotOptions,
+
+		fx.Provide(telegram.NewTelegramHandler),
+
+		fx.Decorate(func(cfg *configs.Config) *configs.Config {
+			cfg.Telegram.BotToken = telegramtest.Token
+			cfg.Telegram.APIBaseURL = s.bot.URL
+			cfg.Telegram.StaffUserIDs = []int64{s.user.ID}
+			return cfg
+		}),
+		fx.Decorate(func(commands.Repository) commands.Repository {
+			return s.store
+		}),
+		fx.Decorate(func(add_product.ProductRepository) add_product.ProductRepository {
+			return s.products
+		}),
+
+		fx.Invoke(func(handler *telegram.TelegramHandler) {
+			s.handler = handler
+		}),
+	)
+	require.NoError(s.T(), s.app.Err())
+}
+
+func (s *AddProductTestSuite) TestProductWithoutVariants() {
+	s.command("/add")
+	s.requirePrompt("add.prompt.sku")
+
+	s.reply(s.sku)
+	s.requirePrompt("add.prompt.name")
+
+	s.reply("經典棉 T")
+	s.requirePrompt("add.prompt.category")
+
+	s.reply("上衣")
+	s.requirePrompt("add.prompt.price")
+
+	s.reply("499")
+	s.requirePrompt("add.prompt.variants")
+
+	s.reply("略過")
+	s.requirePrompt("add.prompt.stock")
+
+	s.reply("12")
+	s.requirePrompt("add.prompt.description")
+
+	s.reply("100% 純棉")
+	s.requirePrompt("add.prompt.specs")
+
+	s.reply("材質: 棉")
+	s.requirePrompt("add.spec_added", i18n.Args{"Count": 1})
+
+	s.reply("產地：台灣")
+	s.requirePrompt("add.spec_added", i18n.Args{"Count": 2})
+
+	s.reply("完成")
+	s.requirePrompt("add.prompt.images", i18n.Args{"Max": 5})
+
+	s.photo("photo-1")
+	s.requirePrompt("add.image_uploaded", i18n.Args{"Count": 1, "Max": 5, "Remaining": 4})
+
+	s.reply("完成")
+	confirm := s.bot.LastSent()
+	require.Contains(s.T(), confirm.Text, s.loc.T("add.summary.sku", i18n.Args{"SKU": s.sku}))
+	require.Contains(s.T(), confirm.Text, s.loc.T("add.summary.stock", i18n.Args{"Stock": 12}))
+	require.Equal(s.T(), []string{"add:ok", "add:c"}, s.lastCall().InlineButtons())
+
+	s.press("add:ok")
+	s.requireText("add.success", i18n.Args{"SKU": s.sku})
+	require.NotEmpty(s.T(), s.bot.Calls("answerCallbackQuery"))
+
+	product, ok := s.products.product(s.sku)
+	require.True(s.T(), ok, "the product should be saved")
+	require.Equal(s.T(), "經典棉 T", product.Product.Name)
+	require.Equal(s.T(), "上衣", product.Product.Category)
+	require.Equal(s.T(), 499.0, product.Product.Price)
+	require.Equal(s.T(), 12, product.StockCount())
+	require.Equal(s.T(), "100% 純棉", product.Product.Description)
+	require.Len(s.T(), product.Specs, 2)
+	require.Equal(s.T(), []string{"photo-1"}, product.ImageFileIDs)
+
+	s.requireNoSession()
+}
+
+func (s *AddProductTestSuite) TestProductWithVariants() {
+	s.command("/add")
+	s.reply(s.sku)
+	s.reply("連帽外套")
+	s.reply("外套")
+	s.reply("1280")
+	s.requirePrompt("add.prompt.variants")
+
+	s.addVariant("紅色 M", "RED-M", "1280", "3")
+	s.requirePrompt("add.variant_added", i18n.Args{"Name": "紅色 M", "SKU": s.sku + "-RED-M", "Count": 1})
+
+	s.addVariant("藍色 L", "BLUE-L", "1380", "5")
+	s.requirePrompt("add.variant_added", i18n.Args{"Name": "藍色 L", "SKU": s.sku + "-BLUE-L", "Count": 2})
+
+	s.reply("完成")
+	s.requirePrompt("add.prompt.description")
+
+	s.reply("略過")
+	s.reply("略過")
+	s.requirePrompt("add.prompt.images", i18n.Args{"Max": 5})
+
+	s.reply("略過")
+	require.Contains(s.T(), s.bot.LastSent().Text, s.loc.T("add.summary.stock_from_variants", i18n.Args{"Stock": 8}))
+
+	s.press("add:ok")
+	s.requireText("add.success", i18n.Args{"SKU": s.sku})
+
+	product, ok := s.products.product(s.sku)
+	require.True(s.T(), ok, "the product should be saved")
+	require.Equal(s.T(), 8, product.StockCount())
+
+	variants := product.Variants
+	require.Len(s.T(), variants, 2)
+	require.Equal(s.T(), s.sku+"-RED-M", variants[0].SKU(s.sku))
+	require.Equal(s.T(), 1280.0, variants[0].Price)
+	require.Equal(s.T(), 3, variants[0].Stock)
+	require.Equal(s.T(), s.sku+"-BLUE-L", variants[1].SKU(s.sku))
+	require.Equal(s.T(), 1380.0, variants[1].Price)
+	require.Equal(s.T(), 5, variants[1].Stock)
+}
+
+func (s *AddProductTestSuite) TestInvalidInputAsksAgain() {
+	s.command("/add")
+	s.reply("HAS SPACE")
+	s.requirePrompt("add.invalid_input")
+
+	s.reply(s.sku)
+	s.reply("帽子")
+	s.reply("配件")
+
+	s.reply("免費")
+	s.requirePrompt("add.invalid_price")
+
+	s.reply("-10")
+	s.requirePrompt("add.invalid_price")
+
+	s.reply("350")
+	s.requirePrompt("add.prompt.variants")
+}
+
+func (s *AddProductTestSuite) TestTakenSKU() {
+	s.products.taken[s.sku] = true
+
+	s.command("/add")
+	s.reply(s.sku)
+	s.requirePrompt("add.sku_exists", i18n.Args{"SKU": s.sku})
+
+	s.reply(s.sku + "-NEW")
+	s.requirePrompt("add.prompt.name")
+}
+
+func (s *AddProductTestSuite) TestPauseAndResume() {
+	s.command("/add")
+	s.reply(s.sku)
+	s.requirePrompt("add.prompt.name")
+
+	s.command("/pause")
+	s.requireText("add.paused")
+
+	s.command("/add")
+	s.requireText("add.resume_flow", i18n.Args{
+		"Paused": s.loc.T("add.paused_label"),
+		"Step":   s.loc.T("add.step.name"),
+		"Index":  2,
+		"Total":  10,
+	})
+	require.Equal(s.T(), []string{"add:r", "add:rs", "add:c"}, s.lastCall().InlineButtons())
+
+	s.press("add:r")
+	s.requirePrompt("add.prompt.name")
+
+	// Entered values survive the pause.
+	s.reply("帆布袋")
+	s.reply("包款")
+	s.reply("690")
+	s.reply("略過")
+	s.reply("20")
+	s.reply("略過")
+	s.reply("略過")
+	s.reply("略過")
+	require.Contains(s.T(), s.bot.LastSent().Text, s.loc.T("add.summary.sku", i18n.Args{"SKU": s.sku}))
+}
+
+func (s *AddProductTestSuite) TestCancel() {
+	s.command("/add")
+	s.reply(s.sku)
+
+	s.command("/cancel")
+	s.requireText("add.cancelled")
+	s.requireNoSession()
+
+	// A new /add starts from scratch.
+	s.command("/add")
+	s.requirePrompt("add.prompt.sku")
+}
+
+func (s *AddProductTestSuite) TestEnglishClient() {
+	s.user.LanguageCode = "en"
+	s.loc = i18n.For("en")
+
+	s.command("/add")
+	s.requirePrompt("add.prompt.sku")
+
+	s.reply(s.sku)
+	s.reply("Tote bag")
+	s.reply("Bags")
+	s.reply("690")
+	s.requirePrompt("add.prompt.variants")
+
+	s.reply("skip")
+	s.requirePrompt("add.prompt.stock")
+}
+
+func (s *AddProductTestSuite) TestStrangerIsRejected() {
+	s.user = telegramtest.User(s.user.ID+1, "en")
+	s.loc = i18n.For("en")
+
+	s.command("/add")
+	s.requireText("auth.not_staff")
+	s.requireNoSession()
+
+	s.send(telegramtest.Callback(s.user, s.bot.LastSent(), "add:ok"))
+	answers := s.bot.Calls("answerCallbackQuery")
+	require.NotEmpty(s.T(), answers)
+	require.Equal(s.T(), s.loc.T("auth.not_staff"), answers[len(answers)-1].Text())
+	require.Empty(s.T(), s.products.saved)
+}
+
+func (s *AddProductTestSuite) addVariant(name, suffix, price, stock string) {
+	s.reply(name)
+	s.requirePrompt("add.prompt.variant_suffix", i18n.Args{"Name": name, "SKU": s.sku})
+
+	s.reply(suffix)
+	s.requirePrompt("add.prompt.variant_price", i18n.Args{"Name": name})
+
+	s.reply(price)
+	s.requirePrompt("add.prompt.variant_stock", i18n.Args{"Name": name})
+
+	s.reply(stock)
+	s.requirePrompt("add.prompt.variant_photos", i18n.Args{"Name": name, "Max": 5})
+
+	s.reply("完成")
+}
+
+func (s *AddProductTestSuite) send(update tgbotapi.Update) {
+	require.NoError(s.T(), s.handler.ProcessUpdate(context.Background(), &update))
+}
+
+func (s *AddProductTestSuite) command(text string) {
+	s.send(telegramtest.Command(s.user, text))
+}
+
+// reply answers the latest message the bot sent, as the user does with force reply prompts
+func (s *AddProductTestSuite) reply(text string) {
+	s.send(telegramtest.Reply(s.user, s.bot.LastSent(), text))
+}
+
+func (s *AddProductTestSuite) photo(fileID string) {
+	s.send(telegramtest.PhotoReply(s.user, s.bot.LastSent(), fileID))
+}
+
+// press taps the inline button with data on the latest message the bot sent
+func (s *AddProductTestSuite) press(data string) {
+	s.send(telegramtest.Callback(s.user, s.bot.LastSent(), data))
+}
+
+func (s *AddProductTestSuite) lastCall() telegramtest.Call {
+	calls := s.bot.Calls("sendMessage")
+	require.NotEmpty(s.T(), calls)
+	return calls[len(calls)-1]
+}
+
+// requireText checks the latest message the bot sent against the catalog message id
+func (s *AddProductTestSuite) requireText(id string, args ...i18n.Args) {
+	last := s.bot.LastSent()
+	require.Equal(s.T(), s.user.ID, last.Chat.ID)
+	require.Equal(s.T(), s.loc.T(id, args...), last.Text)
+}
+
+// requirePrompt is requireText for messages that ask for a force reply
+func (s *AddProductTestSuite) requirePrompt(id string, args ...i18n.Args) {
+	s.requireText(id, args...)
+	require.Equal(s.T(), true, s.lastCall().ReplyMarkup()["force_reply"], "%s should ask for a reply", id)
+}
+
+func (s *AddProductTestSuite) requireNoSession() {
+	require.Empty(s.T(), s.store.Sessions(s.user.ID))
+}
+
+func TestAddProductTestSuite(t *testing.T) {
+	suite.Run(t, new(AddProductTestSuite))
+}
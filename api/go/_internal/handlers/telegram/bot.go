@@ -2,13 +2,17 @@ package telegram
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 
-	"github/huangc28/kikichoice-be/api/go/_internal/configs"
-	"github/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
-	"github/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
 
 	"github.com/go-chi/chi/v5"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -34,34 +38,41 @@ type Product struct {
 	Description string  `json:"description"`
 }
 
+// SecretTokenHeader carries the secret_token registered through setWebhook.
+// Telegram sends it with every webhook request.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
 // TelegramHandler handles Telegram webhook requests
 type TelegramHandler struct {
-	config          *configs.Config
-	botAPI          *tgbotapi.BotAPI
-	commandHandlers map[commands.BotCommand]commands.CommandHandler
-	replyProcessor  *ReplyProcessor
-	logger          *zap.SugaredLogger
+	config           *configs.Config
+	botAPI           *tgbotapi.BotAPI
+	commandHandlers  map[commands.BotCommand]commands.CommandHandler
+	replyProcessor   *ReplyProcessor
+	wizardController *WizardController
+	logger           *zap.SugaredLogger
 }
 
 // TelegramHandlerParams defines dependencies for the telegram handler
 type TelegramHandlerParams struct {
 	fx.In
 
-	Config          *configs.Config
-	BotAPI          *tgbotapi.BotAPI
-	CommandHandlers map[commands.BotCommand]commands.CommandHandler
-	ReplyProcessor  *ReplyProcessor
-	Logger          *zap.SugaredLogger
+	Config           *configs.Config
+	BotAPI           *tgbotapi.BotAPI
+	CommandHandlers  map[commands.BotCommand]commands.CommandHandler
+	ReplyProcessor   *ReplyProcessor
+	WizardController *WizardController
+	Logger           *zap.SugaredLogger
 }
 
 // NewTelegramHandler creates a new telegram handler instance
 func NewTelegramHandler(p TelegramHandlerParams) *TelegramHandler {
 	return &TelegramHandler{
-		logger:          p.Logger,
-		config:          p.Config,
-		botAPI:          p.BotAPI,
-		commandHandlers: p.CommandHandlers,
-		replyProcessor:  p.ReplyProcessor,
+		logger:           p.Logger,
+		config:           p.Config,
+		botAPI:           p.BotAPI,
+		commandHandlers:  p.CommandHandlers,
+		replyProcessor:   p.ReplyProcessor,
+		wizardController: p.WizardController,
 	}
 }
 
@@ -74,6 +85,13 @@ func (h *TelegramHandler) RegisterRoutes(r *chi.Mux) {
 func (h *TelegramHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("Processing telegram webhook request")
 
+	if !h.verifySecretToken(r) {
+		h.logger.Warnw("Rejected telegram webhook request with invalid secret token", "remote_addr", r.RemoteAddr)
+		render.ChiErr(w, r, errors.New("invalid telegram secret token"), InvalidSecretToken,
+			render.WithStatusCode(http.StatusUnauthorized))
+		return
+	}
+
 	var update tgbotapi.Update
 	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 		h.logger.Errorw("Failed to decode telegram update", "error", err)
@@ -82,18 +100,15 @@ func (h *TelegramHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if update.Message == nil {
-		h.logger.Info("Received update without message")
-		render.ChiJSON(w, r, nil)
-		return
-	}
+	if err := h.ProcessUpdate(r.Context(), &update); err != nil {
+		code := FailedToProcessMessage
+		if update.CallbackQuery != nil {
+			code = FailedToProcessCallbackQuery
+		}
 
-	message := h.retrieveMessage(&update)
-	if err := h.processMessage(r.Context(), message); err != nil {
-		h.logger.Errorw("Failed to process message", "error", err)
 		render.ChiErr(
 			w, r, err,
-			FailedToProcessMessage,
+			code,
 			render.WithStatusCode(http.StatusOK),
 		)
 		return
@@ -102,6 +117,84 @@ func (h *TelegramHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	render.ChiJSON(w, r, nil)
 }
 
+// ProcessUpdate routes a single update to the command it belongs to. Updates arrive through
+// the webhook in production and through Poller when running locally.
+func (h *TelegramHandler) ProcessUpdate(ctx context.Context, update *tgbotapi.Update) error {
+	if from := update.SentFrom(); !h.isStaff(from) {
+		h.rejectStranger(update, from)
+		return nil
+	}
+
+	if update.CallbackQuery != nil {
+		if err := h.processCallbackQuery(ctx, update.CallbackQuery); err != nil {
+			h.logger.Errorw("Failed to process callback query", "error", err)
+			return err
+		}
+		return nil
+	}
+
+	if update.Message == nil {
+		h.logger.Info("Received update without message")
+		return nil
+	}
+
+	message := h.retrieveMessage(update)
+	if err := h.processMessage(ctx, message); err != nil {
+		h.logger.Errorw("Failed to process message", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// verifySecretToken checks the secret token header against the configured webhook secret.
+// An empty configured secret rejects every request rather than leaving the endpoint open.
+func (h *TelegramHandler) verifySecretToken(r *http.Request) bool {
+	secret := h.config.Telegram.WebhookSecret
+	if secret == "" {
+		h.logger.Error("telegram webhook secret is not configured")
+		return false
+	}
+
+	token := r.Header.Get(SecretTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// isStaff reports whether user is on the configured staff allowlist. Updates without a sender,
+// such as channel posts, are never from staff.
+func (h *TelegramHandler) isStaff(user *tgbotapi.User) bool {
+	if user == nil {
+		return false
+	}
+
+	return slices.Contains(h.config.Telegram.StaffUserIDs, user.ID)
+}
+
+// rejectStranger tells a user who isn't staff that they can't use the bot, without running
+// anything they sent
+func (h *TelegramHandler) rejectStranger(update *tgbotapi.Update, from *tgbotapi.User) {
+	if from == nil {
+		h.logger.Info("Ignoring update without a sender")
+		return
+	}
+
+	h.logger.Warnw("Rejected telegram update from a user who isn't staff", "user_id", from.ID, "username", from.UserName)
+
+	text := i18n.For(i18n.Match(from.LanguageCode)).T("auth.not_staff")
+	if update.CallbackQuery != nil {
+		if _, err := h.botAPI.Request(tgbotapi.NewCallbackWithAlert(update.CallbackQuery.ID, text)); err != nil {
+			h.logger.Errorw("Failed to answer callback query", "error", err)
+		}
+		return
+	}
+
+	if chat := update.FromChat(); chat != nil {
+		if _, err := h.botAPI.Send(tgbotapi.NewMessage(chat.ID, text)); err != nil {
+			h.logger.Errorw("Failed to reject user", "user_id", from.ID, "error", err)
+		}
+	}
+}
+
 func (h *TelegramHandler) retrieveMessage(update *tgbotapi.Update) *tgbotapi.Message {
 	var message *tgbotapi.Message
 	if update.Message != nil {
@@ -133,6 +226,10 @@ func (h *TelegramHandler) processMessage(ctx context.Context, msg *tgbotapi.Mess
 	if msg.IsCommand() {
 		h.logger.Infow("Processing command", "command", msg.Command())
 
+		if commands.IsWizardControl(commands.BotCommand(msg.Command())) {
+			return h.wizardController.Process(ctx, msg)
+		}
+
 		handler, exists := h.commandHandlers[commands.BotCommand(msg.Command())]
 		if !exists {
 			h.logger.Errorw("Command not found", "command", msg.Command())
@@ -149,9 +246,46 @@ func (h *TelegramHandler) processMessage(ctx context.Context, msg *tgbotapi.Mess
 		}
 	}
 
+	if msg.Document != nil {
+		return h.processDocument(ctx, msg)
+	}
+
 	return nil
 }
 
+// processDocument hands a file sent on its own to the bulk import, the only command taking files
+func (h *TelegramHandler) processDocument(ctx context.Context, msg *tgbotapi.Message) error {
+	handler, ok := h.commandHandlers[commands.Import].(commands.DocumentHandler)
+	if !ok {
+		h.logger.Infow("Ignoring document without a handler", "file_name", msg.Document.FileName)
+		return nil
+	}
+
+	h.logger.Infow("Processing document", "file_name", msg.Document.FileName)
+	return handler.HandleDocument(ctx, msg)
+}
+
+// processCallbackQuery routes an inline button press to the command that rendered the button
+func (h *TelegramHandler) processCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	// Always answer the query so the client stops showing the loading indicator on the button.
+	if _, err := h.botAPI.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		h.logger.Errorw("Failed to answer callback query", "error", err)
+	}
+
+	command, _ := commands.ParseCallbackData(query.Data)
+	handler, exists := h.commandHandlers[command]
+	if !exists {
+		return fmt.Errorf("command %s not found for callback query", command)
+	}
+
+	callbackHandler, ok := handler.(commands.CallbackQueryHandler)
+	if !ok {
+		return fmt.Errorf("command %s does not handle callback queries", command)
+	}
+
+	return callbackHandler.HandleCallbackQuery(ctx, query)
+}
+
 func (h *TelegramHandler) isReplyToCommand(msg *tgbotapi.Message) bool {
 	if msg.ReplyToMessage == nil {
 		return false
@@ -2,36 +2,56 @@ package add_product
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
-	"github/huangc28/kikichoice-be/api/go/_internal/db"
-	"github/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
-// Message constants for better maintainability
+// sessionTTL is long so a paused listing survives a few days of interruptions
+const sessionTTL = 7 * 24 * time.Hour
+
+// Message IDs, see pkg/i18n/locales
 const (
-	msgStartFlow        = "🆕 開始新的商品上架流程"
-	msgNoActiveSession  = "❌ 未找到活動會話"
-	msgUnknownOperation = "❌ 未知的操作"
-	msgUseAddProduct    = "請使用 /add_product 開始上架商品。"
-	msgResumeFlow       = "📋 發現未完成的商品上架流程\n當前步驟: %s\n\n您可以:\n• 繼續輸入以完成當前步驟\n• 輸入 /cancel 取消流程\n• 輸入 /restart 重新開始"
+	msgNoActiveSession  = "add.no_active_session"
+	msgUnknownOperation = "add.unknown_operation"
+	msgUseAddProduct    = "add.use_add"
+	msgResumeFlow       = "add.resume_flow"
+	msgPausedLabel      = "add.paused_label"
+	msgSaveSKUTaken     = "add.save_sku_taken"
+	msgFlowPaused       = "add.flow_paused"
+
+	buttonResume  = "add.button.resume"
+	buttonRestart = "add.button.restart"
+	buttonCancel  = "add.button.cancel"
+	buttonPause   = "add.button.pause"
 )
 
-// Error message constants
+// Callback actions, sent as "add:<action>"
 const (
-	errMaxImages = "❌ 最多只能上傳 5 張圖片，目前已上傳 %d 張"
+	actionResume  = "r"
+	actionRestart = "rs"
+	actionCancel  = "c"
+	actionPause   = "p"
+	actionConfirm = "ok"
+)
+
+// Error message IDs
+const (
+	errMaxImages = "add.max_images"
 )
 
 type AddProductCommand struct {
-	commandDAO       *commands.CommandDAO
-	productDAO       *ProductDAO
+	sessions         *commands.SessionStore[AddProductSessionState]
+	productDAO       ProductRepository
+	locales          *commands.LocaleResolver
 	botAPI           *tgbotapi.BotAPI
 	logger           *zap.SugaredLogger
 	addProductStates map[string]AddProductState
@@ -40,8 +60,9 @@ type AddProductCommand struct {
 type AddProductCommandParams struct {
 	fx.In
 
-	CommandDAO       *commands.CommandDAO
-	ProductDAO       *ProductDAO
+	CommandDAO       commands.Repository
+	ProductDAO       ProductRepository
+	LocaleResolver   *commands.LocaleResolver
 	BotAPI           *tgbotapi.BotAPI
 	Logger           *zap.SugaredLogger
 	AddProductStates map[string]AddProductState
@@ -49,19 +70,21 @@ type AddProductCommandParams struct {
 
 func NewAddProductCommand(p AddProductCommandParams) *AddProductCommand {
 	return &AddProductCommand{
-		commandDAO:       p.CommandDAO,
+		sessions:         commands.NewSessionStore[AddProductSessionState](p.CommandDAO, commands.AddProduct, sessionTTL),
 		productDAO:       p.ProductDAO,
+		locales:          p.LocaleResolver,
 		botAPI:           p.BotAPI,
 		logger:           p.Logger,
 		addProductStates: p.AddProductStates,
 	}
 }
 
-// Handle processes incoming messages using FSM - simplified for readability
+// Handle starts the flow, or shows the resume prompt with progress when the user already has
+// an unfinished one
 func (c *AddProductCommand) Handle(msg *tgbotapi.Message) error {
 	ctx := context.Background()
 
-	state, err := c.getOrCreateUserState(
+	session, err := c.getOrCreateUserState(
 		ctx,
 		msg.From.ID,
 		msg.Chat.ID,
@@ -69,22 +92,22 @@ func (c *AddProductCommand) Handle(msg *tgbotapi.Message) error {
 	if err != nil {
 		return fmt.Errorf("failed to get user state: %w", err)
 	}
+	loc := c.localize(ctx, msg, &session.State)
 
-	userFSM := NewAddProductFSM(
-		state,
-		msg,
-		c.addProductStates,
-	)
+	if session.State.FSMState == StateInit {
+		return c.start(ctx, session, msg)
+	}
 
-	if userFSM.Current() == StateInit {
-		return userFSM.Event(ctx, EventStart)
+	if !slices.Contains(wizardSteps, session.State.FSMState) && session.State.FSMState != StatePaused {
+		// Finished flows have nothing to resume.
+		return c.Restart(ctx, msg)
 	}
 
-	return nil
+	return c.sendResumePrompt(msg.Chat.ID, loc, &session.State)
 }
 
 func (c *AddProductCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
-	state, err := c.getOrCreateUserState(
+	session, err := c.getOrCreateUserState(
 		ctx,
 		msg.From.ID,
 		msg.Chat.ID,
@@ -92,63 +115,316 @@ func (c *AddProductCommand) HandleReply(ctx context.Context, msg *tgbotapi.Messa
 	if err != nil {
 		return fmt.Errorf("failed to get user state: %w", err)
 	}
+	loc := c.localize(ctx, msg, &session.State)
 
-	userFSM := NewAddProductFSM(state, msg, c.addProductStates)
+	userFSM := NewAddProductFSM(&session.State, msg, c.addProductStates)
 
-	c.addProductStates[userFSM.Current()].Reply(ctx, msg, userFSM)
+	state, ok := c.addProductStates[userFSM.Current()]
+	if !ok {
+		// Paused flows, or a reply to a prompt of an older flow, have no step to feed.
+		return c.sendText(msg.Chat.ID, loc.T(msgFlowPaused))
+	}
 
-	return nil
+	if err := state.Reply(ctx, msg, userFSM, &session.State); err != nil {
+		return err
+	}
+
+	// Steps collecting several inputs change the state without moving the FSM, so always save.
+	return c.save(ctx, session, userFSM.Current())
 }
 
-// getOrCreateUserState retrieves existing session or creates new one
-func (c *AddProductCommand) getOrCreateUserState(ctx context.Context, userID, chatID int64) (*AddProductSessionState, error) {
-	session, err := c.commandDAO.GetUserSession(ctx, userID, c.Command().String())
-	if err == nil {
-		var state AddProductSessionState
-		if err := json.Unmarshal(session.State, &state); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal session state: %w", err)
+// HandleCallbackQuery handles the resume prompt and step buttons
+func (c *AddProductCommand) HandleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query.Message == nil {
+		return nil
+	}
+
+	// The wizard methods act on the user pressing the button, not the bot that sent it.
+	msg := *query.Message
+	msg.From = query.From
+
+	_, args := commands.ParseCallbackData(query.Data)
+	if len(args) == 0 {
+		return c.sendText(msg.Chat.ID, c.locales.Resolve(ctx, msg.From).T(msgUnknownOperation))
+	}
+
+	switch args[0] {
+	case actionResume:
+		return c.Resume(ctx, &msg)
+	case actionRestart:
+		return c.Restart(ctx, &msg)
+	case actionCancel:
+		return c.Cancel(ctx, &msg)
+	case actionPause:
+		return c.Pause(ctx, &msg)
+	case actionConfirm:
+		return c.confirm(ctx, &msg)
+	default:
+		return c.sendText(msg.Chat.ID, c.locales.Resolve(ctx, msg.From).T(msgUnknownOperation))
+	}
+}
+
+// Pause moves the flow to StatePaused, remembering the step it was at
+func (c *AddProductCommand) Pause(ctx context.Context, msg *tgbotapi.Message) error {
+	session, err := c.getSession(ctx, msg)
+	if session == nil {
+		return err
+	}
+	loc := c.localize(ctx, msg, &session.State)
+
+	if session.State.FSMState == StatePaused {
+		return c.sendText(msg.Chat.ID, loc.T(msgFlowPaused))
+	}
+
+	pausedFrom := session.State.FSMState
+	userFSM := NewAddProductFSM(&session.State, msg, c.addProductStates)
+	if err := userFSM.Event(ctx, EventPause); err != nil {
+		return c.sendText(msg.Chat.ID, loc.T(msgUnknownOperation))
+	}
+
+	session.State.PausedFrom = pausedFrom
+	if err := c.save(ctx, session, userFSM.Current()); err != nil {
+		return err
+	}
+
+	return c.sendText(msg.Chat.ID, loc.T(msgPaused))
+}
+
+// Resume restores a paused flow to its saved step and re-sends that step's prompt
+func (c *AddProductCommand) Resume(ctx context.Context, msg *tgbotapi.Message) error {
+	session, err := c.getSession(ctx, msg)
+	if session == nil {
+		return err
+	}
+	c.localize(ctx, msg, &session.State)
+
+	if session.State.FSMState == StateInit {
+		return c.start(ctx, session, msg)
+	}
+
+	if session.State.FSMState == StatePaused {
+		userFSM := NewAddProductFSM(&session.State, msg, c.addProductStates)
+		userFSM.SetState(session.State.PausedFrom)
+		session.State.PausedFrom = ""
+
+		if err := c.save(ctx, session, userFSM.Current()); err != nil {
+			return err
 		}
-		return &state, nil
 	}
 
-	if errors.Is(err, sql.ErrNoRows) {
-		state := &AddProductSessionState{
-			Product:      ProductData{},
-			Specs:        []string{},
-			ImageFileIDs: []string{},
-			FSMState:     StateInit,
+	return c.sendStepPrompt(ctx, msg, session)
+}
+
+// Restart discards everything entered so far and starts again from the SKU step
+func (c *AddProductCommand) Restart(ctx context.Context, msg *tgbotapi.Message) error {
+	session, err := c.sessions.Start(ctx, msg.Chat.ID, msg.From.ID, newSessionState())
+	if err != nil {
+		return fmt.Errorf("failed to restart user session: %w", err)
+	}
+	c.localize(ctx, msg, &session.State)
+
+	return c.start(ctx, session, msg)
+}
+
+// Cancel drops the flow and its session
+func (c *AddProductCommand) Cancel(ctx context.Context, msg *tgbotapi.Message) error {
+	if err := c.sessions.Delete(ctx, msg.From.ID); err != nil {
+		return fmt.Errorf("failed to delete user session: %w", err)
+	}
+
+	return c.sendText(msg.Chat.ID, c.locales.Resolve(ctx, msg.From).T(msgCancelled))
+}
+
+// confirm saves the product with its variants and ends the flow
+func (c *AddProductCommand) confirm(ctx context.Context, msg *tgbotapi.Message) error {
+	session, err := c.getSession(ctx, msg)
+	if session == nil {
+		return err
+	}
+	loc := c.localize(ctx, msg, &session.State)
+
+	userFSM := NewAddProductFSM(&session.State, msg, c.addProductStates)
+	if err := userFSM.Event(ctx, EventConfirm); err != nil {
+		return c.sendText(msg.Chat.ID, loc.T(msgUnknownOperation))
+	}
+
+	// Claim the session before saving so a double tapped button can't create the product twice.
+	session.State.FSMState = userFSM.Current()
+	if err := c.sessions.Save(ctx, session); err != nil {
+		if errors.Is(err, commands.ErrSessionConflict) {
+			return nil
 		}
+		return err
+	}
 
-		stateJSON, err := json.Marshal(state)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal state: %w", err)
+	sku := session.State.Product.SKU
+	productID, err := c.productDAO.SaveProduct(ctx, &session.State)
+	if err != nil {
+		// Nothing was written, put the flow back on the confirm step so it can be retried.
+		if saveErr := c.save(ctx, session, StateConfirm); saveErr != nil {
+			c.logger.Errorw("failed to reopen add product session", "user_id", msg.From.ID, "error", saveErr)
 		}
 
-		session = &db.UserSession{
-			ChatID:      chatID,
-			UserID:      userID,
-			SessionType: c.Command().String(),
-			State:       stateJSON,
+		if errors.Is(err, ErrSKUTaken) {
+			return c.sendText(msg.Chat.ID, loc.T(msgSaveSKUTaken, i18n.Args{"SKU": sku}))
 		}
+		return fmt.Errorf("failed to save product: %w", err)
+	}
+
+	if err := c.sessions.Delete(ctx, msg.From.ID); err != nil {
+		c.logger.Errorw("failed to delete add product session", "user_id", msg.From.ID, "error", err)
+	}
+
+	c.logger.Infow(
+		"product created",
+		"product_id", productID,
+		"sku", sku,
+		"variants", len(session.State.Variants),
+		"actor_telegram_id", msg.From.ID,
+	)
+
+	return c.sendText(msg.Chat.ID, loc.T(msgSuccess, i18n.Args{"SKU": sku}))
+}
+
+// start moves a fresh session from StateInit to the first step, which sends its prompt
+func (c *AddProductCommand) start(ctx context.Context, session *commands.Session[AddProductSessionState], msg *tgbotapi.Message) error {
+	userFSM := NewAddProductFSM(&session.State, msg, c.addProductStates)
+	if err := userFSM.Event(ctx, EventStart); err != nil {
+		return err
+	}
+
+	return c.saveFSMState(ctx, session, userFSM.Current())
+}
+
+// sendStepPrompt re-sends the prompt of the session's current step
+func (c *AddProductCommand) sendStepPrompt(ctx context.Context, msg *tgbotapi.Message, session *commands.Session[AddProductSessionState]) error {
+	step := session.State.FSMState
 
-		if err := c.commandDAO.UpsertUserSession(
-			ctx,
-			chatID,
-			userID,
-			c.Command().String(),
-			session,
-		); err != nil {
+	state, ok := c.addProductStates[step]
+	if !ok {
+		return c.sendText(msg.Chat.ID, localizer(&session.State).T(msgUseAddProduct))
+	}
+
+	return state.Enter(ctx, nil, &FSMContext{
+		Message:          msg,
+		UserState:        &session.State,
+		AddProductStates: c.addProductStates,
+		Command:          c,
+	})
+}
+
+func (c *AddProductCommand) sendResumePrompt(chatID int64, loc i18n.Localizer, state *AddProductSessionState) error {
+	step := state.FSMState
+	pausedLabel := ""
+	if step == StatePaused {
+		step = state.PausedFrom
+		pausedLabel = loc.T(msgPausedLabel)
+	}
+
+	message := tgbotapi.NewMessage(chatID, loc.T(msgResumeFlow, i18n.Args{
+		"Paused": pausedLabel,
+		"Step":   loc.T(stepLabel(step)),
+		"Index":  slices.Index(wizardSteps, step) + 1,
+		"Total":  len(wizardSteps),
+	}))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonResume), commands.CallbackData(commands.AddProduct, actionResume)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonRestart), commands.CallbackData(commands.AddProduct, actionRestart)),
+			tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonCancel), commands.CallbackData(commands.AddProduct, actionCancel)),
+		),
+	)
+
+	_, err := c.botAPI.Send(message)
+	return err
+}
+
+func (c *AddProductCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+// getSession returns the user's session. When there is none the user is told so and a nil
+// session is returned along with any error sending that message.
+func (c *AddProductCommand) getSession(ctx context.Context, msg *tgbotapi.Message) (*commands.Session[AddProductSessionState], error) {
+	session, err := c.sessions.Get(ctx, msg.From.ID)
+	if errors.Is(err, commands.ErrSessionNotFound) {
+		return nil, c.sendText(msg.Chat.ID, c.locales.Resolve(ctx, msg.From).T(msgNoActiveSession))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// localize resolves the user's locale into the session state, where the steps read it from
+func (c *AddProductCommand) localize(ctx context.Context, msg *tgbotapi.Message, state *AddProductSessionState) i18n.Localizer {
+	loc := c.locales.Resolve(ctx, msg.From)
+	state.Locale = loc.Locale()
+	return loc
+}
+
+// getOrCreateUserState retrieves existing session or creates new one
+func (c *AddProductCommand) getOrCreateUserState(ctx context.Context, userID, chatID int64) (*commands.Session[AddProductSessionState], error) {
+	session, err := c.sessions.Get(ctx, userID)
+	if err == nil {
+		return session, nil
+	}
+
+	if errors.Is(err, commands.ErrSessionNotFound) {
+		session, err := c.sessions.Start(ctx, chatID, userID, newSessionState())
+		if err != nil {
 			return nil, fmt.Errorf("failed to create user session: %w", err)
 		}
 
-		return state, nil
+		return session, nil
 	}
 
 	return nil, err
 }
 
+// saveFSMState persists the state the FSM moved to, if it moved
+func (c *AddProductCommand) saveFSMState(ctx context.Context, session *commands.Session[AddProductSessionState], current string) error {
+	if session.State.FSMState == current {
+		return nil
+	}
+
+	return c.save(ctx, session, current)
+}
+
+// save writes the session with the FSM at current. A conflict means the same session was
+// advanced concurrently (e.g. a double tapped button), so this update is dropped.
+func (c *AddProductCommand) save(ctx context.Context, session *commands.Session[AddProductSessionState], current string) error {
+	session.State.FSMState = current
+	if err := c.sessions.Save(ctx, session); err != nil {
+		if errors.Is(err, commands.ErrSessionConflict) {
+			c.logger.Warnw("add product session changed concurrently", "user_id", session.UserID)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+func newSessionState() AddProductSessionState {
+	return AddProductSessionState{
+		Product:      ProductData{},
+		Specs:        []string{},
+		ImageFileIDs: []string{},
+		FSMState:     StateInit,
+	}
+}
+
 func (c *AddProductCommand) Command() commands.BotCommand {
 	return commands.AddProduct
 }
 
-var _ commands.CommandHandler = (*AddProductCommand)(nil)
+var (
+	_ commands.CommandHandler       = (*AddProductCommand)(nil)
+	_ commands.CallbackQueryHandler = (*AddProductCommand)(nil)
+	_ commands.Wizard               = (*AddProductCommand)(nil)
+)
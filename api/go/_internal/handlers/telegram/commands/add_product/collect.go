@@ -0,0 +1,160 @@
+package add_product
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/looplab/fsm"
+)
+
+// AddProductStateSpecs collects "名稱: 內容" lines until the user replies「完成」
+type AddProductStateSpecs struct {
+	prompter
+}
+
+func NewAddProductStateSpecs(p StateParams) AddProductState {
+	return &AddProductStateSpecs{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+	}
+}
+
+func (s *AddProductStateSpecs) Name() string {
+	return StateSpecs
+}
+
+func (s *AddProductStateSpecs) Buttons() []tgbotapi.InlineKeyboardButton {
+	return stepButtons(i18n.For(i18n.DefaultLocale))
+}
+
+func (s *AddProductStateSpecs) Prompt() string {
+	return promptSpecs
+}
+
+func (s *AddProductStateSpecs) Send(msg *tgbotapi.Message) error {
+	return s.prompt(context.Background(), msg, messageLocalizer(msg).T(s.Prompt()))
+}
+
+func (s *AddProductStateSpecs) Enter(ctx context.Context, e *fsm.Event, fsmCtx *FSMContext) error {
+	return s.prompt(ctx, fsmCtx.Message, localizer(fsmCtx.UserState).T(s.Prompt()))
+}
+
+func (s *AddProductStateSpecs) Reply(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	if isKeyword(msg, replyDone) || isKeyword(msg, replySkip) {
+		if len(state.Specs) == 0 {
+			return userFSM.Event(ctx, EventSkip)
+		}
+		return userFSM.Event(ctx, EventDone)
+	}
+
+	specs := state.Specs
+	for _, line := range strings.Split(msg.Text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		name, value, err := ValidateSpec(line)
+		if err != nil {
+			return s.prompt(ctx, msg, localizer(state).Error(err))
+		}
+
+		specs = upsertSpec(specs, name, value)
+	}
+	state.Specs = specs
+
+	return s.prompt(ctx, msg, localizer(state).T(msgSpecAdded, i18n.Args{"Count": len(state.Specs)}))
+}
+
+// upsertSpec replaces the spec with the same name, spec names are unique per product
+func upsertSpec(specs []string, name, value string) []string {
+	line := fmt.Sprintf("%s: %s", name, value)
+
+	for i, spec := range specs {
+		if existing, _, _ := ValidateSpec(spec); existing == name {
+			specs[i] = line
+			return specs
+		}
+	}
+
+	return append(specs, line)
+}
+
+// AddProductStateImages collects product photos, one reply per photo, until the user
+// replies「完成」
+type AddProductStateImages struct {
+	prompter
+}
+
+func NewAddProductStateImages(p StateParams) AddProductState {
+	return &AddProductStateImages{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+	}
+}
+
+func (s *AddProductStateImages) Name() string {
+	return StateImages
+}
+
+func (s *AddProductStateImages) Buttons() []tgbotapi.InlineKeyboardButton {
+	return stepButtons(i18n.For(i18n.DefaultLocale))
+}
+
+func (s *AddProductStateImages) Prompt() string {
+	return promptImages
+}
+
+func (s *AddProductStateImages) Send(msg *tgbotapi.Message) error {
+	return s.prompt(context.Background(), msg, messageLocalizer(msg).T(s.Prompt(), i18n.Args{"Max": maxImages}))
+}
+
+func (s *AddProductStateImages) Enter(ctx context.Context, e *fsm.Event, fsmCtx *FSMContext) error {
+	return s.prompt(ctx, fsmCtx.Message, localizer(fsmCtx.UserState).T(s.Prompt(), i18n.Args{"Max": maxImages}))
+}
+
+func (s *AddProductStateImages) Reply(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	if isKeyword(msg, replyDone) || isKeyword(msg, replySkip) {
+		if len(state.ImageFileIDs) == 0 {
+			return userFSM.Event(ctx, EventSkip)
+		}
+		return userFSM.Event(ctx, EventDone)
+	}
+
+	fileIDs, text := collectPhoto(localizer(state), msg, state.ImageFileIDs)
+	state.ImageFileIDs = fileIDs
+
+	return s.prompt(ctx, msg, text)
+}
+
+// collectPhoto appends the largest size of the replied photo and returns the message telling
+// the user how many more can be sent
+func collectPhoto(loc i18n.Localizer, msg *tgbotapi.Message, fileIDs []string) ([]string, string) {
+	if len(msg.Photo) == 0 {
+		return fileIDs, loc.T(msgImageRequired)
+	}
+
+	if len(fileIDs) >= maxImages {
+		return fileIDs, loc.T(errMaxImages, i18n.Args{"Count": len(fileIDs), "Max": maxImages})
+	}
+
+	// Telegram sends every size of the photo, the last one is the largest.
+	fileIDs = append(fileIDs, msg.Photo[len(msg.Photo)-1].FileID)
+
+	args := i18n.Args{
+		"Count":     len(fileIDs),
+		"Max":       maxImages,
+		"Remaining": maxImages - len(fileIDs),
+	}
+	if len(fileIDs) == maxImages {
+		return fileIDs, loc.T(msgImageLimitReached, args)
+	}
+
+	return fileIDs, loc.T(msgImageUploaded, args)
+}
+
+var (
+	_ AddProductState = (*AddProductStateSpecs)(nil)
+	_ AddProductState = (*AddProductStateImages)(nil)
+)
@@ -0,0 +1,119 @@
+package add_product
+
+import (
+	"context"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/looplab/fsm"
+)
+
+// Message IDs, see pkg/i18n/locales
+const (
+	promptConfirm = "add.prompt.confirm"
+	buttonConfirm = "add.button.confirm"
+)
+
+// AddProductStateConfirm shows everything entered with confirm and cancel buttons. The
+// confirm button is handled by AddProductCommand, which saves the product.
+type AddProductStateConfirm struct {
+	botAPI *tgbotapi.BotAPI
+}
+
+func NewAddProductStateConfirm(p StateParams) AddProductState {
+	return &AddProductStateConfirm{
+		botAPI: p.BotAPI,
+	}
+}
+
+func (s *AddProductStateConfirm) Name() string {
+	return StateConfirm
+}
+
+func (s *AddProductStateConfirm) Buttons() []tgbotapi.InlineKeyboardButton {
+	return s.buttons(i18n.For(i18n.DefaultLocale))
+}
+
+func (s *AddProductStateConfirm) Prompt() string {
+	return promptConfirm
+}
+
+func (s *AddProductStateConfirm) Send(msg *tgbotapi.Message) error {
+	return s.send(msg, &AddProductSessionState{Locale: messageLocalizer(msg).Locale()})
+}
+
+func (s *AddProductStateConfirm) Enter(ctx context.Context, e *fsm.Event, fsmCtx *FSMContext) error {
+	return s.send(fsmCtx.Message, fsmCtx.UserState)
+}
+
+// Reply shows the summary again, the step is answered with its buttons
+func (s *AddProductStateConfirm) Reply(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	return s.send(msg, state)
+}
+
+func (s *AddProductStateConfirm) buttons(loc i18n.Localizer) []tgbotapi.InlineKeyboardButton {
+	return []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonConfirm), commands.CallbackData(commands.AddProduct, actionConfirm)),
+		tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonCancel), commands.CallbackData(commands.AddProduct, actionCancel)),
+	}
+}
+
+func (s *AddProductStateConfirm) send(msg *tgbotapi.Message, state *AddProductSessionState) error {
+	loc := localizer(state)
+
+	message := tgbotapi.NewMessage(msg.Chat.ID, loc.T(s.Prompt(), i18n.Args{"Summary": summary(loc, state)}))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(s.buttons(loc)...))
+	_, err := s.botAPI.Send(message)
+	return err
+}
+
+// summary lists the product, its variants and how many specs and photos were entered
+func summary(loc i18n.Localizer, state *AddProductSessionState) string {
+	p := state.Product
+
+	lines := []string{
+		loc.T("add.summary.sku", i18n.Args{"SKU": p.SKU}),
+		loc.T("add.summary.name", i18n.Args{"Name": p.Name}),
+		loc.T("add.summary.category", i18n.Args{"Category": p.Category}),
+		loc.T("add.summary.price", i18n.Args{"Price": p.Price}),
+	}
+
+	if len(state.Variants) > 0 {
+		lines = append(lines, loc.T("add.summary.stock_from_variants", i18n.Args{"Stock": state.StockCount()}))
+	} else {
+		lines = append(lines, loc.T("add.summary.stock", i18n.Args{"Stock": state.StockCount()}))
+	}
+
+	if p.Description != "" {
+		lines = append(lines, loc.T("add.summary.description", i18n.Args{"Description": p.Description}))
+	}
+
+	if len(state.Specs) > 0 {
+		lines = append(lines, loc.T("add.summary.specs"))
+		for _, spec := range state.Specs {
+			lines = append(lines, "• "+spec)
+		}
+	}
+
+	if len(state.Variants) > 0 {
+		lines = append(lines, loc.T("add.summary.variants"))
+		for _, v := range state.Variants {
+			lines = append(lines, loc.T("add.summary.variant", i18n.Args{
+				"Name":   v.Name,
+				"SKU":    v.SKU(p.SKU),
+				"Price":  v.Price,
+				"Stock":  v.Stock,
+				"Images": len(v.ImageFileIDs),
+			}))
+		}
+	}
+
+	lines = append(lines, loc.T("add.summary.images", i18n.Args{"Count": len(state.ImageFileIDs)}))
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+var _ AddProductState = (*AddProductStateConfirm)(nil)
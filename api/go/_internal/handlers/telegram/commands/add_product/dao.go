@@ -2,88 +2,183 @@ package add_product
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
 
+	"github.com/jmoiron/sqlx"
 	"go.uber.org/fx"
 )
 
-// ProductDAO handles product-related database operations
+// ErrSKUTaken is returned by SaveProduct when the product or a variant SKU was taken after
+// it was entered
+var ErrSKUTaken = errors.New("sku already exists")
+
+// ProductRepository checks SKUs and saves the products entered with /add
+type ProductRepository interface {
+	SKUExists(ctx context.Context, sku string) (bool, error)
+	SaveProduct(ctx context.Context, state *AddProductSessionState) (int64, error)
+}
+
+// ProductDAO is the ProductRepository of a database
 type ProductDAO struct {
-	db db.Conn
+	db *sqlx.DB
 }
 
 type ProductDAOParams struct {
 	fx.In
 
-	DB db.Conn
+	DB *sqlx.DB
 }
 
 func NewProductDAO(p ProductDAOParams) *ProductDAO {
 	return &ProductDAO{db: p.DB}
 }
 
-// SaveProduct saves the product to the database using raw SQL
-func (p *ProductDAO) SaveProduct(ctx context.Context, state *AddProductSessionState) error {
-	// Create product using raw SQL
-	query := `
-		INSERT INTO products (sku, name, price, category, stock_count, description)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id
-	`
+var _ ProductRepository = (*ProductDAO)(nil)
 
-	var productID int64
-	err := p.db.QueryRow(query,
-		state.Product.SKU,
-		state.Product.Name,
-		state.Product.Price,
-		state.Product.Category,
-		state.Product.Stock,
-		state.Product.Description,
-	).Scan(&productID)
+// SKUExists reports whether a product or product variant already uses the SKU
+func (p *ProductDAO) SKUExists(ctx context.Context, sku string) (bool, error) {
+	return skuExists(ctx, p.db, sku)
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to create product: %w", err)
-	}
+// SaveProduct creates the product with its specs, variants and images in one transaction and
+// returns the product id. With variants, the product stock is the sum of the variant stock.
+func (p *ProductDAO) SaveProduct(ctx context.Context, state *AddProductSessionState) (int64, error) {
+	res, err := db.Tx(p.db, func(tx *sqlx.Tx) (any, error) {
+		skus := []string{state.Product.SKU}
+		for _, v := range state.Variants {
+			skus = append(skus, v.SKU(state.Product.SKU))
+		}
+
+		for _, sku := range skus {
+			exists, err := skuExists(ctx, tx, sku)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, fmt.Errorf("%w: %s", ErrSKUTaken, sku)
+			}
+		}
+
+		productQuery := `
+			INSERT INTO products (sku, name, price, category, stock_count, full_desc)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+			RETURNING id
+		`
+
+		var productID int64
+		if err := tx.QueryRowContext(
+			ctx,
+			productQuery,
+			state.Product.SKU,
+			state.Product.Name,
+			state.Product.Price,
+			state.Product.Category,
+			state.StockCount(),
+			state.Product.Description,
+		).Scan(&productID); err != nil {
+			return nil, fmt.Errorf("failed to create product: %w", err)
+		}
 
-	// Create product specs
-	for i, spec := range state.Specs {
-		// Assume spec format is "name:value"
 		specQuery := `
 			INSERT INTO product_specs (product_id, spec_name, spec_value, sort_order)
 			VALUES ($1, $2, $3, $4)
 		`
-		// Simple parsing - you might want to improve this
-		specName := spec
-		specValue := ""
-		if len(spec) > 0 {
-			specName = spec
-			specValue = spec // For now, store the whole string as both name and value
+		for i, spec := range state.Specs {
+			name, value, err := ValidateSpec(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid product spec %q: %w", spec, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, specQuery, productID, name, value, i); err != nil {
+				return nil, fmt.Errorf("failed to create product spec: %w", err)
+			}
 		}
 
-		_, err := p.db.Exec(specQuery, productID, specName, specValue, i)
-		if err != nil {
-			return fmt.Errorf("failed to create product spec: %w", err)
+		if err := saveImages(ctx, tx, db.EntityTypeProduct, productID, state.Product.Name, state.ImageFileIDs); err != nil {
+			return nil, err
 		}
-	}
 
-	// Create product images
-	for i, fileID := range state.ImageFileIDs {
-		imageQuery := `
-			INSERT INTO product_images (product_id, url, alt_text, is_primary, sort_order)
+		variantQuery := `
+			INSERT INTO product_variants (product_id, name, sku, price, stock_count)
 			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
 		`
+		for _, v := range state.Variants {
+			var variantID int64
+			if err := tx.QueryRowContext(
+				ctx,
+				variantQuery,
+				productID,
+				v.Name,
+				v.SKU(state.Product.SKU),
+				v.Price,
+				v.Stock,
+			).Scan(&variantID); err != nil {
+				return nil, fmt.Errorf("failed to create product variant: %w", err)
+			}
+
+			altText := fmt.Sprintf("%s %s", state.Product.Name, v.Name)
+			if err := saveImages(ctx, tx, db.EntityTypeProductVariant, variantID, altText, v.ImageFileIDs); err != nil {
+				return nil, err
+			}
+		}
+
+		return productID, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.(int64), nil
+}
+
+// saveImages stores Telegram photos as images linked to the product or variant. The first
+// photo is the primary one.
+func saveImages(ctx context.Context, tx *sqlx.Tx, entityType db.EntityType, entityID int64, altText string, fileIDs []string) error {
+	imageQuery := `INSERT INTO images (url) VALUES ($1) RETURNING id`
+	entityQuery := `
+		INSERT INTO image_entities (image_id, entity_type, entity_id, alt_text, is_primary, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
 
-		isPrimary := i == 0 // First image is primary
-		altText := fmt.Sprintf("%s image %d", state.Product.Name, i+1)
+	for i, fileID := range fileIDs {
+		var imageID int64
 		url := fmt.Sprintf("telegram_file://%s", fileID)
+		if err := tx.QueryRowContext(ctx, imageQuery, url).Scan(&imageID); err != nil {
+			return fmt.Errorf("failed to create image: %w", err)
+		}
 
-		_, err := p.db.Exec(imageQuery, productID, url, altText, isPrimary, i)
-		if err != nil {
-			return fmt.Errorf("failed to create product image: %w", err)
+		if _, err := tx.ExecContext(
+			ctx,
+			entityQuery,
+			imageID,
+			entityType,
+			entityID,
+			fmt.Sprintf("%s %d", altText, i+1),
+			i == 0,
+			i,
+		); err != nil {
+			return fmt.Errorf("failed to link image: %w", err)
 		}
 	}
 
 	return nil
 }
+
+func skuExists(ctx context.Context, q sqlx.QueryerContext, sku string) (bool, error) {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM products WHERE sku = $1) OR
+			EXISTS (SELECT 1 FROM product_variants WHERE sku = $1)
+	`
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, q, &exists, query, sku); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
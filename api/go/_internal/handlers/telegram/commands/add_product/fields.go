@@ -0,0 +1,185 @@
+package add_product
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/looplab/fsm"
+)
+
+// AddProductStateField asks for a single product field. The reply is validated and stored by
+// apply, then the flow moves on with EventNext. Optional fields can be skipped with「略過」.
+type AddProductStateField struct {
+	prompter
+
+	name     string
+	prompt   string
+	optional bool
+	apply    func(state *AddProductSessionState, input string) error
+}
+
+func (s *AddProductStateField) Name() string {
+	return s.name
+}
+
+func (s *AddProductStateField) Buttons() []tgbotapi.InlineKeyboardButton {
+	return stepButtons(i18n.For(i18n.DefaultLocale))
+}
+
+func (s *AddProductStateField) Prompt() string {
+	return s.prompt
+}
+
+func (s *AddProductStateField) Send(msg *tgbotapi.Message) error {
+	return s.prompter.prompt(context.Background(), msg, messageLocalizer(msg).T(s.Prompt()))
+}
+
+func (s *AddProductStateField) Enter(ctx context.Context, e *fsm.Event, fsmCtx *FSMContext) error {
+	return s.prompter.prompt(ctx, fsmCtx.Message, localizer(fsmCtx.UserState).T(s.Prompt()))
+}
+
+func (s *AddProductStateField) Reply(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	if s.optional && isKeyword(msg, replySkip) {
+		return userFSM.Event(ctx, EventSkip)
+	}
+
+	if err := s.apply(state, msg.Text); err != nil {
+		// Validator errors carry the message to show, ask again for the same field.
+		return s.prompter.prompt(ctx, msg, localizer(state).Error(err))
+	}
+
+	return userFSM.Event(ctx, EventNext)
+}
+
+// AddProductStateSKU is the first step. Besides the format, the SKU must not be taken by
+// another product or variant.
+type AddProductStateSKU struct {
+	AddProductStateField
+
+	productDAO ProductRepository
+}
+
+func NewAddProductStateSKU(p StateParams) AddProductState {
+	return &AddProductStateSKU{
+		AddProductStateField: AddProductStateField{
+			prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+			name:     StateSKU,
+			prompt:   promptSKU,
+			apply: func(state *AddProductSessionState, input string) error {
+				sku, err := ValidateSKU(input)
+				if err != nil {
+					return err
+				}
+				state.Product.SKU = sku
+				return nil
+			},
+		},
+		productDAO: p.ProductDAO,
+	}
+}
+
+func (s *AddProductStateSKU) Reply(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	sku := strings.TrimSpace(msg.Text)
+
+	exists, err := s.productDAO.SKUExists(ctx, sku)
+	if err != nil {
+		return fmt.Errorf("failed to check sku: %w", err)
+	}
+	if exists {
+		return s.prompter.prompt(ctx, msg, localizer(state).T(msgSKUExists, i18n.Args{"SKU": sku}))
+	}
+
+	return s.AddProductStateField.Reply(ctx, msg, userFSM, state)
+}
+
+func NewAddProductStateName(p StateParams) AddProductState {
+	return &AddProductStateField{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+		name:     StateName,
+		prompt:   promptName,
+		apply: func(state *AddProductSessionState, input string) error {
+			name, err := ValidateName(input)
+			if err != nil {
+				return err
+			}
+			state.Product.Name = name
+			return nil
+		},
+	}
+}
+
+func NewAddProductStateCategory(p StateParams) AddProductState {
+	return &AddProductStateField{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+		name:     StateCategory,
+		prompt:   promptCategory,
+		apply: func(state *AddProductSessionState, input string) error {
+			category, err := ValidateCategory(input)
+			if err != nil {
+				return err
+			}
+			state.Product.Category = category
+			return nil
+		},
+	}
+}
+
+func NewAddProductStatePrice(p StateParams) AddProductState {
+	return &AddProductStateField{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+		name:     StatePrice,
+		prompt:   promptPrice,
+		apply: func(state *AddProductSessionState, input string) error {
+			price, err := ValidatePrice(input)
+			if err != nil {
+				return err
+			}
+			state.Product.Price = price
+			return nil
+		},
+	}
+}
+
+// NewAddProductStateStock is only reached when the variants step is skipped, otherwise the
+// product stock is the sum of its variants.
+func NewAddProductStateStock(p StateParams) AddProductState {
+	return &AddProductStateField{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+		name:     StateStock,
+		prompt:   promptStock,
+		apply: func(state *AddProductSessionState, input string) error {
+			stock, err := ValidateStock(input)
+			if err != nil {
+				return err
+			}
+			state.Product.Stock = stock
+			return nil
+		},
+	}
+}
+
+func NewAddProductStateDescription(p StateParams) AddProductState {
+	return &AddProductStateField{
+		prompter: prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+		name:     StateDescription,
+		prompt:   promptDescription,
+		optional: true,
+		apply: func(state *AddProductSessionState, input string) error {
+			description, err := ValidateDescription(input)
+			if err != nil {
+				return err
+			}
+			state.Product.Description = description
+			return nil
+		},
+	}
+}
+
+var (
+	_ AddProductState = (*AddProductStateField)(nil)
+	_ AddProductState = (*AddProductStateSKU)(nil)
+)
@@ -15,11 +15,9 @@ const (
 	EventSkip    = "skip"
 	EventDone    = "done"
 	EventCancel  = "cancel"
-	EventRestart = "restart"
 	EventConfirm = "confirm"
 	EventReject  = "reject"
 	EventPause   = "pause"
-	EventResume  = "resume"
 )
 
 // FSMContext holds context for FSM callbacks
@@ -38,28 +36,39 @@ func NewAddProductFSM(state *AddProductSessionState, msg *tgbotapi.Message, addP
 		AddProductStates: addProductStates,
 	}
 
+	// Every registered step sends its prompt when the flow enters it.
+	callbacks := fsm.Callbacks{}
+	for name, step := range addProductStates {
+		callbacks["enter_"+name] = func(ctx context.Context, e *fsm.Event) {
+			if err := step.Enter(ctx, e, fsmCtx); err != nil {
+				log.Printf("failed to enter add product state %s: %v", name, err)
+			}
+		}
+	}
+
 	return fsm.NewFSM(
 		state.FSMState,
 		fsm.Events{
 			// Start flow
 			{Name: EventStart, Src: []string{StateInit}, Dst: StateSKU},
 
-			// Normal progression
+			// Normal progression. Multi-input steps (variants, specs, images) stay in their
+			// state while collecting and leave with EventDone or EventSkip.
 			{Name: EventNext, Src: []string{StateSKU}, Dst: StateName},
 			{Name: EventNext, Src: []string{StateName}, Dst: StateCategory},
 			{Name: EventNext, Src: []string{StateCategory}, Dst: StatePrice},
-			{Name: EventNext, Src: []string{StatePrice}, Dst: StateStock},
+			{Name: EventNext, Src: []string{StatePrice}, Dst: StateVariants},
 			{Name: EventNext, Src: []string{StateStock}, Dst: StateDescription},
 			{Name: EventNext, Src: []string{StateDescription}, Dst: StateSpecs},
-			{Name: EventNext, Src: []string{StateSpecs}, Dst: StateSpecs},   // Stay in specs for multiple entries
-			{Name: EventNext, Src: []string{StateImages}, Dst: StateImages}, // Stay in images for multiple uploads
 
-			// Skip optional states
+			// Skip optional states. Skipping variants asks for the product's own stock.
+			{Name: EventSkip, Src: []string{StateVariants}, Dst: StateStock},
 			{Name: EventSkip, Src: []string{StateDescription}, Dst: StateSpecs},
 			{Name: EventSkip, Src: []string{StateSpecs}, Dst: StateImages},
 			{Name: EventSkip, Src: []string{StateImages}, Dst: StateConfirm},
 
-			// Done events for multi-input states
+			// Done events for multi-input states. With variants the stock is their sum.
+			{Name: EventDone, Src: []string{StateVariants}, Dst: StateDescription},
 			{Name: EventDone, Src: []string{StateSpecs}, Dst: StateImages},
 			{Name: EventDone, Src: []string{StateImages}, Dst: StateConfirm},
 
@@ -67,59 +76,12 @@ func NewAddProductFSM(state *AddProductSessionState, msg *tgbotapi.Message, addP
 			{Name: EventConfirm, Src: []string{StateConfirm}, Dst: StateCompleted},
 			{Name: EventReject, Src: []string{StateConfirm}, Dst: StateCancelled},
 
-			// Global events
+			// Global events. Resuming has no fixed destination: the FSM is set back to the
+			// step saved in AddProductSessionState.PausedFrom, see AddProductCommand.Resume.
+			// Restarting replaces the session with a fresh one, see AddProductCommand.Restart.
 			{Name: EventCancel, Src: []string{"*"}, Dst: StateCancelled},
-			{Name: EventRestart, Src: []string{"*"}, Dst: StateSKU},
-			{Name: EventPause, Src: []string{"*"}, Dst: StatePaused},
-			{Name: EventResume, Src: []string{"*"}, Dst: "*"}, // Resume from where left off
-		},
-		fsm.Callbacks{
-			"enter_" + StateInit: func(ctx context.Context, e *fsm.Event) {
-				log.Printf("trigger enter_%s", StateInit)
-				fsmCtx.AddProductStates[StateInit].Enter(ctx, e, fsmCtx)
-			},
-			"enter_" + StateSKU: func(ctx context.Context, e *fsm.Event) {
-				fsmCtx.AddProductStates[StateSKU].Enter(ctx, e, fsmCtx)
-			},
-			// "enter_" + StateName: func(ctx context.Context, e *fsm.Event) {
-			// 	fsmCtx.AddProductStates[StateName].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateCategory: func(ctx context.Context, e *fsm.Event) {
-			// 	fsmCtx.AddProductStates[StateCategory].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StatePrice: func(ctx context.Context, e *fsm.Event) {
-			// 	log.Println("enter_" + StatePrice)
-			// 	fsmCtx.AddProductStates[StatePrice].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateStock: func(ctx context.Context, e *fsm.Event) {
-			// 	log.Println("enter_" + StateStock)
-			// 	fsmCtx.AddProductStates[StateStock].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateDescription: func(ctx context.Context, e *fsm.Event) {
-			// 	log.Println("enter_" + StateDescription)
-			// 	fsmCtx.AddProductStates[StateDescription].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateSpecs: func(ctx context.Context, e *fsm.Event) {
-			// 	log.Println("enter_" + StateSpecs)
-			// 	fsmCtx.AddProductStates[StateSpecs].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateImages: func(ctx context.Context, e *fsm.Event) {
-			// 	log.Println("enter_" + StateImages)
-			// 	fsmCtx.AddProductStates[StateImages].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateConfirm: func(ctx context.Context, e *fsm.Event) {
-			// 	log.Println("enter_" + StateConfirm)
-			// 	fsmCtx.AddProductStates[StateConfirm].Enter(ctx, e, fsmCtx)
-			// },
-			// "enter_" + StateCompleted: func(ctx context.Context, e *fsm.Event) {},
-			// "enter_" + StateCancelled: func(ctx context.Context, e *fsm.Event) {},
-			// "enter_" + StatePaused:    func(ctx context.Context, e *fsm.Event) {},
-
-			// // Before event callbacks (validation)
-			// "before_" + EventNext: func(ctx context.Context, e *fsm.Event) {},
-
-			// // After event callbacks (data storage)
-			// "after_" + EventNext: func(ctx context.Context, e *fsm.Event) {},
+			{Name: EventPause, Src: wizardSteps, Dst: StatePaused},
 		},
+		callbacks,
 	)
 }
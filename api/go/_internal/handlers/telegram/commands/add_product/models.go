@@ -1,5 +1,7 @@
 package add_product
 
+import "fmt"
+
 type ProductData struct {
 	SKU         string  `json:"sku"`
 	Name        string  `json:"name"`
@@ -9,9 +11,48 @@ type ProductData struct {
 	Description string  `json:"description"`
 }
 
+// VariantData is a product variant (color, size...) entered in the variants step
+type VariantData struct {
+	Name         string   `json:"name"`
+	SKUSuffix    string   `json:"sku_suffix"`
+	Price        float64  `json:"price"`
+	Stock        int      `json:"stock"`
+	ImageFileIDs []string `json:"image_file_ids"`
+}
+
+// SKU is the variant SKU, the parent SKU followed by the variant suffix
+func (v VariantData) SKU(parentSKU string) string {
+	return fmt.Sprintf("%s-%s", parentSKU, v.SKUSuffix)
+}
+
 type AddProductSessionState struct {
-	Product      ProductData `json:"product"`
-	Specs        []string    `json:"specs"`
-	ImageFileIDs []string    `json:"image_file_ids"`
-	FSMState     string      `json:"fsm_state"`
+	Product      ProductData   `json:"product"`
+	Specs        []string      `json:"specs"`
+	ImageFileIDs []string      `json:"image_file_ids"`
+	Variants     []VariantData `json:"variants"`
+	FSMState     string        `json:"fsm_state"`
+
+	// VariantDraft is the variant being entered and VariantField the field asked for next
+	VariantDraft *VariantData `json:"variant_draft,omitempty"`
+	VariantField string       `json:"variant_field,omitempty"`
+
+	// PausedFrom is the step the flow was paused at, restored on resume
+	PausedFrom string `json:"paused_from,omitempty"`
+
+	// Locale is resolved for the user on every update, so a /lang change applies right away
+	Locale string `json:"locale,omitempty"`
+}
+
+// StockCount is the parent stock: the sum of the variants when there are any,
+// otherwise the stock entered for the product
+func (s *AddProductSessionState) StockCount() int {
+	if len(s.Variants) == 0 {
+		return s.Product.Stock
+	}
+
+	total := 0
+	for _, v := range s.Variants {
+		total += v.Stock
+	}
+	return total
 }
@@ -0,0 +1,85 @@
+package add_product
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+)
+
+// Validators parse and validate user input for a single product field. They are shared by the
+// add wizard and the /edit command; each error is a catalog message ready to be sent back to
+// the user, in zh-TW through Error() or localized through i18n.Localizer.Error.
+var (
+	ErrInvalidInput = i18n.NewError(msgInvalidInput)
+	ErrInvalidPrice = i18n.NewError(msgInvalidPrice)
+	ErrInvalidStock = i18n.NewError(msgInvalidStock)
+	ErrInvalidSpec  = i18n.NewError(msgInvalidSpec)
+)
+
+const (
+	maxSKULength  = 100
+	maxNameLength = 255
+)
+
+func ValidateSKU(input string) (string, error) {
+	sku := strings.TrimSpace(input)
+	if sku == "" || len(sku) > maxSKULength || strings.ContainsAny(sku, " \t\n") {
+		return "", ErrInvalidInput
+	}
+	return sku, nil
+}
+
+func ValidateName(input string) (string, error) {
+	name := strings.TrimSpace(input)
+	if name == "" || len([]rune(name)) > maxNameLength {
+		return "", ErrInvalidInput
+	}
+	return name, nil
+}
+
+func ValidateCategory(input string) (string, error) {
+	category := strings.TrimSpace(input)
+	if category == "" {
+		return "", ErrInvalidInput
+	}
+	return category, nil
+}
+
+func ValidatePrice(input string) (float64, error) {
+	price, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+	if err != nil || price <= 0 {
+		return 0, ErrInvalidPrice
+	}
+	return price, nil
+}
+
+func ValidateStock(input string) (int, error) {
+	stock, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || stock < 0 {
+		return 0, ErrInvalidStock
+	}
+	return stock, nil
+}
+
+// ValidateDescription accepts any non-empty text; descriptions are optional in the wizard
+// and skipped with a button instead of an empty reply.
+func ValidateDescription(input string) (string, error) {
+	description := strings.TrimSpace(input)
+	if description == "" {
+		return "", ErrInvalidInput
+	}
+	return description, nil
+}
+
+// ValidateSpec parses a "名稱: 內容" spec line. Both half-width and full-width colons are accepted.
+func ValidateSpec(input string) (name, value string, err error) {
+	line := strings.Replace(strings.TrimSpace(input), "：", ":", 1)
+
+	name, value, ok := strings.Cut(line, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || name == "" || value == "" {
+		return "", "", ErrInvalidSpec
+	}
+	return name, value, nil
+}
@@ -0,0 +1,206 @@
+package add_product
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/looplab/fsm"
+)
+
+// Fields of a variant, asked in this order
+const (
+	variantFieldName   = "name"
+	variantFieldSuffix = "suffix"
+	variantFieldPrice  = "price"
+	variantFieldStock  = "stock"
+	variantFieldPhotos = "photos"
+)
+
+// Message IDs, see pkg/i18n/locales
+const (
+	promptVariants      = "add.prompt.variants"
+	promptVariantSuffix = "add.prompt.variant_suffix"
+	promptVariantPrice  = "add.prompt.variant_price"
+	promptVariantStock  = "add.prompt.variant_stock"
+	promptVariantPhotos = "add.prompt.variant_photos"
+	msgVariantAdded     = "add.variant_added"
+	msgVariantSKUTaken  = "add.variant_sku_taken"
+)
+
+// AddProductStateVariants is an optional step adding any number of variants. Each variant
+// walks through name, SKU suffix, price, stock and photos; the field being asked is kept in
+// AddProductSessionState.VariantField so the step survives between replies and pauses.
+type AddProductStateVariants struct {
+	prompter
+
+	productDAO ProductRepository
+}
+
+func NewAddProductStateVariants(p StateParams) AddProductState {
+	return &AddProductStateVariants{
+		prompter:   prompter{botAPI: p.BotAPI, commandDAO: p.CommandDAO},
+		productDAO: p.ProductDAO,
+	}
+}
+
+func (s *AddProductStateVariants) Name() string {
+	return StateVariants
+}
+
+func (s *AddProductStateVariants) Buttons() []tgbotapi.InlineKeyboardButton {
+	return stepButtons(i18n.For(i18n.DefaultLocale))
+}
+
+func (s *AddProductStateVariants) Prompt() string {
+	return promptVariants
+}
+
+func (s *AddProductStateVariants) Send(msg *tgbotapi.Message) error {
+	return s.prompt(context.Background(), msg, messageLocalizer(msg).T(s.Prompt()))
+}
+
+// Enter asks for the field in progress, so resuming mid-variant continues where it stopped
+func (s *AddProductStateVariants) Enter(ctx context.Context, e *fsm.Event, fsmCtx *FSMContext) error {
+	return s.prompt(ctx, fsmCtx.Message, s.fieldPrompt(fsmCtx.UserState))
+}
+
+func (s *AddProductStateVariants) Reply(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	if state.VariantDraft == nil {
+		return s.replyVariantName(ctx, msg, userFSM, state)
+	}
+
+	loc := localizer(state)
+	draft := state.VariantDraft
+
+	switch state.VariantField {
+	case variantFieldSuffix:
+		suffix, err := ValidateSKU(msg.Text)
+		if err != nil {
+			return s.prompt(ctx, msg, loc.Error(err))
+		}
+
+		sku := VariantData{SKUSuffix: suffix}.SKU(state.Product.SKU)
+		taken, err := s.skuTaken(ctx, state, sku)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return s.prompt(ctx, msg, loc.T(msgVariantSKUTaken, i18n.Args{"SKU": sku}))
+		}
+
+		draft.SKUSuffix = suffix
+		state.VariantField = variantFieldPrice
+	case variantFieldPrice:
+		price, err := ValidatePrice(msg.Text)
+		if err != nil {
+			return s.prompt(ctx, msg, loc.Error(err))
+		}
+
+		draft.Price = price
+		state.VariantField = variantFieldStock
+	case variantFieldStock:
+		stock, err := ValidateStock(msg.Text)
+		if err != nil {
+			return s.prompt(ctx, msg, loc.Error(err))
+		}
+
+		draft.Stock = stock
+		state.VariantField = variantFieldPhotos
+	case variantFieldPhotos:
+		if isKeyword(msg, replyDone) || isKeyword(msg, replySkip) {
+			state.Variants = append(state.Variants, *draft)
+			state.VariantDraft = nil
+			state.VariantField = ""
+
+			return s.prompt(ctx, msg, loc.T(msgVariantAdded, i18n.Args{
+				"Name":  draft.Name,
+				"SKU":   draft.SKU(state.Product.SKU),
+				"Count": len(state.Variants),
+			}))
+		}
+
+		fileIDs, text := collectPhoto(loc, msg, draft.ImageFileIDs)
+		draft.ImageFileIDs = fileIDs
+		return s.prompt(ctx, msg, text)
+	default:
+		// A draft without a known field can't be continued, start the variant over.
+		state.VariantField = variantFieldName
+		state.VariantDraft = nil
+		return s.prompt(ctx, msg, loc.T(promptVariants))
+	}
+
+	return s.prompt(ctx, msg, s.fieldPrompt(state))
+}
+
+// replyVariantName either finishes the step or starts a new variant named by the reply
+func (s *AddProductStateVariants) replyVariantName(ctx context.Context, msg *tgbotapi.Message, userFSM *fsm.FSM, state *AddProductSessionState) error {
+	if isKeyword(msg, replyDone) || isKeyword(msg, replySkip) {
+		// Without variants the product needs its own stock, otherwise it is the variants' sum.
+		if len(state.Variants) == 0 {
+			return userFSM.Event(ctx, EventSkip)
+		}
+		return userFSM.Event(ctx, EventDone)
+	}
+
+	name, err := ValidateName(msg.Text)
+	if err != nil {
+		return s.prompt(ctx, msg, localizer(state).Error(err))
+	}
+
+	state.VariantDraft = &VariantData{
+		Name:         name,
+		ImageFileIDs: []string{},
+	}
+	state.VariantField = variantFieldSuffix
+
+	return s.prompt(ctx, msg, s.fieldPrompt(state))
+}
+
+func (s *AddProductStateVariants) fieldPrompt(state *AddProductSessionState) string {
+	loc := localizer(state)
+
+	draft := state.VariantDraft
+	if draft == nil {
+		return loc.T(promptVariants)
+	}
+
+	args := i18n.Args{
+		"Name": draft.Name,
+		"SKU":  state.Product.SKU,
+		"Max":  maxImages,
+	}
+
+	switch state.VariantField {
+	case variantFieldSuffix:
+		return loc.T(promptVariantSuffix, args)
+	case variantFieldPrice:
+		return loc.T(promptVariantPrice, args)
+	case variantFieldStock:
+		return loc.T(promptVariantStock, args)
+	case variantFieldPhotos:
+		return loc.T(promptVariantPhotos, args)
+	default:
+		return loc.T(promptVariants)
+	}
+}
+
+// skuTaken checks the variant SKU against the variants entered so far and the catalog
+func (s *AddProductStateVariants) skuTaken(ctx context.Context, state *AddProductSessionState, sku string) (bool, error) {
+	for _, v := range state.Variants {
+		if v.SKU(state.Product.SKU) == sku {
+			return true, nil
+		}
+	}
+
+	exists, err := s.productDAO.SKUExists(ctx, sku)
+	if err != nil {
+		return false, fmt.Errorf("failed to check variant sku: %w", err)
+	}
+
+	return exists, nil
+}
+
+var _ AddProductState = (*AddProductStateVariants)(nil)
@@ -0,0 +1,306 @@
+package bulk_import
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog/importer"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Catalog message ids, see pkg/i18n/locales
+const (
+	msgPromptFile       = "import.prompt_file"
+	msgFileTooLarge     = "import.file_too_large"
+	msgDocumentRequired = "import.document_required"
+	msgNoActiveSession  = "import.no_active_session"
+	msgImportCancelled  = "import.cancelled"
+	msgImportFailed     = "import.failed"
+	msgUnknownOperation = "import.unknown_operation"
+	buttonConfirmImport = "import.button.confirm"
+	buttonCancelImport  = "import.button.cancel"
+)
+
+// Callback actions
+const (
+	actionConfirm = "ok"
+	actionCancel  = "c"
+)
+
+const (
+	// sessionTTL is how long a preview can be confirmed
+	sessionTTL = 30 * time.Minute
+
+	maxFileSizeMB   = 5
+	maxFileSize     = maxFileSizeMB << 20
+	downloadTimeout = 30 * time.Second
+
+	// actorSource marks the stock changes of imports in inventory_adjustments
+	actorSource = "telegram_import"
+)
+
+// ImportSessionState holds the parsed file between the preview and the confirmation
+type ImportSessionState struct {
+	FileName string          `json:"file_name"`
+	Batch    *importer.Batch `json:"batch,omitempty"`
+	Applying bool            `json:"applying"`
+}
+
+// ImportCommand imports products and variants from a spreadsheet. A document is previewed as
+// a dry run first and only written when the confirm button is pressed.
+type ImportCommand struct {
+	sessions   *commands.SessionStore[ImportSessionState]
+	importDAO  *importer.ImportDAO
+	botAPI     *tgbotapi.BotAPI
+	locales    *commands.LocaleResolver
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+}
+
+type ImportCommandParams struct {
+	fx.In
+
+	CommandDAO     commands.Repository
+	ImportDAO      *importer.ImportDAO
+	BotAPI         *tgbotapi.BotAPI
+	LocaleResolver *commands.LocaleResolver
+	Logger         *zap.SugaredLogger
+}
+
+func NewImportCommand(p ImportCommandParams) *ImportCommand {
+	return &ImportCommand{
+		sessions:   commands.NewSessionStore[ImportSessionState](p.CommandDAO, commands.Import, sessionTTL),
+		importDAO:  p.ImportDAO,
+		botAPI:     p.BotAPI,
+		locales:    p.LocaleResolver,
+		httpClient: &http.Client{Timeout: downloadTimeout},
+		logger:     p.Logger,
+	}
+}
+
+// Handle asks for the file. Documents sent without /import are previewed as well.
+func (c *ImportCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	if _, err := c.sessions.Start(ctx, msg.Chat.ID, msg.From.ID, ImportSessionState{}); err != nil {
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+
+	return c.prompt(ctx, msg.Chat.ID, msg.From.ID, loc.T(msgPromptFile, i18n.Args{
+		"Columns": strings.Join(importer.Columns, ", "),
+	}))
+}
+
+// HandleReply previews the document replied to the /import prompt
+func (c *ImportCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	if msg.Document == nil {
+		loc := c.locales.Resolve(ctx, msg.From)
+		return c.prompt(ctx, msg.Chat.ID, msg.From.ID, loc.T(msgDocumentRequired))
+	}
+
+	return c.HandleDocument(ctx, msg)
+}
+
+// HandleDocument parses the document, compares it against the catalog and sends the preview
+// with the confirm button. The parsed rows are kept in the session until confirmed.
+func (c *ImportCommand) HandleDocument(ctx context.Context, msg *tgbotapi.Message) error {
+	doc := msg.Document
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	if !importer.Supported(doc.FileName) {
+		return c.sendText(msg.Chat.ID, FileErrorLabel(loc, importer.ErrUnsupportedFormat))
+	}
+	if doc.FileSize > maxFileSize {
+		return c.sendText(msg.Chat.ID, loc.T(msgFileTooLarge, i18n.Args{"MaxMB": maxFileSizeMB}))
+	}
+
+	records, err := c.download(ctx, doc)
+	if err != nil {
+		c.logger.Errorw("failed to read import file", "file_name", doc.FileName, "error", err)
+		return c.sendText(msg.Chat.ID, FileErrorLabel(loc, err))
+	}
+
+	batch, err := importer.Parse(records)
+	if err != nil {
+		return c.sendText(msg.Chat.ID, FileErrorLabel(loc, err))
+	}
+
+	plan, err := c.importDAO.Plan(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("failed to plan import: %w", err)
+	}
+
+	preview := tgbotapi.NewMessage(msg.Chat.ID, FormatPreview(loc, doc.FileName, plan))
+
+	if plan.Empty() {
+		if err := c.sessions.Delete(ctx, msg.From.ID); err != nil {
+			c.logger.Errorw("failed to delete import session", "user_id", msg.From.ID, "error", err)
+		}
+
+		_, err := c.botAPI.Send(preview)
+		return err
+	}
+
+	state := ImportSessionState{
+		FileName: doc.FileName,
+		Batch:    batch,
+	}
+	if _, err := c.sessions.Start(ctx, msg.Chat.ID, msg.From.ID, state); err != nil {
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+
+	preview.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonConfirmImport), commands.CallbackData(commands.Import, actionConfirm)),
+		tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonCancelImport), commands.CallbackData(commands.Import, actionCancel)),
+	))
+	_, err = c.botAPI.Send(preview)
+	return err
+}
+
+// HandleCallbackQuery applies or drops the previewed import
+func (c *ImportCommand) HandleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query.Message == nil {
+		return nil
+	}
+	chatID := query.Message.Chat.ID
+	loc := c.locales.Resolve(ctx, query.From)
+
+	_, args := commands.ParseCallbackData(query.Data)
+	if len(args) == 0 {
+		return c.sendText(chatID, loc.T(msgUnknownOperation))
+	}
+
+	switch args[0] {
+	case actionConfirm:
+		return c.apply(ctx, chatID, query.From, loc)
+	case actionCancel:
+		if err := c.sessions.Delete(ctx, query.From.ID); err != nil {
+			return fmt.Errorf("failed to delete import session: %w", err)
+		}
+		return c.sendText(chatID, loc.T(msgImportCancelled))
+	default:
+		return c.sendText(chatID, loc.T(msgUnknownOperation))
+	}
+}
+
+func (c *ImportCommand) apply(ctx context.Context, chatID int64, from *tgbotapi.User, loc i18n.Localizer) error {
+	session, err := c.sessions.Get(ctx, from.ID)
+	if errors.Is(err, commands.ErrSessionNotFound) {
+		return c.sendText(chatID, loc.T(msgNoActiveSession))
+	}
+	if err != nil {
+		return err
+	}
+	if session.State.Batch == nil || session.State.Applying {
+		return nil
+	}
+
+	// Claim the session before writing so a double tapped button can't import twice.
+	session.State.Applying = true
+	if err := c.sessions.Save(ctx, session); err != nil {
+		if errors.Is(err, commands.ErrSessionConflict) {
+			return nil
+		}
+		return err
+	}
+
+	plan, err := c.importDAO.Apply(ctx, session.State.Batch, importer.Actor{
+		Source:     actorSource,
+		TelegramID: from.ID,
+		Username:   from.UserName,
+	})
+	if err != nil {
+		c.logger.Errorw("failed to apply import", "file_name", session.State.FileName, "error", err)
+
+		// Nothing was written, let the import be confirmed again.
+		session.State.Applying = false
+		if saveErr := c.sessions.Save(ctx, session); saveErr != nil {
+			c.logger.Errorw("failed to reopen import session", "user_id", from.ID, "error", saveErr)
+		}
+
+		return c.sendText(chatID, loc.T(msgImportFailed, i18n.Args{"Error": err.Error()}))
+	}
+
+	if err := c.sessions.Delete(ctx, from.ID); err != nil {
+		c.logger.Errorw("failed to delete import session", "user_id", from.ID, "error", err)
+	}
+
+	created, updated := plan.Counts()
+	c.logger.Infow(
+		"products imported",
+		"file_name", session.State.FileName,
+		"created", created,
+		"updated", updated,
+		"invalid", len(plan.Invalid),
+		"actor_telegram_id", from.ID,
+	)
+
+	return c.sendText(chatID, FormatResult(loc, plan))
+}
+
+// download fetches the document from Telegram and reads its records
+func (c *ImportCommand) download(ctx context.Context, doc *tgbotapi.Document) ([][]string, error) {
+	url, err := c.botAPI.GetFileDirectURL(doc.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	return importer.ReadFile(doc.FileName, io.LimitReader(resp.Body, maxFileSize))
+}
+
+// prompt sends text as a force reply and records it as the message the session expects a reply to
+func (c *ImportCommand) prompt(ctx context.Context, chatID, userID int64, text string) error {
+	message := tgbotapi.NewMessage(chatID, text)
+	message.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply: true,
+		Selective:  true,
+	}
+
+	sent, err := c.botAPI.Send(message)
+	if err != nil {
+		return fmt.Errorf("failed to send prompt: %w", err)
+	}
+
+	return c.sessions.ExpectReply(ctx, chatID, userID, sent.MessageID)
+}
+
+func (c *ImportCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func (c *ImportCommand) Command() commands.BotCommand {
+	return commands.Import
+}
+
+var (
+	_ commands.CommandHandler       = (*ImportCommand)(nil)
+	_ commands.CallbackQueryHandler = (*ImportCommand)(nil)
+	_ commands.DocumentHandler      = (*ImportCommand)(nil)
+)
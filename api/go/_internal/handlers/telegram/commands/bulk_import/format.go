@@ -0,0 +1,208 @@
+package bulk_import
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog/importer"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+)
+
+// maxListedRows caps each section of the preview, Telegram messages are limited to 4096 characters
+const maxListedRows = 15
+
+const (
+	msgFileUnsupported    = "import.file_error.unsupported"
+	msgFileEmpty          = "import.file_error.empty"
+	msgFileMissingColumn  = "import.file_error.missing_column"
+	msgFileTooManyRows    = "import.file_error.too_many_rows"
+	msgFileUnreadable     = "import.file_error.unreadable"
+	msgPreviewTitle       = "import.preview.title"
+	msgPreviewCreated     = "import.preview.created"
+	msgPreviewUpdated     = "import.preview.updated"
+	msgPreviewInvalid     = "import.preview.invalid"
+	msgPreviewNewVariant  = "import.preview.new_variant"
+	msgPreviewInvalidRow  = "import.preview.invalid_row"
+	msgPreviewMoreRows    = "import.preview.more_rows"
+	msgPreviewEmptyValue  = "import.preview.empty_value"
+	msgPreviewSeparator   = "import.preview.separator"
+	msgPreviewNothing     = "import.preview.nothing"
+	msgPreviewConfirmNote = "import.preview.confirm_note"
+	msgResult             = "import.result"
+	msgResultInvalid      = "import.result_invalid"
+)
+
+// fieldLabels are the catalog messages naming the fields of FieldChange
+var fieldLabels = map[string]string{
+	importer.ColumnName:          "import.field.name",
+	importer.ColumnCategory:      "import.field.category",
+	importer.ColumnPrice:         "import.field.price",
+	importer.ColumnOriginalPrice: "import.field.original_price",
+	importer.ColumnShortDesc:     "import.field.short_desc",
+	importer.ColumnFullDesc:      "import.field.full_desc",
+	importer.ColumnReadyForSale:  "import.field.ready_for_sale",
+	importer.ColumnSpecs:         "import.field.specs",
+	importer.ColumnImages:        "import.field.images",
+	importer.FieldStock:          "import.field.stock_count",
+}
+
+var rowErrorLabels = []struct {
+	err error
+	msg string
+}{
+	{importer.ErrMissingSKU, "import.row_error.missing_sku"},
+	{importer.ErrInvalidSKU, "import.row_error.invalid_sku"},
+	{importer.ErrDuplicateSKU, "import.row_error.duplicate_sku"},
+	{importer.ErrMissingName, "import.row_error.missing_name"},
+	{importer.ErrMissingPrice, "import.row_error.missing_price"},
+	{importer.ErrInvalidPrice, "import.row_error.invalid_price"},
+	{importer.ErrInvalidReadyForSale, "import.row_error.invalid_ready_for_sale"},
+	{importer.ErrInvalidStockAdjust, "import.row_error.invalid_stock_adjust"},
+	{importer.ErrInvalidStockCount, "import.row_error.invalid_stock_count"},
+	{importer.ErrStockConflict, "import.row_error.stock_conflict"},
+	{importer.ErrInvalidImage, "import.row_error.invalid_image"},
+	{importer.ErrInvalidSpec, "import.row_error.invalid_spec"},
+	{importer.ErrTooLong, "import.row_error.too_long"},
+	{importer.ErrParentNotFound, "import.row_error.parent_not_found"},
+	{importer.ErrParentInvalid, "import.row_error.parent_invalid"},
+	{importer.ErrSKUIsVariant, "import.row_error.sku_is_variant"},
+	{importer.ErrSKUIsProduct, "import.row_error.sku_is_product"},
+	{importer.ErrParentMismatch, "import.row_error.parent_mismatch"},
+	{importer.ErrStockFromVariants, "import.row_error.stock_from_variants"},
+	{importer.ErrStockBelowReserved, "import.row_error.stock_below_reserved"},
+}
+
+// FileErrorLabel explains why a whole file can't be imported
+func FileErrorLabel(l i18n.Localizer, err error) string {
+	switch {
+	case errors.Is(err, importer.ErrUnsupportedFormat):
+		return l.T(msgFileUnsupported)
+	case errors.Is(err, importer.ErrEmptyFile):
+		return l.T(msgFileEmpty)
+	case errors.Is(err, importer.ErrMissingColumn):
+		columns := strings.Join([]string{importer.ColumnSKU, importer.ColumnName}, l.T(msgPreviewSeparator))
+		return l.T(msgFileMissingColumn, i18n.Args{"Columns": columns})
+	case errors.Is(err, importer.ErrTooManyRows):
+		return l.T(msgFileTooManyRows, i18n.Args{"Max": importer.MaxRows})
+	default:
+		return l.T(msgFileUnreadable)
+	}
+}
+
+func rowErrorLabel(l i18n.Localizer, err error) string {
+	for _, label := range rowErrorLabels {
+		if errors.Is(err, label.err) {
+			return l.T(label.msg)
+		}
+	}
+	return err.Error()
+}
+
+// FormatPreview renders the dry run of an import in the locale
+func FormatPreview(l i18n.Localizer, fileName string, plan *importer.Plan) string {
+	created, updated := plan.Counts()
+
+	sections := []string{
+		l.T(msgPreviewTitle, i18n.Args{
+			"File":      fileName,
+			"Created":   created,
+			"Updated":   updated,
+			"Unchanged": plan.Unchanged,
+			"Invalid":   len(plan.Invalid),
+		}),
+	}
+
+	var newRows, updatedRows []string
+	for _, c := range plan.Products {
+		if c.IsNew() {
+			newRows = append(newRows, fmt.Sprintf("• %s %s", c.Row.SKU, *c.Row.Name))
+		} else {
+			updatedRows = append(updatedRows, fmt.Sprintf("• %s %s", c.Row.SKU, formatChanges(l, c.Changes)))
+		}
+	}
+	for _, c := range plan.Variants {
+		if c.IsNew() {
+			newRows = append(newRows, l.T(msgPreviewNewVariant, i18n.Args{
+				"SKU":    c.Row.SKU,
+				"Name":   *c.Row.Name,
+				"Parent": c.Row.ParentSKU,
+			}))
+		} else {
+			updatedRows = append(updatedRows, fmt.Sprintf("• %s %s", c.Row.SKU, formatChanges(l, c.Changes)))
+		}
+	}
+
+	invalidRows := make([]string, 0, len(plan.Invalid))
+	for _, row := range plan.Invalid {
+		invalidRows = append(invalidRows, l.T(msgPreviewInvalidRow, i18n.Args{
+			"Line":  row.Line,
+			"SKU":   row.SKU,
+			"Error": rowErrorLabel(l, row.Err),
+		}))
+	}
+
+	if len(newRows) > 0 {
+		sections = append(sections, l.T(msgPreviewCreated)+"\n"+listRows(l, newRows))
+	}
+	if len(updatedRows) > 0 {
+		sections = append(sections, l.T(msgPreviewUpdated)+"\n"+listRows(l, updatedRows))
+	}
+	if len(invalidRows) > 0 {
+		sections = append(sections, l.T(msgPreviewInvalid)+"\n"+listRows(l, invalidRows))
+	}
+
+	if plan.Empty() {
+		sections = append(sections, l.T(msgPreviewNothing))
+	} else {
+		sections = append(sections, l.T(msgPreviewConfirmNote))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+// FormatResult renders what an import wrote in the locale
+func FormatResult(l i18n.Localizer, plan *importer.Plan) string {
+	created, updated := plan.Counts()
+
+	text := l.T(msgResult, i18n.Args{"Created": created, "Updated": updated, "Unchanged": plan.Unchanged})
+	if len(plan.Invalid) > 0 {
+		text += "\n" + l.T(msgResultInvalid, i18n.Args{"Count": len(plan.Invalid)})
+	}
+	return text
+}
+
+func formatChanges(l i18n.Localizer, changes []importer.FieldChange) string {
+	parts := make([]string, 0, len(changes))
+	for _, c := range changes {
+		label := c.Field
+		if msg, ok := fieldLabels[c.Field]; ok {
+			label = l.T(msg)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s → %s", label, truncate(l, c.From), truncate(l, c.To)))
+	}
+	return strings.Join(parts, l.T(msgPreviewSeparator))
+}
+
+func listRows(l i18n.Localizer, rows []string) string {
+	if len(rows) <= maxListedRows {
+		return strings.Join(rows, "\n")
+	}
+	more := l.T(msgPreviewMoreRows, i18n.Args{"Count": len(rows) - maxListedRows})
+	return strings.Join(rows[:maxListedRows], "\n") + "\n" + more
+}
+
+// truncate shortens long values such as descriptions to keep the preview readable
+func truncate(l i18n.Localizer, s string) string {
+	const max = 30
+
+	if s == "" {
+		return l.T(msgPreviewEmptyValue)
+	}
+
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
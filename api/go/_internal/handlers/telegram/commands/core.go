@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/fx"
@@ -15,6 +16,18 @@ func (c BotCommand) String() string {
 
 var (
 	AddProduct BotCommand = "add"
+	Stock      BotCommand = "stock"
+	Edit       BotCommand = "edit"
+	Orders     BotCommand = "orders"
+	Order      BotCommand = "order"
+	Report     BotCommand = "report"
+	Lang       BotCommand = "lang"
+	Import     BotCommand = "import"
+
+	// Wizard controls, routed to the command owning the user's active session
+	Pause   BotCommand = "pause"
+	Cancel  BotCommand = "cancel"
+	Restart BotCommand = "restart"
 )
 
 type CommandHandler interface {
@@ -23,6 +36,47 @@ type CommandHandler interface {
 	Command() BotCommand
 }
 
+// CallbackQueryHandler is implemented by command handlers that send inline keyboard buttons.
+// Callback data is prefixed with the command so the bot can route the query back to its handler.
+type CallbackQueryHandler interface {
+	HandleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error
+}
+
+// DocumentHandler is implemented by the command that takes files sent to the bot without a
+// command or a prompt to reply to
+type DocumentHandler interface {
+	HandleDocument(ctx context.Context, msg *tgbotapi.Message) error
+}
+
+// Wizard is implemented by multi-step commands that can be paused, resumed, restarted and
+// cancelled. /pause, /cancel and /restart are dispatched to the wizard owning the user's
+// active session; resuming is done by running the wizard's command again.
+type Wizard interface {
+	Pause(ctx context.Context, msg *tgbotapi.Message) error
+	Resume(ctx context.Context, msg *tgbotapi.Message) error
+	Restart(ctx context.Context, msg *tgbotapi.Message) error
+	Cancel(ctx context.Context, msg *tgbotapi.Message) error
+}
+
+// IsWizardControl reports whether cmd is one of the wizard control commands
+func IsWizardControl(cmd BotCommand) bool {
+	return cmd == Pause || cmd == Cancel || cmd == Restart
+}
+
+const callbackDataSeparator = ":"
+
+// CallbackData builds inline button data in the form "<command>:<arg>:<arg>".
+// Telegram limits callback data to 64 bytes, so keep args short.
+func CallbackData(cmd BotCommand, args ...string) string {
+	return strings.Join(append([]string{cmd.String()}, args...), callbackDataSeparator)
+}
+
+// ParseCallbackData splits callback data built by CallbackData into the command and its args
+func ParseCallbackData(data string) (BotCommand, []string) {
+	parts := strings.Split(data, callbackDataSeparator)
+	return BotCommand(parts[0]), parts[1:]
+}
+
 func AsCommandHandler(f any) any {
 	return fx.Annotate(
 		f,
@@ -6,11 +6,39 @@ import (
 	"fmt"
 	"time"
 
-	"github/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
 
 	"go.uber.org/fx"
 )
 
+const sessionColumns = `
+	id,
+	chat_id,
+	user_id,
+	session_type,
+	state,
+	created_at,
+	updated_at,
+	expires_at,
+	expected_reply_message_id,
+	version
+`
+
+// Repository stores the bot's user sessions and language preferences
+type Repository interface {
+	GetUserSession(ctx context.Context, userID int64, sessionType string) (*db.UserSession, error)
+	FindReplySession(ctx context.Context, chatID, userID int64, replyToMessageID int) (*db.UserSession, error)
+	GetLatestUserSession(ctx context.Context, chatID, userID int64) (*db.UserSession, error)
+	UpsertUserSession(ctx context.Context, chatID, userID int64, sessionType string, state any, ttl time.Duration) (*db.UserSession, error)
+	UpdateUserSessionState(ctx context.Context, id int64, version int32, state any, ttl time.Duration) (*db.UserSession, error)
+	UpdateExpectedReplyMessageID(ctx context.Context, chatID, userID int64, sessionType string, expectedReplyMessageID int) error
+	DeleteUserSession(ctx context.Context, userID int64, sessionType string) error
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+	GetUserLocale(ctx context.Context, userID int64) (string, error)
+	SetUserLocale(ctx context.Context, userID int64, locale string) error
+}
+
+// CommandDAO is the Repository of a database
 type CommandDAO struct {
 	db db.Conn
 }
@@ -21,74 +49,149 @@ type CommandDAOParams struct {
 	DB db.Conn
 }
 
-// UserSession represents a user session from the database
-type UserSession struct {
-	ID          int64           `json:"id"`
-	ChatID      int64           `json:"chat_id"`
-	UserID      int64           `json:"user_id"`
-	SessionType string          `json:"session_type"`
-	State       json.RawMessage `json:"state"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	ExpiresAt   time.Time       `json:"expires_at"`
-}
-
 func NewCommandDAO(p CommandDAOParams) *CommandDAO {
 	return &CommandDAO{db: p.DB}
 }
 
-// GetUserSession retrieves a user session by user_id and session_type
+var _ Repository = (*CommandDAO)(nil)
+
+// GetUserSession retrieves an active user session by user_id and session_type
 func (cmd *CommandDAO) GetUserSession(ctx context.Context, userID int64, sessionType string) (*db.UserSession, error) {
-	query := `
-		SELECT
-			id,
-			chat_id,
-			user_id,
-			session_type,
-			state,
-			created_at,
-			updated_at,
-			expires_at
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM user_sessions
 		WHERE
 			user_id = $1 AND
 			session_type = $2 AND
 			expires_at > NOW()
 		LIMIT 1
-	`
+	`, sessionColumns)
+
+	var session db.UserSession
+	if err := cmd.db.GetContext(ctx, &session, query, userID, sessionType); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// FindReplySession finds the user's active session a reply belongs to, regardless of command.
+// The session waiting for a reply to replyToMessageID wins, otherwise the most recently
+// updated session of the user in that chat is used.
+func (cmd *CommandDAO) FindReplySession(ctx context.Context, chatID, userID int64, replyToMessageID int) (*db.UserSession, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_sessions
+		WHERE
+			chat_id = $1 AND
+			user_id = $2 AND
+			expires_at > NOW()
+		ORDER BY
+			(expected_reply_message_id = $3) DESC NULLS LAST,
+			updated_at DESC
+		LIMIT 1
+	`, sessionColumns)
 
 	var session db.UserSession
-	if err := cmd.db.QueryRowx(query, userID, sessionType).StructScan(&session); err != nil {
+	if err := cmd.db.GetContext(ctx, &session, query, chatID, userID, replyToMessageID); err != nil {
 		return nil, err
 	}
 
 	return &session, nil
 }
 
-// CreateUserSession creates a new user session
-func (cmd *CommandDAO) UpsertUserSession(ctx context.Context, chatID, userID int64, sessionType string, state interface{}) error {
+// GetLatestUserSession returns the user's most recently updated active session in the chat
+func (cmd *CommandDAO) GetLatestUserSession(ctx context.Context, chatID, userID int64) (*db.UserSession, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_sessions
+		WHERE
+			chat_id = $1 AND
+			user_id = $2 AND
+			expires_at > NOW()
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`, sessionColumns)
+
+	var session db.UserSession
+	if err := cmd.db.GetContext(ctx, &session, query, chatID, userID); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// UpsertUserSession creates the session or replaces an existing one of the same type,
+// resetting its version and expiry.
+func (cmd *CommandDAO) UpsertUserSession(ctx context.Context, chatID, userID int64, sessionType string, state any, ttl time.Duration) (*db.UserSession, error) {
 	stateJSON, err := json.Marshal(state)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	query := `
+	query := fmt.Sprintf(`
 		INSERT INTO user_sessions (chat_id, user_id, session_type, state, expires_at)
 		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (user_id, session_type)
 		DO UPDATE SET
 			chat_id = EXCLUDED.chat_id,
 			state = EXCLUDED.state,
-			updated_at = NOW(),
-			expires_at = EXCLUDED.expires_at
-	`
+			expires_at = EXCLUDED.expires_at,
+			expected_reply_message_id = NULL,
+			version = 1,
+			updated_at = NOW()
+		RETURNING %s
+	`, sessionColumns)
 
-	expiresAt := time.Now().Add(24 * time.Hour)
-	_, err = cmd.db.Exec(query, chatID, userID, sessionType, string(stateJSON), expiresAt)
+	var session db.UserSession
+	if err := cmd.db.GetContext(
+		ctx,
+		&session,
+		query,
+		chatID,
+		userID,
+		sessionType,
+		string(stateJSON),
+		time.Now().Add(ttl),
+	); err != nil {
+		return nil, fmt.Errorf("failed to UpsertUserSession: %w", err)
+	}
+
+	return &session, nil
+}
+
+// UpdateUserSessionState writes state only if the session is still at version, bumping the
+// version and sliding the expiry. sql.ErrNoRows means another write won the race.
+func (cmd *CommandDAO) UpdateUserSessionState(ctx context.Context, id int64, version int32, state any, ttl time.Duration) (*db.UserSession, error) {
+	stateJSON, err := json.Marshal(state)
 	if err != nil {
-		return fmt.Errorf("failed to UpsertUserSession: %w", err)
+		return nil, err
 	}
-	return err
+
+	query := fmt.Sprintf(`
+		UPDATE user_sessions
+		SET
+			state = $3,
+			expires_at = $4,
+			version = version + 1
+		WHERE id = $1 AND version = $2
+		RETURNING %s
+	`, sessionColumns)
+
+	var session db.UserSession
+	if err := cmd.db.GetContext(
+		ctx,
+		&session,
+		query,
+		id,
+		version,
+		string(stateJSON),
+		time.Now().Add(ttl),
+	); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
 }
 
 func (cmd *CommandDAO) UpdateExpectedReplyMessageID(ctx context.Context, chatID, userID int64, sessionType string, expectedReplyMessageID int) error {
@@ -97,13 +200,55 @@ func (cmd *CommandDAO) UpdateExpectedReplyMessageID(ctx context.Context, chatID,
 		SET expected_reply_message_id = $1
 		WHERE user_id = $2 AND chat_id = $3 AND session_type = $4
 	`
-	_, err := cmd.db.Exec(query, expectedReplyMessageID, userID, chatID, sessionType)
+	_, err := cmd.db.ExecContext(ctx, query, expectedReplyMessageID, userID, chatID, sessionType)
 	return err
 }
 
 // DeleteUserSession deletes a user session
 func (cmd *CommandDAO) DeleteUserSession(ctx context.Context, userID int64, sessionType string) error {
 	query := `DELETE FROM user_sessions WHERE user_id = $1 AND session_type = $2`
-	_, err := cmd.db.Exec(query, userID, sessionType)
+	_, err := cmd.db.ExecContext(ctx, query, userID, sessionType)
 	return err
 }
+
+// DeleteExpiredSessions removes every session past its expiry and returns how many were removed
+func (cmd *CommandDAO) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	res, err := cmd.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// GetUserLocale returns the locale the user picked with /lang, sql.ErrNoRows when they haven't
+func (cmd *CommandDAO) GetUserLocale(ctx context.Context, userID int64) (string, error) {
+	var locale string
+	if err := cmd.db.GetContext(
+		ctx,
+		&locale,
+		`SELECT locale FROM telegram_user_preferences WHERE user_id = $1`,
+		userID,
+	); err != nil {
+		return "", err
+	}
+
+	return locale, nil
+}
+
+// SetUserLocale stores the user's language preference
+func (cmd *CommandDAO) SetUserLocale(ctx context.Context, userID int64, locale string) error {
+	query := `
+		INSERT INTO telegram_user_preferences (user_id, locale)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			locale = EXCLUDED.locale,
+			updated_at = NOW()
+	`
+	if _, err := cmd.db.ExecContext(ctx, query, userID, locale); err != nil {
+		return fmt.Errorf("failed to set user locale: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,334 @@
+package edit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// sessionTTL covers the time between picking a field and replying with its new value
+const sessionTTL = time.Hour
+
+// Message IDs, see pkg/i18n/locales
+const (
+	msgUsage           = "edit.usage"
+	msgNotFound        = "edit.not_found"
+	msgNoActiveSession = "edit.no_active_session"
+	msgProductSummary  = "edit.summary"
+	msgSummaryField    = "edit.summary.field"
+	msgPromptField     = "edit.prompt_field"
+	msgFieldUpdated    = "edit.field_updated"
+	msgNotSellable     = "edit.not_sellable"
+	msgEditDone        = "edit.done"
+	msgUnknownAction   = "edit.unknown_action"
+
+	labelOnSale    = "edit.on_sale"
+	labelOffSale   = "edit.off_sale"
+	buttonPutOn    = "edit.button.put_on"
+	buttonTakeOff  = "edit.button.take_off"
+	buttonEditDone = "edit.button.done"
+)
+
+type EditCommand struct {
+	sessions   *commands.SessionStore[EditSessionState]
+	productDAO *catalog.ProductDAO
+	botAPI     *tgbotapi.BotAPI
+	locales    *commands.LocaleResolver
+	logger     *zap.SugaredLogger
+}
+
+type EditCommandParams struct {
+	fx.In
+
+	CommandDAO     commands.Repository
+	ProductDAO     *catalog.ProductDAO
+	BotAPI         *tgbotapi.BotAPI
+	LocaleResolver *commands.LocaleResolver
+	Logger         *zap.SugaredLogger
+}
+
+func NewEditCommand(p EditCommandParams) *EditCommand {
+	return &EditCommand{
+		sessions:   commands.NewSessionStore[EditSessionState](p.CommandDAO, commands.Edit, sessionTTL),
+		productDAO: p.ProductDAO,
+		botAPI:     p.BotAPI,
+		locales:    p.LocaleResolver,
+		logger:     p.Logger,
+	}
+}
+
+// Handle shows the product's current fields with inline buttons to pick the field to edit
+func (c *EditCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	sku := strings.TrimSpace(msg.CommandArguments())
+	if sku == "" {
+		return c.sendText(msg.Chat.ID, loc.T(msgUsage))
+	}
+
+	product, err := c.productDAO.GetProductBySKU(ctx, sku)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.sendText(msg.Chat.ID, loc.T(msgNotFound, i18n.Args{"SKU": sku}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return c.sendMenu(loc, msg.Chat.ID, product, "")
+}
+
+// HandleCallbackQuery handles the field, toggle and done buttons of the edit menu
+func (c *EditCommand) HandleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query.Message == nil {
+		return nil
+	}
+	loc := c.locales.Resolve(ctx, query.From)
+
+	_, args := commands.ParseCallbackData(query.Data)
+	if len(args) < 2 {
+		return c.sendText(query.Message.Chat.ID, loc.T(msgUnknownAction))
+	}
+
+	productID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return c.sendText(query.Message.Chat.ID, loc.T(msgUnknownAction))
+	}
+
+	product, err := c.productDAO.GetProductByID(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.sendText(query.Message.Chat.ID, loc.T(msgNotFound, i18n.Args{"SKU": args[1]}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+
+	switch args[0] {
+	case actionField:
+		if len(args) < 3 {
+			return c.sendText(query.Message.Chat.ID, loc.T(msgUnknownAction))
+		}
+		return c.promptField(ctx, loc, query, product, args[2])
+	case actionToggle:
+		return c.toggleReadyForSale(ctx, loc, query, product)
+	case actionDone:
+		return c.done(ctx, loc, query, product)
+	default:
+		return c.sendText(query.Message.Chat.ID, loc.T(msgUnknownAction))
+	}
+}
+
+// HandleReply validates the new field value and saves it through the catalog DAO
+func (c *EditCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	session, err := c.sessions.Get(ctx, msg.From.ID)
+	if errors.Is(err, commands.ErrSessionNotFound) {
+		return c.sendText(msg.Chat.ID, loc.T(msgNoActiveSession))
+	}
+	if err != nil {
+		return err
+	}
+	state := session.State
+
+	field, ok := findEditableField(state.Field)
+	if !ok {
+		return c.sendText(msg.Chat.ID, loc.T(msgUnknownAction))
+	}
+
+	params, err := field.Parse(msg.Text)
+	if err != nil {
+		// Validator errors carry the message to show, ask again for the same field.
+		return c.prompt(ctx, msg.Chat.ID, msg.From.ID, loc.Error(err))
+	}
+
+	product, err := c.productDAO.UpdateProduct(ctx, state.ProductID, params)
+	if errors.Is(err, catalog.ErrNotSellable) {
+		return c.sendText(msg.Chat.ID, loc.T(msgNotSellable))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	if err := c.sessions.Delete(ctx, msg.From.ID); err != nil {
+		c.logger.Errorw("failed to delete edit session", "user_id", msg.From.ID, "error", err)
+	}
+
+	c.logger.Infow(
+		"product edited",
+		"sku", product.Sku,
+		"field", field.Key,
+		"actor_telegram_id", msg.From.ID,
+	)
+
+	return c.sendMenu(loc, msg.Chat.ID, product, loc.T(msgFieldUpdated, i18n.Args{"Label": loc.T(field.LabelID)}))
+}
+
+func (c *EditCommand) promptField(ctx context.Context, loc i18n.Localizer, query *tgbotapi.CallbackQuery, product *db.Product, key string) error {
+	field, ok := findEditableField(key)
+	if !ok {
+		return c.sendText(query.Message.Chat.ID, loc.T(msgUnknownAction))
+	}
+
+	state := EditSessionState{
+		ProductID: product.ID,
+		SKU:       product.Sku,
+		Field:     field.Key,
+	}
+
+	if _, err := c.sessions.Start(ctx, query.Message.Chat.ID, query.From.ID, state); err != nil {
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+
+	return c.prompt(
+		ctx,
+		query.Message.Chat.ID,
+		query.From.ID,
+		loc.T(msgPromptField, i18n.Args{"Label": loc.T(field.LabelID), "Value": field.Current(product)}),
+	)
+}
+
+func (c *EditCommand) toggleReadyForSale(ctx context.Context, loc i18n.Localizer, query *tgbotapi.CallbackQuery, product *db.Product) error {
+	readyForSale := !product.ReadyForSale
+
+	updated, err := c.productDAO.UpdateProduct(ctx, product.ID, catalog.UpdateProductParams{
+		ReadyForSale: &readyForSale,
+	})
+	if errors.Is(err, catalog.ErrNotSellable) {
+		return c.sendText(query.Message.Chat.ID, loc.T(msgNotSellable))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to toggle ready_for_sale: %w", err)
+	}
+
+	c.logger.Infow(
+		"product ready_for_sale toggled",
+		"sku", updated.Sku,
+		"ready_for_sale", updated.ReadyForSale,
+		"actor_telegram_id", query.From.ID,
+	)
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		summary(loc, updated),
+		menuKeyboard(loc, updated),
+	)
+	_, err = c.botAPI.Send(edit)
+	return err
+}
+
+func (c *EditCommand) done(ctx context.Context, loc i18n.Localizer, query *tgbotapi.CallbackQuery, product *db.Product) error {
+	if err := c.sessions.Delete(ctx, query.From.ID); err != nil {
+		c.logger.Errorw("failed to delete edit session", "user_id", query.From.ID, "error", err)
+	}
+
+	edit := tgbotapi.NewEditMessageText(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		loc.T(msgEditDone, i18n.Args{"SKU": product.Sku}),
+	)
+	_, err := c.botAPI.Send(edit)
+	return err
+}
+
+// prompt sends text as a force reply and records it as the message the session expects a reply to
+func (c *EditCommand) prompt(ctx context.Context, chatID, userID int64, text string) error {
+	message := tgbotapi.NewMessage(chatID, text)
+	message.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply: true,
+		Selective:  true,
+	}
+
+	sent, err := c.botAPI.Send(message)
+	if err != nil {
+		return fmt.Errorf("failed to send prompt: %w", err)
+	}
+
+	return c.sessions.ExpectReply(ctx, chatID, userID, sent.MessageID)
+}
+
+func (c *EditCommand) sendMenu(loc i18n.Localizer, chatID int64, product *db.Product, notice string) error {
+	text := summary(loc, product)
+	if notice != "" {
+		text = notice + "\n\n" + text
+	}
+
+	message := tgbotapi.NewMessage(chatID, text)
+	message.ReplyMarkup = menuKeyboard(loc, product)
+	_, err := c.botAPI.Send(message)
+	return err
+}
+
+func (c *EditCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func (c *EditCommand) Command() commands.BotCommand {
+	return commands.Edit
+}
+
+func summary(loc i18n.Localizer, product *db.Product) string {
+	var fields strings.Builder
+	for _, f := range editableFields {
+		fields.WriteString(loc.T(msgSummaryField, i18n.Args{"Label": loc.T(f.LabelID), "Value": f.Current(product)}) + "\n")
+	}
+
+	status := labelOffSale
+	if product.ReadyForSale {
+		status = labelOnSale
+	}
+
+	return loc.T(msgProductSummary, i18n.Args{
+		"SKU":    product.Sku,
+		"Fields": fields.String(),
+		"Status": loc.T(status),
+	})
+}
+
+func menuKeyboard(loc i18n.Localizer, product *db.Product) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(product.ID, 10)
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(editableFields)/2+2)
+	for i := 0; i < len(editableFields); i += 2 {
+		row := make([]tgbotapi.InlineKeyboardButton, 0, 2)
+		for _, f := range editableFields[i:min(i+2, len(editableFields))] {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				loc.T(f.LabelID),
+				commands.CallbackData(commands.Edit, actionField, id, f.Key),
+			))
+		}
+		rows = append(rows, row)
+	}
+
+	toggleLabel := buttonPutOn
+	if product.ReadyForSale {
+		toggleLabel = buttonTakeOff
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(loc.T(toggleLabel), commands.CallbackData(commands.Edit, actionToggle, id)),
+		tgbotapi.NewInlineKeyboardButtonData(loc.T(buttonEditDone), commands.CallbackData(commands.Edit, actionDone, id)),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+var (
+	_ commands.CommandHandler       = (*EditCommand)(nil)
+	_ commands.CallbackQueryHandler = (*EditCommand)(nil)
+)
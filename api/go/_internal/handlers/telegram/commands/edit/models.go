@@ -0,0 +1,116 @@
+package edit
+
+import (
+	"strconv"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/add_product"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Callback actions, kept short because callback data is limited to 64 bytes
+const (
+	actionField  = "f"
+	actionToggle = "t"
+	actionDone   = "d"
+)
+
+// EditSessionState is persisted in user_sessions while waiting for the new field value
+type EditSessionState struct {
+	ProductID int64  `json:"product_id"`
+	SKU       string `json:"sku"`
+	Field     string `json:"field"`
+}
+
+// editableField describes a product field that can be changed from /edit. Parse reuses the
+// add wizard's validators so both flows accept exactly the same input.
+type editableField struct {
+	Key string
+	// LabelID is the message ID of the field name
+	LabelID string
+	Current func(p *db.Product) string
+	Parse   func(input string) (catalog.UpdateProductParams, error)
+}
+
+var editableFields = []editableField{
+	{
+		Key:     "name",
+		LabelID: "edit.field.name",
+		Current: func(p *db.Product) string { return p.Name },
+		Parse: func(input string) (catalog.UpdateProductParams, error) {
+			name, err := add_product.ValidateName(input)
+			return catalog.UpdateProductParams{Name: &name}, err
+		},
+	},
+	{
+		Key:     "category",
+		LabelID: "edit.field.category",
+		Current: func(p *db.Product) string { return textValue(p.Category) },
+		Parse: func(input string) (catalog.UpdateProductParams, error) {
+			category, err := add_product.ValidateCategory(input)
+			return catalog.UpdateProductParams{Category: &category}, err
+		},
+	},
+	{
+		Key:     "price",
+		LabelID: "edit.field.price",
+		Current: func(p *db.Product) string { return numericValue(p.Price) },
+		Parse: func(input string) (catalog.UpdateProductParams, error) {
+			price, err := add_product.ValidatePrice(input)
+			return catalog.UpdateProductParams{Price: &price}, err
+		},
+	},
+	{
+		Key:     "original_price",
+		LabelID: "edit.field.original_price",
+		Current: func(p *db.Product) string { return numericValue(p.OriginalPrice) },
+		Parse: func(input string) (catalog.UpdateProductParams, error) {
+			price, err := add_product.ValidatePrice(input)
+			return catalog.UpdateProductParams{OriginalPrice: &price}, err
+		},
+	},
+	{
+		Key:     "short_desc",
+		LabelID: "edit.field.short_desc",
+		Current: func(p *db.Product) string { return textValue(p.ShortDesc) },
+		Parse: func(input string) (catalog.UpdateProductParams, error) {
+			desc, err := add_product.ValidateDescription(input)
+			return catalog.UpdateProductParams{ShortDesc: &desc}, err
+		},
+	},
+	{
+		Key:     "full_desc",
+		LabelID: "edit.field.full_desc",
+		Current: func(p *db.Product) string { return textValue(p.FullDesc) },
+		Parse: func(input string) (catalog.UpdateProductParams, error) {
+			desc, err := add_product.ValidateDescription(input)
+			return catalog.UpdateProductParams{FullDesc: &desc}, err
+		},
+	},
+}
+
+func findEditableField(key string) (editableField, bool) {
+	for _, f := range editableFields {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return editableField{}, false
+}
+
+func textValue(t pgtype.Text) string {
+	if !t.Valid || t.String == "" {
+		return "-"
+	}
+	return t.String
+}
+
+func numericValue(n pgtype.Numeric) string {
+	f, err := n.Float64Value()
+	if err != nil || !f.Valid {
+		return "-"
+	}
+	return strconv.FormatFloat(f.Float64, 'f', -1, 64)
+}
@@ -0,0 +1,135 @@
+package lang
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Message IDs, see pkg/i18n/locales
+const (
+	msgCurrent     = "lang.current"
+	msgUpdated     = "lang.updated"
+	msgUnsupported = "lang.unsupported"
+)
+
+const actionSet = "s"
+
+// LangCommand shows or sets the language the bot uses with the staff member:
+// /lang lists the locales as buttons, /lang <locale> sets it directly
+type LangCommand struct {
+	commandDAO commands.Repository
+	locales    *commands.LocaleResolver
+	botAPI     *tgbotapi.BotAPI
+	logger     *zap.SugaredLogger
+}
+
+type LangCommandParams struct {
+	fx.In
+
+	CommandDAO     commands.Repository
+	LocaleResolver *commands.LocaleResolver
+	BotAPI         *tgbotapi.BotAPI
+	Logger         *zap.SugaredLogger
+}
+
+func NewLangCommand(p LangCommandParams) *LangCommand {
+	return &LangCommand{
+		commandDAO: p.CommandDAO,
+		locales:    p.LocaleResolver,
+		botAPI:     p.BotAPI,
+		logger:     p.Logger,
+	}
+}
+
+func (c *LangCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		return c.set(ctx, msg.Chat.ID, msg.From, arg)
+	}
+
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(i18n.Locales()))
+	for _, locale := range i18n.Locales() {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(
+			loc.T(localeName(locale)),
+			commands.CallbackData(commands.Lang, actionSet, locale),
+		))
+	}
+
+	message := tgbotapi.NewMessage(msg.Chat.ID, loc.T(msgCurrent, i18n.Args{"Locale": loc.T(localeName(loc.Locale()))}))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(buttons...))
+	_, err := c.botAPI.Send(message)
+	return err
+}
+
+// HandleCallbackQuery sets the locale picked from the /lang buttons
+func (c *LangCommand) HandleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query.Message == nil {
+		return nil
+	}
+
+	_, args := commands.ParseCallbackData(query.Data)
+	if len(args) < 2 || args[0] != actionSet {
+		return nil
+	}
+
+	return c.set(ctx, query.Message.Chat.ID, query.From, args[1])
+}
+
+// HandleReply is a no-op, /lang doesn't prompt for input
+func (c *LangCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	return nil
+}
+
+func (c *LangCommand) set(ctx context.Context, chatID int64, user *tgbotapi.User, input string) error {
+	locale := input
+	if !i18n.Supported(locale) {
+		locale = i18n.Match(input)
+	}
+
+	if locale == "" {
+		loc := c.locales.Resolve(ctx, user)
+		return c.sendText(chatID, loc.T(msgUnsupported, i18n.Args{
+			"Locale":  input,
+			"Locales": strings.Join(i18n.Locales(), ", "),
+		}))
+	}
+
+	if err := c.commandDAO.SetUserLocale(ctx, user.ID, locale); err != nil {
+		return fmt.Errorf("failed to set locale: %w", err)
+	}
+
+	c.logger.Infow("user locale set", "user_id", user.ID, "locale", locale)
+
+	loc := i18n.For(locale)
+	return c.sendText(chatID, loc.T(msgUpdated, i18n.Args{"Locale": loc.T(localeName(locale))}))
+}
+
+func (c *LangCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func (c *LangCommand) Command() commands.BotCommand {
+	return commands.Lang
+}
+
+// localeName is the message ID of a locale's display name
+func localeName(locale string) string {
+	return "locale." + locale
+}
+
+var (
+	_ commands.CommandHandler       = (*LangCommand)(nil)
+	_ commands.CallbackQueryHandler = (*LangCommand)(nil)
+)
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// LocaleResolver picks the language the bot answers a user in: the preference set with /lang,
+// then the Telegram client's language_code, then i18n.DefaultLocale
+type LocaleResolver struct {
+	dao    Repository
+	logger *zap.SugaredLogger
+}
+
+type LocaleResolverParams struct {
+	fx.In
+
+	CommandDAO Repository
+	Logger     *zap.SugaredLogger
+}
+
+func NewLocaleResolver(p LocaleResolverParams) *LocaleResolver {
+	return &LocaleResolver{
+		dao:    p.CommandDAO,
+		logger: p.Logger,
+	}
+}
+
+// Resolve returns the localizer for user. Failing to read the preference isn't fatal, the
+// user is answered in their client language instead.
+func (r *LocaleResolver) Resolve(ctx context.Context, user *tgbotapi.User) i18n.Localizer {
+	if user == nil {
+		return i18n.For(i18n.DefaultLocale)
+	}
+
+	locale, err := r.dao.GetUserLocale(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Errorw("failed to get user locale", "user_id", user.ID, "error", err)
+	}
+	if err == nil && i18n.Supported(locale) {
+		return i18n.For(locale)
+	}
+
+	return i18n.For(i18n.Match(user.LanguageCode))
+}
@@ -0,0 +1,158 @@
+package order
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Callback actions, kept short because callback data is limited to 64 bytes
+const (
+	actionView    = "v"
+	actionSet     = "s"
+	actionConfirm = "c"
+)
+
+const timeLayout = "2006/01/02 15:04"
+
+// Staff are in Taiwan; a fixed zone avoids depending on tzdata in the serverless runtime.
+var displayLocation = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// Message IDs, see pkg/i18n/locales
+const (
+	msgDetailTitle     = "order.detail.title"
+	msgDetailStatus    = "order.detail.status"
+	msgDetailEmail     = "order.detail.email"
+	msgDetailCreatedAt = "order.detail.created_at"
+	msgDetailItems     = "order.detail.items"
+	msgDetailSubtotal  = "order.detail.subtotal"
+	msgDetailDiscount  = "order.detail.discount"
+	msgDetailShipping  = "order.detail.shipping"
+	msgDetailTax       = "order.detail.tax"
+	msgDetailTotal     = "order.detail.total"
+)
+
+// staffActions are the transitions staff can trigger from the bot, in button order
+var staffActions = []db.OrderStatus{
+	db.OrderStatusProcessing,
+	db.OrderStatusShipped,
+	db.OrderStatusCanceled,
+}
+
+// StatusLabel renders the status as "order.status.<status>", or as is when it has no message
+func StatusLabel(loc i18n.Localizer, status db.OrderStatus) string {
+	id := "order.status." + string(status)
+	if label := loc.T(id); label != id {
+		return label
+	}
+	return string(status)
+}
+
+// FormatOrderLine renders an order as a single line for /orders
+func FormatOrderLine(loc i18n.Localizer, o *db.Order) string {
+	return fmt.Sprintf(
+		"#%d %s %s %s",
+		o.OrderNumber,
+		StatusLabel(loc, o.Status),
+		formatMoney(o.Currency, o.GrandTotal),
+		formatTime(o.CreatedAt),
+	)
+}
+
+// FormatOrderDetail renders an order with its items and totals
+func FormatOrderDetail(loc i18n.Localizer, o *orders.OrderDetail) string {
+	var b strings.Builder
+
+	b.WriteString(loc.T(msgDetailTitle, i18n.Args{"Number": o.OrderNumber}) + "\n")
+	b.WriteString(loc.T(msgDetailStatus, i18n.Args{"Status": StatusLabel(loc, o.Status)}) + "\n")
+	b.WriteString(loc.T(msgDetailEmail, i18n.Args{"Email": o.Email}) + "\n")
+	b.WriteString(loc.T(msgDetailCreatedAt, i18n.Args{"CreatedAt": formatTime(o.CreatedAt)}) + "\n\n")
+
+	b.WriteString(loc.T(msgDetailItems) + "\n")
+	for _, item := range o.Items {
+		fmt.Fprintf(
+			&b,
+			"• %s x%d = %s\n",
+			item.Name,
+			item.Quantity,
+			formatMoney(o.Currency, item.LineTotal),
+		)
+	}
+
+	b.WriteString("\n" + loc.T(msgDetailSubtotal, amount(o.Currency, o.Subtotal)) + "\n")
+	if isPositive(o.DiscountTotal) {
+		b.WriteString(loc.T(msgDetailDiscount, amount(o.Currency, o.DiscountTotal)) + "\n")
+	}
+	b.WriteString(loc.T(msgDetailShipping, amount(o.Currency, o.ShippingTotal)) + "\n")
+	if isPositive(o.TaxTotal) {
+		b.WriteString(loc.T(msgDetailTax, amount(o.Currency, o.TaxTotal)) + "\n")
+	}
+	b.WriteString(loc.T(msgDetailTotal, amount(o.Currency, o.GrandTotal)))
+
+	return b.String()
+}
+
+// ActionKeyboard returns buttons for the staff transitions allowed from the order's status,
+// or nil when the order can't be moved by staff anymore.
+func ActionKeyboard(loc i18n.Localizer, o *db.Order) *tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(o.ID, 10)
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(staffActions))
+	for _, to := range staffActions {
+		if !orders.CanTransition(o.Status, to) {
+			continue
+		}
+
+		// Canceling can't be undone, so it asks for confirmation first.
+		act := actionSet
+		if to == db.OrderStatusCanceled {
+			act = actionConfirm
+		}
+
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			loc.T("order.action."+string(to)),
+			commands.CallbackData(commands.Order, act, id, string(to)),
+		))
+	}
+
+	if len(row) == 0 {
+		return nil
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &keyboard
+}
+
+// amount is the template args of the total lines
+func amount(currency string, n pgtype.Numeric) i18n.Args {
+	return i18n.Args{"Amount": formatMoney(currency, n)}
+}
+
+func formatMoney(currency string, n pgtype.Numeric) string {
+	f, err := n.Float64Value()
+	if err != nil || !f.Valid {
+		return "-"
+	}
+	return fmt.Sprintf("%s %s", currency, strconv.FormatFloat(f.Float64, 'f', -1, 64))
+}
+
+func formatTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.In(displayLocation).Format(timeLayout)
+}
+
+func isPositive(n pgtype.Numeric) bool {
+	f, err := n.Float64Value()
+	return err == nil && f.Valid && f.Float64 > 0
+}
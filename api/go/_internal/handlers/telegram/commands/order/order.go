@@ -0,0 +1,334 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Message IDs, see pkg/i18n/locales
+const (
+	msgOrderUsage         = "order.usage"
+	msgOrderNotFound      = "order.not_found"
+	msgNoActiveSession    = "order.no_active_session"
+	msgPromptTracking     = "order.prompt_tracking"
+	msgInvalidTracking    = "order.invalid_tracking"
+	msgConfirmCancel      = "order.confirm_cancel"
+	msgInvalidTransition  = "order.invalid_transition"
+	msgStatusUpdated      = "order.status_updated"
+	msgUnknownOrderAction = "order.unknown_action"
+	buttonConfirmCancel   = "order.button.confirm_cancel"
+	buttonBackToOrder     = "order.button.back"
+)
+
+const maxTrackingNumberRunes = 100
+
+// sessionTTL covers the time between pressing 出貨 and replying with the tracking number
+const sessionTTL = time.Hour
+
+// OrderSessionState is persisted in user_sessions while waiting for the tracking number
+type OrderSessionState struct {
+	OrderID     int64 `json:"order_id"`
+	OrderNumber int64 `json:"order_number"`
+}
+
+// OrderCommand shows a single order and lets staff move it through the order state rules
+type OrderCommand struct {
+	sessions *commands.SessionStore[OrderSessionState]
+	orderDAO *orders.OrderDAO
+	botAPI   *tgbotapi.BotAPI
+	locales  *commands.LocaleResolver
+	logger   *zap.SugaredLogger
+}
+
+type OrderCommandParams struct {
+	fx.In
+
+	CommandDAO     commands.Repository
+	OrderDAO       *orders.OrderDAO
+	BotAPI         *tgbotapi.BotAPI
+	LocaleResolver *commands.LocaleResolver
+	Logger         *zap.SugaredLogger
+}
+
+func NewOrderCommand(p OrderCommandParams) *OrderCommand {
+	return &OrderCommand{
+		sessions: commands.NewSessionStore[OrderSessionState](p.CommandDAO, commands.Order, sessionTTL),
+		orderDAO: p.OrderDAO,
+		botAPI:   p.BotAPI,
+		locales:  p.LocaleResolver,
+		logger:   p.Logger,
+	}
+}
+
+// Handle shows the order with buttons for the allowed staff transitions
+func (c *OrderCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	arg := strings.TrimPrefix(strings.TrimSpace(msg.CommandArguments()), "#")
+	if arg == "" {
+		return c.sendText(msg.Chat.ID, loc.T(msgOrderUsage))
+	}
+
+	orderNumber, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return c.sendText(msg.Chat.ID, loc.T(msgOrderUsage))
+	}
+
+	return c.sendOrder(ctx, loc, msg.Chat.ID, orderNumber)
+}
+
+// HandleCallbackQuery handles the view, transition and cancel confirmation buttons
+func (c *OrderCommand) HandleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query.Message == nil {
+		return nil
+	}
+	chatID := query.Message.Chat.ID
+	loc := c.locales.Resolve(ctx, query.From)
+
+	_, args := commands.ParseCallbackData(query.Data)
+	if len(args) < 2 {
+		return c.sendText(chatID, loc.T(msgUnknownOrderAction))
+	}
+
+	if args[0] == actionView {
+		orderNumber, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return c.sendText(chatID, loc.T(msgUnknownOrderAction))
+		}
+		return c.sendOrder(ctx, loc, chatID, orderNumber)
+	}
+
+	orderID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || len(args) < 3 {
+		return c.sendText(chatID, loc.T(msgUnknownOrderAction))
+	}
+	to := db.OrderStatus(args[2])
+
+	order, err := c.orderDAO.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.sendText(chatID, loc.T(msgOrderNotFound, i18n.Args{"Number": args[1]}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if !orders.CanTransition(order.Status, to) {
+		return c.sendText(chatID, invalidTransition(loc, order.Status, to))
+	}
+
+	switch {
+	case args[0] == actionConfirm:
+		return c.confirm(loc, query, &order.Order, to)
+	case args[0] == actionSet && to == db.OrderStatusShipped:
+		return c.promptTrackingNumber(ctx, loc, query, &order.Order)
+	case args[0] == actionSet:
+		return c.transition(ctx, loc, chatID, query.Message.MessageID, query.From, order.ID, to, "")
+	default:
+		return c.sendText(chatID, loc.T(msgUnknownOrderAction))
+	}
+}
+
+// HandleReply ships the order with the tracking number the staff replied with
+func (c *OrderCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	session, err := c.sessions.Get(ctx, msg.From.ID)
+	if errors.Is(err, commands.ErrSessionNotFound) {
+		return c.sendText(msg.Chat.ID, loc.T(msgNoActiveSession))
+	}
+	if err != nil {
+		return err
+	}
+	state := session.State
+
+	trackingNumber := strings.TrimSpace(msg.Text)
+	if trackingNumber == "" || len([]rune(trackingNumber)) > maxTrackingNumberRunes {
+		return c.prompt(ctx, msg.Chat.ID, msg.From.ID, loc.T(msgInvalidTracking))
+	}
+
+	if err := c.transition(ctx, loc, msg.Chat.ID, 0, msg.From, state.OrderID, db.OrderStatusShipped, trackingNumber); err != nil {
+		return err
+	}
+
+	if err := c.sessions.Delete(ctx, msg.From.ID); err != nil {
+		c.logger.Errorw("failed to delete order session", "user_id", msg.From.ID, "error", err)
+	}
+
+	return nil
+}
+
+// transition applies the status change. When editMessageID is set that order message is
+// edited in place, otherwise the updated order is sent as a new message.
+func (c *OrderCommand) transition(
+	ctx context.Context,
+	loc i18n.Localizer,
+	chatID int64,
+	editMessageID int,
+	from *tgbotapi.User,
+	orderID int64,
+	to db.OrderStatus,
+	trackingNumber string,
+) error {
+	updated, err := c.orderDAO.TransitionStatus(ctx, orders.TransitionParams{
+		OrderID:        orderID,
+		To:             to,
+		Actor:          db.StatusActorStaff,
+		ActorRef:       actorRef(from),
+		TrackingNumber: trackingNumber,
+	})
+
+	var transitionErr *orders.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return c.sendText(chatID, invalidTransition(loc, transitionErr.From, transitionErr.To))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to transition order: %w", err)
+	}
+
+	c.logger.Infow(
+		"order status changed",
+		"order_number", updated.OrderNumber,
+		"status", updated.Status,
+		"actor_telegram_id", from.ID,
+	)
+
+	detail, err := c.orderDAO.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to reload order: %w", err)
+	}
+
+	text := loc.T(msgStatusUpdated, i18n.Args{
+		"Number": updated.OrderNumber,
+		"Status": StatusLabel(loc, updated.Status),
+	}) + "\n\n" + FormatOrderDetail(loc, detail)
+	keyboard := ActionKeyboard(loc, &detail.Order)
+
+	if editMessageID == 0 {
+		message := tgbotapi.NewMessage(chatID, text)
+		if keyboard != nil {
+			message.ReplyMarkup = keyboard
+		}
+		_, err = c.botAPI.Send(message)
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, editMessageID, text)
+	edit.ReplyMarkup = keyboard
+	_, err = c.botAPI.Send(edit)
+	return err
+}
+
+func (c *OrderCommand) confirm(loc i18n.Localizer, query *tgbotapi.CallbackQuery, order *db.Order, to db.OrderStatus) error {
+	id := strconv.FormatInt(order.ID, 10)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			loc.T(buttonConfirmCancel),
+			commands.CallbackData(commands.Order, actionSet, id, string(to)),
+		),
+		tgbotapi.NewInlineKeyboardButtonData(
+			loc.T(buttonBackToOrder),
+			commands.CallbackData(commands.Order, actionView, strconv.FormatInt(order.OrderNumber, 10)),
+		),
+	))
+
+	message := tgbotapi.NewMessage(query.Message.Chat.ID, loc.T(msgConfirmCancel, i18n.Args{"Number": order.OrderNumber}))
+	message.ReplyMarkup = keyboard
+	_, err := c.botAPI.Send(message)
+	return err
+}
+
+func (c *OrderCommand) promptTrackingNumber(ctx context.Context, loc i18n.Localizer, query *tgbotapi.CallbackQuery, order *db.Order) error {
+	state := OrderSessionState{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+	}
+
+	if _, err := c.sessions.Start(ctx, query.Message.Chat.ID, query.From.ID, state); err != nil {
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+
+	return c.prompt(
+		ctx,
+		query.Message.Chat.ID,
+		query.From.ID,
+		loc.T(msgPromptTracking, i18n.Args{"Number": order.OrderNumber}),
+	)
+}
+
+// prompt sends text as a force reply and records it as the message the session expects a reply to
+func (c *OrderCommand) prompt(ctx context.Context, chatID, userID int64, text string) error {
+	message := tgbotapi.NewMessage(chatID, text)
+	message.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply: true,
+		Selective:  true,
+	}
+
+	sent, err := c.botAPI.Send(message)
+	if err != nil {
+		return fmt.Errorf("failed to send prompt: %w", err)
+	}
+
+	return c.sessions.ExpectReply(ctx, chatID, userID, sent.MessageID)
+}
+
+func (c *OrderCommand) sendOrder(ctx context.Context, loc i18n.Localizer, chatID, orderNumber int64) error {
+	order, err := c.orderDAO.GetOrderByNumber(ctx, orderNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.sendText(chatID, loc.T(msgOrderNotFound, i18n.Args{"Number": orderNumber}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	message := tgbotapi.NewMessage(chatID, FormatOrderDetail(loc, order))
+	if keyboard := ActionKeyboard(loc, &order.Order); keyboard != nil {
+		message.ReplyMarkup = keyboard
+	}
+	_, err = c.botAPI.Send(message)
+	return err
+}
+
+func (c *OrderCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func (c *OrderCommand) Command() commands.BotCommand {
+	return commands.Order
+}
+
+func invalidTransition(loc i18n.Localizer, from, to db.OrderStatus) string {
+	return loc.T(msgInvalidTransition, i18n.Args{
+		"From": StatusLabel(loc, from),
+		"To":   StatusLabel(loc, to),
+	})
+}
+
+// actorRef identifies the staff member in order_status_history
+func actorRef(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return fmt.Sprintf("telegram:%d:%s", user.ID, user.UserName)
+	}
+	return fmt.Sprintf("telegram:%d", user.ID)
+}
+
+var (
+	_ commands.CommandHandler       = (*OrderCommand)(nil)
+	_ commands.CallbackQueryHandler = (*OrderCommand)(nil)
+)
@@ -0,0 +1,138 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	recentOrdersLimit = 10
+	statusAll         = "all"
+)
+
+// Message IDs, see pkg/i18n/locales
+const (
+	msgOrdersUsage   = "orders.usage"
+	msgOrdersHeader  = "orders.header"
+	msgNoOrders      = "orders.none"
+	msgAllOrdersName = "orders.all"
+)
+
+// OrdersCommand lists recent orders by status
+type OrdersCommand struct {
+	orderDAO *orders.OrderDAO
+	botAPI   *tgbotapi.BotAPI
+	locales  *commands.LocaleResolver
+	logger   *zap.SugaredLogger
+}
+
+type OrdersCommandParams struct {
+	fx.In
+
+	OrderDAO       *orders.OrderDAO
+	BotAPI         *tgbotapi.BotAPI
+	LocaleResolver *commands.LocaleResolver
+	Logger         *zap.SugaredLogger
+}
+
+func NewOrdersCommand(p OrdersCommandParams) *OrdersCommand {
+	return &OrdersCommand{
+		orderDAO: p.OrderDAO,
+		botAPI:   p.BotAPI,
+		locales:  p.LocaleResolver,
+		logger:   p.Logger,
+	}
+}
+
+// Handle lists the latest orders in the requested status, paid by default.
+// Each order gets a button that opens it like /order <number>.
+func (c *OrdersCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	arg := strings.ToLower(strings.TrimSpace(msg.CommandArguments()))
+
+	var (
+		status     *db.OrderStatus
+		statusName = loc.T(msgAllOrdersName)
+	)
+
+	switch {
+	case arg == "":
+		paid := db.OrderStatusPaid
+		status = &paid
+		statusName = StatusLabel(loc, paid)
+	case arg == statusAll:
+	case orders.IsValidStatus(db.OrderStatus(arg)):
+		s := db.OrderStatus(arg)
+		status = &s
+		statusName = StatusLabel(loc, s)
+	default:
+		return c.sendText(msg.Chat.ID, loc.T(msgOrdersUsage, i18n.Args{"Statuses": statusList()}))
+	}
+
+	list, err := c.orderDAO.ListRecentOrders(ctx, status, recentOrdersLimit)
+	if err != nil {
+		return fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	if len(list) == 0 {
+		return c.sendText(msg.Chat.ID, loc.T(msgNoOrders, i18n.Args{"Status": statusName}))
+	}
+
+	lines := make([]string, 0, len(list))
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(list))
+	for i := range list {
+		o := &list[i]
+		lines = append(lines, FormatOrderLine(loc, o))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("#%d", o.OrderNumber),
+				commands.CallbackData(commands.Order, actionView, strconv.FormatInt(o.OrderNumber, 10)),
+			),
+		))
+	}
+
+	message := tgbotapi.NewMessage(msg.Chat.ID, loc.T(msgOrdersHeader, i18n.Args{
+		"Status": statusName,
+		"Lines":  strings.Join(lines, "\n"),
+	}))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err = c.botAPI.Send(message)
+	return err
+}
+
+// HandleReply is a no-op, /orders doesn't prompt for input
+func (c *OrdersCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	return nil
+}
+
+func (c *OrdersCommand) Command() commands.BotCommand {
+	return commands.Orders
+}
+
+func (c *OrdersCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func statusList() string {
+	names := make([]string, 0, len(orders.Statuses))
+	for _, s := range orders.Statuses {
+		names = append(names, string(s))
+	}
+	return strings.Join(names, ", ")
+}
+
+var _ commands.CommandHandler = (*OrdersCommand)(nil)
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/reports"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const dateLayout = "2006/01/02"
+
+const (
+	msgUsage        = "report.usage"
+	msgTitle        = "report.title"
+	msgPaidOrders   = "report.paid_orders"
+	msgRevenue      = "report.revenue"
+	msgUnits        = "report.units"
+	msgTopProducts  = "report.top_products"
+	msgNoSales      = "report.no_sales"
+	msgLowStock     = "report.low_stock"
+	msgLowStockItem = "report.low_stock_item"
+	msgNone         = "report.none"
+)
+
+// PeriodLabel names the period in the locale, e.g. 「本週」
+func PeriodLabel(l i18n.Localizer, p reports.Period) string {
+	return l.T("report.period." + string(p))
+}
+
+// FormatReport renders the report as Telegram MarkdownV2 in the locale. Every piece of text is
+// escaped, only the markup added here is left as is.
+func FormatReport(l i18n.Localizer, r *reports.SalesReport) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "📊 *%s*\n", escape(l.T(msgTitle, i18n.Args{"Period": PeriodLabel(l, r.Period)})))
+	b.WriteString(escape(formatRange(r)) + "\n\n")
+
+	fmt.Fprintf(&b, "%s: *%s*\n", escape(l.T(msgPaidOrders)), escape(strconv.FormatInt(r.OrderCount, 10)))
+	fmt.Fprintf(&b, "%s: *%s*\n", escape(l.T(msgRevenue)), escape(formatMoney(r.Revenue)))
+	fmt.Fprintf(&b, "%s: *%s*\n", escape(l.T(msgUnits)), escape(strconv.FormatInt(r.Units, 10)))
+
+	fmt.Fprintf(&b, "\n*%s*\n", escape(l.T(msgTopProducts)))
+	if len(r.TopProducts) == 0 {
+		b.WriteString(escape(l.T(msgNoSales)) + "\n")
+	}
+	for i, p := range r.TopProducts {
+		b.WriteString(escape(fmt.Sprintf(
+			"%d. %s (%s) x%d = %s",
+			i+1,
+			p.Name,
+			p.SKU,
+			p.Units,
+			formatMoney(p.Revenue),
+		)) + "\n")
+	}
+
+	fmt.Fprintf(&b, "\n*%s*\n", escape(l.T(msgLowStock, i18n.Args{"Threshold": reports.LowStockThreshold})))
+	if len(r.LowStock) == 0 {
+		b.WriteString(escape(l.T(msgNone)) + "\n")
+	}
+	for _, item := range r.LowStock {
+		b.WriteString(escape(l.T(msgLowStockItem, i18n.Args{
+			"Name":      item.Name,
+			"SKU":       item.SKU,
+			"Available": item.Available,
+		})) + "\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatRange shows the dates covered, the end is exclusive so it is moved back a day when
+// the period ends at midnight
+func formatRange(r *reports.SalesReport) string {
+	from := r.From.In(reports.Location)
+	to := r.To.In(reports.Location)
+	if to.Equal(time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, reports.Location)) {
+		to = to.AddDate(0, 0, -1)
+	}
+
+	if from.Format(dateLayout) == to.Format(dateLayout) {
+		return from.Format(dateLayout)
+	}
+	return from.Format(dateLayout) + " - " + to.Format(dateLayout)
+}
+
+func formatMoney(amount float64) string {
+	return "TWD " + strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+func escape(text string) string {
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, text)
+}
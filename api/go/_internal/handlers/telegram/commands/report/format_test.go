@@ -0,0 +1,58 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/reports"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatReportEscapesMarkdownV2(t *testing.T) {
+	r := &reports.SalesReport{
+		Period:     reports.PeriodToday,
+		From:       time.Date(2025, 7, 1, 0, 0, 0, 0, reports.Location),
+		To:         time.Date(2025, 7, 1, 15, 30, 0, 0, reports.Location),
+		OrderCount: 2,
+		Units:      3,
+		Revenue:    1299.5,
+		TopProducts: []reports.ProductSales{
+			{SKU: "CAT-TOY_1", Name: "Mouse [v2] (red)!", Units: 3, Revenue: 1299.5},
+		},
+		LowStock: []reports.LowStockItem{
+			{SKU: "DOG.BED-L", Name: "Bed *XL*", Available: 1},
+		},
+	}
+
+	got := FormatReport(i18n.For("en"), r)
+
+	for _, want := range []string{
+		"📊 *Sales report: Today*",
+		"2025/07/01\n",
+		"Paid orders: *2*",
+		"Revenue: *TWD 1299\\.5*",
+		"1\\. Mouse \\[v2\\] \\(red\\)\\! \\(CAT\\-TOY\\_1\\) x3 \\= TWD 1299\\.5",
+		"*Low stock \\(≤ 5\\)*",
+		"• Bed \\*XL\\* \\(DOG\\.BED\\-L\\) 1 available",
+	} {
+		assert.Contains(t, got, want)
+	}
+}
+
+func TestFormatReportEmpty(t *testing.T) {
+	r := &reports.SalesReport{
+		Period: reports.PeriodYesterday,
+		From:   time.Date(2025, 6, 30, 0, 0, 0, 0, reports.Location),
+		To:     time.Date(2025, 7, 1, 0, 0, 0, 0, reports.Location),
+	}
+
+	got := FormatReport(i18n.For("zh-TW"), r)
+
+	// The range ends at midnight, so only the day itself is shown.
+	for _, want := range []string{"📊 *昨日銷售報表*", "2025/06/30\n", "尚無銷售", "無"} {
+		assert.Contains(t, got, want)
+	}
+	assert.NotContains(t, got, "2025/07/01", "shows the exclusive end day")
+}
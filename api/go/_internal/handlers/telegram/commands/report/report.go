@@ -0,0 +1,82 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/reports"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ReportCommand sends the sales summary of a period
+type ReportCommand struct {
+	reportDAO *reports.ReportDAO
+	botAPI    *tgbotapi.BotAPI
+	locales   *commands.LocaleResolver
+	logger    *zap.SugaredLogger
+}
+
+type ReportCommandParams struct {
+	fx.In
+
+	ReportDAO      *reports.ReportDAO
+	BotAPI         *tgbotapi.BotAPI
+	LocaleResolver *commands.LocaleResolver
+	Logger         *zap.SugaredLogger
+}
+
+func NewReportCommand(p ReportCommandParams) *ReportCommand {
+	return &ReportCommand{
+		reportDAO: p.ReportDAO,
+		botAPI:    p.BotAPI,
+		locales:   p.LocaleResolver,
+		logger:    p.Logger,
+	}
+}
+
+// Handle reports paid orders, revenue, units per product and low stock for today, yesterday,
+// this week or this month
+func (c *ReportCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	period, ok := reports.ParsePeriod(strings.ToLower(strings.TrimSpace(msg.CommandArguments())))
+	if !ok {
+		_, err := c.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, loc.T(msgUsage)))
+		return err
+	}
+
+	return Send(ctx, c.botAPI, c.reportDAO, loc, msg.Chat.ID, period)
+}
+
+// HandleReply is a no-op, /report doesn't prompt for input
+func (c *ReportCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	return nil
+}
+
+func (c *ReportCommand) Command() commands.BotCommand {
+	return commands.Report
+}
+
+// Send builds the report of the period and sends it to chatID in the locale. It is shared by
+// /report and the scheduled daily report.
+func Send(ctx context.Context, botAPI *tgbotapi.BotAPI, reportDAO *reports.ReportDAO, loc i18n.Localizer, chatID int64, period reports.Period) error {
+	report, err := reportDAO.GetSalesReport(ctx, period, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get sales report: %w", err)
+	}
+
+	message := tgbotapi.NewMessage(chatID, FormatReport(loc, report))
+	message.ParseMode = tgbotapi.ModeMarkdownV2
+	_, err = botAPI.Send(message)
+	return err
+}
+
+var _ commands.CommandHandler = (*ReportCommand)(nil)
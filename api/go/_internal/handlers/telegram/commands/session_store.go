@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionConflict = errors.New("session was modified concurrently")
+)
+
+// DefaultSessionTTL is used by commands that don't need a shorter or longer session
+const DefaultSessionTTL = 24 * time.Hour
+
+// Session is a user session with its state decoded into T
+type Session[T any] struct {
+	ID                     int64
+	ChatID                 int64
+	UserID                 int64
+	Command                BotCommand
+	State                  T
+	Version                int32
+	ExpectedReplyMessageID int64
+	ExpiresAt              time.Time
+}
+
+// SessionStore persists the state of one command in user_sessions. Every command gets its own
+// store with its own TTL; writes are guarded by the session version so two concurrent updates
+// (e.g. a double tapped button) can't silently overwrite each other.
+type SessionStore[T any] struct {
+	dao     Repository
+	command BotCommand
+	ttl     time.Duration
+}
+
+func NewSessionStore[T any](dao Repository, command BotCommand, ttl time.Duration) *SessionStore[T] {
+	return &SessionStore[T]{
+		dao:     dao,
+		command: command,
+		ttl:     ttl,
+	}
+}
+
+// Get returns the user's active session for the command or ErrSessionNotFound
+func (s *SessionStore[T]) Get(ctx context.Context, userID int64) (*Session[T], error) {
+	row, err := s.dao.GetUserSession(ctx, userID, s.command.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s session: %w", s.command, err)
+	}
+
+	return decodeSession[T](row)
+}
+
+// Start creates a new session, replacing any previous session of the same command
+func (s *SessionStore[T]) Start(ctx context.Context, chatID, userID int64, state T) (*Session[T], error) {
+	row, err := s.dao.UpsertUserSession(ctx, chatID, userID, s.command.String(), state, s.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSession[T](row)
+}
+
+// Save writes session.State back, failing with ErrSessionConflict when the session changed since
+// it was read. On success session.Version and ExpiresAt are refreshed.
+func (s *SessionStore[T]) Save(ctx context.Context, session *Session[T]) error {
+	row, err := s.dao.UpdateUserSessionState(ctx, session.ID, session.Version, session.State, s.ttl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save %s session: %w", s.command, err)
+	}
+
+	session.Version = row.Version
+	session.ExpiresAt = row.ExpiresAt.Time
+	return nil
+}
+
+// ExpectReply records the prompt message the user is expected to reply to
+func (s *SessionStore[T]) ExpectReply(ctx context.Context, chatID, userID int64, messageID int) error {
+	return s.dao.UpdateExpectedReplyMessageID(ctx, chatID, userID, s.command.String(), messageID)
+}
+
+func (s *SessionStore[T]) Delete(ctx context.Context, userID int64) error {
+	return s.dao.DeleteUserSession(ctx, userID, s.command.String())
+}
+
+func decodeSession[T any](row *db.UserSession) (*Session[T], error) {
+	session := &Session[T]{
+		ID:                     row.ID,
+		ChatID:                 row.ChatID,
+		UserID:                 row.UserID,
+		Command:                BotCommand(row.SessionType),
+		Version:                row.Version,
+		ExpectedReplyMessageID: row.ExpectedReplyMessageID.Int64,
+		ExpiresAt:              row.ExpiresAt.Time,
+	}
+
+	if len(row.State) > 0 {
+		if err := json.Unmarshal(row.State, &session.State); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s session state: %w", row.SessionType, err)
+		}
+	}
+
+	return session, nil
+}
@@ -0,0 +1,165 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+// StockDAO handles stock lookups and adjustments for products and variants
+type StockDAO struct {
+	db *sqlx.DB
+}
+
+type StockDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewStockDAO(p StockDAOParams) *StockDAO {
+	return &StockDAO{db: p.DB}
+}
+
+// GetStockTarget resolves a SKU against products first, then product variants
+func (dao *StockDAO) GetStockTarget(ctx context.Context, sku string) (*StockTarget, error) {
+	query := `
+		SELECT
+			'product' AS entity_type,
+			p.id AS entity_id,
+			p.id AS product_id,
+			p.sku,
+			p.name,
+			p.stock_count,
+			p.reserved_count,
+			(
+				SELECT COUNT(*)
+				FROM product_variants pv
+				WHERE pv.product_id = p.id
+			) AS variant_count
+		FROM products p
+		WHERE p.sku = $1
+
+		UNION ALL
+
+		SELECT
+			'product_variant' AS entity_type,
+			pv.id AS entity_id,
+			pv.product_id,
+			pv.sku,
+			p.name || ' - ' || pv.name AS name,
+			pv.stock_count,
+			pv.reserved_count,
+			0 AS variant_count
+		FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id
+		WHERE pv.sku = $1
+
+		LIMIT 1
+	`
+
+	var target StockTarget
+	if err := dao.db.GetContext(ctx, &target, query, sku); err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
+
+// AdjustStock applies the adjustment under a row lock, keeps the parent product stock in sync
+// with its variants and records the change in inventory_adjustments.
+func (dao *StockDAO) AdjustStock(ctx context.Context, p AdjustStockParams) (*AdjustStockResult, error) {
+	table, err := stockTable(p.EntityType)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var current struct {
+			StockCount    int32 `json:"stock_count"`
+			ReservedCount int32 `json:"reserved_count"`
+		}
+
+		lockQuery := fmt.Sprintf(`SELECT stock_count, reserved_count FROM %s WHERE id = $1 FOR UPDATE`, table)
+		if err := tx.GetContext(ctx, &current, lockQuery, p.EntityID); err != nil {
+			return nil, fmt.Errorf("failed to lock %s %d: %w", table, p.EntityID, err)
+		}
+
+		newStock := p.Adjustment.Apply(current.StockCount)
+		if newStock < 0 || newStock < current.ReservedCount {
+			return nil, &StockBelowReservedError{
+				NewStock:      newStock,
+				ReservedCount: current.ReservedCount,
+			}
+		}
+
+		updateQuery := fmt.Sprintf(`UPDATE %s SET stock_count = $1, updated_at = NOW() WHERE id = $2`, table)
+		if _, err := tx.ExecContext(ctx, updateQuery, newStock, p.EntityID); err != nil {
+			return nil, fmt.Errorf("failed to update stock: %w", err)
+		}
+
+		if p.EntityType == db.EntityTypeProductVariant {
+			if err := catalog.SyncParentStockTx(ctx, tx, p.ProductID); err != nil {
+				return nil, err
+			}
+		}
+
+		logQuery := `
+			INSERT INTO inventory_adjustments (
+				entity_type,
+				entity_id,
+				sku,
+				adjustment_type,
+				quantity,
+				previous_stock,
+				new_stock,
+				source,
+				actor_telegram_id,
+				actor_username
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, 'telegram', $8, NULLIF($9, ''))
+		`
+		if _, err := tx.ExecContext(
+			ctx,
+			logQuery,
+			p.EntityType,
+			p.EntityID,
+			p.SKU,
+			p.Adjustment.Type,
+			p.Adjustment.Quantity,
+			current.StockCount,
+			newStock,
+			p.ActorTelegramID,
+			p.ActorUsername,
+		); err != nil {
+			return nil, fmt.Errorf("failed to record inventory adjustment: %w", err)
+		}
+
+		return &AdjustStockResult{
+			PreviousStock: current.StockCount,
+			NewStock:      newStock,
+			ReservedCount: current.ReservedCount,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*AdjustStockResult), nil
+}
+
+func stockTable(entityType db.EntityType) (string, error) {
+	switch entityType {
+	case db.EntityTypeProduct:
+		return "products", nil
+	case db.EntityTypeProductVariant:
+		return "product_variants", nil
+	default:
+		return "", fmt.Errorf("unsupported entity type: %s", entityType)
+	}
+}
@@ -0,0 +1,84 @@
+package stock
+
+import (
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+type AdjustmentType string
+
+const (
+	AdjustmentTypeSet       AdjustmentType = "set"
+	AdjustmentTypeIncrement AdjustmentType = "increment"
+)
+
+// StockTarget is the product or product variant resolved from a SKU
+type StockTarget struct {
+	EntityType    db.EntityType `json:"entity_type"`
+	EntityID      int64         `json:"entity_id"`
+	ProductID     int64         `json:"product_id"`
+	SKU           string        `json:"sku"`
+	Name          string        `json:"name"`
+	StockCount    int32         `json:"stock_count"`
+	ReservedCount int32         `json:"reserved_count"`
+	VariantCount  int64         `json:"variant_count"`
+}
+
+// AvailableCount is the stock that can still be sold
+func (t *StockTarget) AvailableCount() int32 {
+	return t.StockCount - t.ReservedCount
+}
+
+// HasVariants reports whether the target is a parent product whose stock is derived from its variants
+func (t *StockTarget) HasVariants() bool {
+	return t.EntityType == db.EntityTypeProduct && t.VariantCount > 0
+}
+
+// StockSessionState is persisted in user_sessions while waiting for the adjustment reply
+type StockSessionState struct {
+	EntityType db.EntityType `json:"entity_type"`
+	EntityID   int64         `json:"entity_id"`
+	ProductID  int64         `json:"product_id"`
+	SKU        string        `json:"sku"`
+	Name       string        `json:"name"`
+}
+
+type Adjustment struct {
+	Type     AdjustmentType
+	Quantity int32
+}
+
+// Apply returns the stock count after applying the adjustment to current
+func (a Adjustment) Apply(current int32) int32 {
+	if a.Type == AdjustmentTypeSet {
+		return a.Quantity
+	}
+	return current + a.Quantity
+}
+
+type AdjustStockParams struct {
+	EntityType      db.EntityType
+	EntityID        int64
+	ProductID       int64
+	SKU             string
+	Adjustment      Adjustment
+	ActorTelegramID int64
+	ActorUsername   string
+}
+
+type AdjustStockResult struct {
+	PreviousStock int32
+	NewStock      int32
+	ReservedCount int32
+}
+
+// StockBelowReservedError is returned when an adjustment would leave less stock than is already reserved
+type StockBelowReservedError struct {
+	NewStock      int32
+	ReservedCount int32
+}
+
+func (e *StockBelowReservedError) Error() string {
+	return fmt.Sprintf("new stock %d is below reserved count %d", e.NewStock, e.ReservedCount)
+}
@@ -0,0 +1,222 @@
+package stock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// sessionTTL is short, a pending stock adjustment is only meaningful right after /stock
+const sessionTTL = 30 * time.Minute
+
+// Message IDs, see pkg/i18n/locales
+const (
+	msgUsage             = "stock.usage"
+	msgNotFound          = "stock.not_found"
+	msgHasVariants       = "stock.has_variants"
+	msgNoActiveSession   = "stock.no_active_session"
+	msgStockSummary      = "stock.summary"
+	msgInvalidAdjustment = "stock.invalid_adjustment"
+	msgBelowReserved     = "stock.below_reserved"
+	msgStockUpdated      = "stock.updated"
+)
+
+type StockCommand struct {
+	sessions *commands.SessionStore[StockSessionState]
+	stockDAO *StockDAO
+	botAPI   *tgbotapi.BotAPI
+	locales  *commands.LocaleResolver
+	logger   *zap.SugaredLogger
+}
+
+type StockCommandParams struct {
+	fx.In
+
+	CommandDAO     commands.Repository
+	StockDAO       *StockDAO
+	BotAPI         *tgbotapi.BotAPI
+	LocaleResolver *commands.LocaleResolver
+	Logger         *zap.SugaredLogger
+}
+
+func NewStockCommand(p StockCommandParams) *StockCommand {
+	return &StockCommand{
+		sessions: commands.NewSessionStore[StockSessionState](p.CommandDAO, commands.Stock, sessionTTL),
+		stockDAO: p.StockDAO,
+		botAPI:   p.BotAPI,
+		locales:  p.LocaleResolver,
+		logger:   p.Logger,
+	}
+}
+
+// Handle shows the current stock of the given SKU and asks for an adjustment via force reply
+func (c *StockCommand) Handle(msg *tgbotapi.Message) error {
+	ctx := context.Background()
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	sku := strings.TrimSpace(msg.CommandArguments())
+	if sku == "" {
+		return c.sendText(msg.Chat.ID, loc.T(msgUsage))
+	}
+
+	target, err := c.stockDAO.GetStockTarget(ctx, sku)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.sendText(msg.Chat.ID, loc.T(msgNotFound, i18n.Args{"SKU": sku}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get stock target: %w", err)
+	}
+
+	if target.HasVariants() {
+		return c.sendText(msg.Chat.ID, loc.T(msgHasVariants, i18n.Args{"Name": target.Name}))
+	}
+
+	state := StockSessionState{
+		EntityType: target.EntityType,
+		EntityID:   target.EntityID,
+		ProductID:  target.ProductID,
+		SKU:        target.SKU,
+		Name:       target.Name,
+	}
+
+	if _, err := c.sessions.Start(ctx, msg.Chat.ID, msg.From.ID, state); err != nil {
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+
+	summary := loc.T(msgStockSummary, i18n.Args{
+		"Name":      target.Name,
+		"SKU":       target.SKU,
+		"Stock":     target.StockCount,
+		"Reserved":  target.ReservedCount,
+		"Available": target.AvailableCount(),
+	})
+
+	return c.prompt(ctx, msg, summary)
+}
+
+// HandleReply applies the adjustment replied by the user and logs who made it
+func (c *StockCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	loc := c.locales.Resolve(ctx, msg.From)
+
+	session, err := c.sessions.Get(ctx, msg.From.ID)
+	if errors.Is(err, commands.ErrSessionNotFound) {
+		return c.sendText(msg.Chat.ID, loc.T(msgNoActiveSession))
+	}
+	if err != nil {
+		return err
+	}
+	state := session.State
+
+	adjustment, err := ParseAdjustment(msg.Text)
+	if err != nil {
+		return c.prompt(ctx, msg, loc.T(msgInvalidAdjustment))
+	}
+
+	res, err := c.stockDAO.AdjustStock(ctx, AdjustStockParams{
+		EntityType:      state.EntityType,
+		EntityID:        state.EntityID,
+		ProductID:       state.ProductID,
+		SKU:             state.SKU,
+		Adjustment:      adjustment,
+		ActorTelegramID: msg.From.ID,
+		ActorUsername:   msg.From.UserName,
+	})
+
+	var belowReservedErr *StockBelowReservedError
+	if errors.As(err, &belowReservedErr) {
+		return c.prompt(ctx, msg, loc.T(msgBelowReserved, i18n.Args{
+			"Stock":    belowReservedErr.NewStock,
+			"Reserved": belowReservedErr.ReservedCount,
+		}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	if err := c.sessions.Delete(ctx, msg.From.ID); err != nil {
+		c.logger.Errorw("failed to delete stock session", "user_id", msg.From.ID, "error", err)
+	}
+
+	c.logger.Infow(
+		"stock adjusted",
+		"sku", state.SKU,
+		"previous_stock", res.PreviousStock,
+		"new_stock", res.NewStock,
+		"actor_telegram_id", msg.From.ID,
+	)
+
+	return c.sendText(msg.Chat.ID, loc.T(msgStockUpdated, i18n.Args{
+		"Name":     state.Name,
+		"SKU":      state.SKU,
+		"Previous": res.PreviousStock,
+		"Stock":    res.NewStock,
+	}))
+}
+
+// prompt sends text as a force reply and records it as the message the session expects a reply to
+func (c *StockCommand) prompt(ctx context.Context, msg *tgbotapi.Message, text string) error {
+	message := tgbotapi.NewMessage(msg.Chat.ID, text)
+	message.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply: true,
+		Selective:  true,
+	}
+
+	sent, err := c.botAPI.Send(message)
+	if err != nil {
+		return fmt.Errorf("failed to send prompt: %w", err)
+	}
+
+	return c.sessions.ExpectReply(ctx, msg.Chat.ID, msg.From.ID, sent.MessageID)
+}
+
+func (c *StockCommand) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func (c *StockCommand) Command() commands.BotCommand {
+	return commands.Stock
+}
+
+// ParseAdjustment parses "=20" (set), "+5" / "-3" (increment) or a bare number (set)
+func ParseAdjustment(input string) (Adjustment, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return Adjustment{}, fmt.Errorf("empty adjustment")
+	}
+
+	adjustmentType := AdjustmentTypeSet
+	switch input[0] {
+	case '=':
+		input = input[1:]
+	case '+', '-':
+		adjustmentType = AdjustmentTypeIncrement
+	}
+
+	quantity, err := strconv.ParseInt(strings.TrimSpace(input), 10, 32)
+	if err != nil {
+		return Adjustment{}, fmt.Errorf("invalid adjustment quantity: %w", err)
+	}
+
+	if adjustmentType == AdjustmentTypeSet && quantity < 0 {
+		return Adjustment{}, fmt.Errorf("stock cannot be set to a negative number")
+	}
+
+	return Adjustment{
+		Type:     adjustmentType,
+		Quantity: int32(quantity),
+	}, nil
+}
+
+var _ commands.CommandHandler = (*StockCommand)(nil)
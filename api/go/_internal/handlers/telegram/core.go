@@ -1,13 +1,22 @@
 package telegram
 
 import (
-	"github/huangc28/kikichoice-be/api/go/_internal/configs"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// NewBotAPI connects to the Bot API at TELEGRAM_API_BASE_URL, or to Telegram when it's unset.
+// Pointing it elsewhere lets the bot run against a local Bot API server or a fake one in tests.
 func NewBotAPI(cfg *configs.Config) (*tgbotapi.BotAPI, error) {
-	bot, err := tgbotapi.NewBotAPI(cfg.Telegram.BotToken)
+	endpoint := tgbotapi.APIEndpoint
+	if baseURL := strings.TrimRight(cfg.Telegram.APIBaseURL, "/"); baseURL != "" {
+		endpoint = baseURL + "/bot%s/%s"
+	}
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.Telegram.BotToken, endpoint)
 	if err != nil {
 		return nil, err
 	}
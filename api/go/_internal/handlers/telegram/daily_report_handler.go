@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/report"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/reports"
+
+	"github.com/go-chi/chi/v5"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DailyReportHandler is hit by Vercel Cron every morning to push yesterday's sales report to
+// TELEGRAM_REPORT_CHAT_ID. The push is off while that chat isn't configured.
+type DailyReportHandler struct {
+	config    *configs.Config
+	botAPI    *tgbotapi.BotAPI
+	reportDAO *reports.ReportDAO
+	logger    *zap.SugaredLogger
+}
+
+type DailyReportHandlerParams struct {
+	fx.In
+
+	Config    *configs.Config
+	BotAPI    *tgbotapi.BotAPI
+	ReportDAO *reports.ReportDAO
+	Logger    *zap.SugaredLogger
+}
+
+func NewDailyReportHandler(p DailyReportHandlerParams) *DailyReportHandler {
+	return &DailyReportHandler{
+		config:    p.Config,
+		botAPI:    p.BotAPI,
+		reportDAO: p.ReportDAO,
+		logger:    p.Logger,
+	}
+}
+
+func (h *DailyReportHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.CronSecret(h.config)).Get("/v1/cron/daily-report", h.Handle)
+}
+
+func (h *DailyReportHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	chatID := h.config.Telegram.ReportChatID
+	if chatID == 0 {
+		render.ChiJSON(w, r, map[string]bool{"sent": false})
+		return
+	}
+
+	if err := report.Send(r.Context(), h.botAPI, h.reportDAO, i18n.For(h.config.Telegram.ChatLocale), chatID, reports.PeriodYesterday); err != nil {
+		h.logger.Errorw("Failed to send daily report", "error", err)
+		render.ChiErr(w, r, err, FailedToSendReport,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	h.logger.Infow("Sent daily report", "chat_id", chatID)
+	render.ChiJSON(w, r, map[string]bool{"sent": true})
+}
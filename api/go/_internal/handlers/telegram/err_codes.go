@@ -7,4 +7,10 @@ const (
 	FailedToCreateBot      = "FAILED_TO_CREATE_BOT"
 	InvalidProductData     = "INVALID_PRODUCT_DATA"
 	FailedToProcessReply   = "FAILED_TO_PROCESS_REPLY"
+	InvalidSecretToken     = "INVALID_SECRET_TOKEN"
+
+	FailedToProcessCallbackQuery  = "FAILED_TO_PROCESS_CALLBACK_QUERY"
+	FailedToDispatchNotifications = "FAILED_TO_DISPATCH_NOTIFICATIONS"
+	FailedToCleanupSessions       = "FAILED_TO_CLEANUP_SESSIONS"
+	FailedToSendReport            = "FAILED_TO_SEND_REPORT"
 )
@@ -0,0 +1,82 @@
+package telegramfx
+
+import (
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog/importer"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	add_product "github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/add_product"
+	bulk_import "github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/bulk_import"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/edit"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/lang"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/order"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/report"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/stock"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/reports"
+
+	"go.uber.org/fx"
+)
+
+// CoreBotOptions provides the bot and its command handlers. The webhook entry and the
+// long-polling command both build on it, so they process updates with the same graph.
+var CoreBotOptions = fx.Options(
+	fx.Provide(
+		fx.Annotate(
+			commands.NewCommandDAO,
+			fx.As(new(commands.Repository)),
+		),
+		commands.NewLocaleResolver,
+		fx.Annotate(
+			add_product.NewProductDAO,
+			fx.As(new(add_product.ProductRepository)),
+		),
+		stock.NewStockDAO,
+		catalog.NewProductDAO,
+		importer.NewImportDAO,
+		orders.NewOrderDAO,
+		reports.NewReportDAO,
+		telegram.NewBotAPI,
+		telegram.NewReplyProcessor,
+		telegram.NewWizardController,
+		telegram.NewOrderNotifier,
+	),
+
+	AddProductStateOptions,
+
+	fx.Provide(
+		commands.AsCommandHandler(add_product.NewAddProductCommand),
+		commands.AsCommandHandler(stock.NewStockCommand),
+		commands.AsCommandHandler(edit.NewEditCommand),
+		commands.AsCommandHandler(order.NewOrdersCommand),
+		commands.AsCommandHandler(order.NewOrderCommand),
+		commands.AsCommandHandler(report.NewReportCommand),
+		commands.AsCommandHandler(lang.NewLangCommand),
+		commands.AsCommandHandler(bulk_import.NewImportCommand),
+
+		fx.Annotate(
+			commands.NewCommandHandlerMap,
+			fx.ParamTags(`group:"command_handlers"`),
+		),
+	),
+)
+
+// AddProductStateOptions registers the steps of the /add wizard
+var AddProductStateOptions = fx.Provide(
+	add_product.AsAddProductState(add_product.NewAddProductStateInit),
+	add_product.AsAddProductState(add_product.NewAddProductStateSKU),
+	add_product.AsAddProductState(add_product.NewAddProductStateName),
+	add_product.AsAddProductState(add_product.NewAddProductStateCategory),
+	add_product.AsAddProductState(add_product.NewAddProductStatePrice),
+	add_product.AsAddProductState(add_product.NewAddProductStateVariants),
+	add_product.AsAddProductState(add_product.NewAddProductStateStock),
+	add_product.AsAddProductState(add_product.NewAddProductStateDescription),
+	add_product.AsAddProductState(add_product.NewAddProductStateSpecs),
+	add_product.AsAddProductState(add_product.NewAddProductStateImages),
+	add_product.AsAddProductState(add_product.NewAddProductStateConfirm),
+
+	fx.Annotate(
+		add_product.NewAddProductStateMap,
+		fx.ParamTags(`group:"add_product_states"`),
+	),
+)
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// OrderNotificationsHandler is hit by Vercel Cron to flush the order notification outbox
+type OrderNotificationsHandler struct {
+	config   *configs.Config
+	notifier *OrderNotifier
+	logger   *zap.SugaredLogger
+}
+
+type OrderNotificationsHandlerParams struct {
+	fx.In
+
+	Config   *configs.Config
+	Notifier *OrderNotifier
+	Logger   *zap.SugaredLogger
+}
+
+func NewOrderNotificationsHandler(p OrderNotificationsHandlerParams) *OrderNotificationsHandler {
+	return &OrderNotificationsHandler{
+		config:   p.Config,
+		notifier: p.Notifier,
+		logger:   p.Logger,
+	}
+}
+
+func (h *OrderNotificationsHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.CronSecret(h.config)).Get("/v1/cron/order-notifications", h.Handle)
+}
+
+func (h *OrderNotificationsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	result, err := h.notifier.Dispatch(r.Context())
+	if err != nil {
+		h.logger.Errorw("Failed to dispatch order notifications", "error", err)
+		render.ChiErr(w, r, err, FailedToDispatchNotifications,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	h.logger.Infow("Dispatched order notifications", "sent", result.Sent, "failed", result.Failed)
+	render.ChiJSON(w, r, result)
+}
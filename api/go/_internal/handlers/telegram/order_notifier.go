@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands/order"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// msgNewPaidOrder is the message ID of the notification, see pkg/i18n/locales
+const msgNewPaidOrder = "order.new_paid"
+
+const (
+	notificationBatchSize   = 20
+	notificationLease       = 2 * time.Minute
+	notificationMaxAttempts = 10
+	notificationMaxBackoff  = time.Hour
+)
+
+// OrderNotifier pushes queued order notifications to the staff chat. Rows are enqueued by a
+// database trigger when an order becomes paid; failed sends are retried with exponential backoff.
+type OrderNotifier struct {
+	config   *configs.Config
+	botAPI   *tgbotapi.BotAPI
+	orderDAO *orders.OrderDAO
+	logger   *zap.SugaredLogger
+}
+
+type OrderNotifierParams struct {
+	fx.In
+
+	Config   *configs.Config
+	BotAPI   *tgbotapi.BotAPI
+	OrderDAO *orders.OrderDAO
+	Logger   *zap.SugaredLogger
+}
+
+func NewOrderNotifier(p OrderNotifierParams) *OrderNotifier {
+	return &OrderNotifier{
+		config:   p.Config,
+		botAPI:   p.BotAPI,
+		orderDAO: p.OrderDAO,
+		logger:   p.Logger,
+	}
+}
+
+type DispatchResult struct {
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+}
+
+// Dispatch sends every notification that is due
+func (n *OrderNotifier) Dispatch(ctx context.Context) (*DispatchResult, error) {
+	if n.config.Telegram.StaffChatID == 0 {
+		return nil, fmt.Errorf("TELEGRAM_STAFF_CHAT_ID is not configured")
+	}
+
+	notifications, err := n.orderDAO.ClaimDueNotifications(ctx, notificationBatchSize, notificationLease)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DispatchResult{}
+	for _, notification := range notifications {
+		if err := n.send(ctx, notification.OrderID); err != nil {
+			result.Failed++
+
+			var nextAttemptAt *time.Time
+			if notification.Attempts < notificationMaxAttempts {
+				next := time.Now().Add(backoff(int(notification.Attempts)))
+				nextAttemptAt = &next
+			}
+
+			n.logger.Errorw(
+				"failed to send order notification",
+				"notification_id", notification.ID,
+				"order_id", notification.OrderID,
+				"attempts", notification.Attempts,
+				"will_retry", nextAttemptAt != nil,
+				"error", err,
+			)
+
+			if err := n.orderDAO.MarkNotificationFailed(ctx, notification.ID, err.Error(), nextAttemptAt); err != nil {
+				return result, fmt.Errorf("failed to mark notification %d as failed: %w", notification.ID, err)
+			}
+			continue
+		}
+
+		result.Sent++
+		if err := n.orderDAO.MarkNotificationSent(ctx, notification.ID); err != nil {
+			return result, fmt.Errorf("failed to mark notification %d as sent: %w", notification.ID, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (n *OrderNotifier) send(ctx context.Context, orderID int64) error {
+	detail, err := n.orderDAO.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	loc := i18n.For(n.config.Telegram.ChatLocale)
+	message := tgbotapi.NewMessage(
+		n.config.Telegram.StaffChatID,
+		loc.T(msgNewPaidOrder, i18n.Args{"Detail": order.FormatOrderDetail(loc, detail)}),
+	)
+	if keyboard := order.ActionKeyboard(loc, &detail.Order); keyboard != nil {
+		message.ReplyMarkup = keyboard
+	}
+
+	_, err = n.botAPI.Send(message)
+	return err
+}
+
+// backoff returns 1m, 2m, 4m ... capped at notificationMaxBackoff for the given attempt number
+func backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	d := time.Minute << (attempts - 1)
+	if d <= 0 || d > notificationMaxBackoff {
+		return notificationMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	pollTimeoutSeconds = 30
+	pollMaxBackoff     = 30 * time.Second
+)
+
+// Poller feeds updates fetched with getUpdates long polling through TelegramHandler, for
+// developing the bot locally without a public webhook url. Telegram refuses getUpdates while
+// a webhook is set, so it has to be deleted first.
+type Poller struct {
+	botAPI  *tgbotapi.BotAPI
+	handler *TelegramHandler
+	logger  *zap.SugaredLogger
+}
+
+type PollerParams struct {
+	fx.In
+
+	BotAPI  *tgbotapi.BotAPI
+	Handler *TelegramHandler
+	Logger  *zap.SugaredLogger
+}
+
+func NewPoller(p PollerParams) *Poller {
+	return &Poller{
+		botAPI:  p.BotAPI,
+		handler: p.Handler,
+		logger:  p.Logger,
+	}
+}
+
+// DeleteWebhook removes the webhook so getUpdates can be used
+func (p *Poller) DeleteWebhook(dropPendingUpdates bool) error {
+	if _, err := p.botAPI.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: dropPendingUpdates}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// Run polls for updates until ctx is done. Updates are processed one at a time, in order, and
+// only acknowledged once processed; like the webhook, an update that fails is logged and
+// not retried.
+func (p *Poller) Run(ctx context.Context) error {
+	p.logger.Infow("Polling telegram updates", "bot", p.botAPI.Self.UserName)
+
+	config := tgbotapi.NewUpdate(0)
+	config.Timeout = pollTimeoutSeconds
+	config.AllowedUpdates = []string{"message", "callback_query"}
+
+	failures := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+
+		updates, err := p.botAPI.GetUpdates(config)
+		if err != nil {
+			failures++
+			wait := pollBackoff(failures)
+			p.logger.Errorw("Failed to get updates", "error", err, "retry_in", wait)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+			continue
+		}
+		failures = 0
+
+		for i := range updates {
+			update := &updates[i]
+			if err := p.handler.ProcessUpdate(ctx, update); err != nil {
+				p.logger.Errorw("Failed to process update", "update_id", update.UpdateID, "error", err)
+			}
+			config.Offset = update.UpdateID + 1
+		}
+	}
+}
+
+// pollBackoff returns 1s, 2s, 4s ... capped at pollMaxBackoff for the given failure count
+func pollBackoff(failures int) time.Duration {
+	d := time.Second << (failures - 1)
+	if d <= 0 || d > pollMaxBackoff {
+		return pollMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/telegramtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// echoCommand answers "/echo <text>" with <text>
+type echoCommand struct {
+	botAPI *tgbotapi.BotAPI
+}
+
+func (c *echoCommand) Handle(msg *tgbotapi.Message) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(msg.Chat.ID, msg.CommandArguments()))
+	return err
+}
+
+func (c *echoCommand) HandleReply(ctx context.Context, msg *tgbotapi.Message) error {
+	return nil
+}
+
+func (c *echoCommand) Command() commands.BotCommand {
+	return "echo"
+}
+
+type PollerTestSuite struct {
+	suite.Suite
+	bot    *telegramtest.Server
+	poller *Poller
+}
+
+func (s *PollerTestSuite) SetupTest() {
+	s.bot = telegramtest.NewServer()
+
+	cfg := &configs.Config{}
+	cfg.Telegram.BotToken = telegramtest.Token
+	cfg.Telegram.APIBaseURL = s.bot.URL
+	cfg.Telegram.StaffUserIDs = []int64{42}
+
+	botAPI, err := NewBotAPI(cfg)
+	require.NoError(s.T(), err, "NewBotAPI should reach the fake Bot API")
+
+	logger := zap.NewNop().Sugar()
+	echo := &echoCommand{botAPI: botAPI}
+
+	handler := NewTelegramHandler(TelegramHandlerParams{
+		Config:          cfg,
+		BotAPI:          botAPI,
+		CommandHandlers: commands.NewCommandHandlerMap([]commands.CommandHandler{echo}),
+		Logger:          logger,
+	})
+
+	s.poller = NewPoller(PollerParams{
+		BotAPI:  botAPI,
+		Handler: handler,
+		Logger:  logger,
+	})
+}
+
+func (s *PollerTestSuite) TearDownTest() {
+	s.bot.Close()
+}
+
+func (s *PollerTestSuite) TestNewBotAPIUsesBaseURL() {
+	require.Equal(s.T(), telegramtest.BotID, s.poller.botAPI.Self.ID)
+	require.Len(s.T(), s.bot.Calls("getMe"), 1)
+}
+
+func (s *PollerTestSuite) TestRunProcessesUpdatesInOrder() {
+	user := telegramtest.User(42, "zh-TW")
+	s.bot.PushUpdate(telegramtest.Command(user, "/echo first"))
+	s.bot.PushUpdate(telegramtest.Command(user, "/echo second"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.poller.Run(ctx) }()
+
+	// Updates are acknowledged with the offset of the next poll, so none is processed twice.
+	require.Eventually(s.T(), func() bool {
+		polls := s.bot.Calls("getUpdates")
+		return len(polls) > 0 && polls[len(polls)-1].Params.Get("offset") == "3"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(s.T(), <-done)
+
+	sent := s.bot.Sent()
+	require.Len(s.T(), sent, 2)
+	require.Equal(s.T(), "first", sent[0].Text)
+	require.Equal(s.T(), "second", sent[1].Text)
+	require.Equal(s.T(), user.ID, sent[0].Chat.ID)
+}
+
+func (s *PollerTestSuite) TestDeleteWebhook() {
+	require.NoError(s.T(), s.poller.DeleteWebhook(true))
+
+	calls := s.bot.Calls("deleteWebhook")
+	require.Len(s.T(), calls, 1)
+	require.Equal(s.T(), "true", calls[0].Params.Get("drop_pending_updates"))
+}
+
+func TestPollerTestSuite(t *testing.T) {
+	suite.Run(t, new(PollerTestSuite))
+}
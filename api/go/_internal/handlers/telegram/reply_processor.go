@@ -2,48 +2,60 @@ package telegram
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 
-	"github/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 type ReplyProcessor struct {
-	commandDAO      *commands.CommandDAO
+	commandDAO      commands.Repository
 	commandHandlers map[commands.BotCommand]commands.CommandHandler
+	logger          *zap.SugaredLogger
 }
 
 type ReplyProcessorParams struct {
 	fx.In
 
-	CommandDAO      *commands.CommandDAO
+	CommandDAO      commands.Repository
 	CommandHandlers map[commands.BotCommand]commands.CommandHandler
+	Logger          *zap.SugaredLogger
 }
 
 func NewReplyProcessor(p ReplyProcessorParams) *ReplyProcessor {
 	return &ReplyProcessor{
 		commandDAO:      p.CommandDAO,
 		commandHandlers: p.CommandHandlers,
+		logger:          p.Logger,
 	}
 }
 
+// Process routes a reply to the command owning the user's active session. Replies carry no
+// command, so the session is looked up by the prompt being replied to.
 func (r *ReplyProcessor) Process(ctx context.Context, reply *tgbotapi.Message) error {
-	log.Printf("** 0 %v", reply.From.ID)
-	session, err := r.commandDAO.GetUserSession(ctx, reply.From.ID, reply.Command())
+	session, err := r.commandDAO.FindReplySession(
+		ctx,
+		reply.Chat.ID,
+		reply.From.ID,
+		reply.ReplyToMessage.MessageID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.Infow("Ignoring reply without an active session", "user_id", reply.From.ID)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get user session: %w", err)
+		return fmt.Errorf("failed to find user session: %w", err)
 	}
 
-	// Determine what command handler to use.
-	log.Printf("** 1 %v", session.ExpectedReplyMessageID)
-
-	// Is the incoming message a reply to the exepcted reply message?
-	if reply.ReplyToMessage.MessageID == int(session.ExpectedReplyMessageID.Int64) {
-		r.commandHandlers[commands.BotCommand(session.SessionType)].HandleReply(ctx, reply)
+	handler, exists := r.commandHandlers[commands.BotCommand(session.SessionType)]
+	if !exists {
+		return fmt.Errorf("no command handler for session type %s", session.SessionType)
 	}
 
-	return nil
+	return handler.HandleReply(ctx, reply)
 }
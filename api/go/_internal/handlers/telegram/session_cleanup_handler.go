@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// SessionCleanupHandler is hit by Vercel Cron to purge expired rows from user_sessions
+type SessionCleanupHandler struct {
+	config     *configs.Config
+	commandDAO commands.Repository
+	logger     *zap.SugaredLogger
+}
+
+type SessionCleanupHandlerParams struct {
+	fx.In
+
+	Config     *configs.Config
+	CommandDAO commands.Repository
+	Logger     *zap.SugaredLogger
+}
+
+func NewSessionCleanupHandler(p SessionCleanupHandlerParams) *SessionCleanupHandler {
+	return &SessionCleanupHandler{
+		config:     p.Config,
+		commandDAO: p.CommandDAO,
+		logger:     p.Logger,
+	}
+}
+
+func (h *SessionCleanupHandler) RegisterRoutes(r *chi.Mux) {
+	r.With(middlewares.CronSecret(h.config)).Get("/v1/cron/session-cleanup", h.Handle)
+}
+
+func (h *SessionCleanupHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.commandDAO.DeleteExpiredSessions(r.Context())
+	if err != nil {
+		h.logger.Errorw("Failed to delete expired sessions", "error", err)
+		render.ChiErr(w, r, err, FailedToCleanupSessions,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	h.logger.Infow("Deleted expired sessions", "deleted", deleted)
+	render.ChiJSON(w, r, map[string]int64{"deleted": deleted})
+}
@@ -0,0 +1,325 @@
+// Package telegramtest provides an in-process fake of the Telegram Bot API, so the bot can be
+// driven end to end in tests without network access.
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Token is accepted by the fake, any other non-empty token works as well
+	Token = "123456:fake-token"
+
+	BotID       int64 = 1000
+	BotUserName       = "kikichoice_test_bot"
+
+	// maxPollWait caps how long getUpdates waits for an update, whatever timeout was asked for
+	maxPollWait = time.Second
+)
+
+// Call is a Bot API request received by the fake
+type Call struct {
+	Method string
+	Params url.Values
+}
+
+func (c Call) ChatID() int64 {
+	id, _ := strconv.ParseInt(c.Params.Get("chat_id"), 10, 64)
+	return id
+}
+
+func (c Call) Text() string {
+	return c.Params.Get("text")
+}
+
+// ReplyMarkup decodes the keyboard sent with the call, nil when there is none
+func (c Call) ReplyMarkup() map[string]any {
+	raw := c.Params.Get("reply_markup")
+	if raw == "" {
+		return nil
+	}
+
+	var markup map[string]any
+	if err := json.Unmarshal([]byte(raw), &markup); err != nil {
+		return nil
+	}
+	return markup
+}
+
+// InlineButtons lists the callback data of the inline keyboard sent with a call
+func (c Call) InlineButtons() []string {
+	markup := c.ReplyMarkup()
+	rows, _ := markup["inline_keyboard"].([]any)
+
+	data := make([]string, 0)
+	for _, row := range rows {
+		buttons, _ := row.([]any)
+		for _, button := range buttons {
+			b, _ := button.(map[string]any)
+			if d, ok := b["callback_data"].(string); ok {
+				data = append(data, d)
+			}
+		}
+	}
+	return data
+}
+
+// Server is a fake Bot API. It records every call, answers the methods the bot uses with
+// plausible results and hands out scripted updates through getUpdates. Point the bot at it
+// with TELEGRAM_API_BASE_URL set to Server.URL.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	calls         []Call
+	sent          []tgbotapi.Message
+	nextMessageID int
+	updates       []tgbotapi.Update
+	nextUpdateID  int
+	pushed        chan struct{}
+}
+
+func NewServer() *Server {
+	s := &Server{
+		nextMessageID: 1,
+		nextUpdateID:  1,
+		pushed:        make(chan struct{}, 1),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Bot is the user the fake answers getMe with
+func Bot() *tgbotapi.User {
+	return &tgbotapi.User{
+		ID:        BotID,
+		IsBot:     true,
+		FirstName: "KikiChoice",
+		UserName:  BotUserName,
+	}
+}
+
+// Calls returns the recorded calls to the given methods, or every call when none is given
+func (s *Server) Calls(methods ...string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]Call, 0, len(s.calls))
+	for _, call := range s.calls {
+		if len(methods) == 0 || slices.Contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Sent returns the messages the bot sent, in order
+func (s *Server) Sent() []tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]tgbotapi.Message(nil), s.sent...)
+}
+
+// LastSent returns the latest message the bot sent, the zero message when there is none
+func (s *Server) LastSent() tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.sent) == 0 {
+		return tgbotapi.Message{}
+	}
+	return s.sent[len(s.sent)-1]
+}
+
+// Reset forgets the recorded calls and sent messages. Message ids keep increasing.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+	s.sent = nil
+}
+
+// PushUpdate queues an update for getUpdates, assigning its update id
+func (s *Server) PushUpdate(update tgbotapi.Update) {
+	s.mu.Lock()
+	update.UpdateID = s.nextUpdateID
+	s.nextUpdateID++
+	s.updates = append(s.updates, update)
+	s.mu.Unlock()
+
+	select {
+	case s.pushed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	// Requests look like /bot<token>/<method>
+	path, isBotPath := strings.CutPrefix(r.URL.Path, "/bot")
+	token, method, ok := strings.Cut(path, "/")
+	if !isBotPath || !ok || token == "" || method == "" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	params, err := parseParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: params})
+	s.mu.Unlock()
+
+	switch method {
+	case "getMe":
+		writeResult(w, Bot())
+	case "sendMessage", "sendPhoto":
+		writeResult(w, s.send(params))
+	case "editMessageText", "editMessageReplyMarkup", "editMessageCaption":
+		writeResult(w, edited(params))
+	case "answerCallbackQuery", "deleteMessage", "setWebhook", "deleteWebhook", "setMyCommands":
+		writeResult(w, true)
+	case "getFile":
+		fileID := params.Get("file_id")
+		writeResult(w, tgbotapi.File{
+			FileID:       fileID,
+			FileUniqueID: "unique-" + fileID,
+			FileSize:     1024,
+			FilePath:     "photos/" + fileID + ".jpg",
+		})
+	case "getUpdates":
+		writeResult(w, s.poll(r, params))
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+// send records the message the bot sent and returns it the way Telegram would
+func (s *Server) send(params url.Values) tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	msg := tgbotapi.Message{
+		MessageID: s.nextMessageID,
+		From:      Bot(),
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Text:      params.Get("text"),
+		Caption:   params.Get("caption"),
+	}
+	if photo := params.Get("photo"); photo != "" {
+		msg.Photo = []tgbotapi.PhotoSize{{FileID: photo}}
+	}
+	s.nextMessageID++
+
+	s.sent = append(s.sent, msg)
+	return msg
+}
+
+// poll returns the queued updates from offset on, waiting a little for one when none is queued
+func (s *Server) poll(r *http.Request, params url.Values) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+
+	wait := maxPollWait
+	if timeout, err := strconv.Atoi(params.Get("timeout")); err == nil && time.Duration(timeout)*time.Second < wait {
+		wait = time.Duration(timeout) * time.Second
+	}
+	deadline := time.After(wait)
+
+	for {
+		if updates := s.pending(offset); len(updates) > 0 {
+			return updates
+		}
+
+		select {
+		case <-s.pushed:
+		case <-deadline:
+			return []tgbotapi.Update{}
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}
+		}
+	}
+}
+
+func (s *Server) pending(offset int) []tgbotapi.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updates := make([]tgbotapi.Update, 0)
+	for _, update := range s.updates {
+		if update.UpdateID >= offset {
+			updates = append(updates, update)
+		}
+	}
+	return updates
+}
+
+func edited(params url.Values) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(params.Get("message_id"))
+
+	return tgbotapi.Message{
+		MessageID: messageID,
+		From:      Bot(),
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Text:      params.Get("text"),
+	}
+}
+
+func parseParams(r *http.Request) (url.Values, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, err
+		}
+
+		params := url.Values{}
+		for key, values := range r.MultipartForm.Value {
+			params[key] = values
+		}
+		for key, files := range r.MultipartForm.File {
+			for _, file := range files {
+				params.Add(key, file.Filename)
+			}
+		}
+		return params, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return r.Form, nil
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Internal Server Error: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: description})
+}
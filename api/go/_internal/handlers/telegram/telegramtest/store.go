@@ -0,0 +1,253 @@
+package telegramtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Store is an in-memory commands.Repository. It follows the user_sessions queries: one session
+// per user and command, versioned writes and sql.ErrNoRows when nothing matches.
+type Store struct {
+	mu       sync.Mutex
+	sessions []*storedSession
+	locales  map[int64]string
+	nextID   int64
+	seq      int64
+}
+
+// storedSession keeps the write order next to the row, timestamps written in the same
+// instant couldn't tell which session was updated last
+type storedSession struct {
+	row db.UserSession
+	seq int64
+}
+
+var _ commands.Repository = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		locales: make(map[int64]string),
+		nextID:  1,
+	}
+}
+
+// Sessions returns the active sessions of the user
+func (s *Store) Sessions(userID int64) []db.UserSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]db.UserSession, 0)
+	for _, session := range s.sessions {
+		if session.row.UserID == userID && active(session) {
+			sessions = append(sessions, session.row)
+		}
+	}
+	return sessions
+}
+
+func (s *Store) GetUserSession(ctx context.Context, userID int64, sessionType string) (*db.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.find(func(session *storedSession) bool {
+		return session.row.UserID == userID && session.row.SessionType == sessionType && active(session)
+	}, nil)
+}
+
+func (s *Store) FindReplySession(ctx context.Context, chatID, userID int64, replyToMessageID int) (*db.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting := func(session *storedSession) bool {
+		return session.row.ExpectedReplyMessageID.Valid && session.row.ExpectedReplyMessageID.Int64 == int64(replyToMessageID)
+	}
+	return s.find(func(session *storedSession) bool {
+		return session.row.ChatID == chatID && session.row.UserID == userID && active(session)
+	}, waiting)
+}
+
+func (s *Store) GetLatestUserSession(ctx context.Context, chatID, userID int64) (*db.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.find(func(session *storedSession) bool {
+		return session.row.ChatID == chatID && session.row.UserID == userID && active(session)
+	}, nil)
+}
+
+func (s *Store) UpsertUserSession(ctx context.Context, chatID, userID int64, sessionType string, state any, ttl time.Duration) (*db.UserSession, error) {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.lookup(userID, sessionType)
+	if session == nil {
+		session = &storedSession{row: db.UserSession{
+			ID:          s.nextID,
+			UserID:      userID,
+			SessionType: sessionType,
+			CreatedAt:   timestamptz(time.Now()),
+		}}
+		s.nextID++
+		s.sessions = append(s.sessions, session)
+	}
+
+	session.row.ChatID = chatID
+	session.row.State = stateJSON
+	session.row.ExpiresAt = timestamptz(time.Now().Add(ttl))
+	session.row.ExpectedReplyMessageID = pgtype.Int8{}
+	session.row.Version = 1
+	s.touch(session)
+
+	row := session.row
+	return &row, nil
+}
+
+func (s *Store) UpdateUserSessionState(ctx context.Context, id int64, version int32, state any, ttl time.Duration) (*db.UserSession, error) {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.row.ID != id || session.row.Version != version {
+			continue
+		}
+
+		session.row.State = stateJSON
+		session.row.ExpiresAt = timestamptz(time.Now().Add(ttl))
+		session.row.Version++
+		s.touch(session)
+
+		row := session.row
+		return &row, nil
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) UpdateExpectedReplyMessageID(ctx context.Context, chatID, userID int64, sessionType string, expectedReplyMessageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session := s.lookup(userID, sessionType); session != nil && session.row.ChatID == chatID {
+		session.row.ExpectedReplyMessageID = pgtype.Int8{Int64: int64(expectedReplyMessageID), Valid: true}
+		s.touch(session)
+	}
+	return nil
+}
+
+func (s *Store) DeleteUserSession(ctx context.Context, userID int64, sessionType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(func(session *storedSession) bool {
+		return session.row.UserID == userID && session.row.SessionType == sessionType
+	})
+	return nil
+}
+
+func (s *Store) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(func(session *storedSession) bool { return !active(session) }), nil
+}
+
+func (s *Store) GetUserLocale(ctx context.Context, userID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locale, ok := s.locales[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return locale, nil
+}
+
+func (s *Store) SetUserLocale(ctx context.Context, userID int64, locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locales[userID] = locale
+	return nil
+}
+
+// find returns the matching session preferred first, then the most recently written one
+func (s *Store) find(match, preferred func(*storedSession) bool) (*db.UserSession, error) {
+	var found *storedSession
+	for _, session := range s.sessions {
+		if !match(session) {
+			continue
+		}
+		if found == nil || better(session, found, preferred) {
+			found = session
+		}
+	}
+
+	if found == nil {
+		return nil, sql.ErrNoRows
+	}
+	row := found.row
+	return &row, nil
+}
+
+func better(a, b *storedSession, preferred func(*storedSession) bool) bool {
+	if preferred != nil && preferred(a) != preferred(b) {
+		return preferred(a)
+	}
+	return a.seq > b.seq
+}
+
+func (s *Store) lookup(userID int64, sessionType string) *storedSession {
+	for _, session := range s.sessions {
+		if session.row.UserID == userID && session.row.SessionType == sessionType {
+			return session
+		}
+	}
+	return nil
+}
+
+func (s *Store) remove(match func(*storedSession) bool) int64 {
+	kept := s.sessions[:0]
+	var removed int64
+	for _, session := range s.sessions {
+		if match(session) {
+			removed++
+			continue
+		}
+		kept = append(kept, session)
+	}
+	s.sessions = kept
+	return removed
+}
+
+// touch does what the user_sessions updated_at trigger does
+func (s *Store) touch(session *storedSession) {
+	s.seq++
+	session.seq = s.seq
+	session.row.UpdatedAt = timestamptz(time.Now())
+}
+
+func active(session *storedSession) bool {
+	return session.row.ExpiresAt.Time.After(time.Now())
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
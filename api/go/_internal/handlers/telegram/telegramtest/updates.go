@@ -0,0 +1,86 @@
+package telegramtest
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Incoming message ids start far from the ones the fake hands out to the bot, so tests can
+// tell them apart
+var lastIncomingMessageID atomic.Int64
+
+func init() {
+	lastIncomingMessageID.Store(1_000_000)
+}
+
+// User returns a staff member with the given id and Telegram client language
+func User(id int64, languageCode string) *tgbotapi.User {
+	return &tgbotapi.User{
+		ID:           id,
+		FirstName:    "Staff",
+		UserName:     "staff_test",
+		LanguageCode: languageCode,
+	}
+}
+
+// Text is a plain message from the user in their private chat with the bot
+func Text(from *tgbotapi.User, text string) tgbotapi.Update {
+	return tgbotapi.Update{Message: message(from, text)}
+}
+
+// Command is a command message such as "/add" or "/stock ABC-001"
+func Command(from *tgbotapi.User, command string) tgbotapi.Update {
+	msg := message(from, command)
+
+	name, _, _ := strings.Cut(command, " ")
+	msg.Entities = []tgbotapi.MessageEntity{{
+		Type:   "bot_command",
+		Offset: 0,
+		Length: len(name),
+	}}
+
+	return tgbotapi.Update{Message: msg}
+}
+
+// Reply answers a message the bot sent, such as a force reply prompt
+func Reply(from *tgbotapi.User, to tgbotapi.Message, text string) tgbotapi.Update {
+	msg := message(from, text)
+	msg.ReplyToMessage = &to
+
+	return tgbotapi.Update{Message: msg}
+}
+
+// PhotoReply answers a message the bot sent with a photo
+func PhotoReply(from *tgbotapi.User, to tgbotapi.Message, fileID string) tgbotapi.Update {
+	msg := message(from, "")
+	msg.ReplyToMessage = &to
+	msg.Photo = []tgbotapi.PhotoSize{
+		{FileID: fileID + "-small", FileUniqueID: fileID + "-small", Width: 90, Height: 90},
+		{FileID: fileID, FileUniqueID: fileID, Width: 1280, Height: 1280},
+	}
+
+	return tgbotapi.Update{Message: msg}
+}
+
+// Callback is the user pressing an inline button carrying data on a message the bot sent
+func Callback(from *tgbotapi.User, on tgbotapi.Message, data string) tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb-" + data,
+		From:    from,
+		Message: &on,
+		Data:    data,
+	}}
+}
+
+func message(from *tgbotapi.User, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: int(lastIncomingMessageID.Add(1)),
+		From:      from,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: from.ID, Type: "private"},
+		Text:      text,
+	}
+}
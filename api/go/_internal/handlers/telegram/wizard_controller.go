@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/commands"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	msgNoActiveWizard   = "目前沒有進行中的流程"
+	msgWizardCancelled  = "❌ 已取消目前的流程"
+	msgWizardNotSupport = "⚠️ 目前的流程不支援此操作，可輸入 /cancel 取消"
+)
+
+// WizardController handles /pause, /cancel and /restart. Like replies, these commands don't
+// name the wizard they apply to, so they go to the command owning the user's latest session.
+type WizardController struct {
+	commandDAO      commands.Repository
+	commandHandlers map[commands.BotCommand]commands.CommandHandler
+	botAPI          *tgbotapi.BotAPI
+	logger          *zap.SugaredLogger
+}
+
+type WizardControllerParams struct {
+	fx.In
+
+	CommandDAO      commands.Repository
+	CommandHandlers map[commands.BotCommand]commands.CommandHandler
+	BotAPI          *tgbotapi.BotAPI
+	Logger          *zap.SugaredLogger
+}
+
+func NewWizardController(p WizardControllerParams) *WizardController {
+	return &WizardController{
+		commandDAO:      p.CommandDAO,
+		commandHandlers: p.CommandHandlers,
+		botAPI:          p.BotAPI,
+		logger:          p.Logger,
+	}
+}
+
+// Process applies the control command in msg to the user's active wizard
+func (c *WizardController) Process(ctx context.Context, msg *tgbotapi.Message) error {
+	session, err := c.commandDAO.GetLatestUserSession(ctx, msg.Chat.ID, msg.From.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.sendText(msg.Chat.ID, msgNoActiveWizard)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user session: %w", err)
+	}
+
+	command := commands.BotCommand(msg.Command())
+
+	wizard, ok := c.commandHandlers[commands.BotCommand(session.SessionType)].(commands.Wizard)
+	if !ok {
+		// Single prompt commands (e.g. /stock) have nothing to pause or restart, but their
+		// pending prompt can still be dropped.
+		if command != commands.Cancel {
+			return c.sendText(msg.Chat.ID, msgWizardNotSupport)
+		}
+
+		if err := c.commandDAO.DeleteUserSession(ctx, msg.From.ID, session.SessionType); err != nil {
+			return fmt.Errorf("failed to delete user session: %w", err)
+		}
+		return c.sendText(msg.Chat.ID, msgWizardCancelled)
+	}
+
+	c.logger.Infow(
+		"Processing wizard control",
+		"command", command,
+		"session_type", session.SessionType,
+		"user_id", msg.From.ID,
+	)
+
+	switch command {
+	case commands.Pause:
+		return wizard.Pause(ctx, msg)
+	case commands.Restart:
+		return wizard.Restart(ctx, msg)
+	case commands.Cancel:
+		return wizard.Cancel(ctx, msg)
+	default:
+		return fmt.Errorf("command %s is not a wizard control", command)
+	}
+}
+
+func (c *WizardController) sendText(chatID int64, text string) error {
+	_, err := c.botAPI.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
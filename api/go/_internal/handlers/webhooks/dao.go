@@ -94,3 +94,35 @@ func (dao *UserDAO) CreateUserFromClerk(ctx context.Context, clerkUser ClerkUser
 
 	return &user, nil
 }
+
+// UpdateUserFromClerk updates the name and email of the user from Clerk webhook data, creating
+// the user if the user.created event was missed
+func (dao *UserDAO) UpdateUserFromClerk(ctx context.Context, clerkUser ClerkUser) (*db.User, error) {
+	var sqlEmail pgtype.Text
+	if email := clerkUser.GetPrimaryEmail(); email != nil {
+		sqlEmail = pgtype.Text{String: *email, Valid: true}
+	}
+
+	updateUserSQL := `
+		UPDATE users
+		SET name = $1, email = $2, updated_at = NOW()
+		WHERE auth_provider_id = $3 AND auth_provider = $4
+		RETURNING id, name, email, created_at, updated_at, deleted_at, auth_provider, auth_provider_id
+	`
+
+	var user db.User
+	err := dao.db.GetContext(ctx, &user, updateUserSQL,
+		clerkUser.GetFullName(),
+		sqlEmail,
+		clerkUser.ID,
+		"clerk",
+	)
+	if err == sql.ErrNoRows {
+		return dao.CreateUserFromClerk(ctx, clerkUser)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return &user, nil
+}
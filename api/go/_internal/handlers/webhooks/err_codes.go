@@ -5,6 +5,8 @@ const (
 	FailedToVerifyWebhook = "FAILED_TO_VERIFY_WEBHOOK"
 	UnsupportedEventType  = "UNSUPPORTED_EVENT_TYPE"
 	FailedToCreateUser    = "FAILED_TO_CREATE_USER"
+	FailedToUpdateUser    = "FAILED_TO_UPDATE_USER"
+	FailedToSyncRoles     = "FAILED_TO_SYNC_ROLES"
 	InvalidWebhookPayload = "INVALID_WEBHOOK_PAYLOAD"
 	UserAlreadyExists     = "USER_ALREADY_EXISTS"
 )
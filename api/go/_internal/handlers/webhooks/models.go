@@ -1,6 +1,10 @@
 package webhooks
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // ClerkWebhookEvent represents the main webhook event structure from Clerk
 type ClerkWebhookEvent struct {
@@ -13,14 +17,15 @@ type ClerkWebhookEvent struct {
 
 // ClerkUser represents the user data structure from Clerk
 type ClerkUser struct {
-	ID             string              `json:"id"`
-	FirstName      *string             `json:"first_name"`
-	LastName       *string             `json:"last_name"`
-	EmailAddresses []ClerkEmailAddress `json:"email_addresses"`
-	ImageURL       *string             `json:"image_url"`
-	CreatedAt      int64               `json:"created_at"`
-	UpdatedAt      int64               `json:"updated_at"`
-	ExternalID     *string             `json:"external_id"`
+	ID             string                     `json:"id"`
+	FirstName      *string                    `json:"first_name"`
+	LastName       *string                    `json:"last_name"`
+	EmailAddresses []ClerkEmailAddress        `json:"email_addresses"`
+	ImageURL       *string                    `json:"image_url"`
+	CreatedAt      int64                      `json:"created_at"`
+	UpdatedAt      int64                      `json:"updated_at"`
+	ExternalID     *string                    `json:"external_id"`
+	PublicMetadata map[string]json.RawMessage `json:"public_metadata"`
 }
 
 // ClerkEmailAddress represents an email address from Clerk
@@ -83,3 +88,17 @@ func (u ClerkUser) GetCreatedAtTime() time.Time {
 func (u ClerkUser) GetUpdatedAtTime() time.Time {
 	return time.UnixMilli(u.UpdatedAt)
 }
+
+// MetadataRoles returns the role names in public_metadata.roles, ok is false when the key is
+// absent so the roles of the user are left alone
+func (u ClerkUser) MetadataRoles() (roles []string, ok bool, err error) {
+	raw, ok := u.PublicMetadata["roles"]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if err := json.Unmarshal(raw, &roles); err != nil {
+		return nil, true, fmt.Errorf("public_metadata.roles is not a list of role names: %w", err)
+	}
+	return roles, true, nil
+}
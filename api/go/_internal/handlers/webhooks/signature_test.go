@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	key := []byte("kikichoice-webhook-secret")
+	secret := "whsec_" + base64.StdEncoding.EncodeToString(key)
+	body := []byte(`{"type":"user.updated"}`)
+	now := time.Unix(1751400000, 0)
+
+	sign := func(id string, sentAt time.Time, body []byte) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(id + "." + strconv.FormatInt(sentAt.Unix(), 10) + "."))
+		mac.Write(body)
+		return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	headers := func(id string, sentAt time.Time, signature string) http.Header {
+		h := http.Header{}
+		h.Set("svix-id", id)
+		h.Set("svix-timestamp", strconv.FormatInt(sentAt.Unix(), 10))
+		h.Set("svix-signature", signature)
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		wantErr bool
+	}{
+		{"valid", headers("msg_1", now, sign("msg_1", now, body)), body, false},
+		{"one of several signatures", headers("msg_1", now, "v1,bm9wZQ== "+sign("msg_1", now, body)), body, false},
+		{"tampered body", headers("msg_1", now, sign("msg_1", now, body)), []byte(`{"type":"user.deleted"}`), true},
+		{"other message id", headers("msg_2", now, sign("msg_1", now, body)), body, true},
+		{"stale timestamp", headers("msg_1", now.Add(-10*time.Minute), sign("msg_1", now.Add(-10*time.Minute), body)), body, true},
+		{"missing headers", http.Header{}, body, true},
+	}
+
+	for _, tt := range tests {
+		err := verifySignature(secret, tt.header, tt.body, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: verifySignature() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: verifySignature() error = %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/router"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// UpdateUserHandler handles Clerk user.updated events, syncing the profile and the roles in
+// public_metadata.roles
+type UpdateUserHandler struct {
+	cfg     *configs.Config
+	userDAO *UserDAO
+	roles   *rbac.RoleDAO
+	logger  *zap.SugaredLogger
+}
+
+type UpdateUserHandlerParams struct {
+	fx.In
+
+	Config  *configs.Config
+	UserDAO *UserDAO
+	Roles   *rbac.RoleDAO
+	Logger  *zap.SugaredLogger
+}
+
+func NewUpdateUserHandler(p UpdateUserHandlerParams) *UpdateUserHandler {
+	return &UpdateUserHandler{
+		cfg:     p.Config,
+		userDAO: p.UserDAO,
+		roles:   p.Roles,
+		logger:  p.Logger,
+	}
+}
+
+func (h *UpdateUserHandler) RegisterRoutes(r *chi.Mux) {
+	r.Post("/v1/webhooks/clerk/update-user", h.Handle)
+}
+
+// Handle only accepts signed deliveries since the payload grants roles
+func (h *UpdateUserHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		render.ChiErr(w, r, err, FailedToDecodeWebhook,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	if h.cfg.Clerk.WebhookSecret == "" {
+		h.logger.Error("CLERK_WEBHOOK_SECRET is not set, rejecting user.updated webhook")
+		render.ChiErr(w, r, errors.New("webhook signing secret is not configured"), FailedToVerifyWebhook,
+			render.WithStatusCode(http.StatusUnauthorized))
+		return
+	}
+	if err := verifySignature(h.cfg.Clerk.WebhookSecret, r.Header, body, time.Now()); err != nil {
+		h.logger.Warnw("Failed to verify Clerk webhook", "error", err)
+		render.ChiErr(w, r, err, FailedToVerifyWebhook,
+			render.WithStatusCode(http.StatusUnauthorized))
+		return
+	}
+
+	var event ClerkWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		render.ChiErr(w, r, err, FailedToDecodeWebhook,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	if event.Type != "user.updated" {
+		render.ChiErr(w, r, fmt.Errorf("unsupported event type %q", event.Type), UnsupportedEventType,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+	if event.Data.ID == "" {
+		render.ChiErr(w, r, errors.New("missing user id"), InvalidWebhookPayload,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	roles, syncRoles, err := event.Data.MetadataRoles()
+	if err != nil {
+		render.ChiErr(w, r, err, InvalidWebhookPayload,
+			render.WithStatusCode(http.StatusBadRequest))
+		return
+	}
+
+	user, err := h.userDAO.UpdateUserFromClerk(r.Context(), event.Data)
+	if err != nil {
+		h.logger.Errorw("Failed to update user from Clerk data", "error", err, "clerk_id", event.Data.ID)
+		render.ChiErr(w, r, err, FailedToUpdateUser,
+			render.WithStatusCode(http.StatusInternalServerError))
+		return
+	}
+
+	response := map[string]any{
+		"message":  "User updated successfully",
+		"user_id":  user.ID,
+		"clerk_id": event.Data.ID,
+	}
+
+	if syncRoles {
+		known, err := h.knownRoles(r, roles)
+		if err == nil {
+			err = h.roles.SetUserRoles(r.Context(), user.ID, known)
+		}
+		if err != nil {
+			h.logger.Errorw("Failed to sync roles from Clerk metadata", "error", err, "user_id", user.ID)
+			render.ChiErr(w, r, err, FailedToSyncRoles,
+				render.WithStatusCode(http.StatusInternalServerError))
+			return
+		}
+
+		h.logger.Infow("Synced roles from Clerk metadata", "user_id", user.ID, "roles", known)
+		response["roles"] = known
+	}
+
+	render.ChiJSON(w, r, response)
+}
+
+// knownRoles drops the names that aren't roles, metadata edited by hand shouldn't fail the
+// webhook and make Clerk retry it
+func (h *UpdateUserHandler) knownRoles(r *http.Request, names []string) ([]string, error) {
+	roles, err := h.roles.ListRoles(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	known := make([]string, 0, len(names))
+	for _, name := range names {
+		if !slices.ContainsFunc(roles, func(role rbac.Role) bool { return role.Name == name }) {
+			h.logger.Warnw("Ignoring unknown role in Clerk metadata", "role", name)
+			continue
+		}
+		if !slices.Contains(known, name) {
+			known = append(known, name)
+		}
+	}
+
+	return known, nil
+}
+
+var _ router.Handler = (*UpdateUserHandler)(nil)
@@ -0,0 +1,241 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rsa"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type contextKey string
+
+const sessionContextKey contextKey = "session"
+
+// session is the signed in user with the roles and permissions loaded by Authenticate
+type session struct {
+	user        *db.User
+	roles       []string
+	permissions []string
+}
+
+// Auth authenticates the Clerk session token of a request and loads the signed in user
+type Auth struct {
+	db      db.Conn
+	roles   *rbac.RoleDAO
+	key     *rsa.PublicKey
+	issuer  string
+	parties []string
+	logger  *zap.SugaredLogger
+}
+
+type AuthParams struct {
+	fx.In
+
+	Config *configs.Config
+	DB     db.Conn
+	Roles  *rbac.RoleDAO
+	Logger *zap.SugaredLogger
+}
+
+// NewAuth keeps going without a valid CLERK_JWT_KEY, CLERK_ISSUER or CLERK_AUTHORIZED_PARTIES so
+// the public routes of the entry still work, every authenticated request is rejected instead.
+func NewAuth(p AuthParams) *Auth {
+	auth := &Auth{
+		db:      p.DB,
+		roles:   p.Roles,
+		issuer:  p.Config.Clerk.Issuer,
+		parties: p.Config.Clerk.AuthorizedParties,
+		logger:  p.Logger,
+	}
+
+	// Env files can't hold multiline values, the key may come with escaped newlines.
+	pem := strings.ReplaceAll(p.Config.Clerk.JWTKey, `\n`, "\n")
+	if pem == "" {
+		return auth
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(pem))
+	if err != nil {
+		p.Logger.Errorw("Invalid CLERK_JWT_KEY, rejecting authenticated requests", "error", err)
+		return auth
+	}
+	auth.key = key
+
+	return auth
+}
+
+// Authenticate only lets through requests carrying "Authorization: Bearer <session token>" of
+// a known user, who is then available through UserFromContext along with their permissions
+func (a *Auth) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			render.ChiErr(w, r, errors.New("missing authorization header"), MissingAuthorizationHeader,
+				render.WithStatusCode(http.StatusUnauthorized))
+			return
+		}
+
+		token, ok := strings.CutPrefix(authorization, "Bearer ")
+		if !ok || token == "" {
+			render.ChiErr(w, r, errors.New("authorization header is not a bearer token"), FailedToExtractBearerToken,
+				render.WithStatusCode(http.StatusUnauthorized))
+			return
+		}
+
+		clerkID, err := a.verify(token)
+		if err != nil {
+			render.ChiErr(w, r, err, InvalidBearerToken,
+				render.WithStatusCode(http.StatusUnauthorized))
+			return
+		}
+
+		user, err := a.findUser(r.Context(), clerkID)
+		if errors.Is(err, sql.ErrNoRows) {
+			render.ChiErr(w, r, errors.New("user not found"), UserNotFound,
+				render.WithStatusCode(http.StatusUnauthorized))
+			return
+		}
+		if err != nil {
+			a.logger.Errorw("Failed to load authenticated user", "clerk_id", clerkID, "error", err)
+			render.ChiErr(w, r, err, FailedToLoadUser,
+				render.WithStatusCode(http.StatusInternalServerError))
+			return
+		}
+
+		roles, permissions, err := a.roles.UserAccess(r.Context(), user.ID)
+		if err != nil {
+			a.logger.Errorw("Failed to load permissions of authenticated user", "user_id", user.ID, "error", err)
+			render.ChiErr(w, r, err, FailedToLoadUser,
+				render.WithStatusCode(http.StatusInternalServerError))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), sessionContextKey, &session{
+			user:        user,
+			roles:       roles,
+			permissions: permissions,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequirePermission only lets through users with a role granting permission, it goes after
+// Auth.Authenticate
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := UserFromContext(r.Context()); !ok {
+				render.ChiErr(w, r, errors.New("request is not authenticated"), MissingAuthorizationHeader,
+					render.WithStatusCode(http.StatusUnauthorized))
+				return
+			}
+
+			if !HasPermission(r.Context(), permission) {
+				render.ChiErr(w, r, fmt.Errorf("permission %q is required", permission), InsufficientPermission,
+					render.WithStatusCode(http.StatusForbidden))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithPermission is the middleware chain of a route requiring permission, Authenticate then
+// RequirePermission
+func WithPermission(auth *Auth, permission string) chi.Middlewares {
+	return chi.Middlewares{auth.Authenticate, RequirePermission(permission)}
+}
+
+// UserFromContext returns the user Authenticate loaded
+func UserFromContext(ctx context.Context) (*db.User, bool) {
+	s, ok := ctx.Value(sessionContextKey).(*session)
+	if !ok {
+		return nil, false
+	}
+	return s.user, true
+}
+
+// RolesFromContext returns the role names of the user Authenticate loaded
+func RolesFromContext(ctx context.Context) []string {
+	if s, ok := ctx.Value(sessionContextKey).(*session); ok {
+		return s.roles
+	}
+	return nil
+}
+
+// HasPermission reports whether the user Authenticate loaded has permission
+func HasPermission(ctx context.Context, permission string) bool {
+	s, ok := ctx.Value(sessionContextKey).(*session)
+	return ok && slices.Contains(s.permissions, permission)
+}
+
+// sessionClaims are the claims of a Clerk session token verify checks
+type sessionClaims struct {
+	jwt.RegisteredClaims
+	// AuthorizedParty is the origin of the frontend the token was issued to
+	AuthorizedParty string `json:"azp"`
+}
+
+// verify checks the signature, lifetime, issuer and authorized party of a session token and
+// returns its subject, the Clerk user ID
+func (a *Auth) verify(token string) (string, error) {
+	if a.key == nil {
+		return "", errors.New("session tokens can't be verified, CLERK_JWT_KEY is not set")
+	}
+	if a.issuer == "" || len(a.parties) == 0 {
+		return "", errors.New("session tokens can't be verified, CLERK_ISSUER and CLERK_AUTHORIZED_PARTIES are required")
+	}
+
+	var claims sessionClaims
+	if _, err := jwt.ParseWithClaims(
+		token,
+		&claims,
+		func(*jwt.Token) (any, error) { return a.key, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithLeeway(5*time.Second),
+	); err != nil {
+		return "", fmt.Errorf("invalid session token: %w", err)
+	}
+
+	if !slices.Contains(a.parties, claims.AuthorizedParty) {
+		return "", fmt.Errorf("session token was issued to %q, which is not an authorized party", claims.AuthorizedParty)
+	}
+
+	if claims.Subject == "" {
+		return "", errors.New("session token has no subject")
+	}
+
+	return claims.Subject, nil
+}
+
+func (a *Auth) findUser(ctx context.Context, clerkID string) (*db.User, error) {
+	query := `
+		SELECT id, name, email, created_at, updated_at, deleted_at, auth_provider, auth_provider_id
+		FROM users
+		WHERE auth_provider = 'clerk' AND auth_provider_id = $1 AND deleted_at IS NULL
+	`
+
+	var user db.User
+	if err := a.db.GetContext(ctx, &user, query, clerkID); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
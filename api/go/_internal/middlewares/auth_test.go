@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	auth := &Auth{
+		key:     &key.PublicKey,
+		issuer:  "https://clerk.kikichoice.com",
+		parties: []string{"https://kikichoice.com", "https://admin.kikichoice.com"},
+	}
+
+	sign := func(issuer, party string, expiresAt time.Time) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, sessionClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   "user_2abc",
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+			AuthorizedParty: party,
+		}).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	later := time.Now().Add(time.Minute)
+
+	subject, err := auth.verify(sign("https://clerk.kikichoice.com", "https://admin.kikichoice.com", later))
+	require.NoError(t, err)
+	assert.Equal(t, "user_2abc", subject)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other instance", sign("https://clerk.example.com", "https://kikichoice.com", later)},
+		{"other origin", sign("https://clerk.kikichoice.com", "https://evil.example.com", later)},
+		{"no authorized party", sign("https://clerk.kikichoice.com", "", later)},
+		{"expired", sign("https://clerk.kikichoice.com", "https://kikichoice.com", time.Now().Add(-time.Minute))},
+	}
+	for _, tt := range tests {
+		_, err := auth.verify(tt.token)
+		assert.Error(t, err, tt.name)
+	}
+
+	unconfigured := &Auth{key: &key.PublicKey}
+	_, err = unconfigured.verify(sign("https://clerk.kikichoice.com", "https://kikichoice.com", later))
+	assert.Error(t, err, "without an issuer and authorized parties")
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/render"
+)
+
+// CronSecret only lets through requests carrying the "Authorization: Bearer <CRON_SECRET>"
+// header Vercel Cron sends. An empty configured secret rejects every request.
+func CronSecret(cfg *configs.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || cfg.Cron.Secret == "" ||
+				subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Cron.Secret)) != 1 {
+				render.ChiErr(w, r, errors.New("invalid cron secret"), InvalidCronSecret,
+					render.WithStatusCode(http.StatusUnauthorized))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
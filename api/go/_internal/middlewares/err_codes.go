@@ -4,4 +4,8 @@ const (
 	MissingAuthorizationHeader = "MISSING_AUTHORIZATION_HEADER"
 	FailedToExtractBearerToken = "FAILED_TO_EXTRACT_BEARER_TOKEN"
 	InvalidBearerToken         = "INVALID_BEARER_TOKEN"
+	InvalidCronSecret          = "INVALID_CRON_SECRET"
+	UserNotFound               = "USER_NOT_FOUND"
+	FailedToLoadUser           = "FAILED_TO_LOAD_USER"
+	InsufficientPermission     = "INSUFFICIENT_PERMISSION"
 )
@@ -0,0 +1,526 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+const orderColumns = `
+	id,
+	order_number,
+	status,
+	currency,
+	subtotal,
+	discount_total,
+	shipping_total,
+	tax_total,
+	grand_total,
+	email,
+	created_at,
+	updated_at
+`
+
+const prefixedOrderColumns = `
+	o.id,
+	o.order_number,
+	o.status,
+	o.currency,
+	o.subtotal,
+	o.discount_total,
+	o.shipping_total,
+	o.tax_total,
+	o.grand_total,
+	o.email,
+	o.created_at,
+	o.updated_at
+`
+
+const notificationColumns = `
+	id,
+	order_id,
+	kind,
+	status,
+	attempts,
+	last_error,
+	next_attempt_at,
+	sent_at,
+	created_at,
+	updated_at
+`
+
+// OrderDAO is the shared data access for orders. Status changes must go through
+// TransitionStatus so the state rules and the status history are always applied.
+type OrderDAO struct {
+	db *sqlx.DB
+}
+
+type OrderDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewOrderDAO(p OrderDAOParams) *OrderDAO {
+	return &OrderDAO{db: p.DB}
+}
+
+// GetOrderByNumber retrieves the order by its customer facing number together with its items
+func (dao *OrderDAO) GetOrderByNumber(ctx context.Context, orderNumber int64) (*OrderDetail, error) {
+	query := fmt.Sprintf(`SELECT %s FROM orders WHERE order_number = $1`, orderColumns)
+
+	var order OrderDetail
+	if err := dao.db.GetContext(ctx, &order.Order, query, orderNumber); err != nil {
+		return nil, err
+	}
+
+	items, err := dao.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+
+	return &order, nil
+}
+
+// GetOrderByID retrieves the order by id together with its items
+func (dao *OrderDAO) GetOrderByID(ctx context.Context, orderID int64) (*OrderDetail, error) {
+	query := fmt.Sprintf(`SELECT %s FROM orders WHERE id = $1`, orderColumns)
+
+	var order OrderDetail
+	if err := dao.db.GetContext(ctx, &order.Order, query, orderID); err != nil {
+		return nil, err
+	}
+
+	items, err := dao.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+
+	return &order, nil
+}
+
+// GetOrderForUpdateTx locks the order row within tx until it commits
+func (dao *OrderDAO) GetOrderForUpdateTx(ctx context.Context, tx *sqlx.Tx, orderID int64) (*db.Order, error) {
+	query := fmt.Sprintf(`SELECT %s FROM orders WHERE id = $1 FOR UPDATE`, orderColumns)
+
+	var order db.Order
+	if err := tx.GetContext(ctx, &order, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to lock order %d: %w", orderID, err)
+	}
+	return &order, nil
+}
+
+func (dao *OrderDAO) GetOrderItems(ctx context.Context, orderID int64) ([]db.OrderItem, error) {
+	query := `
+		SELECT
+			id,
+			order_id,
+			product_id,
+			variant_id,
+			name,
+			image_url,
+			unit_price,
+			quantity,
+			line_total,
+			metadata,
+			committed_quantity
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY id
+	`
+
+	items := make([]db.OrderItem, 0)
+	if err := dao.db.SelectContext(ctx, &items, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ListRecentOrders lists the latest orders, optionally filtered by status
+func (dao *OrderDAO) ListRecentOrders(ctx context.Context, status *db.OrderStatus, limit int) ([]db.Order, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM orders
+		WHERE $1::order_status IS NULL OR status = $1::order_status
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, orderColumns)
+
+	orders := make([]db.Order, 0)
+	if err := dao.db.SelectContext(ctx, &orders, query, status, limit); err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// orderFilter is the WHERE clause of ListOrdersParams, over $1 to $5
+const orderFilter = `
+	($1::order_status IS NULL OR o.status = $1::order_status)
+	AND ($2::timestamptz IS NULL OR o.created_at >= $2::timestamptz)
+	AND ($3::timestamptz IS NULL OR o.created_at < $3::timestamptz)
+	AND ($4::text = '' OR o.email ILIKE '%' || $4::text || '%')
+	AND ($5::bigint IS NULL OR o.order_number = $5::bigint)
+`
+
+func (p ListOrdersParams) filterArgs() []any {
+	return []any{p.Status, p.CreatedFrom, p.CreatedTo, p.Email, p.OrderNumber}
+}
+
+// ListOrders returns a page of the orders matching the filters, newest first, and how many
+// orders match in total
+func (dao *OrderDAO) ListOrders(ctx context.Context, p ListOrdersParams) ([]OrderSummary, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM orders o WHERE ` + orderFilter
+	if err := dao.db.GetContext(ctx, &total, countQuery, p.filterArgs()...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	query := summaryQuery + ` LIMIT $6 OFFSET $7`
+
+	orders := make([]OrderSummary, 0)
+	args := append(p.filterArgs(), p.Limit, p.Offset)
+	if err := dao.db.SelectContext(ctx, &orders, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+// ExportOrders calls fn with every order matching the filters, newest first, without loading
+// them all at once. Limit and Offset are ignored.
+func (dao *OrderDAO) ExportOrders(ctx context.Context, p ListOrdersParams, fn func(*OrderSummary) error) error {
+	rows, err := dao.db.QueryxContext(ctx, summaryQuery, p.filterArgs()...)
+	if err != nil {
+		return fmt.Errorf("failed to export orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var order OrderSummary
+		if err := rows.StructScan(&order); err != nil {
+			return fmt.Errorf("failed to scan order: %w", err)
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+var summaryQuery = fmt.Sprintf(`
+	SELECT
+		%s,
+		COALESCE((SELECT SUM(oi.quantity) FROM order_items oi WHERE oi.order_id = o.id), 0) AS item_count
+	FROM orders o
+	WHERE %s
+	ORDER BY o.created_at DESC, o.id DESC
+`, prefixedOrderColumns, orderFilter)
+
+// GetOrderRecord retrieves the order by id with its items, payments, refunds, shipments,
+// shipping addresses and status history
+func (dao *OrderDAO) GetOrderRecord(ctx context.Context, orderID int64) (*OrderRecord, error) {
+	detail, err := dao.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	record := OrderRecord{OrderDetail: *detail}
+
+	record.Payments = make([]db.Payment, 0)
+	paymentsQuery := `
+		SELECT
+			id,
+			order_id,
+			provider,
+			provider_txn_id,
+			status,
+			currency,
+			amount_authorized,
+			amount_captured,
+			captured_at,
+			raw_response,
+			created_at,
+			updated_at,
+			reference
+		FROM payments
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	if err := dao.db.SelectContext(ctx, &record.Payments, paymentsQuery, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	record.Refunds = make([]db.Refund, 0)
+	refundsQuery := `
+		SELECT
+			id,
+			order_id,
+			payment_id,
+			status,
+			currency,
+			amount,
+			restock,
+			reason,
+			actor_ref,
+			raw_response,
+			refunded_at,
+			created_at,
+			updated_at
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	if err := dao.db.SelectContext(ctx, &record.Refunds, refundsQuery, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	record.RefundItems = make([]db.RefundItem, 0)
+	refundItemsQuery := `
+		SELECT ri.id, ri.refund_id, ri.order_item_id, ri.quantity, ri.amount
+		FROM refund_items ri
+		JOIN refunds r ON r.id = ri.refund_id
+		WHERE r.order_id = $1
+		ORDER BY ri.refund_id, ri.id
+	`
+	if err := dao.db.SelectContext(ctx, &record.RefundItems, refundItemsQuery, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get refund items: %w", err)
+	}
+
+	record.Shipments = make([]db.Shipment, 0)
+	shipmentsQuery := `
+		SELECT
+			id,
+			order_id,
+			address_id,
+			status,
+			carrier,
+			service_level,
+			tracking_number,
+			tracking_url,
+			shipping_cost,
+			insurance_cost,
+			weight_kg,
+			dimensions_cm,
+			eta,
+			shipped_at,
+			delivered_at,
+			carrier_payload,
+			created_at,
+			updated_at
+		FROM shipments
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	if err := dao.db.SelectContext(ctx, &record.Shipments, shipmentsQuery, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get shipments: %w", err)
+	}
+
+	// Addresses are only linked to an order through its shipments.
+	record.Addresses = make([]db.Address, 0)
+	addressesQuery := `
+		SELECT
+			a.id,
+			a.kind,
+			a.name,
+			a.phone,
+			a.address_line_1,
+			a.address_line_2,
+			a.city,
+			a.state_province,
+			a.postal_code,
+			a.country,
+			a.created_at,
+			a.updated_at
+		FROM addresses a
+		WHERE a.id IN (SELECT address_id FROM shipments WHERE order_id = $1)
+		ORDER BY a.id
+	`
+	if err := dao.db.SelectContext(ctx, &record.Addresses, addressesQuery, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	record.History = make([]db.OrderStatusHistory, 0)
+	historyQuery := `
+		SELECT id, order_id, from_status, to_status, actor, actor_ref, note, created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+	if err := dao.db.SelectContext(ctx, &record.History, historyQuery, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %w", err)
+	}
+
+	return &record, nil
+}
+
+// TransitionStatus moves the order to a new status under a row lock, enforcing the
+// transition rules and recording the change in order_status_history. Canceling releases the
+// order's stock in the same transaction.
+func (dao *OrderDAO) TransitionStatus(ctx context.Context, p TransitionParams) (*db.Order, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		return dao.TransitionStatusTx(ctx, tx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*db.Order), nil
+}
+
+// TransitionStatusTx is TransitionStatus within tx, for changes that must commit together with
+// the status, e.g. the payment that paid the order
+func (dao *OrderDAO) TransitionStatusTx(ctx context.Context, tx *sqlx.Tx, p TransitionParams) (*db.Order, error) {
+	if p.To == db.OrderStatusShipped && p.TrackingNumber == "" {
+		return nil, ErrTrackingNumberRequired
+	}
+
+	var current db.Order
+	lockQuery := fmt.Sprintf(`SELECT %s FROM orders WHERE id = $1 FOR UPDATE`, orderColumns)
+	if err := tx.GetContext(ctx, &current, lockQuery, p.OrderID); err != nil {
+		return nil, fmt.Errorf("failed to lock order %d: %w", p.OrderID, err)
+	}
+
+	if !CanTransition(current.Status, p.To) {
+		return nil, &InvalidTransitionError{From: current.Status, To: p.To}
+	}
+
+	var updated db.Order
+	updateQuery := fmt.Sprintf(`
+		UPDATE orders
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING %s
+	`, orderColumns)
+	if err := tx.GetContext(ctx, &updated, updateQuery, p.OrderID, p.To); err != nil {
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	// Stock follows the order: an unpaid order gives back what it reserved, a paid one had its
+	// stock committed and puts it back.
+	if p.To == db.OrderStatusCanceled {
+		switch current.Status {
+		case db.OrderStatusPendingPayment:
+			if err := dao.ReleaseReservationTx(ctx, tx, p.OrderID); err != nil {
+				return nil, err
+			}
+		case db.OrderStatusPaid, db.OrderStatusProcessing:
+			if err := dao.RestockOrderTx(ctx, tx, p.OrderID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	note := p.Note
+	if p.To == db.OrderStatusShipped {
+		// Orders created before checkout stores addresses have no shipment row, so the
+		// tracking number is always kept in the history note as well.
+		shipmentQuery := `
+			UPDATE shipments
+			SET
+				tracking_number = $2,
+				status = 'in_transit',
+				shipped_at = NOW(),
+				updated_at = NOW()
+			WHERE order_id = $1
+		`
+		if _, err := tx.ExecContext(ctx, shipmentQuery, p.OrderID, p.TrackingNumber); err != nil {
+			return nil, fmt.Errorf("failed to update shipment tracking number: %w", err)
+		}
+
+		note = fmt.Sprintf("tracking_number: %s", p.TrackingNumber)
+		if p.Note != "" {
+			note = fmt.Sprintf("%s\n%s", note, p.Note)
+		}
+	}
+
+	historyQuery := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_ref, note)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+	`
+	if _, err := tx.ExecContext(
+		ctx,
+		historyQuery,
+		p.OrderID,
+		current.Status,
+		p.To,
+		p.Actor,
+		p.ActorRef,
+		note,
+	); err != nil {
+		return nil, fmt.Errorf("failed to record order status history: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// ClaimDueNotifications picks pending notifications whose next attempt is due and pushes their
+// next_attempt_at forward by lease, so a concurrent dispatcher won't send the same row.
+func (dao *OrderDAO) ClaimDueNotifications(ctx context.Context, limit int, lease time.Duration) ([]db.OrderNotification, error) {
+	query := fmt.Sprintf(`
+		UPDATE order_notifications
+		SET
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM order_notifications
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s
+	`, notificationColumns)
+
+	notifications := make([]db.OrderNotification, 0)
+	if err := dao.db.SelectContext(ctx, &notifications, query, limit, lease.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim order notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (dao *OrderDAO) MarkNotificationSent(ctx context.Context, id int64) error {
+	query := `
+		UPDATE order_notifications
+		SET
+			status = 'sent',
+			sent_at = NOW(),
+			last_error = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := dao.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// MarkNotificationFailed records the error and schedules the next attempt.
+// A nil nextAttemptAt gives up on the notification.
+func (dao *OrderDAO) MarkNotificationFailed(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error {
+	status := NotificationStatusPending
+	if nextAttemptAt == nil {
+		status = NotificationStatusFailed
+	}
+
+	query := `
+		UPDATE order_notifications
+		SET
+			status = $2,
+			last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := dao.db.ExecContext(ctx, query, id, status, lastError, nextAttemptAt)
+	return err
+}
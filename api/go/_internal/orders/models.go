@@ -0,0 +1,63 @@
+package orders
+
+import (
+	"errors"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+var ErrTrackingNumberRequired = errors.New("tracking number is required to ship an order")
+
+const (
+	NotificationKindOrderPaid = "order_paid"
+
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+)
+
+// OrderDetail is an order together with its line items
+type OrderDetail struct {
+	db.Order
+	Items []db.OrderItem `json:"items"`
+}
+
+// TransitionParams describes a status change and who made it
+type TransitionParams struct {
+	OrderID        int64
+	To             db.OrderStatus
+	Actor          db.StatusActor
+	ActorRef       string
+	Note           string
+	TrackingNumber string
+}
+
+// OrderSummary is an order with the number of units bought, as listed to staff
+type OrderSummary struct {
+	db.Order
+	ItemCount int64 `json:"item_count"`
+}
+
+// OrderRecord is everything known about an order
+type OrderRecord struct {
+	OrderDetail
+	Payments    []db.Payment            `json:"payments"`
+	Refunds     []db.Refund             `json:"refunds"`
+	RefundItems []db.RefundItem         `json:"refund_items"`
+	Shipments   []db.Shipment           `json:"shipments"`
+	Addresses   []db.Address            `json:"addresses"`
+	History     []db.OrderStatusHistory `json:"history"`
+}
+
+// ListOrdersParams filters orders, zero values match every order
+type ListOrdersParams struct {
+	Status      *db.OrderStatus
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	Email       string     // case-insensitive substring
+	OrderNumber *int64
+
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,62 @@
+package orders
+
+import (
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+// transitions lists the statuses an order may move to from its current status.
+// Every writer (staff tools, payment webhooks, admin API) must go through CanTransition.
+// Canceling a paid order doesn't return the money, it becomes refunded once staff refund it.
+var transitions = map[db.OrderStatus][]db.OrderStatus{
+	db.OrderStatusPendingPayment: {db.OrderStatusPaid, db.OrderStatusCanceled},
+	db.OrderStatusPaid:           {db.OrderStatusProcessing, db.OrderStatusCanceled, db.OrderStatusRefunded},
+	db.OrderStatusProcessing:     {db.OrderStatusShipped, db.OrderStatusCanceled, db.OrderStatusRefunded},
+	db.OrderStatusShipped:        {db.OrderStatusDelivered, db.OrderStatusRefunded},
+	db.OrderStatusDelivered:      {db.OrderStatusRefunded},
+	db.OrderStatusCanceled:       {db.OrderStatusRefunded},
+	db.OrderStatusRefunded:       {},
+}
+
+// Statuses lists every order status in lifecycle order
+var Statuses = []db.OrderStatus{
+	db.OrderStatusPendingPayment,
+	db.OrderStatusPaid,
+	db.OrderStatusProcessing,
+	db.OrderStatusShipped,
+	db.OrderStatusDelivered,
+	db.OrderStatusCanceled,
+	db.OrderStatusRefunded,
+}
+
+// IsValidStatus reports whether s is a known order status
+func IsValidStatus(s db.OrderStatus) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransition reports whether an order in status from may move to status to
+func CanTransition(from, to db.OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses returns the statuses reachable from the given status
+func NextStatuses(from db.OrderStatus) []db.OrderStatus {
+	return transitions[from]
+}
+
+// InvalidTransitionError is returned when an order is moved to a status the rules don't allow
+type InvalidTransitionError struct {
+	From db.OrderStatus
+	To   db.OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order cannot move from %s to %s", e.From, e.To)
+}
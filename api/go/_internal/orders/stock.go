@@ -0,0 +1,229 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+)
+
+// StockShortage is an item sold beyond the stock left, found when stock is committed
+type StockShortage struct {
+	SKU     string
+	Ordered int32
+	InStock int32
+}
+
+type stockLine struct {
+	ProductID int64         `json:"product_id"`
+	VariantID sql.NullInt64 `json:"variant_id"`
+	Quantity  int32         `json:"quantity"`
+}
+
+// target returns the table and id of the row holding the stock of the line, the variant or
+// the product when the item has none
+func (l stockLine) target() (string, int64) {
+	if l.VariantID.Valid {
+		return "product_variants", l.VariantID.Int64
+	}
+	return "products", l.ProductID
+}
+
+// orderStockLines sums the quantities of the order's items per stock row. Rows are returned
+// in a fixed order, locking them in it keeps concurrent orders from deadlocking.
+func orderStockLines(ctx context.Context, tx *sqlx.Tx, orderID int64) ([]stockLine, error) {
+	query := `
+		SELECT product_id, variant_id, SUM(quantity)::int AS quantity
+		FROM order_items
+		WHERE order_id = $1
+		GROUP BY product_id, variant_id
+		ORDER BY product_id, variant_id NULLS FIRST
+	`
+	var lines []stockLine
+	if err := tx.SelectContext(ctx, &lines, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to load order items: %w", err)
+	}
+	return lines, nil
+}
+
+// CommitStockTx turns the reservations of a paid order into sold stock: the stock_count and
+// reserved_count of the variant, or of the product when the item has none, go down by the
+// quantity. Neither goes below zero, items sold beyond the stock are returned instead so the
+// payment still goes through. The units taken out of stock_count are recorded in the
+// committed_quantity of the order items, the first items of a stock row first. Must only run
+// once per order, when it becomes paid.
+func (dao *OrderDAO) CommitStockTx(ctx context.Context, tx *sqlx.Tx, orderID int64) ([]StockShortage, error) {
+	lines, err := orderStockLines(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	shortages := make([]StockShortage, 0)
+	for _, line := range lines {
+		table, id := line.target()
+
+		var stock struct {
+			SKU        string `json:"sku"`
+			StockCount int32  `json:"stock_count"`
+		}
+		lockQuery := fmt.Sprintf(`SELECT sku, stock_count FROM %s WHERE id = $1 FOR UPDATE`, table)
+		if err := tx.GetContext(ctx, &stock, lockQuery, id); err != nil {
+			return nil, fmt.Errorf("failed to lock stock of %s %d: %w", table, id, err)
+		}
+
+		committed := line.Quantity
+		if stock.StockCount < line.Quantity {
+			shortages = append(shortages, StockShortage{
+				SKU:     stock.SKU,
+				Ordered: line.Quantity,
+				InStock: stock.StockCount,
+			})
+			committed = max(stock.StockCount, 0)
+		}
+
+		updateQuery := fmt.Sprintf(`
+			UPDATE %s
+			SET
+				stock_count = stock_count - $2,
+				reserved_count = GREATEST(reserved_count - $3, 0),
+				updated_at = NOW()
+			WHERE id = $1
+		`, table)
+		if _, err := tx.ExecContext(ctx, updateQuery, id, committed, line.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to commit stock of %s: %w", stock.SKU, err)
+		}
+
+		itemsQuery := `
+			UPDATE order_items oi
+			SET committed_quantity = LEAST(oi.quantity, GREATEST($4 - earlier.quantity, 0))
+			FROM (
+				SELECT id, SUM(quantity) OVER (ORDER BY id) - quantity AS quantity
+				FROM order_items
+				WHERE order_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+			) earlier
+			WHERE oi.id = earlier.id
+		`
+		if _, err := tx.ExecContext(ctx, itemsQuery, orderID, line.ProductID, line.VariantID, committed); err != nil {
+			return nil, fmt.Errorf("failed to record committed stock of %s: %w", stock.SKU, err)
+		}
+
+		if line.VariantID.Valid {
+			if err := catalog.SyncParentStockTx(ctx, tx, line.ProductID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return shortages, nil
+}
+
+// RestockRefundTx puts the items of the refund back into the stock_count of the variant, or of
+// the product when the item has none. Only committed units go back, less those earlier refunds
+// put back. Must only run once per refund, when it succeeds.
+func (dao *OrderDAO) RestockRefundTx(ctx context.Context, tx *sqlx.Tx, refundID int64) error {
+	linesQuery := `
+		SELECT
+			oi.product_id,
+			oi.variant_id,
+			SUM(LEAST(ri.quantity, GREATEST(oi.committed_quantity - COALESCE(restocked.quantity, 0), 0)))::int AS quantity
+		FROM refund_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		LEFT JOIN (
+			SELECT eri.order_item_id, SUM(eri.quantity) AS quantity
+			FROM refund_items eri
+			JOIN refunds r ON r.id = eri.refund_id
+			WHERE r.id <> $1 AND r.status = 'succeeded' AND r.restock
+			GROUP BY eri.order_item_id
+		) restocked ON restocked.order_item_id = oi.id
+		WHERE ri.refund_id = $1
+		GROUP BY oi.product_id, oi.variant_id
+		ORDER BY oi.product_id, oi.variant_id NULLS FIRST
+	`
+	var lines []stockLine
+	if err := tx.SelectContext(ctx, &lines, linesQuery, refundID); err != nil {
+		return fmt.Errorf("failed to load refund items: %w", err)
+	}
+
+	return restockLines(ctx, tx, lines)
+}
+
+// ReleaseReservationTx gives back the units a canceled unpaid order held in reserved_count.
+// Never goes below zero.
+func (dao *OrderDAO) ReleaseReservationTx(ctx context.Context, tx *sqlx.Tx, orderID int64) error {
+	lines, err := orderStockLines(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		table, id := line.target()
+		query := fmt.Sprintf(`
+			UPDATE %s
+			SET reserved_count = GREATEST(reserved_count - $2, 0), updated_at = NOW()
+			WHERE id = $1
+		`, table)
+		if _, err := tx.ExecContext(ctx, query, id, line.Quantity); err != nil {
+			return fmt.Errorf("failed to release reservation of %s %d: %w", table, id, err)
+		}
+	}
+
+	return nil
+}
+
+// RestockOrderTx puts the committed items of a paid order back into stock_count. Units refunds
+// already put back are left out.
+func (dao *OrderDAO) RestockOrderTx(ctx context.Context, tx *sqlx.Tx, orderID int64) error {
+	query := `
+		SELECT
+			oi.product_id,
+			oi.variant_id,
+			SUM(GREATEST(oi.committed_quantity - COALESCE(restocked.quantity, 0), 0))::int AS quantity
+		FROM order_items oi
+		LEFT JOIN (
+			SELECT ri.order_item_id, SUM(ri.quantity) AS quantity
+			FROM refund_items ri
+			JOIN refunds r ON r.id = ri.refund_id
+			WHERE r.order_id = $1 AND r.status = 'succeeded' AND r.restock
+			GROUP BY ri.order_item_id
+		) restocked ON restocked.order_item_id = oi.id
+		WHERE oi.order_id = $1
+		GROUP BY oi.product_id, oi.variant_id
+		ORDER BY oi.product_id, oi.variant_id NULLS FIRST
+	`
+	var lines []stockLine
+	if err := tx.SelectContext(ctx, &lines, query, orderID); err != nil {
+		return fmt.Errorf("failed to load committed order items: %w", err)
+	}
+
+	return restockLines(ctx, tx, lines)
+}
+
+// restockLines adds the lines to the stock_count of their rows, skipping empty ones
+func restockLines(ctx context.Context, tx *sqlx.Tx, lines []stockLine) error {
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			continue
+		}
+		table, id := line.target()
+
+		updateQuery := fmt.Sprintf(`
+			UPDATE %s
+			SET stock_count = stock_count + $2, updated_at = NOW()
+			WHERE id = $1
+		`, table)
+		if _, err := tx.ExecContext(ctx, updateQuery, id, line.Quantity); err != nil {
+			return fmt.Errorf("failed to restock %s %d: %w", table, id, err)
+		}
+
+		if line.VariantID.Valid {
+			if err := catalog.SyncParentStockTx(ctx, tx, line.ProductID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
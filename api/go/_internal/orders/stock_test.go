@@ -0,0 +1,145 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db/dbtest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stockCounts struct {
+	Stock    int32 `json:"stock_count"`
+	Reserved int32 `json:"reserved_count"`
+}
+
+func countsOf(t *testing.T, tx *sqlx.Tx, table string, id int64) stockCounts {
+	t.Helper()
+
+	var counts stockCounts
+	query := fmt.Sprintf(`SELECT stock_count, reserved_count FROM %s WHERE id = $1`, table)
+	require.NoError(t, tx.Get(&counts, query, id))
+	return counts
+}
+
+func committedOf(t *testing.T, tx *sqlx.Tx, itemIDs []int64) []int32 {
+	t.Helper()
+
+	committed := make([]int32, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		var quantity int32
+		require.NoError(t, tx.Get(&quantity, `SELECT committed_quantity FROM order_items WHERE id = $1`, id))
+		committed = append(committed, quantity)
+	}
+	return committed
+}
+
+// shortOrder is a paid order of three units of a product with one left, over two items, and
+// two units of a variant with five left
+func shortOrder(t *testing.T, tx *sqlx.Tx) (orderID, productID, variantID int64, itemIDs []int64) {
+	t.Helper()
+
+	productID = dbtest.Product(t, tx, 1, 3)
+	parentID := dbtest.Product(t, tx, 0, 0)
+	variantID = dbtest.Variant(t, tx, parentID, 5, 2)
+
+	orderID, itemIDs = dbtest.Order(t, tx, db.OrderStatusPendingPayment,
+		dbtest.Item{ProductID: productID, Quantity: 2},
+		dbtest.Item{ProductID: parentID, VariantID: sql.NullInt64{Int64: variantID, Valid: true}, Quantity: 2},
+		dbtest.Item{ProductID: productID, Quantity: 1},
+	)
+	return orderID, productID, variantID, itemIDs
+}
+
+func TestCommitStockTx(t *testing.T) {
+	tx := dbtest.Tx(t)
+	ctx := context.Background()
+	dao := &OrderDAO{}
+
+	orderID, productID, variantID, itemIDs := shortOrder(t, tx)
+
+	shortages, err := dao.CommitStockTx(ctx, tx, orderID)
+	require.NoError(t, err)
+	require.Len(t, shortages, 1)
+	assert.Equal(t, int32(3), shortages[0].Ordered)
+	assert.Equal(t, int32(1), shortages[0].InStock)
+
+	assert.Equal(t, stockCounts{Stock: 0, Reserved: 0}, countsOf(t, tx, "products", productID))
+	assert.Equal(t, stockCounts{Stock: 3, Reserved: 0}, countsOf(t, tx, "product_variants", variantID))
+
+	// The one unit left goes to the first item of the product.
+	assert.Equal(t, []int32{1, 2, 0}, committedOf(t, tx, itemIDs))
+}
+
+func TestRestockOrderTx(t *testing.T) {
+	tx := dbtest.Tx(t)
+	ctx := context.Background()
+	dao := &OrderDAO{}
+
+	orderID, productID, variantID, _ := shortOrder(t, tx)
+
+	_, err := dao.CommitStockTx(ctx, tx, orderID)
+	require.NoError(t, err)
+	require.NoError(t, dao.RestockOrderTx(ctx, tx, orderID))
+
+	// Only the unit committed goes back, not the two sold beyond the stock.
+	assert.Equal(t, int32(1), countsOf(t, tx, "products", productID).Stock)
+	assert.Equal(t, int32(5), countsOf(t, tx, "product_variants", variantID).Stock)
+}
+
+// succeededRefund inserts a succeeded refund of the order returning the quantities of the
+// order items, the way it is before its stock is put back
+func succeededRefund(t *testing.T, tx *sqlx.Tx, orderID, paymentID int64, restock bool, quantities map[int64]int32) int64 {
+	t.Helper()
+
+	var refundID int64
+	refundQuery := `
+		INSERT INTO refunds (order_id, payment_id, status, currency, amount, restock, refunded_at)
+		VALUES ($1, $2, 'succeeded', 'TWD', 0, $3, NOW())
+		RETURNING id
+	`
+	require.NoError(t, tx.Get(&refundID, refundQuery, orderID, paymentID, restock))
+
+	for itemID, quantity := range quantities {
+		_, err := tx.Exec(`
+			INSERT INTO refund_items (refund_id, order_item_id, quantity, amount)
+			VALUES ($1, $2, $3, 0)
+		`, refundID, itemID, quantity)
+		require.NoError(t, err)
+	}
+	return refundID
+}
+
+func TestRestockAfterPartialRefunds(t *testing.T) {
+	tx := dbtest.Tx(t)
+	ctx := context.Background()
+	dao := &OrderDAO{}
+
+	orderID, productID, variantID, itemIDs := shortOrder(t, tx)
+	paymentID := dbtest.Payment(t, tx, orderID, "fake", fmt.Sprintf("R%d", orderID), db.PaymentStatusCaptured)
+
+	_, err := dao.CommitStockTx(ctx, tx, orderID)
+	require.NoError(t, err)
+
+	// The committed unit of the first item and one of the variant go back.
+	first := succeededRefund(t, tx, orderID, paymentID, true, map[int64]int32{itemIDs[0]: 1, itemIDs[1]: 1})
+	require.NoError(t, dao.RestockRefundTx(ctx, tx, first))
+	assert.Equal(t, int32(1), countsOf(t, tx, "products", productID).Stock)
+	assert.Equal(t, int32(4), countsOf(t, tx, "product_variants", variantID).Stock)
+
+	// The other unit of the first item was sold beyond the stock, nothing goes back.
+	second := succeededRefund(t, tx, orderID, paymentID, true, map[int64]int32{itemIDs[0]: 1})
+	require.NoError(t, dao.RestockRefundTx(ctx, tx, second))
+	assert.Equal(t, int32(1), countsOf(t, tx, "products", productID).Stock)
+
+	// Canceling puts back the one committed unit of the variant refunds left.
+	require.NoError(t, dao.RestockOrderTx(ctx, tx, orderID))
+	assert.Equal(t, int32(1), countsOf(t, tx, "products", productID).Stock)
+	assert.Equal(t, int32(5), countsOf(t, tx, "product_variants", variantID).Stock)
+}
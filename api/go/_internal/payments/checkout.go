@@ -0,0 +1,137 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// orderReader reads the orders to pay, OrderDAO outside of tests
+type orderReader interface {
+	GetOrderByID(ctx context.Context, orderID int64) (*orders.OrderDetail, error)
+}
+
+// paymentStore records payments and refunds, PaymentDAO outside of tests
+type paymentStore interface {
+	CreatePayment(ctx context.Context, p CreatePaymentParams) (*db.Payment, error)
+	CreateRefund(ctx context.Context, provider string, p RefundOrderParams) (*PendingRefund, error)
+	FailRefund(ctx context.Context, refundID int64, cause error) error
+	CompleteRefund(ctx context.Context, refundID int64, result *RefundResult) (*RefundOutcome, error)
+}
+
+// Service starts payments of orders with the configured provider
+type Service struct {
+	provider Provider
+	payments paymentStore
+	orders   orderReader
+	logger   *zap.SugaredLogger
+}
+
+type ServiceParams struct {
+	fx.In
+
+	Provider Provider
+	Payments *PaymentDAO
+	Orders   *orders.OrderDAO
+	Logger   *zap.SugaredLogger
+}
+
+func NewService(p ServiceParams) *Service {
+	return &Service{
+		provider: p.Provider,
+		payments: p.Payments,
+		orders:   p.Orders,
+		logger:   p.Logger,
+	}
+}
+
+// StartCheckout prepares the payment page of the order and records the attempt as an
+// initiated payment. Every call is a new attempt, e.g. after a failed card.
+func (s *Service) StartCheckout(ctx context.Context, orderID int64) (*Checkout, *db.Payment, error) {
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("%w: order %d", ErrOrderNotFound, orderID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.Status != db.OrderStatusPendingPayment {
+		return nil, nil, fmt.Errorf("%w: order %d is %s", ErrOrderNotPayable, order.OrderNumber, order.Status)
+	}
+
+	amount, err := WholeAmount(order.GrandTotal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itemNames := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		itemNames = append(itemNames, fmt.Sprintf("%s x %d", item.Name, item.Quantity))
+	}
+
+	now := time.Now()
+	reference := NewReference(order.OrderNumber, now)
+	checkout, err := s.provider.CreateCheckout(ctx, CheckoutParams{
+		Reference:   reference,
+		OrderNumber: order.OrderNumber,
+		Amount:      amount,
+		Currency:    order.Currency,
+		Description: fmt.Sprintf("KikiChoice 訂單 #%d", order.OrderNumber),
+		ItemNames:   itemNames,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create checkout: %w", err)
+	}
+
+	payment, err := s.payments.CreatePayment(ctx, CreatePaymentParams{
+		OrderID:   order.ID,
+		Provider:  s.provider.Name(),
+		Reference: reference,
+		Currency:  order.Currency,
+		Checkout:  checkout,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Infow("payment initiated",
+		"order_number", order.OrderNumber,
+		"payment_id", payment.ID,
+		"provider", payment.Provider,
+		"reference", reference,
+		"amount", amount,
+	)
+
+	return checkout, payment, nil
+}
+
+// NewReference returns a reference for a payment attempt of the order. ECPay takes at most 20
+// letters and digits and never the same one twice.
+func NewReference(orderNumber int64, now time.Time) string {
+	return "K" + strconv.FormatInt(orderNumber, 10) + strings.ToUpper(strconv.FormatInt(now.UnixMilli(), 36))
+}
+
+// WholeAmount returns n as whole currency units, providers here only charge TWD dollars
+func WholeAmount(n pgtype.Numeric) (int64, error) {
+	f, err := n.Float64Value()
+	if err != nil || !f.Valid {
+		return 0, fmt.Errorf("%w: missing amount", ErrInvalidAmount)
+	}
+	if f.Float64 <= 0 || f.Float64 != math.Trunc(f.Float64) {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, f.Float64)
+	}
+	return int64(f.Float64), nil
+}
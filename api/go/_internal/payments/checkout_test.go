@@ -0,0 +1,122 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeOrders holds orders by ID
+type fakeOrders map[int64]*orders.OrderDetail
+
+func (f fakeOrders) GetOrderByID(ctx context.Context, orderID int64) (*orders.OrderDetail, error) {
+	if order, ok := f[orderID]; ok {
+		return order, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// fakePayments keeps the payments rows CreatePayment inserts
+type fakePayments struct {
+	paymentStore
+	payments []db.Payment
+}
+
+func (f *fakePayments) CreatePayment(ctx context.Context, p CreatePaymentParams) (*db.Payment, error) {
+	raw, err := json.Marshal(p.Checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := db.Payment{
+		ID:          int64(len(f.payments) + 1),
+		OrderID:     p.OrderID,
+		Provider:    p.Provider,
+		Reference:   pgtype.Text{String: p.Reference, Valid: true},
+		Status:      db.PaymentStatusInitiated,
+		Currency:    p.Currency,
+		RawResponse: raw,
+	}
+	f.payments = append(f.payments, payment)
+	return &payment, nil
+}
+
+func newCheckoutService(t *testing.T, status db.OrderStatus) (*Service, *fakePayments) {
+	t.Helper()
+
+	order := &orders.OrderDetail{
+		Order: db.Order{
+			ID:          7,
+			OrderNumber: 1042,
+			Status:      status,
+			Currency:    "TWD",
+			GrandTotal:  price(t, "1280.00"),
+			Email:       "buyer@example.com",
+		},
+		Items: []db.OrderItem{{ID: 1, OrderID: 7, Name: "Kivy", Quantity: 2}},
+	}
+
+	store := &fakePayments{}
+	return &Service{
+		provider: NewFakeProvider(),
+		payments: store,
+		orders:   fakeOrders{order.ID: order},
+		logger:   zap.NewNop().Sugar(),
+	}, store
+}
+
+func TestStartCheckout(t *testing.T) {
+	s, store := newCheckoutService(t, db.OrderStatusPendingPayment)
+
+	checkout, payment, err := s.StartCheckout(context.Background(), 7)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, checkout.Method)
+	assert.NotEmpty(t, checkout.URL)
+	assert.True(t, strings.HasPrefix(checkout.Reference, "K1042"), "reference %q", checkout.Reference)
+	assert.Equal(t, checkout.Reference, checkout.Fields["reference"], "the reference is posted with the form")
+	assert.Equal(t, "1280", checkout.Fields["amount"])
+
+	require.Len(t, store.payments, 1)
+	row := store.payments[0]
+	assert.Equal(t, row.ID, payment.ID)
+	assert.Equal(t, int64(7), row.OrderID)
+	assert.Equal(t, db.PaymentStatusInitiated, row.Status)
+	assert.Equal(t, ProviderFake, row.Provider)
+	assert.Equal(t, checkout.Reference, row.Reference.String)
+	assert.Equal(t, "TWD", row.Currency)
+
+	var recorded Checkout
+	require.NoError(t, json.Unmarshal(row.RawResponse, &recorded), "raw_response holds the checkout form")
+	assert.Equal(t, checkout.Reference, recorded.Reference)
+}
+
+func TestStartCheckoutRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  db.OrderStatus
+		orderID int64
+		want    error
+	}{
+		{"unknown order", db.OrderStatusPendingPayment, 8, ErrOrderNotFound},
+		{"paid order", db.OrderStatusPaid, 7, ErrOrderNotPayable},
+	}
+	for _, tt := range tests {
+		s, store := newCheckoutService(t, tt.status)
+
+		_, _, err := s.StartCheckout(context.Background(), tt.orderID)
+		assert.ErrorIs(t, err, tt.want, tt.name)
+		assert.Empty(t, store.payments, tt.name)
+	}
+}
@@ -0,0 +1,460 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+const paymentColumns = `
+	id,
+	order_id,
+	provider,
+	provider_txn_id,
+	status,
+	currency,
+	amount_authorized,
+	amount_captured,
+	captured_at,
+	raw_response,
+	created_at,
+	updated_at,
+	reference
+`
+
+// PaymentDAO stores the payment attempts of orders
+type PaymentDAO struct {
+	db     *sqlx.DB
+	orders *orders.OrderDAO
+}
+
+type PaymentDAOParams struct {
+	fx.In
+
+	DB     *sqlx.DB
+	Orders *orders.OrderDAO
+}
+
+func NewPaymentDAO(p PaymentDAOParams) *PaymentDAO {
+	return &PaymentDAO{db: p.DB, orders: p.Orders}
+}
+
+// CreatePayment records a new attempt to pay for the order as initiated
+func (dao *PaymentDAO) CreatePayment(ctx context.Context, p CreatePaymentParams) (*db.Payment, error) {
+	raw, err := json.Marshal(p.Checkout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkout: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO payments (order_id, provider, reference, status, currency, raw_response)
+		VALUES ($1, $2, $3, 'initiated', $4, $5)
+		RETURNING %s
+	`, paymentColumns)
+
+	var payment db.Payment
+	if err := dao.db.GetContext(ctx, &payment, query, p.OrderID, p.Provider, p.Reference, p.Currency, raw); err != nil {
+		return nil, fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	return &payment, nil
+}
+
+// paymentTransitions lists the statuses a payment result may move a payment to. Anything else,
+// such as the same result delivered again, leaves the payment alone.
+var paymentTransitions = map[db.PaymentStatus][]db.PaymentStatus{
+	db.PaymentStatusInitiated:  {db.PaymentStatusAuthorized, db.PaymentStatusCaptured, db.PaymentStatusFailed},
+	db.PaymentStatusFailed:     {db.PaymentStatusAuthorized, db.PaymentStatusCaptured},
+	db.PaymentStatusAuthorized: {db.PaymentStatusCaptured, db.PaymentStatusFailed},
+}
+
+// ApplyResult records a payment result of the provider in one transaction: the payment gets
+// the status and raw payload, and the first successful payment of a pending order moves it to
+// paid and commits its stock. Results already applied are reported as Duplicate and change
+// nothing, so providers may deliver them any number of times.
+func (dao *PaymentDAO) ApplyResult(ctx context.Context, provider string, result *Result) (*ApplyOutcome, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var payment db.Payment
+		lockQuery := fmt.Sprintf(`
+			SELECT %s
+			FROM payments
+			WHERE provider = $1 AND reference = $2
+			FOR UPDATE
+		`, paymentColumns)
+		err := tx.GetContext(ctx, &payment, lockQuery, provider, result.Reference)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s %s", ErrPaymentNotFound, provider, result.Reference)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock payment: %w", err)
+		}
+
+		if !slices.Contains(paymentTransitions[payment.Status], result.Status) {
+			return &ApplyOutcome{Payment: &payment, Duplicate: true}, nil
+		}
+
+		raw, err := json.Marshal(result.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode payment result: %w", err)
+		}
+
+		succeeded := result.Status == db.PaymentStatusAuthorized || result.Status == db.PaymentStatusCaptured
+		var amountAuthorized, amountCaptured *int64
+		var capturedAt *time.Time
+		if succeeded {
+			amountAuthorized = &result.Amount
+		}
+		if result.Status == db.PaymentStatusCaptured {
+			amountCaptured = &result.Amount
+			capturedAt = &result.PaidAt
+			if result.PaidAt.IsZero() {
+				now := time.Now()
+				capturedAt = &now
+			}
+		}
+
+		var updated db.Payment
+		updateQuery := fmt.Sprintf(`
+			UPDATE payments
+			SET
+				status = $2,
+				provider_txn_id = COALESCE(NULLIF($3, ''), provider_txn_id),
+				raw_response = $4,
+				amount_authorized = COALESCE($5, amount_authorized),
+				amount_captured = COALESCE($6, amount_captured),
+				captured_at = COALESCE($7, captured_at),
+				updated_at = NOW()
+			WHERE id = $1
+			RETURNING %s
+		`, paymentColumns)
+		if err := tx.GetContext(
+			ctx,
+			&updated,
+			updateQuery,
+			payment.ID,
+			result.Status,
+			result.ProviderTxnID,
+			raw,
+			amountAuthorized,
+			amountCaptured,
+			capturedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		outcome := &ApplyOutcome{Payment: &updated}
+		if !succeeded {
+			return outcome, nil
+		}
+
+		// A second successful payment must not take stock again, the lock makes concurrent
+		// payments of the same order wait for this one.
+		order, err := dao.orders.GetOrderForUpdateTx(ctx, tx, payment.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if order.Status != db.OrderStatusPendingPayment {
+			outcome.Review = fmt.Sprintf("order #%d is %s, the payment didn't change it", order.OrderNumber, order.Status)
+			return outcome, nil
+		}
+
+		total, err := WholeAmount(order.GrandTotal)
+		if err != nil {
+			return nil, err
+		}
+		if total != result.Amount {
+			outcome.Review = fmt.Sprintf("paid %d but order #%d is %d", result.Amount, order.OrderNumber, total)
+			return outcome, nil
+		}
+
+		if _, err := dao.orders.TransitionStatusTx(ctx, tx, orders.TransitionParams{
+			OrderID:  order.ID,
+			To:       db.OrderStatusPaid,
+			Actor:    db.StatusActorWebhook,
+			ActorRef: fmt.Sprintf("%s:%s", provider, result.Reference),
+		}); err != nil {
+			return nil, err
+		}
+
+		outcome.Shortages, err = dao.orders.CommitStockTx(ctx, tx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		outcome.OrderPaid = true
+
+		return outcome, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*ApplyOutcome), nil
+}
+
+const refundColumns = `
+	id,
+	order_id,
+	payment_id,
+	status,
+	currency,
+	amount,
+	restock,
+	reason,
+	actor_ref,
+	raw_response,
+	refunded_at,
+	created_at,
+	updated_at
+`
+
+// CreateRefund records a pending refund of the order's captured payment, which must have been
+// made with provider. Pending and succeeded refunds count as refunded when working out what
+// is left. Canceled orders are refunded without restocking, see checkRefundable.
+func (dao *PaymentDAO) CreateRefund(ctx context.Context, provider string, p RefundOrderParams) (*PendingRefund, error) {
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		// The order lock keeps refunds of the same order from racing.
+		order, err := dao.orders.GetOrderForUpdateTx(ctx, tx, p.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRefundable(order, p.Restock); err != nil {
+			return nil, err
+		}
+
+		// The first captured payment paid the order, later ones are left to staff.
+		var payment db.Payment
+		paymentQuery := fmt.Sprintf(`
+			SELECT %s
+			FROM payments
+			WHERE order_id = $1 AND status = 'captured'
+			ORDER BY id
+			LIMIT 1
+		`, paymentColumns)
+		err = tx.GetContext(ctx, &payment, paymentQuery, order.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: order #%d has no captured payment", ErrOrderNotRefundable, order.OrderNumber)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get captured payment: %w", err)
+		}
+		if payment.Provider != provider {
+			return nil, fmt.Errorf("%w: order #%d was paid through %s", ErrOrderNotRefundable, order.OrderNumber, payment.Provider)
+		}
+
+		captured, err := WholeAmount(payment.AmountCaptured)
+		if err != nil {
+			return nil, err
+		}
+		var refunded int64
+		refundedQuery := `
+			SELECT COALESCE(SUM(amount), 0)::bigint
+			FROM refunds
+			WHERE payment_id = $1 AND status <> 'failed'
+		`
+		if err := tx.GetContext(ctx, &refunded, refundedQuery, payment.ID); err != nil {
+			return nil, fmt.Errorf("failed to sum refunds: %w", err)
+		}
+
+		var items []refundableItem
+		itemsQuery := `
+			SELECT
+				oi.id,
+				oi.unit_price,
+				oi.quantity,
+				COALESCE((
+					SELECT SUM(ri.quantity)
+					FROM refund_items ri
+					JOIN refunds r ON r.id = ri.refund_id
+					WHERE ri.order_item_id = oi.id AND r.status <> 'failed'
+				), 0)::int AS refunded
+			FROM order_items oi
+			WHERE oi.order_id = $1
+			ORDER BY oi.id
+		`
+		if err := tx.SelectContext(ctx, &items, itemsQuery, order.ID); err != nil {
+			return nil, fmt.Errorf("failed to get refundable items: %w", err)
+		}
+
+		plan, err := planRefund(items, p.Items, captured-refunded)
+		if err != nil {
+			return nil, err
+		}
+
+		var refund db.Refund
+		refundQuery := fmt.Sprintf(`
+			INSERT INTO refunds (order_id, payment_id, currency, amount, restock, reason, actor_ref)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+			RETURNING %s
+		`, refundColumns)
+		if err := tx.GetContext(
+			ctx,
+			&refund,
+			refundQuery,
+			order.ID,
+			payment.ID,
+			payment.Currency,
+			plan.Amount,
+			p.Restock,
+			p.Reason,
+			p.ActorRef,
+		); err != nil {
+			return nil, fmt.Errorf("failed to create refund: %w", err)
+		}
+
+		itemQuery := `
+			INSERT INTO refund_items (refund_id, order_item_id, quantity, amount)
+			VALUES ($1, $2, $3, $4)
+		`
+		for _, line := range plan.Lines {
+			if _, err := tx.ExecContext(ctx, itemQuery, refund.ID, line.OrderItemID, line.Quantity, line.Amount); err != nil {
+				return nil, fmt.Errorf("failed to create refund item: %w", err)
+			}
+		}
+
+		return &PendingRefund{Refund: &refund, Payment: &payment, Amount: plan.Amount}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*PendingRefund), nil
+}
+
+// FailRefund marks a pending refund the provider turned down as failed, its items may be
+// refunded again
+func (dao *PaymentDAO) FailRefund(ctx context.Context, refundID int64, cause error) error {
+	raw, err := json.Marshal(map[string]string{"error": cause.Error()})
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE refunds
+		SET status = 'failed', raw_response = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+	if _, err := dao.db.ExecContext(ctx, query, refundID, raw); err != nil {
+		return fmt.Errorf("failed to mark refund %d failed: %w", refundID, err)
+	}
+	return nil
+}
+
+// CompleteRefund marks a pending refund succeeded in one transaction with what follows from
+// it: the items go back to stock when the refund restocks, and once no item is left to refund
+// the payment and the order become refunded.
+func (dao *PaymentDAO) CompleteRefund(ctx context.Context, refundID int64, result *RefundResult) (*RefundOutcome, error) {
+	raw := result.Raw
+	if raw == nil {
+		raw = map[string]string{"message": result.Message}
+	}
+	rawJSON, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refund result: %w", err)
+	}
+
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var refund db.Refund
+		updateQuery := fmt.Sprintf(`
+			UPDATE refunds
+			SET
+				status = 'succeeded',
+				raw_response = $2,
+				refunded_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+			RETURNING %s
+		`, refundColumns)
+		err := tx.GetContext(ctx, &refund, updateQuery, refundID, rawJSON)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("refund %d is not pending", refundID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to complete refund: %w", err)
+		}
+
+		order, err := dao.orders.GetOrderForUpdateTx(ctx, tx, refund.OrderID)
+		if err != nil {
+			return nil, err
+		}
+
+		// An order canceled while the refund was pending put the items back already.
+		if refund.Restock && order.Status != db.OrderStatusCanceled {
+			if err := dao.orders.RestockRefundTx(ctx, tx, refund.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		outcome := &RefundOutcome{Refund: &refund, Items: make([]db.RefundItem, 0)}
+		itemsQuery := `
+			SELECT id, refund_id, order_item_id, quantity, amount
+			FROM refund_items
+			WHERE refund_id = $1
+			ORDER BY id
+		`
+		if err := tx.SelectContext(ctx, &outcome.Items, itemsQuery, refund.ID); err != nil {
+			return nil, fmt.Errorf("failed to get refund items: %w", err)
+		}
+
+		var full bool
+		fullQuery := `
+			SELECT NOT EXISTS (
+				SELECT 1
+				FROM order_items oi
+				WHERE oi.order_id = $1
+					AND oi.quantity > COALESCE((
+						SELECT SUM(ri.quantity)
+						FROM refund_items ri
+						JOIN refunds r ON r.id = ri.refund_id
+						WHERE ri.order_item_id = oi.id AND r.status = 'succeeded'
+					), 0)
+			)
+		`
+		if err := tx.GetContext(ctx, &full, fullQuery, order.ID); err != nil {
+			return nil, fmt.Errorf("failed to check refunded items: %w", err)
+		}
+		if !full {
+			return outcome, nil
+		}
+
+		paymentQuery := `
+			UPDATE payments
+			SET status = 'refunded', updated_at = NOW()
+			WHERE id = $1
+		`
+		if _, err := tx.ExecContext(ctx, paymentQuery, refund.PaymentID); err != nil {
+			return nil, fmt.Errorf("failed to mark payment refunded: %w", err)
+		}
+
+		// The money is back already, an order that meanwhile moved on keeps its status.
+		if !orders.CanTransition(order.Status, db.OrderStatusRefunded) {
+			return outcome, nil
+		}
+		if _, err := dao.orders.TransitionStatusTx(ctx, tx, orders.TransitionParams{
+			OrderID:  order.ID,
+			To:       db.OrderStatusRefunded,
+			Actor:    db.StatusActorStaff,
+			ActorRef: refund.ActorRef.String,
+			Note:     refund.Reason.String,
+		}); err != nil {
+			return nil, err
+		}
+		outcome.OrderRefunded = true
+
+		return outcome, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*RefundOutcome), nil
+}
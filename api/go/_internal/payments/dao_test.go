@@ -0,0 +1,72 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db/dbtest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyResultIsIdempotent(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx := context.Background()
+
+	// ApplyResult commits its own transaction, the rows are deleted afterwards instead.
+	productID := dbtest.Product(t, conn, 5, 2)
+	orderID, _ := dbtest.Order(t, conn, db.OrderStatusPendingPayment, dbtest.Item{ProductID: productID, Quantity: 2})
+	t.Cleanup(func() {
+		conn.Exec(`DELETE FROM orders WHERE id = $1`, orderID)
+		conn.Exec(`DELETE FROM products WHERE id = $1`, productID)
+	})
+	reference := fmt.Sprintf("T%d", orderID)
+	dbtest.Payment(t, conn, orderID, ProviderFake, reference, db.PaymentStatusInitiated)
+
+	dao := NewPaymentDAO(PaymentDAOParams{DB: conn, Orders: orders.NewOrderDAO(orders.OrderDAOParams{DB: conn})})
+	result := &Result{
+		Reference:     reference,
+		ProviderTxnID: "TXN1",
+		Status:        db.PaymentStatusCaptured,
+		Amount:        200,
+	}
+
+	stock := func() (count, reserved int32) {
+		row := conn.QueryRow(`SELECT stock_count, reserved_count FROM products WHERE id = $1`, productID)
+		require.NoError(t, row.Scan(&count, &reserved))
+		return count, reserved
+	}
+
+	first, err := dao.ApplyResult(ctx, ProviderFake, result)
+	require.NoError(t, err)
+	assert.True(t, first.OrderPaid)
+	assert.False(t, first.Duplicate)
+	count, reserved := stock()
+	assert.Equal(t, int32(3), count)
+	assert.Equal(t, int32(0), reserved)
+
+	again, err := dao.ApplyResult(ctx, ProviderFake, result)
+	require.NoError(t, err)
+	assert.True(t, again.Duplicate)
+	assert.False(t, again.OrderPaid)
+	assert.Equal(t, db.PaymentStatusCaptured, again.Payment.Status)
+	count, _ = stock()
+	assert.Equal(t, int32(3), count, "the repeated result took stock again")
+
+	// A second payment of the paid order is recorded for review, the stock stays.
+	dbtest.Payment(t, conn, orderID, ProviderFake, reference+"B", db.PaymentStatusInitiated)
+	other, err := dao.ApplyResult(ctx, ProviderFake, &Result{Reference: reference + "B", Status: db.PaymentStatusCaptured, Amount: 200})
+	require.NoError(t, err)
+	assert.False(t, other.OrderPaid)
+	assert.NotEmpty(t, other.Review)
+	count, _ = stock()
+	assert.Equal(t, int32(3), count)
+
+	var status db.OrderStatus
+	require.NoError(t, conn.Get(&status, `SELECT status FROM orders WHERE id = $1`, orderID))
+	assert.Equal(t, db.OrderStatusPaid, status)
+}
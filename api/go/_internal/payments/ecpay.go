@@ -0,0 +1,299 @@
+package payments
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+const (
+	ecpayCheckoutPath = "/Cashier/AioCheckOut/V5"
+	ecpayQueryPath    = "/Cashier/QueryTradeInfo/V5"
+	ecpayActionPath   = "/CreditDetail/DoAction"
+
+	ecpayTimeLayout = "2006/01/02 15:04:05"
+
+	// ECPay limits, ItemName is truncated rather than failing the checkout
+	ecpayMaxItemName  = 400
+	ecpayMaxTradeDesc = 200
+
+	// ecpayAck is the body ECPay expects back from the ReturnURL, it retries otherwise
+	ecpayAck = "1|OK"
+)
+
+// ecpayLocation is the time zone ECPay dates are written in
+var ecpayLocation = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// ECPayProvider takes payments through the ECPay all-in-one (AIO) payment page
+type ECPayProvider struct {
+	merchantID    string
+	hashKey       string
+	hashIV        string
+	baseURL       string
+	returnURL     string
+	clientBackURL string
+	client        *http.Client
+}
+
+func NewECPayProvider(cfg *configs.Config) (*ECPayProvider, error) {
+	ecpay := cfg.Payments.ECPay
+	if ecpay.MerchantID == "" || ecpay.HashKey == "" || ecpay.HashIV == "" {
+		return nil, errors.New("PAYMENTS_ECPAY_MERCHANT_ID, PAYMENTS_ECPAY_HASH_KEY and PAYMENTS_ECPAY_HASH_IV are required")
+	}
+	if cfg.Payments.ReturnURL == "" {
+		return nil, errors.New("PAYMENTS_RETURN_URL is required, ECPay posts payment results to it")
+	}
+
+	return &ECPayProvider{
+		merchantID:    ecpay.MerchantID,
+		hashKey:       ecpay.HashKey,
+		hashIV:        ecpay.HashIV,
+		baseURL:       strings.TrimSuffix(ecpay.BaseURL, "/"),
+		returnURL:     cfg.Payments.ReturnURL,
+		clientBackURL: cfg.Payments.ClientBackURL,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (p *ECPayProvider) Name() string {
+	return ProviderECPay
+}
+
+func (p *ECPayProvider) CreateCheckout(ctx context.Context, params CheckoutParams) (*Checkout, error) {
+	if params.Currency != "TWD" {
+		return nil, fmt.Errorf("ECPay only takes TWD, not %s", params.Currency)
+	}
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("invalid payment amount %d", params.Amount)
+	}
+
+	fields := map[string]string{
+		"MerchantID":        p.merchantID,
+		"MerchantTradeNo":   params.Reference,
+		"MerchantTradeDate": params.CreatedAt.In(ecpayLocation).Format(ecpayTimeLayout),
+		"PaymentType":       "aio",
+		"TotalAmount":       strconv.FormatInt(params.Amount, 10),
+		"TradeDesc":         truncate(params.Description, ecpayMaxTradeDesc),
+		"ItemName":          truncate(strings.Join(params.ItemNames, "#"), ecpayMaxItemName),
+		"ReturnURL":         p.returnURL,
+		"ChoosePayment":     "Credit", // refunds go through /CreditDetail/DoAction, which only knows card payments
+		"EncryptType":       "1",
+		"CustomField1":      strconv.FormatInt(params.OrderNumber, 10),
+	}
+	if p.clientBackURL != "" {
+		fields["ClientBackURL"] = strings.ReplaceAll(p.clientBackURL, "{order_number}", strconv.FormatInt(params.OrderNumber, 10))
+	}
+	fields["CheckMacValue"] = CheckMacValue(fields, p.hashKey, p.hashIV)
+
+	return &Checkout{
+		Reference: params.Reference,
+		Method:    http.MethodPost,
+		URL:       p.baseURL + ecpayCheckoutPath,
+		Fields:    fields,
+	}, nil
+}
+
+func (p *ECPayProvider) VerifyCallback(ctx context.Context, r *http.Request) (*Result, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("failed to parse ECPay callback: %w", err)
+	}
+
+	fields := flatten(r.PostForm)
+	if err := p.verify(fields); err != nil {
+		return nil, err
+	}
+
+	result, err := ecpayResult(fields, fields["RtnCode"] == "1")
+	if err != nil {
+		return nil, err
+	}
+	result.Ack = ecpayAck
+	return result, nil
+}
+
+func (p *ECPayProvider) Query(ctx context.Context, reference string) (*Result, error) {
+	fields, err := p.post(ctx, ecpayQueryPath, map[string]string{
+		"MerchantID":      p.merchantID,
+		"MerchantTradeNo": reference,
+		"TimeStamp":       strconv.FormatInt(time.Now().Unix(), 10),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.verify(fields); err != nil {
+		return nil, err
+	}
+
+	// TradeStatus is 1 once paid, 0 while waiting for the shopper, anything else failed.
+	result, err := ecpayResult(fields, fields["TradeStatus"] == "1")
+	if err != nil {
+		return nil, err
+	}
+	if fields["TradeStatus"] == "0" {
+		result.Status = db.PaymentStatusInitiated
+	}
+	return result, nil
+}
+
+// Refund refunds a credit card payment, ECPay only accepts it once the payment is settled
+func (p *ECPayProvider) Refund(ctx context.Context, params RefundParams) (*RefundResult, error) {
+	fields, err := p.post(ctx, ecpayActionPath, map[string]string{
+		"MerchantID":      p.merchantID,
+		"MerchantTradeNo": params.Reference,
+		"TradeNo":         params.ProviderTxnID,
+		"Action":          "R",
+		"TotalAmount":     strconv.FormatInt(params.Amount, 10),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if fields["RtnCode"] != "1" {
+		return nil, fmt.Errorf("%w: %s %s", ErrRefundRejected, fields["RtnCode"], fields["RtnMsg"])
+	}
+
+	return &RefundResult{Message: fields["RtnMsg"], Raw: fields}, nil
+}
+
+// post signs and sends fields to an ECPay API, which answers with a form encoded body
+func (p *ECPayProvider) post(ctx context.Context, path string, fields map[string]string) (map[string]string, error) {
+	fields["CheckMacValue"] = CheckMacValue(fields, p.hashKey, p.hashIV)
+
+	form := url.Values{}
+	for k, v := range fields {
+		form.Set(k, v)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call ECPay %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ECPay %s response: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECPay %s returned %d: %s", path, resp.StatusCode, body)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ECPay %s response: %w", path, err)
+	}
+	return flatten(values), nil
+}
+
+func (p *ECPayProvider) verify(fields map[string]string) error {
+	got := fields["CheckMacValue"]
+	want := CheckMacValue(fields, p.hashKey, p.hashIV)
+	if got == "" || subtle.ConstantTimeCompare([]byte(strings.ToUpper(got)), []byte(want)) != 1 {
+		return ErrInvalidSignature
+	}
+	if fields["MerchantID"] != p.merchantID {
+		return fmt.Errorf("%w: merchant %s", ErrInvalidSignature, fields["MerchantID"])
+	}
+	return nil
+}
+
+// ecpayResult reads the fields callbacks and trade queries have in common
+func ecpayResult(fields map[string]string, paid bool) (*Result, error) {
+	amount, err := strconv.ParseInt(fields["TradeAmt"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECPay TradeAmt %q", fields["TradeAmt"])
+	}
+
+	result := &Result{
+		Reference:     fields["MerchantTradeNo"],
+		ProviderTxnID: fields["TradeNo"],
+		Status:        db.PaymentStatusFailed,
+		Amount:        amount,
+		Simulated:     fields["SimulatePaid"] == "1",
+		Message:       fields["RtnMsg"],
+		Raw:           fields,
+	}
+	if paid {
+		result.Status = db.PaymentStatusCaptured
+		if paidAt, err := time.ParseInLocation(ecpayTimeLayout, fields["PaymentDate"], ecpayLocation); err == nil {
+			result.PaidAt = paidAt
+		}
+	}
+
+	return result, nil
+}
+
+// CheckMacValue signs ECPay fields: the fields sorted by name between HashKey and HashIV,
+// URL encoded the way .NET does it, lowercased and hashed with SHA256. A CheckMacValue among
+// fields is left out.
+func CheckMacValue(fields map[string]string, hashKey, hashIV string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if k != "CheckMacValue" {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	var sb strings.Builder
+	sb.WriteString("HashKey=" + hashKey)
+	for _, k := range keys {
+		sb.WriteString("&" + k + "=" + fields[k])
+	}
+	sb.WriteString("&HashIV=" + hashIV)
+
+	encoded := strings.ToLower(dotNetURLEncode(sb.String()))
+	sum := sha256.Sum256([]byte(encoded))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// dotNetURLEncode matches HttpUtility.UrlEncode, which ECPay signs with: like
+// url.QueryEscape but "!*()" are kept and "~" is escaped
+func dotNetURLEncode(s string) string {
+	return strings.NewReplacer(
+		"%21", "!",
+		"%2A", "*",
+		"%28", "(",
+		"%29", ")",
+		"~", "%7E",
+	).Replace(url.QueryEscape(s))
+}
+
+func flatten(values url.Values) map[string]string {
+	fields := make(map[string]string, len(values))
+	for k := range values {
+		fields[k] = values.Get(k)
+	}
+	return fields
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+var _ Provider = (*ECPayProvider)(nil)
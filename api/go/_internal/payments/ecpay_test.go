@@ -0,0 +1,150 @@
+package payments
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ECPay stage test merchant
+const (
+	testMerchantID = "3002607"
+	testHashKey    = "pwFHCqoQZGmho4w6"
+	testHashIV     = "EkRm7iFT261dpevs"
+)
+
+func TestCheckMacValue(t *testing.T) {
+	// Example from the ECPay AIO documentation
+	fields := map[string]string{
+		"ChoosePayment":     "ALL",
+		"EncryptType":       "1",
+		"ItemName":          "Apple iphone 15",
+		"MerchantID":        testMerchantID,
+		"MerchantTradeDate": "2023/03/12 15:30:23",
+		"MerchantTradeNo":   "ecpay20230312153023",
+		"PaymentType":       "aio",
+		"ReturnURL":         "https://www.ecpay.com.tw/receive.php",
+		"TotalAmount":       "30000",
+		"TradeDesc":         "促銷方案",
+	}
+
+	want := "6C51C9E6888DE861FD62FB1DD17029FC742634498FD813DC43D4243B5685B840"
+	assert.Equal(t, want, CheckMacValue(fields, testHashKey, testHashIV))
+
+	fields["CheckMacValue"] = "ignored"
+	assert.Equal(t, want, CheckMacValue(fields, testHashKey, testHashIV), "with a CheckMacValue field")
+}
+
+func TestDotNetURLEncode(t *testing.T) {
+	assert.Equal(t, "a+b-c_d.e!f*g(h)i%7Ej%2Fk", dotNetURLEncode("a b-c_d.e!f*g(h)i~j/k"))
+}
+
+func newTestECPay(t *testing.T) *ECPayProvider {
+	t.Helper()
+
+	var cfg configs.Config
+	cfg.Payments.ReturnURL = "https://api.example.com/v1/webhooks/payments/ecpay"
+	cfg.Payments.ClientBackURL = "https://shop.example.com/orders/{order_number}"
+	cfg.Payments.ECPay.MerchantID = testMerchantID
+	cfg.Payments.ECPay.HashKey = testHashKey
+	cfg.Payments.ECPay.HashIV = testHashIV
+	cfg.Payments.ECPay.BaseURL = "https://payment-stage.ecpay.com.tw"
+
+	p, err := NewECPayProvider(&cfg)
+	require.NoError(t, err)
+	return p
+}
+
+func TestECPayCreateCheckout(t *testing.T) {
+	p := newTestECPay(t)
+
+	checkout, err := p.CreateCheckout(context.Background(), CheckoutParams{
+		Reference:   "K1042ABCDEFGH",
+		OrderNumber: 1042,
+		Amount:      1420,
+		Currency:    "TWD",
+		Description: "KikiChoice 訂單 #1042",
+		ItemNames:   []string{"Kivy 貓抓板 x 1", "逗貓棒 x 2"},
+		CreatedAt:   time.Date(2025, 7, 3, 1, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://payment-stage.ecpay.com.tw/Cashier/AioCheckOut/V5", checkout.URL)
+	assert.Equal(t, http.MethodPost, checkout.Method)
+
+	want := map[string]string{
+		"MerchantTradeNo":   "K1042ABCDEFGH",
+		"MerchantTradeDate": "2025/07/03 09:30:00",
+		"TotalAmount":       "1420",
+		"ItemName":          "Kivy 貓抓板 x 1#逗貓棒 x 2",
+		"ClientBackURL":     "https://shop.example.com/orders/1042",
+		"ChoosePayment":     "Credit",
+	}
+	for k, v := range want {
+		assert.Equal(t, v, checkout.Fields[k], "field %s", k)
+	}
+	assert.Equal(t, CheckMacValue(checkout.Fields, testHashKey, testHashIV), checkout.Fields["CheckMacValue"], "checkout is not signed")
+
+	_, err = p.CreateCheckout(context.Background(), CheckoutParams{Reference: "K1", Amount: 10, Currency: "USD"})
+	assert.Error(t, err, "in USD")
+}
+
+func TestECPayVerifyCallback(t *testing.T) {
+	p := newTestECPay(t)
+
+	fields := map[string]string{
+		"MerchantID":      testMerchantID,
+		"MerchantTradeNo": "K1042ABCDEFGH",
+		"RtnCode":         "1",
+		"RtnMsg":          "交易成功",
+		"TradeNo":         "2507030930001234",
+		"TradeAmt":        "1420",
+		"PaymentDate":     "2025/07/03 09:35:12",
+		"PaymentType":     "Credit_CreditCard",
+		"SimulatePaid":    "0",
+	}
+	callback := func(fields map[string]string) *http.Request {
+		form := url.Values{}
+		for k, v := range fields {
+			form.Set(k, v)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/payments/ecpay", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	fields["CheckMacValue"] = CheckMacValue(fields, testHashKey, testHashIV)
+	result, err := p.VerifyCallback(context.Background(), callback(fields))
+	require.NoError(t, err)
+	assert.Equal(t, db.PaymentStatusCaptured, result.Status)
+	assert.Equal(t, int64(1420), result.Amount)
+	assert.Equal(t, "2507030930001234", result.ProviderTxnID)
+	assert.Equal(t, "1|OK", result.Ack)
+	assert.True(t, result.PaidAt.Equal(time.Date(2025, 7, 3, 1, 35, 12, 0, time.UTC)), "PaidAt = %v", result.PaidAt)
+
+	fields["TradeAmt"] = "1"
+	_, err = p.VerifyCallback(context.Background(), callback(fields))
+	assert.ErrorIs(t, err, ErrInvalidSignature, "with a tampered amount")
+
+	fields["TradeAmt"] = "1420"
+	fields["RtnCode"] = "10100058"
+	fields["CheckMacValue"] = CheckMacValue(fields, testHashKey, testHashIV)
+	result, err = p.VerifyCallback(context.Background(), callback(fields))
+	require.NoError(t, err, "failed payment")
+	assert.Equal(t, db.PaymentStatusFailed, result.Status, "failed payment")
+}
+
+func TestNewReference(t *testing.T) {
+	ref := NewReference(9999999999, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.LessOrEqual(t, len(ref), 20, "%s is longer than ECPay's 20 characters", ref)
+	assert.Regexp(t, `^[0-9A-Z]+$`, ref)
+}
@@ -0,0 +1,148 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+// FakeProvider keeps payments in memory and takes whatever result it is posted, for tests and
+// local development. Use Callback to build the request of a payment result.
+type FakeProvider struct {
+	mu       sync.Mutex
+	payments map[string]*Result
+	refunds  map[string]int64
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		payments: make(map[string]*Result),
+		refunds:  make(map[string]int64),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (p *FakeProvider) CreateCheckout(ctx context.Context, params CheckoutParams) (*Checkout, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("invalid payment amount %d", params.Amount)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.payments[params.Reference] = &Result{
+		Reference: params.Reference,
+		Status:    db.PaymentStatusInitiated,
+		Amount:    params.Amount,
+	}
+
+	return &Checkout{
+		Reference: params.Reference,
+		Method:    http.MethodPost,
+		URL:       "https://payments.invalid/checkout",
+		Fields: map[string]string{
+			"reference": params.Reference,
+			"amount":    strconv.FormatInt(params.Amount, 10),
+		},
+	}, nil
+}
+
+// VerifyCallback reads the form Callback builds, there is no signature to check
+func (p *FakeProvider) VerifyCallback(ctx context.Context, r *http.Request) (*Result, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	amount, err := strconv.ParseInt(r.PostForm.Get("amount"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", r.PostForm.Get("amount"))
+	}
+
+	result := &Result{
+		Reference:     r.PostForm.Get("reference"),
+		ProviderTxnID: r.PostForm.Get("txn_id"),
+		Status:        db.PaymentStatus(r.PostForm.Get("status")),
+		Amount:        amount,
+		Raw:           flatten(r.PostForm),
+		Ack:           "OK",
+	}
+	if result.Status == db.PaymentStatusCaptured {
+		result.PaidAt = time.Now()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.payments[result.Reference]; !ok {
+		return nil, fmt.Errorf("%w: unknown reference %s", ErrInvalidSignature, result.Reference)
+	}
+	stored := *result
+	p.payments[result.Reference] = &stored
+
+	return result, nil
+}
+
+func (p *FakeProvider) Query(ctx context.Context, reference string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result, ok := p.payments[reference]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment reference %s", reference)
+	}
+	copied := *result
+	return &copied, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, params RefundParams) (*RefundResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result, ok := p.payments[params.Reference]
+	if !ok || result.Status != db.PaymentStatusCaptured {
+		return nil, fmt.Errorf("%w: %s is not a captured payment", ErrRefundRejected, params.Reference)
+	}
+	if p.refunds[params.Reference]+params.Amount > result.Amount {
+		return nil, fmt.Errorf("%w: refunds exceed the paid amount", ErrRefundRejected)
+	}
+	p.refunds[params.Reference] += params.Amount
+
+	return &RefundResult{Message: "refunded"}, nil
+}
+
+// Refunded returns how much of the payment with reference was refunded
+func (p *FakeProvider) Refunded(reference string) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.refunds[reference]
+}
+
+// Callback builds the payment result request the provider would post for reference
+func (p *FakeProvider) Callback(target, reference string, status db.PaymentStatus, amount int64) (*http.Request, error) {
+	form := url.Values{
+		"reference": {reference},
+		"txn_id":    {"fake-" + reference},
+		"status":    {string(status)},
+		"amount":    {strconv.FormatInt(amount, 10)},
+	}
+
+	r, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r, nil
+}
+
+var _ Provider = (*FakeProvider)(nil)
@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"errors"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+)
+
+var (
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrOrderNotPayable = errors.New("order is not waiting for payment")
+	ErrInvalidAmount   = errors.New("order amount can't be charged")
+	ErrPaymentNotFound = errors.New("payment not found")
+
+	ErrOrderNotRefundable = errors.New("order can't be refunded")
+	ErrInvalidRefundItem  = errors.New("invalid refund item")
+	ErrNothingToRefund    = errors.New("nothing left to refund")
+	ErrAlreadyRestocked   = errors.New("items are back in stock already")
+)
+
+type CreatePaymentParams struct {
+	OrderID   int64
+	Provider  string
+	Reference string
+	Currency  string
+	// Checkout is kept in raw_response until the provider reports back
+	Checkout *Checkout
+}
+
+// ApplyOutcome is what a payment result changed
+type ApplyOutcome struct {
+	Payment *db.Payment
+	// Duplicate is set when the payment already had the result, nothing was changed
+	Duplicate bool
+	// OrderPaid is set when the result moved the order to paid and committed its stock
+	OrderPaid bool
+	// Review explains why a successful payment didn't pay the order, e.g. a second payment of
+	// an order already paid, staff should refund or follow up
+	Review string
+	// Shortages are the items sold beyond stock when the order was paid
+	Shortages []orders.StockShortage
+}
+
+// RefundItemParams returns Quantity units of an order item
+type RefundItemParams struct {
+	OrderItemID int64
+	Quantity    int32
+}
+
+type RefundOrderParams struct {
+	OrderID int64
+	// Items to refund, none refunds everything not refunded yet
+	Items []RefundItemParams
+	// Restock puts the refunded items back into stock_count
+	Restock  bool
+	Reason   string
+	ActorRef string
+}
+
+// PendingRefund is a refund recorded before the provider is asked for the money
+type PendingRefund struct {
+	Refund  *db.Refund
+	Payment *db.Payment
+	Amount  int64
+}
+
+// RefundOutcome is a refund the provider accepted
+type RefundOutcome struct {
+	Refund *db.Refund
+	Items  []db.RefundItem
+	// OrderRefunded is set when the refund returned the last items and moved the order to
+	// refunded
+	OrderRefunded bool
+}
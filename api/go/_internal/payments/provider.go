@@ -0,0 +1,104 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+// Providers selected by configs.Config Payments.Provider, also stored in payments.provider
+const (
+	ProviderECPay = "ecpay"
+	ProviderFake  = "fake"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid payment signature")
+	ErrRefundRejected   = errors.New("refund rejected by the payment provider")
+)
+
+// Provider takes payments for orders. Amounts are whole units of the currency, TWD has no
+// cents.
+type Provider interface {
+	// Name is stored in payments.provider
+	Name() string
+	// CreateCheckout prepares the payment page of an attempt, nothing is charged yet
+	CreateCheckout(ctx context.Context, p CheckoutParams) (*Checkout, error)
+	// VerifyCallback checks the signature of a payment result the provider posted and parses
+	// it, ErrInvalidSignature when it wasn't sent by the provider
+	VerifyCallback(ctx context.Context, r *http.Request) (*Result, error)
+	// Query asks the provider for the current state of the attempt with reference
+	Query(ctx context.Context, reference string) (*Result, error)
+	// Refund gives back part or all of a captured payment
+	Refund(ctx context.Context, p RefundParams) (*RefundResult, error)
+}
+
+type CheckoutParams struct {
+	// Reference identifies the attempt at the provider, see NewReference
+	Reference   string
+	OrderNumber int64
+	Amount      int64
+	Currency    string
+	Description string
+	ItemNames   []string
+	CreatedAt   time.Time
+}
+
+// Checkout is the payment page: the shopper's browser sends Fields to URL with Method, e.g. as
+// an auto-submitted form
+type Checkout struct {
+	Reference string            `json:"reference"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields"`
+}
+
+// Result is the state of a payment attempt at the provider
+type Result struct {
+	Reference     string
+	ProviderTxnID string
+	// Status is captured once paid, failed when the attempt failed and initiated while the
+	// shopper hasn't paid yet
+	Status db.PaymentStatus
+	Amount int64
+	PaidAt time.Time
+	// Simulated marks payments faked from the provider's test console, never ship those
+	Simulated bool
+	Message   string
+	// Raw holds the fields the provider sent, kept in payments.raw_response
+	Raw map[string]string
+	// Ack is the response body the provider expects from a callback handler
+	Ack string
+}
+
+type RefundParams struct {
+	Reference     string
+	ProviderTxnID string
+	Amount        int64
+}
+
+type RefundResult struct {
+	Message string
+	Raw     map[string]string
+}
+
+// NewProvider returns the provider of the configured name
+func NewProvider(cfg *configs.Config) (Provider, error) {
+	switch cfg.Payments.Provider {
+	case ProviderECPay, "":
+		return NewECPayProvider(cfg)
+	case ProviderFake:
+		// The fake provider approves whatever it is told, it would give orders away.
+		if cfg.ENV == configs.Production {
+			return nil, errors.New("the fake payment provider can't be used in production")
+		}
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Payments.Provider)
+	}
+}
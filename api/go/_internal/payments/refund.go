@@ -0,0 +1,167 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// refundableItem is an order item with the quantity earlier refunds already took
+type refundableItem struct {
+	ID        int64          `json:"id"`
+	UnitPrice pgtype.Numeric `json:"unit_price"`
+	Quantity  int32          `json:"quantity"`
+	Refunded  int32          `json:"refunded"`
+}
+
+type refundLine struct {
+	OrderItemID int64
+	Quantity    int32
+	Amount      int64
+}
+
+// refundPlan is what a refund returns
+type refundPlan struct {
+	Lines  []refundLine
+	Amount int64
+	// Full is set when no item is left to refund afterwards
+	Full bool
+}
+
+// checkRefundable tells whether the order may be refunded. Canceling a paid order put its items
+// back in stock and kept the money, its refunds return the money only.
+func checkRefundable(order *db.Order, restock bool) error {
+	if !orders.CanTransition(order.Status, db.OrderStatusRefunded) {
+		return fmt.Errorf("%w: order #%d is %s", ErrOrderNotRefundable, order.OrderNumber, order.Status)
+	}
+	if restock && order.Status == db.OrderStatusCanceled {
+		return fmt.Errorf("%w: order #%d was canceled", ErrAlreadyRestocked, order.OrderNumber)
+	}
+	return nil
+}
+
+// planRefund works out the refund of the requested items, none meaning everything left. Items
+// are refunded at their unit price, capped at what is left of the payment. The refund taking
+// the last items returns all that is left, e.g. the shipping fee.
+func planRefund(items []refundableItem, requested []RefundItemParams, remaining int64) (*refundPlan, error) {
+	byID := make(map[int64]refundableItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	quantities := make(map[int64]int32)
+	var ids []int64
+	if len(requested) == 0 {
+		for _, item := range items {
+			if left := item.Quantity - item.Refunded; left > 0 {
+				quantities[item.ID] = left
+				ids = append(ids, item.ID)
+			}
+		}
+	}
+	for _, req := range requested {
+		item, ok := byID[req.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: order item %d isn't in the order", ErrInvalidRefundItem, req.OrderItemID)
+		}
+		if req.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity of order item %d must be positive", ErrInvalidRefundItem, item.ID)
+		}
+		if _, seen := quantities[item.ID]; !seen {
+			ids = append(ids, item.ID)
+		}
+		quantities[item.ID] += req.Quantity
+		if left := item.Quantity - item.Refunded; quantities[item.ID] > left {
+			return nil, fmt.Errorf("%w: only %d of order item %d left to refund", ErrInvalidRefundItem, left, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrNothingToRefund
+	}
+
+	plan := &refundPlan{Full: true}
+	for _, item := range items {
+		if item.Refunded+quantities[item.ID] < item.Quantity {
+			plan.Full = false
+		}
+	}
+	for _, id := range ids {
+		price, err := byID[id].UnitPrice.Float64Value()
+		if err != nil || !price.Valid {
+			return nil, fmt.Errorf("%w: unit price of order item %d", ErrInvalidAmount, id)
+		}
+
+		line := refundLine{
+			OrderItemID: id,
+			Quantity:    quantities[id],
+			Amount:      int64(math.Round(price.Float64 * float64(quantities[id]))),
+		}
+		plan.Lines = append(plan.Lines, line)
+		plan.Amount += line.Amount
+	}
+	if plan.Full || plan.Amount > remaining {
+		plan.Amount = max(remaining, 0)
+	}
+
+	return plan, nil
+}
+
+// Refund returns order items and their money through the provider. The refund is recorded as
+// pending before the provider is asked, so the same items can't be refunded twice meanwhile,
+// and failed when the provider turns it down. A refund left pending, e.g. after a timeout, has
+// to be checked with the provider by staff.
+func (s *Service) Refund(ctx context.Context, p RefundOrderParams) (*RefundOutcome, error) {
+	pending, err := s.payments.CreateRefund(ctx, s.provider.Name(), p)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.With(
+		"refund_id", pending.Refund.ID,
+		"order_id", p.OrderID,
+		"reference", pending.Payment.Reference.String,
+		"amount", pending.Amount,
+	)
+
+	// Items taken back after the money was, e.g. when discounts covered them, have nothing to
+	// charge back.
+	result := &RefundResult{Message: "no amount left to refund"}
+	if pending.Amount > 0 {
+		result, err = s.provider.Refund(ctx, RefundParams{
+			Reference:     pending.Payment.Reference.String,
+			ProviderTxnID: pending.Payment.ProviderTxnID.String,
+			Amount:        pending.Amount,
+		})
+		if errors.Is(err, ErrRefundRejected) {
+			if failErr := s.payments.FailRefund(ctx, pending.Refund.ID, err); failErr != nil {
+				logger.Errorw("Failed to record rejected refund", "error", failErr)
+			}
+			return nil, err
+		}
+		if err != nil {
+			logger.Errorw("Refund outcome unknown, left pending", "error", err)
+			return nil, fmt.Errorf("failed to refund payment: %w", err)
+		}
+	}
+
+	outcome, err := s.payments.CompleteRefund(ctx, pending.Refund.ID, result)
+	if err != nil {
+		// The provider has refunded the money, the pending refund tells staff to reconcile.
+		logger.Errorw("Failed to record accepted refund", "error", err)
+		return nil, err
+	}
+
+	logger.Infow("order refunded",
+		"restock", outcome.Refund.Restock,
+		"order_refunded", outcome.OrderRefunded,
+		"actor_ref", p.ActorRef,
+	)
+
+	return outcome, nil
+}
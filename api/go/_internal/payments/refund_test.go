@@ -0,0 +1,92 @@
+package payments
+
+import (
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func price(t *testing.T, s string) pgtype.Numeric {
+	t.Helper()
+
+	var n pgtype.Numeric
+	require.NoError(t, n.Scan(s))
+	return n
+}
+
+func TestPlanRefund(t *testing.T) {
+	// Two items worth 1,000 plus 80 shipping, one unit of item 2 already refunded.
+	items := []refundableItem{
+		{ID: 1, UnitPrice: price(t, "350.00"), Quantity: 2},
+		{ID: 2, UnitPrice: price(t, "150.00"), Quantity: 2, Refunded: 1},
+	}
+	const remaining = 930
+
+	plan, err := planRefund(items, []RefundItemParams{{OrderItemID: 1, Quantity: 1}}, remaining)
+	require.NoError(t, err, "partial")
+	assert.False(t, plan.Full, "partial")
+	assert.Equal(t, int64(350), plan.Amount, "partial")
+	require.Len(t, plan.Lines, 1, "partial")
+	assert.Equal(t, int32(1), plan.Lines[0].Quantity, "partial")
+
+	// Refunding everything left returns the shipping fee as well.
+	plan, err = planRefund(items, nil, remaining)
+	require.NoError(t, err, "full")
+	assert.True(t, plan.Full, "full")
+	assert.Equal(t, int64(remaining), plan.Amount, "full")
+	require.Len(t, plan.Lines, 2, "full")
+	assert.Equal(t, int32(1), plan.Lines[1].Quantity, "full")
+
+	// The same items requested in pieces add up.
+	plan, err = planRefund(items, []RefundItemParams{
+		{OrderItemID: 1, Quantity: 1},
+		{OrderItemID: 2, Quantity: 1},
+		{OrderItemID: 1, Quantity: 1},
+	}, remaining)
+	require.NoError(t, err, "pieces")
+	assert.True(t, plan.Full, "pieces")
+	assert.Equal(t, int64(remaining), plan.Amount, "pieces")
+	assert.Len(t, plan.Lines, 2, "pieces")
+
+	// Discounts may leave less money than the items are worth.
+	plan, err = planRefund(items, []RefundItemParams{{OrderItemID: 1, Quantity: 2}}, 500)
+	require.NoError(t, err, "capped")
+	assert.Equal(t, int64(500), plan.Amount, "capped")
+
+	for _, bad := range [][]RefundItemParams{
+		{{OrderItemID: 3, Quantity: 1}},
+		{{OrderItemID: 2, Quantity: 2}},
+		{{OrderItemID: 1, Quantity: 0}},
+	} {
+		_, err := planRefund(items, bad, remaining)
+		assert.ErrorIs(t, err, ErrInvalidRefundItem, "%+v", bad)
+	}
+
+	done := []refundableItem{{ID: 1, UnitPrice: price(t, "350.00"), Quantity: 2, Refunded: 2}}
+	_, err = planRefund(done, nil, 0)
+	assert.ErrorIs(t, err, ErrNothingToRefund, "refunded order")
+}
+
+func TestRefundAfterCancel(t *testing.T) {
+	order := &db.Order{OrderNumber: 1042, Status: db.OrderStatusPaid}
+
+	// Canceling a paid order restocks it and keeps the money, a refund returns it.
+	require.True(t, orders.CanTransition(order.Status, db.OrderStatusCanceled), "a paid order can't be canceled")
+	order.Status = db.OrderStatusCanceled
+
+	assert.NoError(t, checkRefundable(order, false), "canceled order")
+	assert.True(t, orders.CanTransition(order.Status, db.OrderStatusRefunded), "a canceled order can't become refunded")
+
+	// The cancel put the items back already.
+	assert.ErrorIs(t, checkRefundable(order, true), ErrAlreadyRestocked, "canceled order with restock")
+
+	for _, status := range []db.OrderStatus{db.OrderStatusPendingPayment, db.OrderStatusRefunded} {
+		assert.ErrorIs(t, checkRefundable(&db.Order{Status: status}, false), ErrOrderNotRefundable, "%s order", status)
+	}
+	assert.NoError(t, checkRefundable(&db.Order{Status: db.OrderStatusProcessing}, true), "processing order with restock")
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
+	"time"
 
 	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 )
 
 const (
@@ -26,9 +29,6 @@ func NewSharedKeyCredential(cfg *configs.Config) (*azblob.SharedKeyCredential, e
 func NewBlobStorageClient(cfg *configs.Config, cred *azblob.SharedKeyCredential) (*azblob.Client, error) {
 	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.Azure.BlobStorageAccountName)
 
-	log.Printf("serviceURL: %+v", cfg.Azure)
-	log.Println("serviceURL", serviceURL)
-
 	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %v", err)
@@ -50,18 +50,31 @@ func NewBlobStorageWrapperClient(cfg *configs.Config, client *azblob.Client) (*B
 }
 
 func (c *BlobStorageWrapperClient) UploadProductImage(ctx context.Context, blobName string, contentReader io.Reader) (string, error) {
+	return c.UploadBlob(ctx, ProductImageContainerName, blobName, contentReader, "")
+}
+
+// UploadBlob uploads the content to the container and returns its public URL. An empty
+// contentType leaves Azure's default, application/octet-stream.
+func (c *BlobStorageWrapperClient) UploadBlob(ctx context.Context, containerName, blobName string, contentReader io.Reader, contentType string) (string, error) {
+	var opts *azblob.UploadStreamOptions
+	if contentType != "" {
+		opts = &azblob.UploadStreamOptions{
+			HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+		}
+	}
+
 	_, err := c.Client.UploadStream(
 		ctx,
-		ProductImageContainerName,
+		containerName,
 		blobName,
 		contentReader,
-		nil,
+		opts,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to Azure blob storage: %v", err)
 	}
 
-	return c.GetPublicURL(ProductImageContainerName, blobName), nil
+	return c.GetPublicURL(containerName, blobName), nil
 }
 
 func (c *BlobStorageWrapperClient) GetPublicURL(containerName, blobName string) string {
@@ -72,6 +85,57 @@ func (c *BlobStorageWrapperClient) GetPublicURL(containerName, blobName string)
 	)
 }
 
+// GetBlobProperties returns the properties of the blob, e.g. its size and content type
+func (c *BlobStorageWrapperClient) GetBlobProperties(ctx context.Context, containerName, blobName string) (blob.GetPropertiesResponse, error) {
+	blobClient := c.Client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+
+	props, err := blobClient.GetProperties(ctx, nil)
+	if err != nil {
+		return blob.GetPropertiesResponse{}, fmt.Errorf("failed to get properties of blob %s: %w", blobName, err)
+	}
+	return props, nil
+}
+
+// DownloadBlob opens the content of the blob, the caller closes it
+func (c *BlobStorageWrapperClient) DownloadBlob(ctx context.Context, containerName, blobName string) (io.ReadCloser, error) {
+	resp, err := c.Client.DownloadStream(ctx, containerName, blobName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob %s: %w", blobName, err)
+	}
+	return resp.Body, nil
+}
+
+// GetSASURL returns a URL granting the permissions on the blob until expiry, signed with the
+// shared key the client was created with
+func (c *BlobStorageWrapperClient) GetSASURL(containerName, blobName string, permissions sas.BlobPermissions, expiry time.Time) (string, error) {
+	blobClient := c.Client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+
+	url, err := blobClient.GetSASURL(permissions, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign blob %s: %v", blobName, err)
+	}
+	return url, nil
+}
+
+// ListBlobItemsWithPrefix lists all blobs in the container that start with the given prefix,
+// along with their properties
+func (c *BlobStorageWrapperClient) ListBlobItemsWithPrefix(ctx context.Context, containerName, prefix string) ([]*container.BlobItem, error) {
+	pager := c.Client.NewListBlobsFlatPager(containerName, &azblob.ListBlobsFlatOptions{
+		Prefix: &prefix,
+	})
+
+	var items []*container.BlobItem
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %v", err)
+		}
+		items = append(items, resp.Segment.BlobItems...)
+	}
+
+	return items, nil
+}
+
 // ListBlobsWithPrefix lists all blobs in the container that start with the given prefix
 func (c *BlobStorageWrapperClient) ListBlobsWithPrefix(ctx context.Context, containerName, prefix string) ([]string, error) {
 	pager := c.Client.NewListBlobsFlatPager(containerName, &azblob.ListBlobsFlatOptions{
@@ -99,7 +163,7 @@ func (c *BlobStorageWrapperClient) ListBlobsWithPrefix(ctx context.Context, cont
 func (c *BlobStorageWrapperClient) DeleteBlob(ctx context.Context, containerName, blobName string) error {
 	_, err := c.Client.DeleteBlob(ctx, containerName, blobName, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete blob %s: %v", blobName, err)
+		return fmt.Errorf("failed to delete blob %s: %w", blobName, err)
 	}
 	return nil
 }
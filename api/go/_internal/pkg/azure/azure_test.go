@@ -6,7 +6,8 @@ import (
 	"strings"
 	"testing"
 
-	appfx "github/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -19,6 +20,12 @@ type AzureTestSuite struct {
 }
 
 func (s *AzureTestSuite) SetupSuite() {
+	cfg, err := configs.NewConfig(configs.NewViper())
+	require.NoError(s.T(), err)
+	if cfg.Azure.BlobStorageAccountName == "" {
+		s.T().Skip("AZURE_BLOB_STORAGE_ACCOUNT_NAME is not set, the suite uploads to a real storage account")
+	}
+
 	fx.New(
 		appfx.CoreConfigOptions,
 		fx.Provide(
@@ -0,0 +1,205 @@
+// Package i18n holds the bot's message catalog. Messages live in per-locale JSON files embedded
+// in the binary, keyed by message ID, and are rendered with text/template so counts and names
+// can be placed anywhere in the sentence:
+//
+//	"add.image_uploaded": "✅ 圖片已上傳 ({{.Count}}/{{.Max}})"
+//
+// Lookups fall back to DefaultLocale, then to the message ID itself.
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultLocale is used when the user has no preference and their Telegram language isn't supported
+const DefaultLocale = "zh-TW"
+
+// Args are the template values of a message
+type Args map[string]any
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalog maps locale -> message ID -> template
+var catalog = mustLoad()
+
+func mustLoad() map[string]map[string]*template.Template {
+	c, err := load()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func load() (map[string]map[string]*template.Template, error) {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locales: %w", err)
+	}
+
+	c := make(map[string]map[string]*template.Template, len(files))
+	for _, f := range files {
+		locale := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+
+		raw, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read locale %s: %w", locale, err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return nil, fmt.Errorf("failed to parse locale %s: %w", locale, err)
+		}
+
+		c[locale] = make(map[string]*template.Template, len(messages))
+		for id, text := range messages {
+			tmpl, err := template.New(locale + "/" + id).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse message %s in locale %s: %w", id, locale, err)
+			}
+			c[locale][id] = tmpl
+		}
+	}
+
+	if _, ok := c[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("default locale %s is missing", DefaultLocale)
+	}
+
+	return c, nil
+}
+
+// Locales returns the supported locales, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(catalog))
+	for locale := range catalog {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Supported reports whether locale has a catalog
+func Supported(locale string) bool {
+	_, ok := catalog[locale]
+	return ok
+}
+
+// Match maps a Telegram language_code (an IETF tag like "en", "en-US" or "zh-hant") to a
+// supported locale: an exact match first, then any locale with the same base language.
+// It returns "" when nothing matches.
+func Match(languageCode string) string {
+	if languageCode == "" {
+		return ""
+	}
+
+	for _, locale := range Locales() {
+		if strings.EqualFold(locale, languageCode) {
+			return locale
+		}
+	}
+
+	base := baseLanguage(languageCode)
+	for _, locale := range Locales() {
+		if baseLanguage(locale) == base {
+			return locale
+		}
+	}
+
+	return ""
+}
+
+// Matches reports whether input is the text of message id in any locale, so keywords such as
+// 「完成」/ "done" work whatever language the user picked
+func Matches(id, input string) bool {
+	input = strings.TrimSpace(input)
+	for locale := range catalog {
+		if text, ok := render(locale, id, nil); ok && strings.EqualFold(text, input) {
+			return true
+		}
+	}
+	return false
+}
+
+// Localizer renders messages in one locale
+type Localizer struct {
+	locale string
+}
+
+// For returns a Localizer for locale, or for DefaultLocale when locale isn't supported
+func For(locale string) Localizer {
+	if !Supported(locale) {
+		locale = DefaultLocale
+	}
+	return Localizer{locale: locale}
+}
+
+func (l Localizer) Locale() string {
+	if l.locale == "" {
+		return DefaultLocale
+	}
+	return l.locale
+}
+
+// T renders message id with the optional template args
+func (l Localizer) T(id string, args ...Args) string {
+	var data Args
+	if len(args) > 0 {
+		data = args[0]
+	}
+
+	if text, ok := render(l.Locale(), id, data); ok {
+		return text
+	}
+	if text, ok := render(DefaultLocale, id, data); ok {
+		return text
+	}
+	return id
+}
+
+// Error renders err in the locale when it is a message error, otherwise returns err.Error()
+func (l Localizer) Error(err error) string {
+	if msgErr, ok := err.(*Error); ok {
+		return l.T(msgErr.ID)
+	}
+	return err.Error()
+}
+
+// Error is an error whose text is a catalog message, e.g. a validation error shown to the user.
+// Error() renders it in DefaultLocale; use Localizer.Error for the user's locale.
+type Error struct {
+	ID string
+}
+
+func NewError(id string) error {
+	return &Error{ID: id}
+}
+
+func (e *Error) Error() string {
+	return For(DefaultLocale).T(e.ID)
+}
+
+func render(locale, id string, data Args) (string, bool) {
+	tmpl, ok := catalog[locale][id]
+	if !ok {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", false
+	}
+
+	return buf.String(), true
+}
+
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	return base
+}
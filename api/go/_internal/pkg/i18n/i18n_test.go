@@ -0,0 +1,31 @@
+package i18n
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalesDefineTheSameMessages(t *testing.T) {
+	want := messageIDs(DefaultLocale)
+	require.NotEmpty(t, want)
+
+	for _, locale := range Locales() {
+		require.Equal(t, want, messageIDs(locale), "%s should define the same message IDs as %s", locale, DefaultLocale)
+	}
+}
+
+func TestLocalizerFallsBackToDefaultLocale(t *testing.T) {
+	require.Equal(t, DefaultLocale, For("fr").Locale())
+	require.Equal(t, "missing.message", For("en").T("missing.message"))
+}
+
+func messageIDs(locale string) []string {
+	ids := make([]string, 0, len(catalog[locale]))
+	for id := range catalog[locale] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,203 @@
+package imageingest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+var ErrEntityNotFound = errors.New("entity not found")
+
+// Entity is the product or product variant images are attached to
+type Entity struct {
+	Type db.EntityType `json:"entity_type"`
+	ID   int64         `json:"id"`
+	SKU  string        `json:"sku"`
+	Name string        `json:"name"`
+}
+
+// Image is a stored image with the renditions generated from it
+type Image struct {
+	URL        string
+	Width      int
+	Height     int
+	Renditions imageproc.Renditions
+}
+
+// Repository looks up the entities images belong to and records the uploaded images
+type Repository interface {
+	// FindEntity returns the product or variant with the SKU, ErrEntityNotFound if there is none
+	FindEntity(ctx context.Context, entityType db.EntityType, sku string) (Entity, error)
+	// FindVariants returns the variants among skus keyed by SKU
+	FindVariants(ctx context.Context, skus []string) (map[string]Entity, error)
+	// CreateImage records the image for the entity
+	CreateImage(ctx context.Context, entity Entity, image Image, sortOrder int) error
+	// DeleteImages removes the image records of the entity and returns how many were removed
+	DeleteImages(ctx context.Context, entity Entity) (int, error)
+}
+
+// ImageDAO is the Repository of a database
+type ImageDAO struct {
+	db *sqlx.DB
+}
+
+type ImageDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewImageDAO(p ImageDAOParams) *ImageDAO {
+	return &ImageDAO{db: p.DB}
+}
+
+func (dao *ImageDAO) FindEntity(ctx context.Context, entityType db.EntityType, sku string) (Entity, error) {
+	query := `SELECT id, sku, name FROM products WHERE sku = $1 LIMIT 1`
+	if entityType == db.EntityTypeProductVariant {
+		query = `SELECT id, sku, name FROM product_variants WHERE sku = $1 LIMIT 1`
+	}
+
+	entity := Entity{Type: entityType}
+	err := dao.db.QueryRowxContext(ctx, query, sku).Scan(&entity.ID, &entity.SKU, &entity.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entity{}, ErrEntityNotFound
+	}
+	if err != nil {
+		return Entity{}, fmt.Errorf("failed to find %s %s: %w", entityType, sku, err)
+	}
+
+	return entity, nil
+}
+
+func (dao *ImageDAO) FindVariants(ctx context.Context, skus []string) (map[string]Entity, error) {
+	query := `SELECT id, sku, name FROM product_variants WHERE sku = ANY($1)`
+	rows, err := dao.db.QueryxContext(ctx, query, skus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variant SKUs: %w", err)
+	}
+	defer rows.Close()
+
+	variants := make(map[string]Entity)
+	for rows.Next() {
+		entity := Entity{Type: db.EntityTypeProductVariant}
+		if err := rows.Scan(&entity.ID, &entity.SKU, &entity.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan variant row: %w", err)
+		}
+		variants[entity.SKU] = entity
+	}
+
+	return variants, rows.Err()
+}
+
+func (dao *ImageDAO) CreateImage(ctx context.Context, entity Entity, image Image, sortOrder int) error {
+	tx, err := dao.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var imageID int64
+	imageInsertQuery := `
+		INSERT INTO images (url, width, height, renditions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	if err := tx.GetContext(
+		ctx,
+		&imageID,
+		imageInsertQuery,
+		image.URL,
+		image.Width,
+		image.Height,
+		image.Renditions,
+	); err != nil {
+		return fmt.Errorf("failed to insert into images table: %w", err)
+	}
+
+	// The first image is the primary one.
+	entityInsertQuery := `
+		INSERT INTO image_entities (entity_id, image_id, alt_text, is_primary, sort_order, entity_type)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.ExecContext(
+		ctx,
+		entityInsertQuery,
+		entity.ID,
+		imageID,
+		AltText(entity),
+		sortOrder == 0,
+		sortOrder,
+		entity.Type,
+	); err != nil {
+		return fmt.Errorf("failed to insert into image_entities table: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// NextSortOrder returns the sort order after the last image of the entity, 0 when it has none
+func (dao *ImageDAO) NextSortOrder(ctx context.Context, entity Entity) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(sort_order) + 1, 0)
+		FROM image_entities
+		WHERE entity_id = $1 AND entity_type = $2
+	`
+
+	var sortOrder int
+	if err := dao.db.GetContext(ctx, &sortOrder, query, entity.ID, entity.Type); err != nil {
+		return 0, fmt.Errorf("failed to get next sort order: %w", err)
+	}
+	return sortOrder, nil
+}
+
+func (dao *ImageDAO) DeleteImages(ctx context.Context, entity Entity) (int, error) {
+	tx, err := dao.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var imageIDs []int64
+	deleteEntitiesQuery := `
+		DELETE FROM image_entities
+		WHERE entity_id = $1 AND entity_type = $2
+		RETURNING image_id
+	`
+	if err := tx.SelectContext(ctx, &imageIDs, deleteEntitiesQuery, entity.ID, entity.Type); err != nil {
+		return 0, fmt.Errorf("failed to delete image entities: %w", err)
+	}
+
+	if len(imageIDs) > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM images WHERE id = ANY($1)`, imageIDs); err != nil {
+			return 0, fmt.Errorf("failed to delete images: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit cleanup transaction: %w", err)
+	}
+
+	return len(imageIDs), nil
+}
+
+// AltText reads like "Product image for kivy-007" or "Product Variant image for kivy-007-dog"
+func AltText(entity Entity) string {
+	label := "Product"
+	if entity.Type == db.EntityTypeProductVariant {
+		label = "Product Variant"
+	}
+	return fmt.Sprintf("%s image for %s", label, entity.SKU)
+}
+
+var _ Repository = (*ImageDAO)(nil)
@@ -0,0 +1,150 @@
+package imageingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DirectoryType represents the type of directory (product or variant)
+type DirectoryType int
+
+const (
+	ProductDirectory DirectoryType = iota
+	VariantDirectory
+)
+
+// DirectoryInfo contains information about a directory and its images
+type DirectoryInfo struct {
+	Path      string
+	SKU       string
+	Type      DirectoryType
+	ParentSKU string // Only for variants
+	Images    []string
+}
+
+// SupportedExtensions are the image files picked up from the source directory
+var SupportedExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+}
+
+// scanDirectory scans the source directory and classifies directories as product or variant.
+// Images are keyed by the name of the directory holding them, which is the SKU.
+func scanDirectory(sourcePath string, logger *zap.SugaredLogger) (map[string]DirectoryInfo, error) {
+	directories := make(map[string]DirectoryInfo)
+
+	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logger.Warnf("Error accessing path %s: %v", path, err)
+			return nil // Continue processing other files
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if !SupportedExtensions[ext] {
+			logger.Debugf("Skipping unsupported file: %s", path)
+			return nil
+		}
+
+		// Skip if the parent directory is the source directory itself
+		if filepath.Dir(path) == filepath.Clean(sourcePath) {
+			logger.Warnf("Skipping image in root directory: %s", path)
+			return nil
+		}
+
+		if info.Size() == 0 {
+			logger.Warnf("Skipping empty file: %s", path)
+			return nil
+		}
+
+		// Get the parent directory name as potential SKU
+		sku := filepath.Base(filepath.Dir(path))
+
+		dirInfo, exists := directories[sku]
+		if !exists {
+			dirType, parentSKU := classifyDirectory(sku)
+			dirInfo = DirectoryInfo{
+				Path:      filepath.Dir(path),
+				SKU:       sku,
+				Type:      dirType,
+				ParentSKU: parentSKU,
+				Images:    make([]string, 0),
+			}
+		}
+
+		dirInfo.Images = append(dirInfo.Images, path)
+		directories[sku] = dirInfo
+
+		logger.Debugf("Found image: %s (SKU: %s, Type: %v, Size: %d bytes)",
+			filepath.Base(path), sku, dirInfo.Type, info.Size())
+		return nil
+	})
+
+	return directories, err
+}
+
+// classifyDirectory determines if a directory is for a product or variant from its name. The
+// guess is checked against the database by validateVariantDirectories.
+func classifyDirectory(dirName string) (DirectoryType, string) {
+	// Check if directory name contains hyphens (potential variant)
+	parts := strings.Split(dirName, "-")
+	if len(parts) <= 1 {
+		// No hyphens, must be a product directory
+		return ProductDirectory, ""
+	}
+
+	// For variant directories, the parent SKU is everything except the last part
+	// e.g., "kivy-007-dog" -> parent SKU is "kivy-007", variant suffix is "dog"
+	parentSKU := strings.Join(parts[:len(parts)-1], "-")
+
+	// If parent SKU is empty, treat as product directory
+	if parentSKU == "" {
+		return ProductDirectory, ""
+	}
+
+	return VariantDirectory, parentSKU
+}
+
+// validateVariantDirectories turns variant directories whose SKU is not a variant in the
+// database into product directories
+func validateVariantDirectories(ctx context.Context, repo Repository, variantDirs map[string]DirectoryInfo, logger *zap.SugaredLogger) error {
+	if len(variantDirs) == 0 {
+		return nil
+	}
+
+	variantSKUs := make([]string, 0, len(variantDirs))
+	for sku := range variantDirs {
+		variantSKUs = append(variantSKUs, sku)
+	}
+
+	validVariants, err := repo.FindVariants(ctx, variantSKUs)
+	if err != nil {
+		return err
+	}
+
+	validCount := 0
+	for sku, dirInfo := range variantDirs {
+		if _, exists := validVariants[sku]; exists {
+			validCount++
+			logger.Debugf("Validated variant directory: %s", sku)
+			continue
+		}
+
+		logger.Warnf("Variant SKU not found in database, treating as product: %s", sku)
+		dirInfo.Type = ProductDirectory
+		dirInfo.ParentSKU = ""
+		variantDirs[sku] = dirInfo
+	}
+
+	logger.Infof("Validated %d variant directories out of %d", validCount, len(variantDirs))
+	return nil
+}
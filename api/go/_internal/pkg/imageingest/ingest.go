@@ -0,0 +1,391 @@
+package imageingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Ingester uploads product and variant images to the storage and records them in the
+// repository. Stored files are named "<sku>/<nanoid><ext>", their renditions
+// "<sku>/<nanoid>_<size><ext>".
+type Ingester struct {
+	storage storage.BlobStore
+	repo    Repository
+	logger  *zap.SugaredLogger
+}
+
+type IngesterParams struct {
+	fx.In
+
+	Storage storage.BlobStore
+	Repo    Repository
+	Logger  *zap.SugaredLogger
+}
+
+func NewIngester(p IngesterParams) *Ingester {
+	return &Ingester{
+		storage: p.Storage,
+		repo:    p.Repo,
+		logger:  p.Logger,
+	}
+}
+
+// Options of a directory ingestion
+type Options struct {
+	// DryRun reports what would be uploaded and deleted without changing anything
+	DryRun bool
+	// CleanFirst removes the existing images of each SKU before uploading the new ones
+	CleanFirst bool
+	// Mirror, when set, also gets the image records, e.g. a local database that should point
+	// at the same files. Its failures are counted in Result.MirrorErrors and don't stop the run.
+	Mirror Repository
+}
+
+type Result struct {
+	ProcessedProducts     int
+	ProcessedVariants     int
+	UploadedImages        int
+	UploadedProductImages int
+	UploadedVariantImages int
+	SkippedImages         int
+	SkippedProductImages  int
+	SkippedVariantImages  int
+	Errors                []error
+	TotalSizeBytes        int64
+	DryRun                bool
+	MirrorEnabled         bool
+	MirrorErrors          int
+}
+
+func (r *Result) uploaded(entityType db.EntityType) {
+	r.UploadedImages++
+	if entityType == db.EntityTypeProductVariant {
+		r.UploadedVariantImages++
+	} else {
+		r.UploadedProductImages++
+	}
+}
+
+func (r *Result) skipped(entityType db.EntityType, n int) {
+	r.SkippedImages += n
+	if entityType == db.EntityTypeProductVariant {
+		r.SkippedVariantImages += n
+	} else {
+		r.SkippedProductImages += n
+	}
+}
+
+// IngestDirectory uploads the images of sourcePath. Each subdirectory is named after the SKU of
+// the product or variant its images belong to; variant directories are "<parent sku>-<suffix>".
+func (i *Ingester) IngestDirectory(ctx context.Context, sourcePath string, opts Options) (*Result, error) {
+	i.logger.Infof("Starting image upload from path: %s (dry-run: %v)", sourcePath, opts.DryRun)
+
+	result := &Result{
+		Errors:        make([]error, 0),
+		DryRun:        opts.DryRun,
+		MirrorEnabled: opts.Mirror != nil,
+	}
+
+	if err := validateSourcePath(sourcePath); err != nil {
+		return nil, fmt.Errorf("invalid source path: %w", err)
+	}
+
+	directories, err := scanDirectory(sourcePath, i.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	if len(directories) == 0 {
+		i.logger.Warn("No directories found in source path")
+		return result, nil
+	}
+
+	variantDirs := make(map[string]DirectoryInfo)
+	for sku, dirInfo := range directories {
+		if dirInfo.Type == VariantDirectory {
+			variantDirs[sku] = dirInfo
+		}
+	}
+
+	if err := validateVariantDirectories(ctx, i.repo, variantDirs, i.logger); err != nil {
+		return nil, fmt.Errorf("variant validation failed: %w", err)
+	}
+	for sku, dirInfo := range variantDirs {
+		directories[sku] = dirInfo
+	}
+
+	i.logger.Infof("Found %d directories to process, %d of them variants", len(directories), len(variantDirs))
+
+	for sku, dirInfo := range directories {
+		entityType := db.EntityTypeProduct
+		if dirInfo.Type == VariantDirectory {
+			entityType = db.EntityTypeProductVariant
+		}
+
+		if err := i.processDirectory(ctx, entityType, dirInfo, opts, result); err != nil {
+			i.logger.Errorf("Failed to process %s images for SKU %s: %v", entityType, sku, err)
+			result.Errors = append(result.Errors, fmt.Errorf("%s SKU %s: %w", entityType, sku, err))
+			continue
+		}
+
+		if entityType == db.EntityTypeProductVariant {
+			result.ProcessedVariants++
+		} else {
+			result.ProcessedProducts++
+		}
+	}
+
+	status := "completed"
+	if opts.DryRun {
+		status = "completed (DRY RUN - no changes made)"
+	}
+
+	i.logger.Infof("Upload %s. Products: %d, Variants: %d, Total Images: %d (Product: %d, Variant: %d), Skipped: %d, Errors: %d, Total size: %.2f MB",
+		status, result.ProcessedProducts, result.ProcessedVariants, result.UploadedImages,
+		result.UploadedProductImages, result.UploadedVariantImages,
+		result.SkippedImages, len(result.Errors), float64(result.TotalSizeBytes)/(1024*1024))
+
+	return result, nil
+}
+
+// UploadImage processes one image of the entity, stores it with its renditions and records it
+// at sortOrder, the first image being the primary one.
+func (i *Ingester) UploadImage(ctx context.Context, entity Entity, r io.Reader, sortOrder int) (Image, error) {
+	image, err := i.StoreImage(ctx, entity.SKU, r)
+	if err != nil {
+		return Image{}, err
+	}
+
+	if err := i.repo.CreateImage(ctx, entity, image, sortOrder); err != nil {
+		return Image{}, fmt.Errorf("failed to record image: %w", err)
+	}
+
+	return image, nil
+}
+
+// StoreImage processes one image and stores it with its renditions under sku without recording
+// it, for callers that record it themselves.
+func (i *Ingester) StoreImage(ctx context.Context, sku string, r io.Reader) (Image, error) {
+	processed, err := imageproc.Process(r, imageproc.DefaultSizes)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to process image: %w", err)
+	}
+
+	id := i.generateNanoID()
+	blobName := fmt.Sprintf("%s/%s%s", sku, id, imageproc.Extension(processed.Original.Format))
+
+	i.logger.Debugf("Uploading %s with %d renditions", blobName, len(processed.Renditions))
+
+	publicURL, err := i.storage.Upload(
+		ctx,
+		blobName,
+		bytes.NewReader(processed.Original.Data),
+		imageproc.ContentType(processed.Original.Format),
+	)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	image := Image{
+		URL:        publicURL,
+		Width:      processed.Original.Width,
+		Height:     processed.Original.Height,
+		Renditions: make(imageproc.Renditions, 0, len(processed.Renditions)),
+	}
+
+	for _, rendition := range processed.Renditions {
+		renditionName := fmt.Sprintf("%s/%s_%s%s", sku, id, rendition.Name, imageproc.Extension(rendition.Format))
+
+		renditionURL, err := i.storage.Upload(
+			ctx,
+			renditionName,
+			bytes.NewReader(rendition.Data),
+			imageproc.ContentType(rendition.Format),
+		)
+		if err != nil {
+			return Image{}, fmt.Errorf("failed to upload %s %s rendition: %w", rendition.Name, rendition.Format, err)
+		}
+
+		image.Renditions = append(image.Renditions, imageproc.Rendition{
+			Name:   rendition.Name,
+			Format: rendition.Format,
+			Width:  rendition.Width,
+			Height: rendition.Height,
+			URL:    renditionURL,
+		})
+	}
+
+	return image, nil
+}
+
+// CleanImages removes the stored files and image records of the entity
+func (i *Ingester) CleanImages(ctx context.Context, entity Entity, dryRun bool) error {
+	blobPrefix := entity.SKU + "/"
+
+	if dryRun {
+		files, err := i.storage.List(ctx, blobPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to list existing images for SKU %s: %w", entity.SKU, err)
+		}
+
+		if len(files) == 0 {
+			i.logger.Infof("[DRY RUN] No existing images found for %s %s", entity.Type, entity.SKU)
+			return nil
+		}
+
+		i.logger.Infof("[DRY RUN] Would delete %d existing images for %s %s:", len(files), entity.Type, entity.SKU)
+		for _, file := range files {
+			i.logger.Infof("[DRY RUN]   - %s", file.Key)
+		}
+		return nil
+	}
+
+	deletedCount, err := storage.DeleteWithPrefix(ctx, i.storage, blobPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to delete existing images for SKU %s: %w", entity.SKU, err)
+	}
+
+	if deletedCount == 0 {
+		i.logger.Infof("No existing images found for %s %s", entity.Type, entity.SKU)
+		return nil
+	}
+
+	i.logger.Infof("Deleted %d existing images from storage for %s %s", deletedCount, entity.Type, entity.SKU)
+
+	// The files are gone already, stale records only cost a broken image.
+	if _, err := i.repo.DeleteImages(ctx, entity); err != nil {
+		i.logger.Warnf("Failed to cleanup database records for %s ID %d: %v", entity.Type, entity.ID, err)
+	}
+
+	return nil
+}
+
+func (i *Ingester) processDirectory(ctx context.Context, entityType db.EntityType, dirInfo DirectoryInfo, opts Options, result *Result) error {
+	entity, err := i.repo.FindEntity(ctx, entityType, dirInfo.SKU)
+	if errors.Is(err, ErrEntityNotFound) {
+		i.logger.Warnf("%s with SKU %s not found, skipping %d images", entityType, dirInfo.SKU, len(dirInfo.Images))
+		result.skipped(entityType, len(dirInfo.Images))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	i.logger.Infof("Processing %d images for %s '%s' (SKU: %s, ID: %d)", len(dirInfo.Images), entityType, entity.Name, entity.SKU, entity.ID)
+
+	if opts.CleanFirst {
+		if err := i.CleanImages(ctx, entity, opts.DryRun); err != nil {
+			return fmt.Errorf("failed to cleanup existing images for %s SKU %s: %w", entityType, entity.SKU, err)
+		}
+	}
+
+	for sortOrder, imagePath := range dirInfo.Images {
+		fileInfo, err := os.Stat(imagePath)
+		if err != nil {
+			i.logger.Errorf("Cannot access image file %s: %v", imagePath, err)
+			result.Errors = append(result.Errors, fmt.Errorf("file access %s: %w", imagePath, err))
+			result.skipped(entityType, 1)
+			continue
+		}
+
+		result.TotalSizeBytes += fileInfo.Size()
+
+		if opts.DryRun {
+			i.logger.Infof("[DRY RUN] Would upload %s image: %s (%.2f KB)", entityType, filepath.Base(imagePath), float64(fileInfo.Size())/1024)
+			result.uploaded(entityType)
+			continue
+		}
+
+		if err := i.processImage(ctx, entity, imagePath, sortOrder, opts, result); err != nil {
+			i.logger.Errorf("Failed to process %s image %s: %v", entityType, imagePath, err)
+			result.Errors = append(result.Errors, fmt.Errorf("%s image %s: %w", entityType, imagePath, err))
+			result.skipped(entityType, 1)
+			continue
+		}
+		result.uploaded(entityType)
+	}
+
+	return nil
+}
+
+// processImage uploads a single image file and records it in the repository and the mirror
+func (i *Ingester) processImage(ctx context.Context, entity Entity, imagePath string, sortOrder int, opts Options, result *Result) error {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to open image file: %w", err)
+	}
+	defer file.Close()
+
+	image, err := i.UploadImage(ctx, entity, file, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	if opts.Mirror != nil {
+		if err := mirrorImage(ctx, opts.Mirror, entity, image, sortOrder); err != nil {
+			i.logger.Warnf("Failed to sync image to mirror database for SKU %s: %v", entity.SKU, err)
+			result.MirrorErrors++
+		}
+	}
+
+	i.logger.Debugf("Successfully processed %s image: %s -> %s", entity.Type, filepath.Base(imagePath), image.URL)
+	return nil
+}
+
+// mirrorImage records the image for the entity with the same SKU in the mirror, IDs differ
+// between databases
+func mirrorImage(ctx context.Context, mirror Repository, entity Entity, image Image, sortOrder int) error {
+	mirrored, err := mirror.FindEntity(ctx, entity.Type, entity.SKU)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s %s: %w", entity.Type, entity.SKU, err)
+	}
+
+	return mirror.CreateImage(ctx, mirrored, image, sortOrder)
+}
+
+// generateNanoID generates a short unique ID for filenames
+func (i *Ingester) generateNanoID() string {
+	// Generate a 12-character nanoid (good balance of uniqueness and brevity)
+	id, err := gonanoid.New(12)
+	if err != nil {
+		// Fallback to timestamp-based approach if nanoid fails
+		i.logger.Warnf("Failed to generate nanoid, using timestamp fallback: %v", err)
+		return fmt.Sprintf("%d", time.Now().UnixNano()%1000000000)
+	}
+	return id
+}
+
+// validateSourcePath validates that the source path exists and is readable
+func validateSourcePath(sourcePath string) error {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("directory does not exist: %s", sourcePath)
+		}
+		return fmt.Errorf("cannot access directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("path is not a directory: %s", sourcePath)
+	}
+
+	if _, err := os.ReadDir(sourcePath); err != nil {
+		return fmt.Errorf("cannot read directory: %w", err)
+	}
+
+	return nil
+}
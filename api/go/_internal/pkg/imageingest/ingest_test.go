@@ -0,0 +1,378 @@
+package imageingest
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// fakeStorage keeps uploaded files in memory
+type fakeStorage struct {
+	files map[string][]byte
+}
+
+func (s *fakeStorage) Upload(ctx context.Context, name string, r io.Reader, contentType string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	s.files[name] = data
+	return s.PublicURL(name), nil
+}
+
+func (s *fakeStorage) Download(ctx context.Context, name string) (io.ReadCloser, error) {
+	data, ok := s.files[name]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, name string) error {
+	delete(s.files, name)
+	return nil
+}
+
+func (s *fakeStorage) List(ctx context.Context, prefix string) ([]storage.FileInfo, error) {
+	var files []storage.FileInfo
+	for name, data := range s.files {
+		if strings.HasPrefix(name, prefix) {
+			files = append(files, storage.FileInfo{Key: name, Size: int64(len(data))})
+		}
+	}
+	return files, nil
+}
+
+func (s *fakeStorage) Stat(ctx context.Context, name string) (storage.FileInfo, error) {
+	data, ok := s.files[name]
+	if !ok {
+		return storage.FileInfo{}, storage.ErrNotFound
+	}
+	return storage.FileInfo{Key: name, Size: int64(len(data))}, nil
+}
+
+func (s *fakeStorage) PublicURL(name string) string {
+	return "https://cdn.test/" + name
+}
+
+func (s *fakeStorage) SignedURL(ctx context.Context, name string, opts storage.SignedURLOptions) (string, error) {
+	return s.PublicURL(name) + "?signed", nil
+}
+
+type fakeImage struct {
+	Entity Entity
+	Image
+	SortOrder int
+}
+
+// fakeRepository holds products and variants by SKU and records the created images
+type fakeRepository struct {
+	products map[string]Entity
+	variants map[string]Entity
+	images   []fakeImage
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		products: make(map[string]Entity),
+		variants: make(map[string]Entity),
+	}
+}
+
+func (r *fakeRepository) addProduct(id int64, sku string) {
+	r.products[sku] = Entity{Type: db.EntityTypeProduct, ID: id, SKU: sku, Name: sku}
+}
+
+func (r *fakeRepository) addVariant(id int64, sku string) {
+	r.variants[sku] = Entity{Type: db.EntityTypeProductVariant, ID: id, SKU: sku, Name: sku}
+}
+
+func (r *fakeRepository) FindEntity(ctx context.Context, entityType db.EntityType, sku string) (Entity, error) {
+	entities := r.products
+	if entityType == db.EntityTypeProductVariant {
+		entities = r.variants
+	}
+
+	entity, ok := entities[sku]
+	if !ok {
+		return Entity{}, ErrEntityNotFound
+	}
+	return entity, nil
+}
+
+func (r *fakeRepository) FindVariants(ctx context.Context, skus []string) (map[string]Entity, error) {
+	found := make(map[string]Entity)
+	for _, sku := range skus {
+		if entity, ok := r.variants[sku]; ok {
+			found[sku] = entity
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepository) CreateImage(ctx context.Context, entity Entity, image Image, sortOrder int) error {
+	r.images = append(r.images, fakeImage{Entity: entity, Image: image, SortOrder: sortOrder})
+	return nil
+}
+
+func (r *fakeRepository) DeleteImages(ctx context.Context, entity Entity) (int, error) {
+	kept := r.images[:0]
+	for _, image := range r.images {
+		if image.Entity.Type != entity.Type || image.Entity.ID != entity.ID {
+			kept = append(kept, image)
+		}
+	}
+	deleted := len(r.images) - len(kept)
+	r.images = kept
+	return deleted, nil
+}
+
+func TestClassifyDirectory(t *testing.T) {
+	tests := []struct {
+		dirName   string
+		dirType   DirectoryType
+		parentSKU string
+	}{
+		{dirName: "kivy", dirType: ProductDirectory},
+		{dirName: "kivy-007", dirType: VariantDirectory, parentSKU: "kivy"},
+		{dirName: "kivy-007-dog", dirType: VariantDirectory, parentSKU: "kivy-007"},
+		{dirName: "-dog", dirType: ProductDirectory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dirName, func(t *testing.T) {
+			dirType, parentSKU := classifyDirectory(tt.dirName)
+			require.Equal(t, tt.dirType, dirType)
+			require.Equal(t, tt.parentSKU, parentSKU)
+		})
+	}
+}
+
+type IngesterTestSuite struct {
+	suite.Suite
+	storage  *fakeStorage
+	repo     *fakeRepository
+	ingester *Ingester
+	source   string
+}
+
+func (s *IngesterTestSuite) SetupTest() {
+	s.storage = &fakeStorage{files: make(map[string][]byte)}
+	s.repo = newFakeRepository()
+	s.ingester = NewIngester(IngesterParams{
+		Storage: s.storage,
+		Repo:    s.repo,
+		Logger:  zap.NewNop().Sugar(),
+	})
+	s.source = s.T().TempDir()
+}
+
+// encodeImage returns a width x width/2 PNG, or a JPEG when jpg is set
+func encodeImage(t *testing.T, width int, jpg bool) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, width/2))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, G: 120, B: 40, A: 255}), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if jpg {
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	} else {
+		require.NoError(t, png.Encode(&buf, img))
+	}
+	return buf.Bytes()
+}
+
+// writeImage creates a small image file in the directory of sku, too small to get renditions
+func (s *IngesterTestSuite) writeImage(sku, name string) {
+	dir := filepath.Join(s.source, sku)
+	s.Require().NoError(os.MkdirAll(dir, 0o755))
+
+	data := []byte("notes")
+	switch filepath.Ext(name) {
+	case ".jpg":
+		data = encodeImage(s.T(), 64, true)
+	case ".png", ".webp":
+		// The webp decoder sniffs the content, a PNG named .webp is still read.
+		data = encodeImage(s.T(), 64, false)
+	}
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, name), data, 0o644))
+}
+
+func (s *IngesterTestSuite) TestValidateVariantDirectories() {
+	s.repo.addVariant(11, "kivy-007-dog")
+
+	variantDirs := map[string]DirectoryInfo{
+		"kivy-007-dog": {SKU: "kivy-007-dog", Type: VariantDirectory, ParentSKU: "kivy-007"},
+		"kivy-008":     {SKU: "kivy-008", Type: VariantDirectory, ParentSKU: "kivy"},
+	}
+
+	err := validateVariantDirectories(context.Background(), s.repo, variantDirs, zap.NewNop().Sugar())
+	s.Require().NoError(err)
+
+	s.Equal(VariantDirectory, variantDirs["kivy-007-dog"].Type)
+	s.Equal("kivy-007", variantDirs["kivy-007-dog"].ParentSKU)
+
+	// Hyphenated product SKUs look like variants until checked against the database.
+	s.Equal(ProductDirectory, variantDirs["kivy-008"].Type)
+	s.Empty(variantDirs["kivy-008"].ParentSKU)
+}
+
+func (s *IngesterTestSuite) TestIngestDirectory() {
+	s.repo.addProduct(1, "kivy-008")
+	s.repo.addVariant(11, "kivy-008-dog")
+
+	s.writeImage("kivy-008", "1.jpg")
+	s.writeImage("kivy-008", "2.png")
+	s.writeImage("kivy-008", "notes.txt")
+	s.writeImage("kivy-008-dog", "1.webp")
+	s.writeImage("missing", "1.jpg")
+
+	result, err := s.ingester.IngestDirectory(context.Background(), s.source, Options{})
+	s.Require().NoError(err)
+
+	// The directory of an unknown SKU is processed with its images skipped.
+	s.Equal(2, result.ProcessedProducts)
+	s.Equal(1, result.ProcessedVariants)
+	s.Equal(2, result.UploadedProductImages)
+	s.Equal(1, result.UploadedVariantImages)
+	s.Equal(1, result.SkippedProductImages)
+	s.Empty(result.Errors)
+
+	s.Len(s.storage.files, 3)
+	s.Require().Len(s.repo.images, 3)
+
+	for _, image := range s.repo.images {
+		s.True(strings.HasPrefix(image.URL, "https://cdn.test/"+image.Entity.SKU+"/"))
+
+		name := strings.TrimPrefix(image.URL, "https://cdn.test/")
+		s.Contains(s.storage.files, name)
+	}
+}
+
+func (s *IngesterTestSuite) TestDryRunChangesNothing() {
+	s.repo.addProduct(1, "kivy")
+	s.writeImage("kivy", "1.jpg")
+
+	s.storage.files["kivy/old.jpg"] = []byte("old")
+	s.repo.images = []fakeImage{{Entity: s.repo.products["kivy"], Image: Image{URL: "https://cdn.test/kivy/old.jpg"}}}
+
+	result, err := s.ingester.IngestDirectory(context.Background(), s.source, Options{DryRun: true, CleanFirst: true})
+	s.Require().NoError(err)
+
+	s.True(result.DryRun)
+	s.Equal(1, result.UploadedProductImages)
+	s.Equal(map[string][]byte{"kivy/old.jpg": []byte("old")}, s.storage.files)
+	s.Len(s.repo.images, 1)
+}
+
+func (s *IngesterTestSuite) TestCleanFirstReplacesImages() {
+	s.repo.addProduct(1, "kivy")
+	s.writeImage("kivy", "1.jpg")
+
+	s.storage.files["kivy/old.jpg"] = []byte("old")
+	s.repo.images = []fakeImage{{Entity: s.repo.products["kivy"], Image: Image{URL: "https://cdn.test/kivy/old.jpg"}}}
+
+	_, err := s.ingester.IngestDirectory(context.Background(), s.source, Options{CleanFirst: true})
+	s.Require().NoError(err)
+
+	s.NotContains(s.storage.files, "kivy/old.jpg")
+	s.Require().Len(s.repo.images, 1)
+	s.NotEqual("https://cdn.test/kivy/old.jpg", s.repo.images[0].URL)
+	s.Equal(0, s.repo.images[0].SortOrder)
+}
+
+func (s *IngesterTestSuite) TestMirrorGetsImagesByItsOwnIDs() {
+	s.repo.addProduct(1, "kivy")
+	s.writeImage("kivy", "1.jpg")
+
+	mirror := newFakeRepository()
+	mirror.addProduct(42, "kivy")
+
+	result, err := s.ingester.IngestDirectory(context.Background(), s.source, Options{Mirror: mirror})
+	s.Require().NoError(err)
+
+	s.True(result.MirrorEnabled)
+	s.Zero(result.MirrorErrors)
+	s.Require().Len(mirror.images, 1)
+	s.Equal(int64(42), mirror.images[0].Entity.ID)
+	s.Equal(s.repo.images[0].URL, mirror.images[0].URL)
+}
+
+func (s *IngesterTestSuite) TestMirrorFailureDoesNotStopUpload() {
+	s.repo.addProduct(1, "kivy")
+	s.writeImage("kivy", "1.jpg")
+
+	result, err := s.ingester.IngestDirectory(context.Background(), s.source, Options{Mirror: newFakeRepository()})
+	s.Require().NoError(err)
+
+	s.Equal(1, result.UploadedProductImages)
+	s.Equal(1, result.MirrorErrors)
+	s.Len(s.repo.images, 1)
+}
+
+func (s *IngesterTestSuite) TestUploadImage() {
+	s.repo.addVariant(11, "kivy-dog")
+
+	uploaded, err := s.ingester.UploadImage(context.Background(), s.repo.variants["kivy-dog"], bytes.NewReader(encodeImage(s.T(), 1000, true)), 2)
+	s.Require().NoError(err)
+
+	s.True(strings.HasPrefix(uploaded.URL, "https://cdn.test/kivy-dog/"))
+	s.True(strings.HasSuffix(uploaded.URL, ".jpg"))
+	s.Equal(1000, uploaded.Width)
+	s.Equal(500, uploaded.Height)
+
+	// Thumbnail and medium in JPEG and WebP, large would be an upscale.
+	s.Require().Len(uploaded.Renditions, 4)
+	s.Len(s.storage.files, 5)
+	for _, rendition := range uploaded.Renditions {
+		s.Contains([]string{"thumbnail", "medium"}, rendition.Name)
+		s.Contains(s.storage.files, strings.TrimPrefix(rendition.URL, "https://cdn.test/"))
+	}
+	s.Equal(
+		strings.TrimSuffix(uploaded.URL, ".jpg")+"_thumbnail.webp 320w, "+strings.TrimSuffix(uploaded.URL, ".jpg")+"_medium.webp 800w",
+		uploaded.Renditions.SrcSet("webp"),
+	)
+
+	s.Require().Len(s.repo.images, 1)
+	s.Equal(2, s.repo.images[0].SortOrder)
+	s.Equal(uploaded.URL, s.repo.images[0].URL)
+}
+
+func (s *IngesterTestSuite) TestUploadImageRejectsNonImages() {
+	s.repo.addProduct(1, "kivy")
+
+	_, err := s.ingester.UploadImage(context.Background(), s.repo.products["kivy"], strings.NewReader("not an image"), 0)
+	s.Require().ErrorIs(err, imageproc.ErrUnsupportedImage)
+	s.Empty(s.storage.files)
+	s.Empty(s.repo.images)
+}
+
+func (s *IngesterTestSuite) TestStoreImageDoesNotRecord() {
+	stored, err := s.ingester.StoreImage(context.Background(), "kivy", bytes.NewReader(encodeImage(s.T(), 400, false)))
+	s.Require().NoError(err)
+
+	s.True(strings.HasPrefix(stored.URL, "https://cdn.test/kivy/"))
+	s.Equal(400, stored.Width)
+	s.Len(s.storage.files, 1+len(stored.Renditions))
+	s.Empty(s.repo.images)
+}
+
+func TestIngesterTestSuite(t *testing.T) {
+	suite.Run(t, new(IngesterTestSuite))
+}
@@ -0,0 +1,254 @@
+package imageingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+)
+
+const (
+	ProcessingStatusPending = "pending"
+	ProcessingStatusDone    = "done"
+	ProcessingStatusFailed  = "failed"
+)
+
+const processingJobColumns = `
+	id,
+	image_id,
+	upload_key,
+	status,
+	attempts,
+	last_error,
+	next_attempt_at,
+	processed_at,
+	created_at,
+	updated_at
+`
+
+// ErrUploadRecorded is returned when the uploaded file was completed already
+var ErrUploadRecorded = errors.New("upload is recorded already")
+
+// Upload is an image recorded from an uploaded file, waiting for its renditions
+type Upload struct {
+	ImageID   int64
+	SortOrder int
+}
+
+// CreateUpload records the file at url as the entity's image after its existing ones and queues
+// the job generating its renditions. The image has no size until processed. Completing the same
+// upload twice is ErrUploadRecorded.
+func (dao *ImageDAO) CreateUpload(ctx context.Context, entity Entity, url, uploadKey string) (Upload, error) {
+	tx, err := dao.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Upload{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var recorded bool
+	recordedQuery := `SELECT EXISTS (SELECT 1 FROM image_processing_jobs WHERE upload_key = $1)`
+	if err := tx.GetContext(ctx, &recorded, recordedQuery, uploadKey); err != nil {
+		return Upload{}, fmt.Errorf("failed to look up upload %s: %w", uploadKey, err)
+	}
+	if recorded {
+		return Upload{}, ErrUploadRecorded
+	}
+
+	upload := Upload{}
+	sortOrderQuery := `
+		SELECT COALESCE(MAX(sort_order) + 1, 0)
+		FROM image_entities
+		WHERE entity_id = $1 AND entity_type = $2
+	`
+	if err := tx.GetContext(ctx, &upload.SortOrder, sortOrderQuery, entity.ID, entity.Type); err != nil {
+		return Upload{}, fmt.Errorf("failed to get next sort order: %w", err)
+	}
+
+	if err := tx.GetContext(ctx, &upload.ImageID, `INSERT INTO images (url) VALUES ($1) RETURNING id`, url); err != nil {
+		return Upload{}, fmt.Errorf("failed to insert into images table: %w", err)
+	}
+
+	entityInsertQuery := `
+		INSERT INTO image_entities (entity_id, image_id, alt_text, is_primary, sort_order, entity_type)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.ExecContext(
+		ctx,
+		entityInsertQuery,
+		entity.ID,
+		upload.ImageID,
+		AltText(entity),
+		upload.SortOrder == 0,
+		upload.SortOrder,
+		entity.Type,
+	); err != nil {
+		return Upload{}, fmt.Errorf("failed to insert into image_entities table: %w", err)
+	}
+
+	jobInsertQuery := `
+		INSERT INTO image_processing_jobs (image_id, upload_key)
+		VALUES ($1, $2)
+	`
+	if _, err := tx.ExecContext(ctx, jobInsertQuery, upload.ImageID, uploadKey); err != nil {
+		return Upload{}, fmt.Errorf("failed to queue image processing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Upload{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return upload, nil
+}
+
+// ClaimDueProcessingJobs picks pending jobs whose next attempt is due and pushes their
+// next_attempt_at forward by lease, so a concurrent run won't process the same upload.
+func (dao *ImageDAO) ClaimDueProcessingJobs(ctx context.Context, limit int, lease time.Duration) ([]db.ImageProcessingJob, error) {
+	query := fmt.Sprintf(`
+		UPDATE image_processing_jobs
+		SET
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM image_processing_jobs
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s
+	`, processingJobColumns)
+
+	jobs := make([]db.ImageProcessingJob, 0)
+	if err := dao.db.SelectContext(ctx, &jobs, query, limit, lease.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim image processing jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// CompleteProcessingJob points the image of the job at the processed file and its renditions.
+// An image deleted in the meantime is left deleted, its new files are picked up by the image GC.
+func (dao *ImageDAO) CompleteProcessingJob(ctx context.Context, job db.ImageProcessingJob, image Image) error {
+	tx, err := dao.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	imageUpdateQuery := `
+		UPDATE images
+		SET
+			url = $2,
+			width = $3,
+			height = $4,
+			renditions = $5,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(
+		ctx,
+		imageUpdateQuery,
+		job.ImageID,
+		image.URL,
+		image.Width,
+		image.Height,
+		image.Renditions,
+	); err != nil {
+		return fmt.Errorf("failed to update image %d: %w", job.ImageID, err)
+	}
+
+	jobUpdateQuery := `
+		UPDATE image_processing_jobs
+		SET
+			status = 'done',
+			processed_at = NOW(),
+			last_error = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, jobUpdateQuery, job.ID); err != nil {
+		return fmt.Errorf("failed to complete image processing job %d: %w", job.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// MarkProcessingJobFailed records the error and schedules the next attempt.
+// A nil nextAttemptAt gives up on the job, the image keeps pointing at the uploaded file.
+func (dao *ImageDAO) MarkProcessingJobFailed(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error {
+	status := ProcessingStatusPending
+	if nextAttemptAt == nil {
+		status = ProcessingStatusFailed
+	}
+
+	query := `
+		UPDATE image_processing_jobs
+		SET
+			status = $2,
+			last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := dao.db.ExecContext(ctx, query, id, status, lastError, nextAttemptAt)
+	return err
+}
+
+// DiscardUpload deletes an image whose upload can't be processed, and its job with it. Entities
+// it was the primary image of get their next image as primary.
+func (dao *ImageDAO) DiscardUpload(ctx context.Context, imageID int64) error {
+	tx, err := dao.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var primaryOf []struct {
+		EntityID   int64         `json:"entity_id"`
+		EntityType db.EntityType `json:"entity_type"`
+	}
+	deleteEntitiesQuery := `
+		DELETE FROM image_entities
+		WHERE image_id = $1 AND is_primary
+		RETURNING entity_id, entity_type
+	`
+	if err := tx.SelectContext(ctx, &primaryOf, deleteEntitiesQuery, imageID); err != nil {
+		return fmt.Errorf("failed to delete image entities: %w", err)
+	}
+
+	for _, entity := range primaryOf {
+		promoteQuery := `
+			UPDATE image_entities
+			SET is_primary = true, updated_at = NOW()
+			WHERE id = (
+				SELECT id
+				FROM image_entities
+				WHERE entity_id = $1 AND entity_type = $2 AND image_id <> $3
+				ORDER BY sort_order, id
+				LIMIT 1
+			)
+		`
+		if _, err := tx.ExecContext(ctx, promoteQuery, entity.EntityID, entity.EntityType, imageID); err != nil {
+			return fmt.Errorf("failed to promote next primary image: %w", err)
+		}
+	}
+
+	// The remaining links and the job go with the image.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM images WHERE id = $1`, imageID); err != nil {
+		return fmt.Errorf("failed to delete image %d: %w", imageID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("image must be a jpeg, png, gif or webp")
+	ErrImageTooLarge    = fmt.Errorf("image has more than %d megapixels", MaxPixels/1_000_000)
+)
+
+// MaxPixels caps the decoded size, a small file can still decode to gigabytes of pixels
+const MaxPixels = 40_000_000
+
+const (
+	originalQuality  = 90
+	renditionQuality = 82
+)
+
+// Size is a rendition width, heights follow the aspect ratio
+type Size struct {
+	Name  string
+	Width int
+}
+
+// DefaultSizes are the renditions generated for product images
+var DefaultSizes = []Size{
+	{Name: "thumbnail", Width: 320},
+	{Name: "medium", Width: 800},
+	{Name: "large", Width: 1600},
+}
+
+// File is an encoded image. Name is empty for the original.
+type File struct {
+	Name   string
+	Format string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Result is the original, re-encoded without metadata, and its renditions
+type Result struct {
+	Original   File
+	Renditions []File
+}
+
+// Process decodes the image, applies its EXIF orientation and re-encodes it, which strips
+// EXIF and any other metadata. Every size narrower than the image gets a rendition in the
+// original's format; images are never upscaled.
+//
+// Opaque images are stored as JPEG, images with transparency as PNG. WebP is encoded
+// losslessly, the only mode available without cgo, so it usually beats PNG but not JPEG.
+// The WebP renditions are only kept when each is smaller than its JPEG or PNG counterpart,
+// a srcset missing sizes would have browsers pick a blurry one. This is a known limitation:
+// photos, most product images, end up with JPEG renditions only. Lossy WebP needs libwebp,
+// which the functions are built without.
+func Process(r io.Reader, sizes []Size) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	outputFormat := FormatJPEG
+	if !isOpaque(img) {
+		outputFormat = FormatPNG
+	}
+
+	original, err := encode(img, outputFormat, originalQuality)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	result := &Result{
+		Original: File{
+			Format: outputFormat,
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+			Data:   original,
+		},
+	}
+
+	var webp []File
+	for _, size := range sizes {
+		if size.Width >= bounds.Dx() {
+			continue
+		}
+
+		resized := resize(img, size.Width)
+		for _, format := range []string{outputFormat, FormatWebP} {
+			data, err := encode(resized, format, renditionQuality)
+			if err != nil {
+				return nil, err
+			}
+
+			file := File{
+				Name:   size.Name,
+				Format: format,
+				Width:  resized.Bounds().Dx(),
+				Height: resized.Bounds().Dy(),
+				Data:   data,
+			}
+			if format == FormatWebP {
+				webp = append(webp, file)
+			} else {
+				result.Renditions = append(result.Renditions, file)
+			}
+		}
+	}
+
+	if smaller(webp, result.Renditions) {
+		result.Renditions = append(result.Renditions, webp...)
+	}
+
+	return result, nil
+}
+
+// smaller reports whether every WebP rendition is smaller than the rendition of the same size
+// in the original's format
+func smaller(webp, renditions []File) bool {
+	for i := range webp {
+		if len(webp[i].Data) >= len(renditions[i].Data) {
+			return false
+		}
+	}
+	return true
+}
+
+// resize scales img down to width keeping the aspect ratio
+func resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	height := max(1, b.Dy()*width/b.Dx())
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func encode(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatWebP:
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("unsupported output format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
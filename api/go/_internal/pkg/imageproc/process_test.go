@@ -0,0 +1,146 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// withOrientation inserts an EXIF segment carrying the orientation right after the SOI marker
+func withOrientation(t *testing.T, data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	entry := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(entry[0:], 1)
+	binary.BigEndian.PutUint16(entry[2:], exifOrientationTag)
+	binary.BigEndian.PutUint16(entry[4:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[6:], 1)
+	binary.BigEndian.PutUint16(entry[10:], orientation)
+	payload := append([]byte("Exif\x00\x00"), append(tiff, entry...)...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	require.Equal(t, []byte{0xFF, 0xD8}, data[:2])
+	return append(append([]byte{0xFF, 0xD8}, segment...), data[2:]...)
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil))
+	return buf.Bytes()
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	data := withOrientation(t, encodeJPEG(t, 400, 200), 6)
+	require.Equal(t, 6, jpegOrientation(data))
+
+	result, err := Process(bytes.NewReader(data), DefaultSizes)
+	require.NoError(t, err)
+
+	require.Equal(t, FormatJPEG, result.Original.Format)
+	require.Equal(t, 200, result.Original.Width)
+	require.Equal(t, 400, result.Original.Height)
+	require.Equal(t, 1, jpegOrientation(result.Original.Data), "EXIF should be stripped")
+	require.Empty(t, result.Renditions)
+}
+
+func TestOrientRotatesClockwise(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{B: 255, A: 255})
+
+	rotated := orient(img, 6).(*image.NRGBA)
+	require.Equal(t, image.Rect(0, 0, 1, 2), rotated.Bounds())
+	require.Equal(t, color.NRGBA{R: 255, A: 255}, rotated.NRGBAAt(0, 0))
+	require.Equal(t, color.NRGBA{B: 255, A: 255}, rotated.NRGBAAt(0, 1))
+}
+
+func TestProcessKeepsTransparency(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 500, 250))))
+
+	result, err := Process(&buf, DefaultSizes)
+	require.NoError(t, err)
+
+	require.Equal(t, FormatPNG, result.Original.Format)
+	require.Len(t, result.Renditions, 2)
+	for _, rendition := range result.Renditions {
+		require.Equal(t, "thumbnail", rendition.Name)
+		require.Equal(t, 320, rendition.Width)
+		require.Equal(t, 160, rendition.Height)
+	}
+	require.ElementsMatch(t, []string{FormatPNG, FormatWebP}, []string{result.Renditions[0].Format, result.Renditions[1].Format})
+
+	lossless, webp := result.Renditions[0], result.Renditions[1]
+	require.Equal(t, FormatWebP, webp.Format)
+	require.Less(t, len(webp.Data), len(lossless.Data))
+}
+
+// encodePhoto encodes a noisy gradient, which compresses like a photo
+func encodePhoto(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for y := range h {
+		for x := range w {
+			noise := uint8(rng.IntN(32))
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x) + noise, G: uint8(y) + noise, B: uint8(x+y) + noise, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}))
+	return buf.Bytes()
+}
+
+func TestProcessDropsLargerWebP(t *testing.T) {
+	result, err := Process(bytes.NewReader(encodePhoto(t, 1000, 600)), DefaultSizes)
+	require.NoError(t, err)
+
+	require.Equal(t, FormatJPEG, result.Original.Format)
+	require.Len(t, result.Renditions, 2)
+	for _, rendition := range result.Renditions {
+		require.Equal(t, FormatJPEG, rendition.Format)
+	}
+
+	// Lossless WebP of a photo is larger than the JPEG, which is why it's left out.
+	photo, _, err := image.Decode(bytes.NewReader(encodePhoto(t, 1000, 600)))
+	require.NoError(t, err)
+	resized := resize(photo, 320)
+
+	jpegData, err := encode(resized, FormatJPEG, renditionQuality)
+	require.NoError(t, err)
+	webpData, err := encode(resized, FormatWebP, renditionQuality)
+	require.NoError(t, err)
+	require.Greater(t, len(webpData), len(jpegData))
+}
+
+func TestProcessRejectsNonImages(t *testing.T) {
+	_, err := Process(bytes.NewReader([]byte("not an image")), DefaultSizes)
+	require.ErrorIs(t, err, ErrUnsupportedImage)
+}
+
+func TestRenditions(t *testing.T) {
+	renditions := Renditions{
+		{Name: "medium", Format: FormatJPEG, Width: 800, URL: "a_medium.jpg"},
+		{Name: "thumbnail", Format: FormatJPEG, Width: 320, URL: "a_thumbnail.jpg"},
+		{Name: "thumbnail", Format: FormatWebP, Width: 320, URL: "a_thumbnail.webp"},
+	}
+
+	require.Equal(t, []string{FormatWebP, FormatJPEG}, renditions.Formats())
+	require.Equal(t, "a_thumbnail.jpg 320w, a_medium.jpg 800w", renditions.SrcSet(FormatJPEG))
+
+	value, err := renditions.Value()
+	require.NoError(t, err)
+
+	var scanned Renditions
+	require.NoError(t, scanned.Scan(value))
+	require.Equal(t, renditions, scanned)
+}
@@ -0,0 +1,112 @@
+package imageproc
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Image formats of the stored files
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// ContentType returns the MIME type of the format
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Extension returns the file extension of the format
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// Rendition is a resized copy of an image as recorded on the images row
+type Rendition struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+// Renditions is the renditions jsonb column of images
+type Renditions []Rendition
+
+func (r *Renditions) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("unsupported scan type for Renditions: %T", src)
+	}
+}
+
+func (r Renditions) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Formats returns the formats of the renditions, WebP first as browsers take the first
+// <source> they support
+func (r Renditions) Formats() []string {
+	var formats []string
+	for _, rendition := range r {
+		if !slices.Contains(formats, rendition.Format) {
+			formats = append(formats, rendition.Format)
+		}
+	}
+
+	slices.SortStableFunc(formats, func(a, b string) int {
+		switch {
+		case a == b:
+			return 0
+		case a == FormatWebP:
+			return -1
+		case b == FormatWebP:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return formats
+}
+
+// SrcSet builds the srcset attribute of the renditions in format, e.g.
+// "https://.../a_thumbnail.webp 320w, https://.../a_medium.webp 800w"
+func (r Renditions) SrcSet(format string) string {
+	var candidates []Rendition
+	for _, rendition := range r {
+		if rendition.Format == format {
+			candidates = append(candidates, rendition)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b Rendition) int {
+		return a.Width - b.Width
+	})
+
+	entries := make([]string, 0, len(candidates))
+	for _, rendition := range candidates {
+		entries = append(entries, fmt.Sprintf("%s %dw", rendition.URL, rendition.Width))
+	}
+	return strings.Join(entries, ", ")
+}
@@ -0,0 +1,22 @@
+package render
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// DecodeJSON decodes and validates the JSON body into req, rendering the error with appCode and
+// a 400 when it fails
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v *validator.Validate, req any, appCode string) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		ChiErr(w, r, err, appCode, WithStatusCode(http.StatusBadRequest))
+		return false
+	}
+	if err := v.Struct(req); err != nil {
+		ChiErr(w, r, err, appCode, WithStatusCode(http.StatusBadRequest))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/azure"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+// AzureStore keeps files in the products container of Azure Blob Storage
+type AzureStore struct {
+	client    *azure.BlobStorageWrapperClient
+	container string
+}
+
+func NewAzureStore(cfg *configs.Config) (*AzureStore, error) {
+	cred, err := azure.NewSharedKeyCredential(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := azure.NewBlobStorageClient(cfg, cred)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper, err := azure.NewBlobStorageWrapperClient(cfg, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAzureStoreWithClient(wrapper), nil
+}
+
+// NewAzureStoreWithClient wraps an existing client, e.g. one already provided to fx
+func NewAzureStoreWithClient(client *azure.BlobStorageWrapperClient) *AzureStore {
+	return &AzureStore{
+		client:    client,
+		container: azure.ProductImageContainerName,
+	}
+}
+
+func (s *AzureStore) Upload(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	return s.client.UploadBlob(ctx, s.container, key, r, contentType)
+}
+
+func (s *AzureStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := s.client.DownloadBlob(ctx, s.container, key)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, ErrNotFound
+	}
+	return body, err
+}
+
+func (s *AzureStore) Delete(ctx context.Context, key string) error {
+	err := s.client.DeleteBlob(ctx, s.container, key)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *AzureStore) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	items, err := s.client.ListBlobItemsWithPrefix(ctx, s.container, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileInfo, 0, len(items))
+	for _, item := range items {
+		if item.Name == nil {
+			continue
+		}
+
+		info := FileInfo{Key: *item.Name}
+		if props := item.Properties; props != nil {
+			if props.ContentLength != nil {
+				info.Size = *props.ContentLength
+			}
+			if props.ContentType != nil {
+				info.ContentType = *props.ContentType
+			}
+			if props.LastModified != nil {
+				info.LastModified = *props.LastModified
+			}
+		}
+		files = append(files, info)
+	}
+	return files, nil
+}
+
+func (s *AzureStore) Stat(ctx context.Context, key string) (FileInfo, error) {
+	props, err := s.client.GetBlobProperties(ctx, s.container, key)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return FileInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info := FileInfo{Key: key}
+	if props.ContentLength != nil {
+		info.Size = *props.ContentLength
+	}
+	if props.ContentType != nil {
+		info.ContentType = *props.ContentType
+	}
+	if props.LastModified != nil {
+		info.LastModified = *props.LastModified
+	}
+	return info, nil
+}
+
+func (s *AzureStore) PublicURL(key string) string {
+	return s.client.GetPublicURL(s.container, key)
+}
+
+func (s *AzureStore) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+
+	permissions := sas.BlobPermissions{Read: true}
+	if opts.Method == http.MethodPut {
+		permissions = sas.BlobPermissions{Create: true, Write: true}
+	}
+
+	url, err := s.client.GetSASURL(s.container, key, permissions, time.Now().Add(opts.Expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", key, err)
+	}
+	return url, nil
+}
+
+var _ BlobStore = (*AzureStore)(nil)
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid storage key")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// LocalStore keeps files under a directory for development and tests. The files are served
+// by the files handler at BaseURL.
+type LocalStore struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+func NewLocalStore(cfg *configs.Config) (*LocalStore, error) {
+	return NewLocalStoreAt(cfg.Storage.Local.Root, cfg.Storage.Local.BaseURL, cfg.Storage.Local.SigningSecret), nil
+}
+
+// NewLocalStoreAt keeps files under root. Signing URLs fails when secret is empty.
+func NewLocalStoreAt(root, baseURL, secret string) *LocalStore {
+	return &LocalStore{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  []byte(secret),
+	}
+}
+
+// Path returns the file of key under the root, rejecting keys escaping it
+func (s *LocalStore) Path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if key == "" || cleaned != key || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) Upload(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	filePath, err := s.Path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	// Write next to the target and rename so readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file for %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return s.PublicURL(key), nil
+}
+
+func (s *LocalStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	filePath, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	var files []FileInfo
+	err := filepath.WalkDir(s.root, func(filePath string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, filePath)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{
+			Key:          key,
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(path.Ext(key)),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	return files, nil
+}
+
+// Stat guesses the content type from the extension, the local store doesn't keep it
+func (s *LocalStore) Stat(ctx context.Context, key string) (FileInfo, error) {
+	filePath, err := s.Path(key)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info, err := os.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return FileInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+
+	return FileInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+func (s *LocalStore) PublicURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.baseURL + "/" + strings.Join(segments, "/")
+}
+
+// SignedURL appends the method, expiry and an HMAC of them to the public URL, checked by
+// VerifySignature when the files handler receives the request
+func (s *LocalStore) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	if len(s.secret) == 0 {
+		return "", errors.New("local storage signing secret is not set")
+	}
+	if _, err := s.Path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(opts.Expiry).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("method", opts.Method)
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(opts.Method, key, expires))
+
+	return s.PublicURL(key) + "?" + query.Encode(), nil
+}
+
+// VerifySignature checks the query of a signed URL allows method on key now
+func (s *LocalStore) VerifySignature(method, key string, query url.Values, now time.Time) error {
+	if len(s.secret) == 0 || query.Get("method") != method {
+		return ErrInvalidSignature
+	}
+
+	expires := query.Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+
+	expected := s.sign(method, key, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s *LocalStore) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var _ BlobStore = (*LocalStore)(nil)
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalStoreTestSuite struct {
+	suite.Suite
+	store *LocalStore
+}
+
+func (s *LocalStoreTestSuite) SetupTest() {
+	s.store = NewLocalStoreAt(s.T().TempDir(), "http://localhost:3008/v1/files/", "secret")
+}
+
+func (s *LocalStoreTestSuite) upload(key, content string) string {
+	url, err := s.store.Upload(context.Background(), key, strings.NewReader(content), "text/plain")
+	s.Require().NoError(err)
+	return url
+}
+
+func (s *LocalStoreTestSuite) TestUploadListDelete() {
+	ctx := context.Background()
+
+	url := s.upload("kivy/a.jpg", "a")
+	s.upload("kivy/b.jpg", "b")
+	s.upload("kivy-dog/c.jpg", "c")
+
+	s.Equal("http://localhost:3008/v1/files/kivy/a.jpg", url)
+
+	key, ok := KeyFromURL(s.store, url)
+	s.True(ok)
+	s.Equal("kivy/a.jpg", key)
+
+	_, ok = KeyFromURL(s.store, "https://elsewhere.test/kivy/a.jpg")
+	s.False(ok)
+
+	filePath, err := s.store.Path("kivy/a.jpg")
+	s.Require().NoError(err)
+	content, err := os.ReadFile(filePath)
+	s.Require().NoError(err)
+	s.Equal("a", string(content))
+
+	files, err := s.store.List(ctx, "kivy/")
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{"kivy/a.jpg", "kivy/b.jpg"}, fileKeys(files))
+	for _, file := range files {
+		s.Equal(int64(1), file.Size)
+		s.False(file.LastModified.IsZero())
+	}
+
+	deleted, err := DeleteWithPrefix(ctx, s.store, "kivy/")
+	s.Require().NoError(err)
+	s.Equal(2, deleted)
+
+	files, err = s.store.List(ctx, "")
+	s.Require().NoError(err)
+	s.Equal([]string{"kivy-dog/c.jpg"}, fileKeys(files))
+
+	s.NoError(s.store.Delete(ctx, "kivy/a.jpg"), "deleting a missing file")
+}
+
+func (s *LocalStoreTestSuite) TestDownload() {
+	ctx := context.Background()
+	s.upload("kivy/a.jpg", "a")
+
+	body, err := s.store.Download(ctx, "kivy/a.jpg")
+	s.Require().NoError(err)
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	s.Require().NoError(err)
+	s.Equal("a", string(content))
+
+	_, err = s.store.Download(ctx, "kivy/missing.jpg")
+	s.ErrorIs(err, ErrNotFound)
+}
+
+func (s *LocalStoreTestSuite) TestListMissingRoot() {
+	store := NewLocalStoreAt(s.T().TempDir()+"/missing", "http://localhost", "")
+
+	keys, err := store.List(context.Background(), "")
+	s.Require().NoError(err)
+	s.Empty(keys)
+}
+
+func (s *LocalStoreTestSuite) TestRejectsKeysOutsideRoot() {
+	for _, key := range []string{"", "../secret", "kivy/../../secret", "/etc/passwd", "kivy/"} {
+		_, err := s.store.Upload(context.Background(), key, strings.NewReader("x"), "")
+		s.ErrorIs(err, ErrInvalidKey, key)
+	}
+}
+
+func (s *LocalStoreTestSuite) TestSignedURL() {
+	signed, err := s.store.SignedURL(context.Background(), "kivy/a.jpg", SignedURLOptions{
+		Method: http.MethodPut,
+		Expiry: time.Minute,
+	})
+	s.Require().NoError(err)
+
+	u, err := url.Parse(signed)
+	s.Require().NoError(err)
+	s.Equal("/v1/files/kivy/a.jpg", u.Path)
+
+	now := time.Now()
+	s.NoError(s.store.VerifySignature(http.MethodPut, "kivy/a.jpg", u.Query(), now))
+	s.ErrorIs(s.store.VerifySignature(http.MethodGet, "kivy/a.jpg", u.Query(), now), ErrInvalidSignature)
+	s.ErrorIs(s.store.VerifySignature(http.MethodPut, "kivy/b.jpg", u.Query(), now), ErrInvalidSignature)
+	s.ErrorIs(s.store.VerifySignature(http.MethodPut, "kivy/a.jpg", u.Query(), now.Add(2*time.Minute)), ErrInvalidSignature)
+
+	_, err = s.store.SignedURL(context.Background(), "kivy/a.jpg", SignedURLOptions{Method: http.MethodDelete, Expiry: time.Minute})
+	s.ErrorIs(err, ErrUnsupportedMethod)
+}
+
+func TestLocalStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(LocalStoreTestSuite))
+}
+
+func fileKeys(files []FileInfo) []string {
+	keys := make([]string, len(files))
+	for i, file := range files {
+		keys[i] = file.Key
+	}
+	return keys
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store keeps files in a bucket of an S3-compatible service, e.g. AWS S3, Cloudflare R2 or
+// MinIO
+type S3Store struct {
+	client        *minio.Client
+	bucket        string
+	publicBaseURL string
+}
+
+func NewS3Store(cfg *configs.Config) (*S3Store, error) {
+	s3cfg := cfg.Storage.S3
+	if s3cfg.Endpoint == "" || s3cfg.Bucket == "" {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+
+	// Endpoints are host[:port], the scheme comes from UseSSL.
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(s3cfg.Endpoint, "https://"), "http://")
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(s3cfg.AccessKeyID, s3cfg.SecretAccessKey, ""),
+		Secure: s3cfg.UseSSL,
+		Region: s3cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	publicBaseURL := s3cfg.PublicBaseURL
+	if publicBaseURL == "" {
+		publicBaseURL = client.EndpointURL().String() + "/" + s3cfg.Bucket
+	}
+
+	return &S3Store{
+		client:        client,
+		bucket:        s3cfg.Bucket,
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+	}, nil
+}
+
+func (s *S3Store) Upload(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	// A size of -1 streams the content in multipart chunks.
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+	}); err != nil {
+		return "", fmt.Errorf("failed to upload %s to s3: %w", key, err)
+	}
+
+	return s.PublicURL(key), nil
+}
+
+func (s *S3Store) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from s3: %w", key, err)
+	}
+
+	// GetObject is lazy, a missing key only shows on the first request.
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to download %s from s3: %w", key, err)
+	}
+
+	return object, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	// S3 doesn't report missing keys on delete.
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete %s from s3: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	var files []FileInfo
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list %s in s3: %w", prefix, object.Err)
+		}
+		files = append(files, FileInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			ContentType:  object.ContentType,
+			LastModified: object.LastModified,
+		})
+	}
+
+	return files, nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (FileInfo, error) {
+	object, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return FileInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to stat %s in s3: %w", key, err)
+	}
+
+	return FileInfo{
+		Key:          key,
+		Size:         object.Size,
+		ContentType:  object.ContentType,
+		LastModified: object.LastModified,
+	}, nil
+}
+
+func (s *S3Store) PublicURL(key string) string {
+	return s.publicBaseURL + "/" + key
+}
+
+func (s *S3Store) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+
+	var err error
+	var signed *url.URL
+	if opts.Method == http.MethodPut {
+		signed, err = s.client.PresignedPutObject(ctx, s.bucket, key, opts.Expiry)
+	} else {
+		signed, err = s.client.PresignedGetObject(ctx, s.bucket, key, opts.Expiry, nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", key, err)
+	}
+
+	return signed.String(), nil
+}
+
+var _ BlobStore = (*S3Store)(nil)
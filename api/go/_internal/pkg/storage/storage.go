@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+)
+
+// Storage drivers selected by configs.Config Storage.Driver
+const (
+	DriverAzure = "azure"
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	ErrNotFound          = errors.New("file not found")
+	ErrUnsupportedMethod = errors.New("signed URLs are only available for GET and PUT")
+)
+
+// BlobStore keeps uploaded files by key, e.g. "kivy-007/Xy9z8w7v6u5t.jpg". Keys use "/" as
+// separator whatever the backend.
+type BlobStore interface {
+	// Upload stores the content at key and returns its public URL. An empty contentType leaves
+	// the backend default.
+	Upload(ctx context.Context, key string, r io.Reader, contentType string) (string, error)
+	// Download opens the file at key, ErrNotFound if there is none. The caller closes it.
+	Download(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file at key, deleting a missing file is not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the files whose key starts with prefix, their size and last modification
+	// time included
+	List(ctx context.Context, prefix string) ([]FileInfo, error)
+	// Stat returns the size and content type of the file at key, ErrNotFound if there is none
+	Stat(ctx context.Context, key string) (FileInfo, error)
+	// PublicURL returns the URL the file at key is publicly served from
+	PublicURL(key string) string
+	// SignedURL returns a URL allowing opts.Method on key without credentials until it expires
+	SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error)
+}
+
+type FileInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+type SignedURLOptions struct {
+	// Method is http.MethodGet to download or http.MethodPut to upload
+	Method string
+	Expiry time.Duration
+}
+
+func (o SignedURLOptions) validate() error {
+	if o.Method != http.MethodGet && o.Method != http.MethodPut {
+		return ErrUnsupportedMethod
+	}
+	if o.Expiry <= 0 {
+		return errors.New("signed URL expiry must be positive")
+	}
+	return nil
+}
+
+// NewBlobStore returns the blob store of the configured driver
+func NewBlobStore(cfg *configs.Config) (BlobStore, error) {
+	switch cfg.Storage.Driver {
+	case DriverAzure, "":
+		return NewAzureStore(cfg)
+	case DriverLocal:
+		return NewLocalStore(cfg)
+	case DriverS3:
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// UploadHeaders returns the headers a client must send along a PUT to a signed URL of the store
+func UploadHeaders(store BlobStore, contentType string) map[string]string {
+	headers := map[string]string{"Content-Type": contentType}
+	if _, ok := store.(*AzureStore); ok {
+		headers["x-ms-blob-type"] = "BlockBlob"
+	}
+	return headers
+}
+
+// KeyFromURL returns the key of a public URL of the store, false for URLs it doesn't serve
+func KeyFromURL(store BlobStore, publicURL string) (string, bool) {
+	key, ok := strings.CutPrefix(publicURL, store.PublicURL(""))
+	if !ok || key == "" {
+		return "", false
+	}
+
+	key, err := url.PathUnescape(key)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// DeleteWithPrefix removes every file whose key starts with prefix and returns how many were
+// removed
+func DeleteWithPrefix(ctx context.Context, store BlobStore, prefix string) (int, error) {
+	files, err := store.List(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list files for deletion: %w", err)
+	}
+
+	deleted := 0
+	for _, file := range files {
+		if err := store.Delete(ctx, file.Key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,219 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+// RoleDAO reads and assigns the roles of users
+type RoleDAO struct {
+	db *sqlx.DB
+}
+
+type RoleDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewRoleDAO(p RoleDAOParams) *RoleDAO {
+	return &RoleDAO{db: p.DB}
+}
+
+// UserAccess returns the names of the user's roles and of the permissions they grant
+func (dao *RoleDAO) UserAccess(ctx context.Context, userID int64) (roles []string, permissions []string, err error) {
+	roles = make([]string, 0)
+	rolesQuery := `
+		SELECT r.name
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name
+	`
+	if err := dao.db.SelectContext(ctx, &roles, rolesQuery, userID); err != nil {
+		return nil, nil, fmt.Errorf("failed to load roles of user %d: %w", userID, err)
+	}
+
+	permissions = make([]string, 0)
+	permissionsQuery := `
+		SELECT DISTINCT p.name
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name
+	`
+	if err := dao.db.SelectContext(ctx, &permissions, permissionsQuery, userID); err != nil {
+		return nil, nil, fmt.Errorf("failed to load permissions of user %d: %w", userID, err)
+	}
+
+	return roles, permissions, nil
+}
+
+// ListRoles returns every role with its permissions
+func (dao *RoleDAO) ListRoles(ctx context.Context) ([]Role, error) {
+	var rows []struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Permission  *string `json:"permission"`
+	}
+	query := `
+		SELECT r.name, COALESCE(r.description, '') as description, p.name as permission
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		ORDER BY r.name, p.name
+	`
+	if err := dao.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := make([]Role, 0)
+	for _, row := range rows {
+		if len(roles) == 0 || roles[len(roles)-1].Name != row.Name {
+			roles = append(roles, Role{
+				Name:        row.Name,
+				Description: row.Description,
+				Permissions: make([]string, 0),
+			})
+		}
+		if row.Permission != nil {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, *row.Permission)
+		}
+	}
+
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles of the user, ErrUnknownRole if one of roles doesn't exist
+func (dao *RoleDAO) SetUserRoles(ctx context.Context, userID int64, roles []string) error {
+	_, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		roleIDs, err := roleIDs(ctx, tx, roles)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+			return nil, fmt.Errorf("failed to clear roles of user %d: %w", userID, err)
+		}
+
+		for _, roleID := range roleIDs {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2)`,
+				userID,
+				roleID,
+			); err != nil {
+				return nil, fmt.Errorf("failed to assign role to user %d: %w", userID, err)
+			}
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+// AssignRole adds the role to the user, assigning a role twice is not an error
+func (dao *RoleDAO) AssignRole(ctx context.Context, userID int64, role string) error {
+	ids, err := roleIDs(ctx, dao.db, []string{role})
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_roles (user_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, role_id) DO NOTHING
+	`
+	if _, err := dao.db.ExecContext(ctx, query, userID, ids[0]); err != nil {
+		return fmt.Errorf("failed to assign role %s to user %d: %w", role, userID, err)
+	}
+	return nil
+}
+
+// RevokeRole removes the role from the user
+func (dao *RoleDAO) RevokeRole(ctx context.Context, userID int64, role string) error {
+	query := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)
+	`
+	if _, err := dao.db.ExecContext(ctx, query, userID, role); err != nil {
+		return fmt.Errorf("failed to revoke role %s from user %d: %w", role, userID, err)
+	}
+	return nil
+}
+
+// Seed creates the permissions and default roles and resets the permissions of the default
+// roles to the ones defined in code. Other roles are left alone.
+func (dao *RoleDAO) Seed(ctx context.Context) error {
+	_, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		permissionQuery := `
+			INSERT INTO permissions (name, description)
+			VALUES ($1, $2)
+			ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description
+		`
+		for _, permission := range Permissions {
+			if _, err := tx.ExecContext(ctx, permissionQuery, permission.Name, permission.Description); err != nil {
+				return nil, fmt.Errorf("failed to seed permission %s: %w", permission.Name, err)
+			}
+		}
+
+		roleQuery := `
+			INSERT INTO roles (name, description)
+			VALUES ($1, $2)
+			ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description, updated_at = NOW()
+			RETURNING id
+		`
+		grantQuery := `
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT $1, id FROM permissions WHERE name = ANY($2)
+		`
+		for _, role := range DefaultRoles {
+			var roleID int64
+			if err := tx.GetContext(ctx, &roleID, roleQuery, role.Name, role.Description); err != nil {
+				return nil, fmt.Errorf("failed to seed role %s: %w", role.Name, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+				return nil, fmt.Errorf("failed to reset permissions of role %s: %w", role.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, grantQuery, roleID, role.Permissions); err != nil {
+				return nil, fmt.Errorf("failed to grant permissions to role %s: %w", role.Name, err)
+			}
+		}
+
+		return nil, nil
+	})
+	return err
+}
+
+type roleRow struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// roleIDs returns the ids of the roles in the order of names
+func roleIDs(ctx context.Context, q sqlx.QueryerContext, names []string) ([]int64, error) {
+	var rows []roleRow
+	if err := sqlx.SelectContext(ctx, q, &rows, `SELECT id, name FROM roles WHERE name = ANY($1)`, names); err != nil {
+		return nil, fmt.Errorf("failed to find roles: %w", err)
+	}
+
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(rows, func(row roleRow) bool { return row.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRole, name)
+		}
+		ids = append(ids, rows[i].ID)
+	}
+
+	return ids, nil
+}
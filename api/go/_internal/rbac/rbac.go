@@ -0,0 +1,70 @@
+package rbac
+
+import "errors"
+
+// Permissions are "<resource>:<action>" names checked by middlewares.RequirePermission
+const (
+	PermProductsWrite = "products:write"
+	PermImagesWrite   = "images:write"
+	PermOrdersRead    = "orders:read"
+	PermOrdersWrite   = "orders:write"
+	PermRolesWrite    = "roles:write"
+)
+
+// Built-in roles
+const (
+	RoleAdmin = "admin"
+	RoleStaff = "staff"
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+type Definition struct {
+	Name        string
+	Description string
+}
+
+// Permissions lists every permission with its description
+var Permissions = []Definition{
+	{PermProductsWrite, "Create, edit and delete products and variants"},
+	{PermImagesWrite, "Upload and manage product images"},
+	{PermOrdersRead, "View orders"},
+	{PermOrdersWrite, "Process, ship, cancel and refund orders"},
+	{PermRolesWrite, "Assign roles to users"},
+}
+
+// DefaultRole is a built-in role and the permissions it grants
+type DefaultRole struct {
+	Definition
+	Permissions []string
+}
+
+// DefaultRoles are created by the roles migration and kept in sync by the seed command
+var DefaultRoles = []DefaultRole{
+	{
+		Definition: Definition{RoleAdmin, "Full access"},
+		Permissions: []string{
+			PermProductsWrite,
+			PermImagesWrite,
+			PermOrdersRead,
+			PermOrdersWrite,
+			PermRolesWrite,
+		},
+	},
+	{
+		Definition: Definition{RoleStaff, "Runs the shop: products, images and orders"},
+		Permissions: []string{
+			PermProductsWrite,
+			PermImagesWrite,
+			PermOrdersRead,
+			PermOrdersWrite,
+		},
+	},
+}
+
+// Role is a role with the permissions it grants
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,143 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+const (
+	topProductsLimit = 10
+	lowStockLimit    = 20
+)
+
+// paidOrdersCTE selects the orders paid in [$1, $2) that are still sales. An order counts
+// from its first move to paid, or from its creation when it was created already paid.
+const paidOrdersCTE = `
+	WITH paid_orders AS (
+		SELECT o.id, o.grand_total
+		FROM orders o
+		LEFT JOIN LATERAL (
+			SELECT MIN(h.created_at) AS paid_at
+			FROM order_status_history h
+			WHERE h.order_id = o.id AND h.to_status = 'paid'
+		) paid ON TRUE
+		WHERE
+			o.status IN ('paid', 'processing', 'shipped', 'delivered') AND
+			COALESCE(paid.paid_at, o.created_at) >= $1 AND
+			COALESCE(paid.paid_at, o.created_at) < $2
+	)
+`
+
+// ReportDAO aggregates orders, order items and stock for sales reports
+type ReportDAO struct {
+	db *sqlx.DB
+}
+
+type ReportDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewReportDAO(p ReportDAOParams) *ReportDAO {
+	return &ReportDAO{db: p.DB}
+}
+
+// GetSalesReport builds the report of the period as of now
+func (dao *ReportDAO) GetSalesReport(ctx context.Context, period Period, now time.Time) (*SalesReport, error) {
+	from, to := period.Range(now)
+
+	report := &SalesReport{
+		Period: period,
+		From:   from,
+		To:     to,
+	}
+
+	summaryQuery := paidOrdersCTE + `
+		SELECT
+			COUNT(*) AS order_count,
+			COALESCE(SUM(grand_total), 0)::float8 AS revenue,
+			(
+				SELECT COALESCE(SUM(oi.quantity), 0)
+				FROM order_items oi
+				WHERE oi.order_id IN (SELECT id FROM paid_orders)
+			) AS units
+		FROM paid_orders
+	`
+	if err := dao.db.GetContext(ctx, report, summaryQuery, from, to); err != nil {
+		return nil, fmt.Errorf("failed to get sales summary: %w", err)
+	}
+
+	productsQuery := paidOrdersCTE + `
+		SELECT
+			p.id AS product_id,
+			p.sku,
+			p.name,
+			SUM(oi.quantity) AS units,
+			SUM(oi.line_total)::float8 AS revenue
+		FROM paid_orders po
+		JOIN order_items oi ON oi.order_id = po.id
+		JOIN products p ON p.id = oi.product_id
+		GROUP BY p.id, p.sku, p.name
+		ORDER BY units DESC, revenue DESC
+		LIMIT $3
+	`
+	report.TopProducts = make([]ProductSales, 0)
+	if err := dao.db.SelectContext(ctx, &report.TopProducts, productsQuery, from, to, topProductsLimit); err != nil {
+		return nil, fmt.Errorf("failed to get product sales: %w", err)
+	}
+
+	lowStock, err := dao.GetLowStockItems(ctx, LowStockThreshold, lowStockLimit)
+	if err != nil {
+		return nil, err
+	}
+	report.LowStock = lowStock
+
+	return report, nil
+}
+
+// GetLowStockItems lists sellable products without variants, and variants of sellable
+// products, whose available stock is at or below threshold, lowest first
+func (dao *ReportDAO) GetLowStockItems(ctx context.Context, threshold, limit int) ([]LowStockItem, error) {
+	query := `
+		SELECT
+			p.sku,
+			p.name,
+			p.stock_count - p.reserved_count AS available
+		FROM products p
+		WHERE
+			p.ready_for_sale AND
+			p.stock_count - p.reserved_count <= $1 AND
+			NOT EXISTS (
+				SELECT 1
+				FROM product_variants pv
+				WHERE pv.product_id = p.id
+			)
+
+		UNION ALL
+
+		SELECT
+			pv.sku,
+			p.name || ' - ' || pv.name AS name,
+			pv.stock_count - pv.reserved_count AS available
+		FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id
+		WHERE
+			p.ready_for_sale AND
+			pv.stock_count - pv.reserved_count <= $1
+
+		ORDER BY available, sku
+		LIMIT $2
+	`
+
+	items := make([]LowStockItem, 0)
+	if err := dao.db.SelectContext(ctx, &items, query, threshold, limit); err != nil {
+		return nil, fmt.Errorf("failed to get low stock items: %w", err)
+	}
+
+	return items, nil
+}
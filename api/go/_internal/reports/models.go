@@ -0,0 +1,36 @@
+package reports
+
+import "time"
+
+// LowStockThreshold is the available quantity at or below which a sellable product or
+// variant is listed as running low
+const LowStockThreshold = 5
+
+// SalesReport summarizes orders paid within a period. Orders that were later canceled or
+// refunded are left out.
+type SalesReport struct {
+	Period      Period         `json:"period"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	OrderCount  int64          `json:"order_count"`
+	Units       int64          `json:"units"`
+	Revenue     float64        `json:"revenue"`
+	TopProducts []ProductSales `json:"top_products"`
+	LowStock    []LowStockItem `json:"low_stock"`
+}
+
+// ProductSales is the units sold and revenue of one product, its variants included
+type ProductSales struct {
+	ProductID int64   `json:"product_id"`
+	SKU       string  `json:"sku"`
+	Name      string  `json:"name"`
+	Units     int64   `json:"units"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// LowStockItem is a product without variants, or a variant, that is running out
+type LowStockItem struct {
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	Available int64  `json:"available"`
+}
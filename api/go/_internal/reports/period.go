@@ -0,0 +1,57 @@
+package reports
+
+import "time"
+
+// Period is the time window a sales report covers, always ending now
+type Period string
+
+const (
+	PeriodToday     Period = "today"
+	PeriodYesterday Period = "yesterday"
+	PeriodWeek      Period = "week"
+	PeriodMonth     Period = "month"
+)
+
+// Periods lists the periods /report accepts
+var Periods = []Period{
+	PeriodToday,
+	PeriodYesterday,
+	PeriodWeek,
+	PeriodMonth,
+}
+
+// Location is where day, week and month boundaries are drawn. The shop is in Taiwan; a fixed
+// zone avoids depending on tzdata in the serverless runtime.
+var Location = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// ParsePeriod returns the period named s, PeriodToday when s is empty
+func ParsePeriod(s string) (Period, bool) {
+	if s == "" {
+		return PeriodToday, true
+	}
+
+	for _, p := range Periods {
+		if string(p) == s {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// Range returns the [from, to) window of the period as of now. Weeks start on Monday.
+func (p Period) Range(now time.Time) (time.Time, time.Time) {
+	now = now.In(Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, Location)
+
+	switch p {
+	case PeriodYesterday:
+		return today.AddDate(0, 0, -1), today
+	case PeriodWeek:
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, -daysSinceMonday), now
+	case PeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, Location), now
+	default:
+		return today, now
+	}
+}
@@ -0,0 +1,40 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodRange(t *testing.T) {
+	taipei := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, Location)
+	}
+
+	// 2025-07-02 16:30 UTC is already Thursday 2025-07-03 00:30 in Taipei.
+	now := time.Date(2025, 7, 2, 16, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period   Period
+		from, to time.Time
+	}{
+		{PeriodToday, taipei(7, 3, 0, 0), now},
+		{PeriodYesterday, taipei(7, 2, 0, 0), taipei(7, 3, 0, 0)},
+		{PeriodWeek, taipei(6, 30, 0, 0), now},
+		{PeriodMonth, taipei(7, 1, 0, 0), now},
+	}
+	for _, tt := range tests {
+		from, to := tt.period.Range(now)
+		assert.WithinDuration(t, tt.from, from, 0, "%s from", tt.period)
+		assert.WithinDuration(t, tt.to, to, 0, "%s to", tt.period)
+	}
+
+	// A Sunday night still belongs to the week that started on Monday.
+	from, _ := PeriodWeek.Range(taipei(7, 6, 23, 59))
+	assert.WithinDuration(t, taipei(6, 30, 0, 0), from, 0, "week of Sunday")
+
+	// 23:59 in Taipei is still the same day.
+	from, _ = PeriodToday.Range(time.Date(2025, 7, 3, 15, 59, 0, 0, time.UTC))
+	assert.WithinDuration(t, taipei(7, 3, 0, 0), from, 0, "today at 23:59 Taipei")
+}
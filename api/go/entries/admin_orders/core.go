@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/admin_orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/orders"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("admin-orders"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		fx.Provide(
+			orders.NewOrderDAO,
+			rbac.NewRoleDAO,
+			middlewares.NewAuth,
+		),
+		fx.Provide(
+			router.AsRoute(admin_orders.NewListOrdersHandler),
+			router.AsRoute(admin_orders.NewGetOrderHandler),
+			router.AsRoute(admin_orders.NewOrderActionHandler),
+		),
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog"
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/admin_products"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("admin-products"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		fx.Provide(
+			catalog.NewProductDAO,
+			rbac.NewRoleDAO,
+			middlewares.NewAuth,
+		),
+		fx.Provide(
+			router.AsRoute(admin_products.NewCreateProductHandler),
+			router.AsRoute(admin_products.NewUpdateProductHandler),
+			router.AsRoute(admin_products.NewDeleteProductHandler),
+			router.AsRoute(admin_products.NewCreateVariantHandler),
+			router.AsRoute(admin_products.NewUpdateVariantHandler),
+			router.AsRoute(admin_products.NewDeleteVariantHandler),
+		),
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/admin_roles"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("admin-roles"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		fx.Provide(
+			rbac.NewRoleDAO,
+			middlewares.NewAuth,
+		),
+		fx.Provide(
+			router.AsRoute(admin_roles.NewListRolesHandler),
+			router.AsRoute(admin_roles.NewGetUserRolesHandler),
+			router.AsRoute(admin_roles.NewSetUserRolesHandler),
+		),
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/files"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+// Handle serves the files of the local blob store when STORAGE_DRIVER=local in development
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("files"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		fx.Provide(
+			storage.NewBlobStore,
+		),
+		fx.Provide(
+			router.AsRoute(files.NewFileHandler),
+		),
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
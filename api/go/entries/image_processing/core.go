@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/images"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+// Handle runs the image processing cron on its own function, processing takes longer than the
+// admin image routes are allowed to.
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("image-processing"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		fx.Provide(
+			storage.NewBlobStore,
+			fx.Annotate(
+				imageingest.NewImageDAO,
+				fx.As(fx.Self()),
+				fx.As(new(imageingest.Repository)),
+			),
+			imageingest.NewIngester,
+			images.NewUploadProcessor,
+		),
+		fx.Provide(
+			router.AsRoute(images.NewProcessUploadsHandler),
+		),
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
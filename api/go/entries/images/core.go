@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/images"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/middlewares"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageingest"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("images"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		fx.Provide(
+			storage.NewBlobStore,
+			imageingest.NewImageDAO,
+			images.NewGalleryDAO,
+			rbac.NewRoleDAO,
+			middlewares.NewAuth,
+		),
+		fx.Provide(
+			router.AsRoute(images.NewCreateUploadHandler),
+			router.AsRoute(images.NewCompleteUploadHandler),
+			router.AsRoute(images.NewListGalleryHandler),
+			router.AsRoute(images.NewReorderImagesHandler),
+			router.AsRoute(images.NewSetPrimaryImageHandler),
+			router.AsRoute(images.NewUpdateImageHandler),
+			router.AsRoute(images.NewAttachImageHandler),
+			router.AsRoute(images.NewDeleteImageHandler),
+		),
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram"
+	telegramfx "github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/router"
+	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/fx"
+)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	fx.New(
+		logger.TagLogger("telegram"),
+		appfx.CoreConfigOptions,
+		routerfx.CoreRouterOptions,
+		telegramfx.CoreBotOptions,
+
+		fx.Provide(
+			router.AsRoute(telegram.NewTelegramHandler),
+			router.AsRoute(telegram.NewOrderNotificationsHandler),
+			router.AsRoute(telegram.NewSessionCleanupHandler),
+			router.AsRoute(telegram.NewDailyReportHandler),
+		),
+
+		fx.Invoke(func(router *chi.Mux) {
+			router.ServeHTTP(w, r)
+		}),
+	)
+}
@@ -6,6 +6,7 @@ import (
 	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
 	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/webhooks"
 	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
 	router "github.com/huangc28/kikichoice-be/api/go/_internal/router"
 	routerfx "github.com/huangc28/kikichoice-be/api/go/_internal/router/fx"
 
@@ -20,9 +21,11 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 		routerfx.CoreRouterOptions,
 		fx.Provide(
 			webhooks.NewUserDAO,
+			rbac.NewRoleDAO,
 		),
 		fx.Provide(
 			router.AsRoute(webhooks.NewWebhookHandler),
+			router.AsRoute(webhooks.NewUpdateUserHandler),
 		),
 		fx.Invoke(func(router *chi.Mux) {
 			router.ServeHTTP(w, r)
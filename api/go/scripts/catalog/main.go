@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/catalog/importer"
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Exports the catalog, products with their variants, specs and image URLs, to CSV and imports
+// CSV or XLSX files back through the same importer as the Telegram /import command. Used to
+// back up the catalog and to move it between Supabase projects: export with the DB_* variables
+// of one project, import with the other's.
+
+const (
+	commandExport = "export"
+	commandImport = "import"
+)
+
+// adjustmentSource marks the stock changes of catalog imports in inventory_adjustments
+const adjustmentSource = "catalog_import"
+
+// Command line flags
+var (
+	dryRunFlag = flag.Bool("dry-run", false, "Report what the import would change without writing anything")
+	helpFlag   = flag.Bool("help", false, "Show help information")
+)
+
+func Run(dao *importer.ImportDAO, sugar *zap.SugaredLogger) {
+	flag.Parse()
+
+	args := flag.Args()
+	if *helpFlag || len(args) == 0 {
+		fmt.Println("Catalog Import/Export Tool")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Printf("  %s [flags] export [output_file.csv]\n", os.Args[0])
+		fmt.Printf("  %s [flags] import <input_file.csv|input_file.xlsx>\n", os.Args[0])
+		fmt.Println()
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Import:")
+		fmt.Println("  Products and variants are matched by SKU, new SKUs are created and existing ones")
+		fmt.Println("  updated with the file's non-blank cells. SKUs missing from the file are left alone.")
+		fmt.Println("  stock_count sets the stock, stock_adjust_count adds to it. The stock of a product")
+		fmt.Println("  with variants is the sum of its variants, its own stock_count is ignored.")
+		fmt.Println()
+		fmt.Println("Examples:")
+		fmt.Println("  go run ./scripts/catalog export catalog.csv")
+		fmt.Println("  go run ./scripts/catalog export > catalog.csv")
+		fmt.Println("  go run ./scripts/catalog --dry-run import catalog.csv")
+		fmt.Println("  DB_HOST=localhost DB_PORT=55322 go run ./scripts/catalog import catalog.csv")
+		fmt.Println()
+		fmt.Println("See scripts/README.md for detailed documentation")
+		return
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case commandExport:
+		path := ""
+		if len(args) > 1 {
+			path = args[1]
+		}
+		if err := export(ctx, dao, path); err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+	case commandImport:
+		if len(args) < 2 {
+			log.Fatalf("import needs the file to read, see --help")
+		}
+		plan, err := importFile(ctx, dao, args[1], *dryRunFlag)
+		if err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+		printPlan(plan, *dryRunFlag)
+
+		created, updated := plan.Counts()
+		sugar.Infow("catalog imported", "file", args[1], "dry_run", *dryRunFlag, "created", created, "updated", updated, "invalid", len(plan.Invalid))
+	default:
+		log.Fatalf("Unknown command %q, expected export or import", args[0])
+	}
+}
+
+// export writes the catalog as CSV to path, or to stdout without one
+func export(ctx context.Context, dao *importer.ImportDAO, path string) error {
+	records, err := dao.Export(ctx)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+
+	if path != "" {
+		fmt.Printf("Exported %d products and variants to %s\n", len(records)-1, path)
+	}
+
+	return nil
+}
+
+func importFile(ctx context.Context, dao *importer.ImportDAO, path string, dryRun bool) (*importer.Plan, error) {
+	if !importer.Supported(path) {
+		return nil, importer.ErrUnsupportedFormat
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	records, err := importer.ReadFile(path, f)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := importer.Parse(records)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return dao.Plan(ctx, batch)
+	}
+	return dao.Apply(ctx, batch, importer.Actor{Source: adjustmentSource})
+}
+
+func printPlan(plan *importer.Plan, dryRun bool) {
+	created, updated := plan.Counts()
+
+	fmt.Printf("\n=== Import Summary ===\n")
+	if dryRun {
+		fmt.Printf("🔍 DRY RUN MODE - No actual changes were made\n")
+	}
+	fmt.Printf("Created: %d\n", created)
+	fmt.Printf("Updated: %d\n", updated)
+	fmt.Printf("Unchanged: %d\n", plan.Unchanged)
+	fmt.Printf("Invalid: %d\n", len(plan.Invalid))
+
+	if !plan.Empty() {
+		fmt.Printf("\nChanges:\n")
+		for _, c := range plan.Products {
+			printChange("product", c.Row.Line, c.Row.SKU, c.IsNew(), c.Changes)
+		}
+		for _, c := range plan.Variants {
+			printChange("variant", c.Row.Line, c.Row.SKU, c.IsNew(), c.Changes)
+		}
+	}
+
+	if len(plan.Invalid) > 0 {
+		fmt.Printf("\nInvalid rows, left out of the import:\n")
+		for _, row := range plan.Invalid {
+			fmt.Printf("- line %d %s: %v\n", row.Line, row.SKU, row.Err)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\n💡 Run without --dry-run flag to perform actual import\n")
+	}
+}
+
+func printChange(kind string, line int, sku string, isNew bool, changes []importer.FieldChange) {
+	if isNew {
+		fmt.Printf("+ line %d %s %s\n", line, kind, sku)
+		return
+	}
+
+	fmt.Printf("~ line %d %s %s\n", line, kind, sku)
+	for _, fc := range changes {
+		fmt.Printf("    %s: %q -> %q\n", fc.Field, fc.From, fc.To)
+	}
+}
+
+func main() {
+	fx.New(
+		logger.TagLogger("catalog"),
+		appfx.CoreConfigOptions,
+		fx.Provide(
+			importer.NewImportDAO,
+		),
+		fx.Invoke(Run),
+	)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/db"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/imageproc"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+// danglingCondition matches links of image_entities whose product or variant is gone, entity_id
+// has no foreign key to catch them
+const danglingCondition = `
+	NOT EXISTS (SELECT 1 FROM products p WHERE ie.entity_type = 'product' AND p.id = ie.entity_id)
+	AND NOT EXISTS (SELECT 1 FROM product_variants pv WHERE ie.entity_type = 'product_variant' AND pv.id = ie.entity_id)
+`
+
+// unreferencedCondition matches images no entity uses once the dangling links older than $1 are
+// deleted
+const unreferencedCondition = `
+	i.created_at < $1
+	AND NOT EXISTS (
+		SELECT 1
+		FROM image_entities ie
+		WHERE ie.image_id = i.id
+		AND NOT (ie.created_at < $1 AND ` + danglingCondition + `)
+	)
+`
+
+type DanglingEntity struct {
+	ID         int64         `json:"id"`
+	EntityType db.EntityType `json:"entity_type"`
+	EntityID   int64         `json:"entity_id"`
+	ImageID    int64         `json:"image_id"`
+}
+
+type StoredImage struct {
+	ID         int64                `json:"id"`
+	URL        string               `json:"url"`
+	Renditions imageproc.Renditions `json:"renditions"`
+}
+
+// URLs returns the URLs of the image's files, the original and its renditions
+func (i StoredImage) URLs() []string {
+	urls := []string{i.URL}
+	for _, rendition := range i.Renditions {
+		urls = append(urls, rendition.URL)
+	}
+	return urls
+}
+
+// GCDAO finds the image rows left behind by deleted products and variants
+type GCDAO struct {
+	db *sqlx.DB
+}
+
+type GCDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewGCDAO(p GCDAOParams) *GCDAO {
+	return &GCDAO{db: p.DB}
+}
+
+// DanglingEntities returns the links created before cutoff whose product or variant is gone
+func (dao *GCDAO) DanglingEntities(ctx context.Context, cutoff time.Time) ([]DanglingEntity, error) {
+	query := `
+		SELECT ie.id, ie.entity_type, ie.entity_id, ie.image_id
+		FROM image_entities ie
+		WHERE ie.created_at < $1 AND ` + danglingCondition + `
+		ORDER BY ie.id
+	`
+
+	var entities []DanglingEntity
+	if err := dao.db.SelectContext(ctx, &entities, query, cutoff); err != nil {
+		return nil, fmt.Errorf("failed to find dangling image entities: %w", err)
+	}
+	return entities, nil
+}
+
+// UnreferencedImages returns the images created before cutoff that no entity uses, counting the
+// dangling links as deleted
+func (dao *GCDAO) UnreferencedImages(ctx context.Context, cutoff time.Time) ([]StoredImage, error) {
+	query := `
+		SELECT i.id, i.url, i.renditions
+		FROM images i
+		WHERE ` + unreferencedCondition + `
+		ORDER BY i.id
+	`
+
+	var images []StoredImage
+	if err := dao.db.SelectContext(ctx, &images, query, cutoff); err != nil {
+		return nil, fmt.Errorf("failed to find unreferenced images: %w", err)
+	}
+	return images, nil
+}
+
+// Images returns every image row, to tell which files of the blob store are still used
+func (dao *GCDAO) Images(ctx context.Context) ([]StoredImage, error) {
+	var images []StoredImage
+	if err := dao.db.SelectContext(ctx, &images, `SELECT id, url, renditions FROM images`); err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	return images, nil
+}
+
+// DeleteRows deletes the given dangling links and unreferenced images in one transaction. The
+// conditions are checked again so rows used since they were found are kept. It returns how many
+// links were deleted and the IDs of the deleted images.
+func (dao *GCDAO) DeleteRows(ctx context.Context, cutoff time.Time, entityIDs, imageIDs []int64) (int64, []int64, error) {
+	type deleted struct {
+		entities int64
+		images   []int64
+	}
+
+	res, err := db.Tx(dao.db, func(tx *sqlx.Tx) (any, error) {
+		var rows deleted
+
+		entitiesQuery := `
+			DELETE FROM image_entities ie
+			WHERE ie.id = ANY($2) AND ie.created_at < $1 AND ` + danglingCondition
+		entitiesRes, err := tx.ExecContext(ctx, entitiesQuery, cutoff, entityIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete dangling image entities: %w", err)
+		}
+		if rows.entities, err = entitiesRes.RowsAffected(); err != nil {
+			return nil, err
+		}
+
+		imagesQuery := `
+			DELETE FROM images i
+			WHERE i.id = ANY($2) AND ` + unreferencedCondition + `
+			RETURNING i.id`
+		if err := tx.SelectContext(ctx, &rows.images, imagesQuery, cutoff, imageIDs); err != nil {
+			return nil, fmt.Errorf("failed to delete unreferenced images: %w", err)
+		}
+
+		return rows, nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows := res.(deleted)
+	return rows.entities, rows.images, nil
+}
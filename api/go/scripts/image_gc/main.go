@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/storage"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Finds what deleting products and variants leaves behind, image_entities.entity_id has no
+// foreign key: links to entities that are gone, images rows no entity uses and files under
+// --prefix of the blob store no images row points to. Only reports them unless --dry-run=false
+// is given, both modes find them the same way before anything is deleted. Anything younger than
+// --min-age is kept so uploads still in progress are never touched.
+
+// Command line flags
+var (
+	dryRunFlag = flag.Bool("dry-run", true, "Report what would be deleted without deleting anything, pass --dry-run=false to delete")
+	minAgeFlag = flag.Duration("min-age", 24*time.Hour, "Keep rows and files younger than this")
+	prefixFlag = flag.String("prefix", "", "Only look at files whose key starts with this. The Azure store is the products container already, set it when an S3 bucket or local root holds other files too.")
+	helpFlag   = flag.Bool("help", false, "Show help information")
+)
+
+type Report struct {
+	DryRun           bool
+	Cutoff           time.Time
+	DanglingEntities []DanglingEntity
+	Images           []StoredImage
+	Blobs            []string
+
+	// Deleted counts, only set when deleting
+	DeletedEntities int64
+	DeletedImages   int64
+	DeletedBlobs    int
+}
+
+func Run(dao *GCDAO, store storage.BlobStore, sugar *zap.SugaredLogger) {
+	flag.Parse()
+
+	if *helpFlag {
+		fmt.Println("Orphaned Image Cleanup Tool")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Printf("  %s [flags]\n", os.Args[0])
+		fmt.Println()
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Finds:")
+		fmt.Println("  - image_entities rows whose product or variant no longer exists")
+		fmt.Println("  - images rows no product or variant uses")
+		fmt.Println("  - files of the blob store no images row points to, renditions included")
+		fmt.Println()
+		fmt.Println("Examples:")
+		fmt.Println("  go run ./scripts/image_gc")
+		fmt.Println("  go run ./scripts/image_gc --min-age 168h")
+		fmt.Println("  go run ./scripts/image_gc --dry-run=false")
+		fmt.Println()
+		fmt.Println("See scripts/README.md for detailed documentation")
+		return
+	}
+
+	if *minAgeFlag < time.Hour {
+		log.Fatalf("--min-age must be at least 1h so uploads in progress are kept")
+	}
+
+	report, err := collect(context.Background(), dao, store, *prefixFlag, *dryRunFlag, time.Now().Add(-*minAgeFlag))
+	if err != nil {
+		log.Fatalf("Image cleanup failed: %v", err)
+	}
+	printReport(report)
+
+	sugar.Infow("orphaned images collected",
+		"dry_run", report.DryRun,
+		"dangling_entities", len(report.DanglingEntities),
+		"images", len(report.Images),
+		"blobs", len(report.Blobs),
+		"deleted_entities", report.DeletedEntities,
+		"deleted_images", report.DeletedImages,
+		"deleted_blobs", report.DeletedBlobs,
+	)
+}
+
+func collect(ctx context.Context, dao *GCDAO, store storage.BlobStore, prefix string, dryRun bool, cutoff time.Time) (*Report, error) {
+	report := &Report{DryRun: dryRun, Cutoff: cutoff}
+
+	var err error
+	if report.DanglingEntities, err = dao.DanglingEntities(ctx, cutoff); err != nil {
+		return nil, err
+	}
+	if report.Images, err = dao.UnreferencedImages(ctx, cutoff); err != nil {
+		return nil, err
+	}
+
+	// Files are matched against the images that are kept, the unreferenced ones are left out.
+	images, err := dao.Images(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if report.Blobs, err = orphanedBlobs(ctx, store, prefix, keptImages(images, imageIDs(report.Images)), cutoff); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	entityIDs := make([]int64, len(report.DanglingEntities))
+	for i, entity := range report.DanglingEntities {
+		entityIDs[i] = entity.ID
+	}
+	unreferenced := imageIDs(report.Images)
+
+	deletedEntities, deletedImages, err := dao.DeleteRows(ctx, cutoff, entityIDs, slices.Collect(maps.Keys(unreferenced)))
+	if err != nil {
+		return nil, err
+	}
+	report.DeletedEntities = deletedEntities
+	report.DeletedImages = int64(len(deletedImages))
+
+	// DeleteRows keeps the images used again since they were found, their files stay too.
+	deleted := make(map[int64]bool, len(deletedImages))
+	for _, id := range deletedImages {
+		deleted[id] = true
+	}
+	spared := make(map[string]bool)
+	for _, image := range keptImages(report.Images, deleted) {
+		for _, url := range image.URLs() {
+			if key, ok := storage.KeyFromURL(store, url); ok {
+				spared[key] = true
+			}
+		}
+	}
+
+	for _, key := range report.Blobs {
+		if spared[key] {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			return report, fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+		report.DeletedBlobs++
+	}
+
+	return report, nil
+}
+
+func imageIDs(images []StoredImage) map[int64]bool {
+	ids := make(map[int64]bool, len(images))
+	for _, image := range images {
+		ids[image.ID] = true
+	}
+	return ids
+}
+
+// keptImages returns the images whose ID is not in removed
+func keptImages(images []StoredImage, removed map[int64]bool) []StoredImage {
+	var kept []StoredImage
+	for _, image := range images {
+		if !removed[image.ID] {
+			kept = append(kept, image)
+		}
+	}
+	return kept
+}
+
+// orphanedBlobs returns the keys under prefix no image points to, last modified before cutoff
+func orphanedBlobs(ctx context.Context, store storage.BlobStore, prefix string, images []StoredImage, cutoff time.Time) ([]string, error) {
+	referenced := make(map[string]bool)
+	for _, image := range images {
+		for _, url := range image.URLs() {
+			if key, ok := storage.KeyFromURL(store, url); ok {
+				referenced[key] = true
+			}
+		}
+	}
+
+	files, err := store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphaned []string
+	for _, file := range files {
+		if !referenced[file.Key] && file.LastModified.Before(cutoff) {
+			orphaned = append(orphaned, file.Key)
+		}
+	}
+
+	return orphaned, nil
+}
+
+func printReport(report *Report) {
+	fmt.Printf("\n=== Image Cleanup Summary ===\n")
+	if report.DryRun {
+		fmt.Printf("🔍 DRY RUN MODE - No actual changes were made\n")
+	}
+	fmt.Printf("Older than: %s\n", report.Cutoff.Format(time.RFC3339))
+
+	fmt.Printf("\nDangling image entities: %d\n", len(report.DanglingEntities))
+	for _, entity := range report.DanglingEntities {
+		fmt.Printf("  - #%d %s %d -> image %d\n", entity.ID, entity.EntityType, entity.EntityID, entity.ImageID)
+	}
+
+	fmt.Printf("\nUnreferenced images: %d\n", len(report.Images))
+	for _, image := range report.Images {
+		fmt.Printf("  - #%d %s\n", image.ID, image.URL)
+	}
+
+	fmt.Printf("\nOrphaned files: %d\n", len(report.Blobs))
+	for _, key := range report.Blobs {
+		fmt.Printf("  - %s\n", key)
+	}
+
+	if !report.DryRun {
+		fmt.Printf("\n✅ Deleted %d image entities, %d images and %d files\n",
+			report.DeletedEntities, report.DeletedImages, report.DeletedBlobs)
+	}
+}
+
+func main() {
+	fx.New(
+		logger.TagLogger("image-gc"),
+		appfx.CoreConfigOptions,
+		fx.Provide(
+			storage.NewBlobStore,
+			NewGCDAO,
+		),
+		fx.Invoke(Run),
+	)
+}
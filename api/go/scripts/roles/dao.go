@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+var errUserNotFound = errors.New("user not found")
+
+// UserDAO finds the users roles are assigned to
+type UserDAO struct {
+	db *sqlx.DB
+}
+
+type UserDAOParams struct {
+	fx.In
+
+	DB *sqlx.DB
+}
+
+func NewUserDAO(p UserDAOParams) *UserDAO {
+	return &UserDAO{db: p.DB}
+}
+
+// FindIDByEmail returns the id of the user signed up with email, ignoring case
+func (dao *UserDAO) FindIDByEmail(ctx context.Context, email string) (int64, error) {
+	query := `
+		SELECT id
+		FROM users
+		WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT 1
+	`
+
+	var id int64
+	err := dao.db.GetContext(ctx, &id, query, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", errUserNotFound, email)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find user %s: %w", email, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/rbac"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Seeds the built-in roles and permissions and assigns roles to users by email. Granting the
+// first admin has to happen here, the role endpoints themselves require roles:write.
+
+const (
+	commandSeed   = "seed"
+	commandAssign = "assign"
+	commandRevoke = "revoke"
+	commandList   = "list"
+)
+
+var helpFlag = flag.Bool("help", false, "Show help information")
+
+func Run(roles *rbac.RoleDAO, users *UserDAO, sugar *zap.SugaredLogger) {
+	flag.Parse()
+
+	args := flag.Args()
+	if *helpFlag || len(args) == 0 {
+		fmt.Println("Role Management Tool")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Printf("  %s seed\n", os.Args[0])
+		fmt.Printf("  %s assign <email> <role>\n", os.Args[0])
+		fmt.Printf("  %s revoke <email> <role>\n", os.Args[0])
+		fmt.Printf("  %s list [email]\n", os.Args[0])
+		fmt.Println()
+		fmt.Println("Commands:")
+		fmt.Println("  seed    Create the built-in roles and permissions, resetting the permissions of")
+		fmt.Println("          the built-in roles. Other roles are left alone.")
+		fmt.Println("  assign  Give the role to the user")
+		fmt.Println("  revoke  Take the role away from the user")
+		fmt.Println("  list    List the roles, or the roles and permissions of the user")
+		fmt.Println()
+		fmt.Println("Examples:")
+		fmt.Println("  go run ./scripts/roles seed")
+		fmt.Println("  go run ./scripts/roles assign owner@kikichoice.com admin")
+		fmt.Println("  go run ./scripts/roles list owner@kikichoice.com")
+		fmt.Println()
+		fmt.Println("See scripts/README.md for detailed documentation")
+		return
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case commandSeed:
+		if err := roles.Seed(ctx); err != nil {
+			log.Fatalf("Seed failed: %v", err)
+		}
+		fmt.Printf("Seeded %d permissions and %d roles\n", len(rbac.Permissions), len(rbac.DefaultRoles))
+	case commandAssign, commandRevoke:
+		if len(args) < 3 {
+			log.Fatalf("%s needs the email of the user and the role, see --help", args[0])
+		}
+		email, role := args[1], args[2]
+
+		userID, err := users.FindIDByEmail(ctx, email)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		if args[0] == commandAssign {
+			err = roles.AssignRole(ctx, userID, role)
+		} else {
+			err = roles.RevokeRole(ctx, userID, role)
+		}
+		if err != nil {
+			log.Fatalf("Failed to %s role: %v", args[0], err)
+		}
+
+		sugar.Infow("user roles changed", "command", args[0], "user_id", userID, "email", email, "role", role)
+		if err := printUserAccess(ctx, roles, email, userID); err != nil {
+			log.Fatalf("%v", err)
+		}
+	case commandList:
+		if len(args) > 1 {
+			userID, err := users.FindIDByEmail(ctx, args[1])
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			if err := printUserAccess(ctx, roles, args[1], userID); err != nil {
+				log.Fatalf("%v", err)
+			}
+			return
+		}
+
+		list, err := roles.ListRoles(ctx)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		for _, role := range list {
+			fmt.Printf("%s: %s\n", role.Name, strings.Join(role.Permissions, ", "))
+		}
+	default:
+		log.Fatalf("Unknown command %q, expected seed, assign, revoke or list", args[0])
+	}
+}
+
+func printUserAccess(ctx context.Context, roles *rbac.RoleDAO, email string, userID int64) error {
+	names, permissions, err := roles.UserAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (user %d)\n", email, userID)
+	fmt.Printf("Roles: %s\n", orNone(names))
+	fmt.Printf("Permissions: %s\n", orNone(permissions))
+	return nil
+}
+
+func orNone(names []string) string {
+	if len(names) == 0 {
+		return "(none)"
+	}
+	return strings.Join(names, ", ")
+}
+
+func main() {
+	fx.New(
+		logger.TagLogger("roles"),
+		appfx.CoreConfigOptions,
+		fx.Provide(
+			rbac.NewRoleDAO,
+			NewUserDAO,
+		),
+		fx.Invoke(Run),
+	)
+}
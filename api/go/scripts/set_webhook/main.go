@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huangc28/kikichoice-be/api/go/_internal/configs"
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Registers (or removes) the Telegram bot webhook together with the secret token
+// that TelegramHandler expects in the X-Telegram-Bot-Api-Secret-Token header.
+
+const telegramAPIEndpoint = "https://api.telegram.org/bot%s/%s"
+
+// secretTokenPattern mirrors the Bot API constraint on secret_token: 1-256 characters, A-Z, a-z, 0-9, _ and -.
+var secretTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// allowedUpdates lists the update types the bot handles.
+var allowedUpdates = []string{"message", "callback_query"}
+
+type botAPIResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+}
+
+type WebhookRegistrar struct {
+	cfg        *configs.Config
+	logger     *zap.SugaredLogger
+	httpClient *http.Client
+}
+
+type WebhookRegistrarParams struct {
+	fx.In
+
+	Cfg    *configs.Config
+	Logger *zap.SugaredLogger
+}
+
+func NewWebhookRegistrar(p WebhookRegistrarParams) *WebhookRegistrar {
+	return &WebhookRegistrar{
+		cfg:        p.Cfg,
+		logger:     p.Logger,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// SetWebhook points the bot at webhookURL and registers the configured secret token.
+func (r *WebhookRegistrar) SetWebhook(ctx context.Context, webhookURL string, dropPendingUpdates bool) error {
+	if !secretTokenPattern.MatchString(r.cfg.Telegram.WebhookSecret) {
+		return fmt.Errorf("TELEGRAM_WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ or -")
+	}
+
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute https url: %s", webhookURL)
+	}
+
+	allowedUpdatesJSON, err := json.Marshal(allowedUpdates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allowed updates: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("url", webhookURL)
+	params.Set("secret_token", r.cfg.Telegram.WebhookSecret)
+	params.Set("allowed_updates", string(allowedUpdatesJSON))
+	params.Set("drop_pending_updates", strconv.FormatBool(dropPendingUpdates))
+
+	return r.call(ctx, "setWebhook", params)
+}
+
+// DeleteWebhook removes the webhook so the bot stops receiving updates over HTTP.
+func (r *WebhookRegistrar) DeleteWebhook(ctx context.Context, dropPendingUpdates bool) error {
+	params := url.Values{}
+	params.Set("drop_pending_updates", strconv.FormatBool(dropPendingUpdates))
+
+	return r.call(ctx, "deleteWebhook", params)
+}
+
+func (r *WebhookRegistrar) call(ctx context.Context, method string, params url.Values) error {
+	if r.cfg.Telegram.BotToken == "" {
+		return fmt.Errorf("TELEGRAM_BOT_TOKEN is not configured")
+	}
+
+	endpoint := fmt.Sprintf(telegramAPIEndpoint, r.cfg.Telegram.BotToken, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		// Unwrap *url.Error so the bot token embedded in the request url is not logged.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp botAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+
+	if !apiResp.OK {
+		return fmt.Errorf("%s failed (%d): %s", method, apiResp.ErrorCode, apiResp.Description)
+	}
+
+	r.logger.Infof("%s succeeded: %s", method, apiResp.Description)
+	return nil
+}
+
+// Command line flags
+var (
+	urlFlag         = flag.String("url", "", "Public https url of the telegram webhook, e.g. https://example.com/v1/webhooks/telegram")
+	deleteFlag      = flag.Bool("delete", false, "Delete the webhook instead of setting it")
+	dropPendingFlag = flag.Bool("drop-pending-updates", false, "Drop all pending updates queued on Telegram")
+	helpFlag        = flag.Bool("help", false, "Show help information")
+)
+
+func Run(registrar *WebhookRegistrar) {
+	flag.Parse()
+
+	if *helpFlag {
+		fmt.Println("Telegram Webhook Registration Tool")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Printf("  %s [flags]\n", os.Args[0])
+		fmt.Println()
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Examples:")
+		fmt.Println("  go run ./scripts/set_webhook --url https://xxxx.ngrok-free.app/v1/webhooks/telegram")
+		fmt.Println("  go run ./scripts/set_webhook --delete --drop-pending-updates")
+		fmt.Println()
+		fmt.Println("Environment Variables:")
+		fmt.Println("  TELEGRAM_BOT_TOKEN       # Bot token issued by BotFather")
+		fmt.Println("  TELEGRAM_WEBHOOK_SECRET  # Secret token sent back in X-Telegram-Bot-Api-Secret-Token")
+		return
+	}
+
+	ctx := context.Background()
+
+	if *deleteFlag {
+		if err := registrar.DeleteWebhook(ctx, *dropPendingFlag); err != nil {
+			log.Fatalf("Failed to delete webhook: %v", err)
+		}
+		return
+	}
+
+	if *urlFlag == "" {
+		log.Fatalf("--url is required when setting the webhook")
+	}
+
+	if err := registrar.SetWebhook(ctx, *urlFlag, *dropPendingFlag); err != nil {
+		log.Fatalf("Failed to set webhook: %v", err)
+	}
+}
+
+func main() {
+	fx.New(
+		logger.TagLogger("telegram-set-webhook"),
+		appfx.CoreConfigOptions,
+		fx.Provide(
+			NewWebhookRegistrar,
+		),
+		fx.Invoke(Run),
+	)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	appfx "github.com/huangc28/kikichoice-be/api/go/_internal/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram"
+	telegramfx "github.com/huangc28/kikichoice-be/api/go/_internal/handlers/telegram/fx"
+	"github.com/huangc28/kikichoice-be/api/go/_internal/pkg/logger"
+
+	"go.uber.org/fx"
+)
+
+// Runs the bot locally with getUpdates long polling instead of the webhook. Updates go through
+// the same TelegramHandler and command handlers the webhook entry uses.
+
+// Command line flags
+var (
+	deleteWebhookFlag = flag.Bool("delete-webhook", false, "Delete the webhook first, getUpdates is refused while one is set")
+	dropPendingFlag   = flag.Bool("drop-pending-updates", false, "Drop updates queued on Telegram when deleting the webhook")
+	helpFlag          = flag.Bool("help", false, "Show help information")
+)
+
+func Run(lc fx.Lifecycle, shutdowner fx.Shutdowner, poller *telegram.Poller) error {
+	if *deleteWebhookFlag {
+		if err := poller.DeleteWebhook(*dropPendingFlag); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				if err := poller.Run(ctx); err != nil {
+					fmt.Fprintf(os.Stderr, "Polling stopped: %v\n", err)
+				}
+				_ = shutdowner.Shutdown()
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	if *helpFlag {
+		fmt.Println("Telegram Long Polling Tool")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Printf("  %s [flags]\n", os.Args[0])
+		fmt.Println()
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Examples:")
+		fmt.Println("  go run ./scripts/telegram_poll --delete-webhook")
+		fmt.Println("  TELEGRAM_API_BASE_URL=http://localhost:8081 go run ./scripts/telegram_poll")
+		fmt.Println()
+		fmt.Println("Environment Variables:")
+		fmt.Println("  TELEGRAM_BOT_TOKEN     # Bot token, preferably of a bot dedicated to development")
+		fmt.Println("  TELEGRAM_API_BASE_URL  # Bot API server, defaults to https://api.telegram.org")
+		return
+	}
+
+	fx.New(
+		logger.TagLogger("telegram-poll"),
+		appfx.CoreConfigOptions,
+		telegramfx.CoreBotOptions,
+
+		fx.Provide(
+			telegram.NewTelegramHandler,
+			telegram.NewPoller,
+		),
+
+		fx.Invoke(Run),
+	).Run()
+}
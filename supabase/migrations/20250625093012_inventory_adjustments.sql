@@ -0,0 +1,18 @@
+-- Audit log of manual stock corrections made by staff (e.g. via the Telegram /stock command)
+create table inventory_adjustments (
+  id                  bigserial primary key,
+  entity_type         entity_type not null,                 -- 'product' or 'product_variant'
+  entity_id           bigint not null,
+  sku                 varchar(100) not null,
+  adjustment_type     text not null check (adjustment_type in ('set', 'increment')),
+  quantity            int not null,                         -- absolute value for 'set', delta for 'increment'
+  previous_stock      int not null,
+  new_stock           int not null check (new_stock >= 0),
+  source              text not null default 'telegram',
+  actor_telegram_id   bigint,
+  actor_username      text,
+  created_at          timestamptz not null default now()
+);
+
+create index inventory_adjustments_entity_idx on inventory_adjustments(entity_type, entity_id);
+create index inventory_adjustments_created_idx on inventory_adjustments(created_at desc);
@@ -0,0 +1,50 @@
+-- Audit trail of order status changes, written by every code path that moves an order
+create table order_status_history (
+  id            bigserial primary key,
+  order_id      bigint not null references orders(id) on delete cascade,
+  from_status   order_status,                          -- null for the initial status
+  to_status     order_status not null,
+  actor         status_actor not null,
+  actor_ref     text,                                  -- e.g. telegram user id, webhook provider
+  note          text,
+  created_at    timestamptz not null default now()
+);
+
+create index order_status_history_order_idx on order_status_history(order_id, created_at);
+
+-- Outbox of staff notifications, retried until the Bot API accepts them
+create table order_notifications (
+  id               bigserial primary key,
+  order_id         bigint not null references orders(id) on delete cascade,
+  kind             text not null default 'order_paid',
+  status           text not null default 'pending' check (status in ('pending', 'sent', 'failed')),
+  attempts         int not null default 0,
+  last_error       text,
+  next_attempt_at  timestamptz not null default now(),
+  sent_at          timestamptz,
+  created_at       timestamptz not null default now(),
+  updated_at       timestamptz not null default now(),
+  unique (order_id, kind)
+);
+
+create index order_notifications_pending_idx on order_notifications(next_attempt_at) where status = 'pending';
+
+-- Enqueue a notification whenever an order becomes paid, no matter which writer marked it paid
+create or replace function enqueue_order_paid_notification()
+ returns trigger
+ language plpgsql
+as $function$
+begin
+    if new.status = 'paid' and (tg_op = 'INSERT' or old.status is distinct from 'paid') then
+        insert into order_notifications (order_id, kind)
+        values (new.id, 'order_paid')
+        on conflict (order_id, kind) do nothing;
+    end if;
+    return new;
+end;
+$function$
+;
+
+create trigger enqueue_order_paid_notification
+  after insert or update of status on orders
+  for each row execute function enqueue_order_paid_notification();
@@ -0,0 +1,5 @@
+-- Optimistic concurrency for bot sessions: every write bumps version and must match the version it read
+alter table user_sessions add column version integer not null default 1;
+
+-- Replies are matched to sessions by chat, user and the prompt they reply to
+create index idx_user_sessions_chat_user on user_sessions(chat_id, user_id);
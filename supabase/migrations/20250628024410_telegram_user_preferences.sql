@@ -0,0 +1,7 @@
+-- Language picked by a staff member with /lang; without a row the bot follows the Telegram client language
+create table telegram_user_preferences (
+    user_id bigint primary key,
+    locale varchar(16) not null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
@@ -0,0 +1,5 @@
+-- Dimensions of the stored image and its resized renditions, EXIF stripped on ingestion.
+-- renditions holds [{"name": "medium", "format": "webp", "width": 800, "height": 600, "url": "..."}]
+alter table images add column width integer;
+alter table images add column height integer;
+alter table images add column renditions jsonb not null default '[]'::jsonb;
@@ -0,0 +1,19 @@
+-- Images uploaded through signed URLs are recorded as soon as the upload completes, pointing at
+-- the uploaded file. A cron job then processes them: re-encodes the original, generates the
+-- renditions and swaps them into the images row. Failed runs are retried with backoff.
+create table image_processing_jobs (
+  id               bigserial primary key,
+  image_id         bigint not null references images(id) on delete cascade,
+  upload_key       text not null,                -- the uploaded file, deleted once processed
+  status           text not null default 'pending' check (status in ('pending', 'done', 'failed')),
+  attempts         int not null default 0,
+  last_error       text,
+  next_attempt_at  timestamptz not null default now(),
+  processed_at     timestamptz,
+  created_at       timestamptz not null default now(),
+  updated_at       timestamptz not null default now(),
+  unique (image_id),
+  unique (upload_key)
+);
+
+create index image_processing_jobs_pending_idx on image_processing_jobs(next_attempt_at) where status = 'pending';
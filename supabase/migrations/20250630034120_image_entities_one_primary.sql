@@ -0,0 +1,15 @@
+-- At most one primary image per product or variant. Entities with several primaries keep the
+-- first one by sort order.
+update image_entities ie
+set is_primary = false
+where ie.is_primary
+  and exists (
+    select 1
+    from image_entities other
+    where other.entity_type = ie.entity_type
+      and other.entity_id = ie.entity_id
+      and other.is_primary
+      and (coalesce(other.sort_order, 0), other.id) < (coalesce(ie.sort_order, 0), ie.id)
+  );
+
+create unique index image_entities_one_primary_idx on image_entities (entity_type, entity_id) where is_primary;
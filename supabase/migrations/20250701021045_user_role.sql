@@ -0,0 +1,4 @@
+-- Staff accounts are told apart from shoppers by their role, admin endpoints require 'admin'.
+create type user_role as enum ('customer', 'admin');
+
+alter table users add column role user_role not null default 'customer';
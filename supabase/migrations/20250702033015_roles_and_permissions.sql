@@ -0,0 +1,60 @@
+-- Role based access for admin endpoints. Users get permissions through their roles, the
+-- defaults below are kept in sync by `go run ./scripts/roles seed`.
+create table roles (
+  id          bigserial primary key,
+  name        varchar(50) not null unique,
+  description text,
+  created_at  timestamptz not null default now(),
+  updated_at  timestamptz not null default now()
+);
+
+create table permissions (
+  id          bigserial primary key,
+  name        varchar(100) not null unique,               -- '<resource>:<action>', e.g. 'products:write'
+  description text,
+  created_at  timestamptz not null default now()
+);
+
+create table role_permissions (
+  role_id       bigint not null references roles(id) on delete cascade,
+  permission_id bigint not null references permissions(id) on delete cascade,
+  created_at    timestamptz not null default now(),
+  primary key (role_id, permission_id)
+);
+
+create table user_roles (
+  user_id    bigint not null references users(id) on delete cascade,
+  role_id    bigint not null references roles(id) on delete cascade,
+  created_at timestamptz not null default now(),
+  primary key (user_id, role_id)
+);
+
+create index user_roles_role_id_idx on user_roles(role_id);
+
+insert into permissions (name, description) values
+  ('products:write', 'Create, edit and delete products and variants'),
+  ('images:write', 'Upload and manage product images'),
+  ('orders:read', 'View orders'),
+  ('orders:write', 'Process, ship, cancel and refund orders'),
+  ('roles:write', 'Assign roles to users');
+
+insert into roles (name, description) values
+  ('admin', 'Full access'),
+  ('staff', 'Runs the shop: products, images and orders');
+
+insert into role_permissions (role_id, permission_id)
+select r.id, p.id
+from roles r
+join permissions p on r.name = 'admin'
+  or (r.name = 'staff' and p.name in ('products:write', 'images:write', 'orders:read', 'orders:write'));
+
+-- users.role only told admins apart, they keep their access through the admin role.
+insert into user_roles (user_id, role_id)
+select u.id, r.id
+from users u
+join roles r on r.name = 'admin'
+where u.role = 'admin';
+
+alter table users drop column role;
+
+drop type user_role;
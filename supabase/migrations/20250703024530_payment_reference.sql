@@ -0,0 +1,5 @@
+-- Our id of a payment attempt at the provider, e.g. the ECPay MerchantTradeNo. Every attempt
+-- needs a new one, so an order retrying payment gets a new payments row.
+alter table payments add column reference text;
+
+create unique index payments_provider_reference_idx on payments(provider, reference);
@@ -0,0 +1,34 @@
+-- Refund lifecycle. A refund is pending while the provider is asked, pending refunds count as
+-- refunded so a second request can't return the same items twice.
+create type refund_status as enum ('pending','succeeded','failed');
+
+create table refunds (
+  id            bigserial primary key,
+  order_id      bigint not null references orders(id) on delete cascade,
+  payment_id    bigint not null references payments(id),
+  status        refund_status not null default 'pending',
+  currency      text not null,
+  amount        numeric(12,2) not null check (amount >= 0),
+  restock       boolean not null default false,   -- refunded items went back to stock_count
+  reason        text,
+  actor_ref     text,                             -- staff who issued it, 'user:<id>:<email>'
+  raw_response  jsonb,                            -- what the provider answered
+  refunded_at   timestamptz,
+  created_at    timestamptz not null default now(),
+  updated_at    timestamptz not null default now()
+);
+
+create index refunds_order_idx on refunds(order_id);
+create index refunds_payment_idx on refunds(payment_id);
+
+-- The order items a refund returns
+create table refund_items (
+  id             bigserial primary key,
+  refund_id      bigint not null references refunds(id) on delete cascade,
+  order_item_id  bigint not null references order_items(id),
+  quantity       integer not null check (quantity > 0),
+  amount         numeric(12,2) not null check (amount >= 0)
+);
+
+create index refund_items_refund_idx on refund_items(refund_id);
+create index refund_items_order_item_idx on refund_items(order_item_id);
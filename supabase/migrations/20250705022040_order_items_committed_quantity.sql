@@ -0,0 +1,12 @@
+-- Units of an order item taken out of stock_count when the order was paid. Items sold beyond
+-- the stock left commit fewer than ordered, only these go back to stock on cancel or refund.
+alter table order_items
+  add column committed_quantity integer not null default 0
+  check (committed_quantity >= 0 and committed_quantity <= quantity);
+
+-- Orders paid until now had all of their items committed, shortages were not recorded.
+update order_items oi
+set committed_quantity = oi.quantity
+from orders o
+where o.id = oi.order_id
+  and o.status in ('paid', 'processing', 'shipped', 'delivered', 'refunded');
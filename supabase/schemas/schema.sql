@@ -103,6 +103,17 @@ CREATE TYPE "public"."payment_status" AS ENUM (
 ALTER TYPE "public"."payment_status" OWNER TO "postgres";
 
 
+CREATE TYPE "public"."refund_status" AS ENUM (
+    'pending',
+    'succeeded',
+    'failed'
+);
+
+
+ALTER TYPE "public"."refund_status" OWNER TO "postgres";
+
+
+
 CREATE TYPE "public"."shipping_status" AS ENUM (
     'pending_label',
     'label_purchased',
@@ -128,6 +139,23 @@ CREATE TYPE "public"."status_actor" AS ENUM (
 ALTER TYPE "public"."status_actor" OWNER TO "postgres";
 
 
+CREATE OR REPLACE FUNCTION "public"."enqueue_order_paid_notification"() RETURNS "trigger"
+    LANGUAGE "plpgsql"
+    AS $$
+begin
+    if new.status = 'paid' and (tg_op = 'INSERT' or old.status is distinct from 'paid') then
+        insert into order_notifications (order_id, kind)
+        values (new.id, 'order_paid')
+        on conflict (order_id, kind) do nothing;
+    end if;
+    return new;
+end;
+$$;
+
+
+ALTER FUNCTION "public"."enqueue_order_paid_notification"() OWNER TO "postgres";
+
+
 CREATE OR REPLACE FUNCTION "public"."update_user_sessions_updated_at"() RETURNS "trigger"
     LANGUAGE "plpgsql"
     AS $$
@@ -210,11 +238,47 @@ ALTER SEQUENCE "public"."image_entities_id_seq" OWNED BY "public"."image_entitie
 
 
 
+CREATE TABLE IF NOT EXISTS "public"."image_processing_jobs" (
+    "id" bigint NOT NULL,
+    "image_id" bigint NOT NULL,
+    "upload_key" "text" NOT NULL,
+    "status" "text" DEFAULT 'pending'::"text" NOT NULL,
+    "attempts" integer DEFAULT 0 NOT NULL,
+    "last_error" "text",
+    "next_attempt_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "processed_at" timestamp with time zone,
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    CONSTRAINT "image_processing_jobs_status_check" CHECK (("status" = ANY (ARRAY['pending'::"text", 'done'::"text", 'failed'::"text"])))
+);
+
+
+ALTER TABLE "public"."image_processing_jobs" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."image_processing_jobs_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."image_processing_jobs_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."image_processing_jobs_id_seq" OWNED BY "public"."image_processing_jobs"."id";
+
+
+
 CREATE TABLE IF NOT EXISTS "public"."images" (
     "id" bigint NOT NULL,
     "url" "text" NOT NULL,
     "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
-    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL
+    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "width" integer,
+    "height" integer,
+    "renditions" "jsonb" DEFAULT '[]'::"jsonb" NOT NULL
 );
 
 
@@ -236,6 +300,42 @@ ALTER SEQUENCE "public"."images_id_seq" OWNED BY "public"."images"."id";
 
 
 
+CREATE TABLE IF NOT EXISTS "public"."inventory_adjustments" (
+    "id" bigint NOT NULL,
+    "entity_type" "public"."entity_type" NOT NULL,
+    "entity_id" bigint NOT NULL,
+    "sku" character varying(100) NOT NULL,
+    "adjustment_type" "text" NOT NULL,
+    "quantity" integer NOT NULL,
+    "previous_stock" integer NOT NULL,
+    "new_stock" integer NOT NULL,
+    "source" "text" DEFAULT 'telegram'::"text" NOT NULL,
+    "actor_telegram_id" bigint,
+    "actor_username" "text",
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    CONSTRAINT "inventory_adjustments_adjustment_type_check" CHECK (("adjustment_type" = ANY (ARRAY['set'::"text", 'increment'::"text"]))),
+    CONSTRAINT "inventory_adjustments_new_stock_check" CHECK (("new_stock" >= 0))
+);
+
+
+ALTER TABLE "public"."inventory_adjustments" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."inventory_adjustments_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."inventory_adjustments_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."inventory_adjustments_id_seq" OWNED BY "public"."inventory_adjustments"."id";
+
+
+
 CREATE TABLE IF NOT EXISTS "public"."order_items" (
     "id" bigint NOT NULL,
     "order_id" bigint NOT NULL,
@@ -247,6 +347,8 @@ CREATE TABLE IF NOT EXISTS "public"."order_items" (
     "quantity" integer NOT NULL,
     "line_total" numeric(10,2) GENERATED ALWAYS AS (("unit_price" * ("quantity")::numeric)) STORED,
     "metadata" "jsonb",
+    "committed_quantity" integer DEFAULT 0 NOT NULL,
+    CONSTRAINT "order_items_check" CHECK ((("committed_quantity" >= 0) AND ("committed_quantity" <= "quantity"))),
     CONSTRAINT "order_items_quantity_check" CHECK (("quantity" > 0))
 );
 
@@ -269,6 +371,69 @@ ALTER SEQUENCE "public"."order_items_id_seq" OWNED BY "public"."order_items"."id
 
 
 
+CREATE TABLE IF NOT EXISTS "public"."order_notifications" (
+    "id" bigint NOT NULL,
+    "order_id" bigint NOT NULL,
+    "kind" "text" DEFAULT 'order_paid'::"text" NOT NULL,
+    "status" "text" DEFAULT 'pending'::"text" NOT NULL,
+    "attempts" integer DEFAULT 0 NOT NULL,
+    "last_error" "text",
+    "next_attempt_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "sent_at" timestamp with time zone,
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    CONSTRAINT "order_notifications_status_check" CHECK (("status" = ANY (ARRAY['pending'::"text", 'sent'::"text", 'failed'::"text"])))
+);
+
+
+ALTER TABLE "public"."order_notifications" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."order_notifications_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."order_notifications_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."order_notifications_id_seq" OWNED BY "public"."order_notifications"."id";
+
+
+
+CREATE TABLE IF NOT EXISTS "public"."order_status_history" (
+    "id" bigint NOT NULL,
+    "order_id" bigint NOT NULL,
+    "from_status" "public"."order_status",
+    "to_status" "public"."order_status" NOT NULL,
+    "actor" "public"."status_actor" NOT NULL,
+    "actor_ref" "text",
+    "note" "text",
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL
+);
+
+
+ALTER TABLE "public"."order_status_history" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."order_status_history_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."order_status_history_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."order_status_history_id_seq" OWNED BY "public"."order_status_history"."id";
+
+
+
 CREATE TABLE IF NOT EXISTS "public"."orders" (
     "id" bigint NOT NULL,
     "order_number" bigint NOT NULL,
@@ -331,7 +496,8 @@ CREATE TABLE IF NOT EXISTS "public"."payments" (
     "captured_at" timestamp with time zone,
     "raw_response" "jsonb",
     "created_at" timestamp with time zone DEFAULT "now"(),
-    "updated_at" timestamp with time zone DEFAULT "now"()
+    "updated_at" timestamp with time zone DEFAULT "now"(),
+    "reference" "text"
 );
 
 
@@ -353,6 +519,32 @@ ALTER SEQUENCE "public"."payments_id_seq" OWNED BY "public"."payments"."id";
 
 
 
+CREATE TABLE IF NOT EXISTS "public"."permissions" (
+    "id" bigint NOT NULL,
+    "name" character varying(100) NOT NULL,
+    "description" "text",
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL
+);
+
+
+ALTER TABLE "public"."permissions" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."permissions_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."permissions_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."permissions_id_seq" OWNED BY "public"."permissions"."id";
+
+
+
 CREATE TABLE IF NOT EXISTS "public"."product_specs" (
     "id" bigint NOT NULL,
     "product_id" bigint NOT NULL,
@@ -459,6 +651,109 @@ ALTER SEQUENCE "public"."products_id_seq" OWNED BY "public"."products"."id";
 
 
 
+CREATE TABLE IF NOT EXISTS "public"."refund_items" (
+    "id" bigint NOT NULL,
+    "refund_id" bigint NOT NULL,
+    "order_item_id" bigint NOT NULL,
+    "quantity" integer NOT NULL,
+    "amount" numeric(12,2) NOT NULL,
+    CONSTRAINT "refund_items_amount_check" CHECK (("amount" >= (0)::numeric)),
+    CONSTRAINT "refund_items_quantity_check" CHECK (("quantity" > 0))
+);
+
+
+ALTER TABLE "public"."refund_items" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."refund_items_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."refund_items_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."refund_items_id_seq" OWNED BY "public"."refund_items"."id";
+
+
+
+CREATE TABLE IF NOT EXISTS "public"."refunds" (
+    "id" bigint NOT NULL,
+    "order_id" bigint NOT NULL,
+    "payment_id" bigint NOT NULL,
+    "status" "public"."refund_status" DEFAULT 'pending'::"public"."refund_status" NOT NULL,
+    "currency" "text" NOT NULL,
+    "amount" numeric(12,2) NOT NULL,
+    "restock" boolean DEFAULT false NOT NULL,
+    "reason" "text",
+    "actor_ref" "text",
+    "raw_response" "jsonb",
+    "refunded_at" timestamp with time zone,
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    CONSTRAINT "refunds_amount_check" CHECK (("amount" >= (0)::numeric))
+);
+
+
+ALTER TABLE "public"."refunds" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."refunds_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."refunds_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."refunds_id_seq" OWNED BY "public"."refunds"."id";
+
+
+
+CREATE TABLE IF NOT EXISTS "public"."role_permissions" (
+    "role_id" bigint NOT NULL,
+    "permission_id" bigint NOT NULL,
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL
+);
+
+
+ALTER TABLE "public"."role_permissions" OWNER TO "postgres";
+
+
+
+CREATE TABLE IF NOT EXISTS "public"."roles" (
+    "id" bigint NOT NULL,
+    "name" character varying(50) NOT NULL,
+    "description" "text",
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL
+);
+
+
+ALTER TABLE "public"."roles" OWNER TO "postgres";
+
+
+CREATE SEQUENCE IF NOT EXISTS "public"."roles_id_seq"
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "public"."roles_id_seq" OWNER TO "postgres";
+
+
+ALTER SEQUENCE "public"."roles_id_seq" OWNED BY "public"."roles"."id";
+
+
+
 CREATE TABLE IF NOT EXISTS "public"."shipments" (
     "id" bigint NOT NULL,
     "order_id" bigint NOT NULL,
@@ -499,6 +794,29 @@ ALTER SEQUENCE "public"."shipments_id_seq" OWNED BY "public"."shipments"."id";
 
 
 
+CREATE TABLE IF NOT EXISTS "public"."telegram_user_preferences" (
+    "user_id" bigint NOT NULL,
+    "locale" character varying(16) NOT NULL,
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL
+);
+
+
+ALTER TABLE "public"."telegram_user_preferences" OWNER TO "postgres";
+
+
+
+CREATE TABLE IF NOT EXISTS "public"."user_roles" (
+    "user_id" bigint NOT NULL,
+    "role_id" bigint NOT NULL,
+    "created_at" timestamp with time zone DEFAULT "now"() NOT NULL
+);
+
+
+ALTER TABLE "public"."user_roles" OWNER TO "postgres";
+
+
+
 CREATE TABLE IF NOT EXISTS "public"."user_sessions" (
     "id" bigint NOT NULL,
     "chat_id" bigint NOT NULL,
@@ -508,7 +826,8 @@ CREATE TABLE IF NOT EXISTS "public"."user_sessions" (
     "created_at" timestamp with time zone DEFAULT "now"() NOT NULL,
     "updated_at" timestamp with time zone DEFAULT "now"() NOT NULL,
     "expires_at" timestamp with time zone DEFAULT ("now"() + '24:00:00'::interval) NOT NULL,
-    "expected_reply_message_id" bigint
+    "expected_reply_message_id" bigint,
+    "version" integer DEFAULT 1 NOT NULL
 );
 
 
@@ -564,14 +883,30 @@ ALTER TABLE ONLY "public"."image_entities" ALTER COLUMN "id" SET DEFAULT "nextva
 
 
 
+ALTER TABLE ONLY "public"."image_processing_jobs" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."image_processing_jobs_id_seq"'::"regclass");
+
+
+
 ALTER TABLE ONLY "public"."images" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."images_id_seq"'::"regclass");
 
 
 
+ALTER TABLE ONLY "public"."inventory_adjustments" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."inventory_adjustments_id_seq"'::"regclass");
+
+
+
 ALTER TABLE ONLY "public"."order_items" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."order_items_id_seq"'::"regclass");
 
 
 
+ALTER TABLE ONLY "public"."order_notifications" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."order_notifications_id_seq"'::"regclass");
+
+
+
+ALTER TABLE ONLY "public"."order_status_history" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."order_status_history_id_seq"'::"regclass");
+
+
+
 ALTER TABLE ONLY "public"."orders" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."orders_id_seq"'::"regclass");
 
 
@@ -584,6 +919,10 @@ ALTER TABLE ONLY "public"."payments" ALTER COLUMN "id" SET DEFAULT "nextval"('"p
 
 
 
+ALTER TABLE ONLY "public"."permissions" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."permissions_id_seq"'::"regclass");
+
+
+
 ALTER TABLE ONLY "public"."product_specs" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."product_specs_id_seq"'::"regclass");
 
 
@@ -596,6 +935,18 @@ ALTER TABLE ONLY "public"."products" ALTER COLUMN "id" SET DEFAULT "nextval"('"p
 
 
 
+ALTER TABLE ONLY "public"."refund_items" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."refund_items_id_seq"'::"regclass");
+
+
+
+ALTER TABLE ONLY "public"."refunds" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."refunds_id_seq"'::"regclass");
+
+
+
+ALTER TABLE ONLY "public"."roles" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."roles_id_seq"'::"regclass");
+
+
+
 ALTER TABLE ONLY "public"."shipments" ALTER COLUMN "id" SET DEFAULT "nextval"('"public"."shipments_id_seq"'::"regclass");
 
 
@@ -609,16 +960,51 @@ ALTER TABLE ONLY "public"."addresses"
 
 
 
+ALTER TABLE ONLY "public"."image_processing_jobs"
+    ADD CONSTRAINT "image_processing_jobs_image_id_key" UNIQUE ("image_id");
+
+
+
+ALTER TABLE ONLY "public"."image_processing_jobs"
+    ADD CONSTRAINT "image_processing_jobs_pkey" PRIMARY KEY ("id");
+
+
+
+ALTER TABLE ONLY "public"."image_processing_jobs"
+    ADD CONSTRAINT "image_processing_jobs_upload_key_key" UNIQUE ("upload_key");
+
+
+
 ALTER TABLE ONLY "public"."images"
     ADD CONSTRAINT "images_pkey" PRIMARY KEY ("id");
 
 
 
+ALTER TABLE ONLY "public"."inventory_adjustments"
+    ADD CONSTRAINT "inventory_adjustments_pkey" PRIMARY KEY ("id");
+
+
+
 ALTER TABLE ONLY "public"."order_items"
     ADD CONSTRAINT "order_items_pkey" PRIMARY KEY ("id");
 
 
 
+ALTER TABLE ONLY "public"."order_notifications"
+    ADD CONSTRAINT "order_notifications_order_id_kind_key" UNIQUE ("order_id", "kind");
+
+
+
+ALTER TABLE ONLY "public"."order_notifications"
+    ADD CONSTRAINT "order_notifications_pkey" PRIMARY KEY ("id");
+
+
+
+ALTER TABLE ONLY "public"."order_status_history"
+    ADD CONSTRAINT "order_status_history_pkey" PRIMARY KEY ("id");
+
+
+
 ALTER TABLE ONLY "public"."orders"
     ADD CONSTRAINT "orders_pkey" PRIMARY KEY ("id");
 
@@ -629,6 +1015,16 @@ ALTER TABLE ONLY "public"."payments"
 
 
 
+ALTER TABLE ONLY "public"."permissions"
+    ADD CONSTRAINT "permissions_name_key" UNIQUE ("name");
+
+
+
+ALTER TABLE ONLY "public"."permissions"
+    ADD CONSTRAINT "permissions_pkey" PRIMARY KEY ("id");
+
+
+
 ALTER TABLE ONLY "public"."image_entities"
     ADD CONSTRAINT "product_images_pkey" PRIMARY KEY ("id");
 
@@ -664,6 +1060,31 @@ ALTER TABLE ONLY "public"."products"
 
 
 
+ALTER TABLE ONLY "public"."refund_items"
+    ADD CONSTRAINT "refund_items_pkey" PRIMARY KEY ("id");
+
+
+
+ALTER TABLE ONLY "public"."refunds"
+    ADD CONSTRAINT "refunds_pkey" PRIMARY KEY ("id");
+
+
+
+ALTER TABLE ONLY "public"."role_permissions"
+    ADD CONSTRAINT "role_permissions_pkey" PRIMARY KEY ("role_id", "permission_id");
+
+
+
+ALTER TABLE ONLY "public"."roles"
+    ADD CONSTRAINT "roles_name_key" UNIQUE ("name");
+
+
+
+ALTER TABLE ONLY "public"."roles"
+    ADD CONSTRAINT "roles_pkey" PRIMARY KEY ("id");
+
+
+
 ALTER TABLE ONLY "public"."shipments"
     ADD CONSTRAINT "shipments_pkey" PRIMARY KEY ("id");
 
@@ -674,11 +1095,21 @@ ALTER TABLE ONLY "public"."shipments"
 
 
 
+ALTER TABLE ONLY "public"."telegram_user_preferences"
+    ADD CONSTRAINT "telegram_user_preferences_pkey" PRIMARY KEY ("user_id");
+
+
+
 ALTER TABLE ONLY "public"."product_specs"
     ADD CONSTRAINT "unique_product_spec" UNIQUE ("product_id", "spec_name");
 
 
 
+ALTER TABLE ONLY "public"."user_roles"
+    ADD CONSTRAINT "user_roles_pkey" PRIMARY KEY ("user_id", "role_id");
+
+
+
 ALTER TABLE ONLY "public"."user_sessions"
     ADD CONSTRAINT "user_sessions_pkey" PRIMARY KEY ("id");
 
@@ -767,6 +1198,10 @@ CREATE INDEX "idx_products_uuid" ON "public"."products" USING "btree" ("uuid");
 
 
 
+CREATE INDEX "idx_user_sessions_chat_user" ON "public"."user_sessions" USING "btree" ("chat_id", "user_id");
+
+
+
 CREATE INDEX "idx_user_sessions_expires_at" ON "public"."user_sessions" USING "btree" ("expires_at");
 
 
@@ -775,6 +1210,22 @@ CREATE INDEX "idx_user_sessions_user_id" ON "public"."user_sessions" USING "btre
 
 
 
+CREATE UNIQUE INDEX "image_entities_one_primary_idx" ON "public"."image_entities" USING "btree" ("entity_type", "entity_id") WHERE "is_primary";
+
+
+
+CREATE INDEX "image_processing_jobs_pending_idx" ON "public"."image_processing_jobs" USING "btree" ("next_attempt_at") WHERE ("status" = 'pending'::"text");
+
+
+
+CREATE INDEX "inventory_adjustments_created_idx" ON "public"."inventory_adjustments" USING "btree" ("created_at" DESC);
+
+
+
+CREATE INDEX "inventory_adjustments_entity_idx" ON "public"."inventory_adjustments" USING "btree" ("entity_type", "entity_id");
+
+
+
 CREATE INDEX "order_items_order_idx" ON "public"."order_items" USING "btree" ("order_id");
 
 
@@ -787,6 +1238,14 @@ CREATE INDEX "order_items_variant_idx" ON "public"."order_items" USING "btree" (
 
 
 
+CREATE INDEX "order_notifications_pending_idx" ON "public"."order_notifications" USING "btree" ("next_attempt_at") WHERE ("status" = 'pending'::"text");
+
+
+
+CREATE INDEX "order_status_history_order_idx" ON "public"."order_status_history" USING "btree" ("order_id", "created_at");
+
+
+
 CREATE INDEX "orders_created_idx" ON "public"."orders" USING "btree" ("created_at" DESC);
 
 
@@ -795,10 +1254,38 @@ CREATE INDEX "payments_order_idx" ON "public"."payments" USING "btree" ("order_i
 
 
 
+CREATE UNIQUE INDEX "payments_provider_reference_idx" ON "public"."payments" USING "btree" ("provider", "reference");
+
+
+
+CREATE INDEX "refund_items_order_item_idx" ON "public"."refund_items" USING "btree" ("order_item_id");
+
+
+
+CREATE INDEX "refund_items_refund_idx" ON "public"."refund_items" USING "btree" ("refund_id");
+
+
+
+CREATE INDEX "refunds_order_idx" ON "public"."refunds" USING "btree" ("order_id");
+
+
+
+CREATE INDEX "refunds_payment_idx" ON "public"."refunds" USING "btree" ("payment_id");
+
+
+
 CREATE INDEX "shipments_order_idx" ON "public"."shipments" USING "btree" ("order_id");
 
 
 
+CREATE INDEX "user_roles_role_id_idx" ON "public"."user_roles" USING "btree" ("role_id");
+
+
+
+CREATE OR REPLACE TRIGGER "enqueue_order_paid_notification" AFTER INSERT OR UPDATE OF "status" ON "public"."orders" FOR EACH ROW EXECUTE FUNCTION "public"."enqueue_order_paid_notification"();
+
+
+
 CREATE OR REPLACE TRIGGER "update_user_sessions_updated_at" BEFORE UPDATE ON "public"."user_sessions" FOR EACH ROW EXECUTE FUNCTION "public"."update_user_sessions_updated_at"();
 
 
@@ -808,6 +1295,11 @@ ALTER TABLE ONLY "public"."image_entities"
 
 
 
+ALTER TABLE ONLY "public"."image_processing_jobs"
+    ADD CONSTRAINT "image_processing_jobs_image_id_fkey" FOREIGN KEY ("image_id") REFERENCES "public"."images"("id") ON DELETE CASCADE;
+
+
+
 ALTER TABLE ONLY "public"."order_items"
     ADD CONSTRAINT "order_items_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "public"."orders"("id") ON DELETE CASCADE;
 
@@ -823,6 +1315,16 @@ ALTER TABLE ONLY "public"."order_items"
 
 
 
+ALTER TABLE ONLY "public"."order_notifications"
+    ADD CONSTRAINT "order_notifications_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "public"."orders"("id") ON DELETE CASCADE;
+
+
+
+ALTER TABLE ONLY "public"."order_status_history"
+    ADD CONSTRAINT "order_status_history_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "public"."orders"("id") ON DELETE CASCADE;
+
+
+
 ALTER TABLE ONLY "public"."payments"
     ADD CONSTRAINT "payments_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "public"."orders"("id") ON DELETE CASCADE;
 
@@ -838,6 +1340,36 @@ ALTER TABLE ONLY "public"."product_variants"
 
 
 
+ALTER TABLE ONLY "public"."refund_items"
+    ADD CONSTRAINT "refund_items_order_item_id_fkey" FOREIGN KEY ("order_item_id") REFERENCES "public"."order_items"("id");
+
+
+
+ALTER TABLE ONLY "public"."refund_items"
+    ADD CONSTRAINT "refund_items_refund_id_fkey" FOREIGN KEY ("refund_id") REFERENCES "public"."refunds"("id") ON DELETE CASCADE;
+
+
+
+ALTER TABLE ONLY "public"."refunds"
+    ADD CONSTRAINT "refunds_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "public"."orders"("id") ON DELETE CASCADE;
+
+
+
+ALTER TABLE ONLY "public"."refunds"
+    ADD CONSTRAINT "refunds_payment_id_fkey" FOREIGN KEY ("payment_id") REFERENCES "public"."payments"("id");
+
+
+
+ALTER TABLE ONLY "public"."role_permissions"
+    ADD CONSTRAINT "role_permissions_permission_id_fkey" FOREIGN KEY ("permission_id") REFERENCES "public"."permissions"("id") ON DELETE CASCADE;
+
+
+
+ALTER TABLE ONLY "public"."role_permissions"
+    ADD CONSTRAINT "role_permissions_role_id_fkey" FOREIGN KEY ("role_id") REFERENCES "public"."roles"("id") ON DELETE CASCADE;
+
+
+
 ALTER TABLE ONLY "public"."shipments"
     ADD CONSTRAINT "shipments_address_id_fkey" FOREIGN KEY ("address_id") REFERENCES "public"."addresses"("id");
 
@@ -848,6 +1380,16 @@ ALTER TABLE ONLY "public"."shipments"
 
 
 
+ALTER TABLE ONLY "public"."user_roles"
+    ADD CONSTRAINT "user_roles_role_id_fkey" FOREIGN KEY ("role_id") REFERENCES "public"."roles"("id") ON DELETE CASCADE;
+
+
+
+ALTER TABLE ONLY "public"."user_roles"
+    ADD CONSTRAINT "user_roles_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users"("id") ON DELETE CASCADE;
+
+
+
 
 
 ALTER PUBLICATION "supabase_realtime" OWNER TO "postgres";
@@ -1007,6 +1549,12 @@ GRANT USAGE ON SCHEMA "public" TO "service_role";
 
 
 
+
+
+
+GRANT ALL ON FUNCTION "public"."enqueue_order_paid_notification"() TO "anon";
+GRANT ALL ON FUNCTION "public"."enqueue_order_paid_notification"() TO "authenticated";
+GRANT ALL ON FUNCTION "public"."enqueue_order_paid_notification"() TO "service_role";
 
 
 
@@ -1055,6 +1603,18 @@ GRANT ALL ON SEQUENCE "public"."image_entities_id_seq" TO "service_role";
 
 
 
+GRANT ALL ON TABLE "public"."image_processing_jobs" TO "anon";
+GRANT ALL ON TABLE "public"."image_processing_jobs" TO "authenticated";
+GRANT ALL ON TABLE "public"."image_processing_jobs" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."image_processing_jobs_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."image_processing_jobs_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."image_processing_jobs_id_seq" TO "service_role";
+
+
+
 GRANT ALL ON TABLE "public"."images" TO "anon";
 GRANT ALL ON TABLE "public"."images" TO "authenticated";
 GRANT ALL ON TABLE "public"."images" TO "service_role";
@@ -1067,6 +1627,18 @@ GRANT ALL ON SEQUENCE "public"."images_id_seq" TO "service_role";
 
 
 
+GRANT ALL ON TABLE "public"."inventory_adjustments" TO "anon";
+GRANT ALL ON TABLE "public"."inventory_adjustments" TO "authenticated";
+GRANT ALL ON TABLE "public"."inventory_adjustments" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."inventory_adjustments_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."inventory_adjustments_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."inventory_adjustments_id_seq" TO "service_role";
+
+
+
 GRANT ALL ON TABLE "public"."order_items" TO "anon";
 GRANT ALL ON TABLE "public"."order_items" TO "authenticated";
 GRANT ALL ON TABLE "public"."order_items" TO "service_role";
@@ -1079,6 +1651,30 @@ GRANT ALL ON SEQUENCE "public"."order_items_id_seq" TO "service_role";
 
 
 
+GRANT ALL ON TABLE "public"."order_notifications" TO "anon";
+GRANT ALL ON TABLE "public"."order_notifications" TO "authenticated";
+GRANT ALL ON TABLE "public"."order_notifications" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."order_notifications_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."order_notifications_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."order_notifications_id_seq" TO "service_role";
+
+
+
+GRANT ALL ON TABLE "public"."order_status_history" TO "anon";
+GRANT ALL ON TABLE "public"."order_status_history" TO "authenticated";
+GRANT ALL ON TABLE "public"."order_status_history" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."order_status_history_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."order_status_history_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."order_status_history_id_seq" TO "service_role";
+
+
+
 GRANT ALL ON TABLE "public"."orders" TO "anon";
 GRANT ALL ON TABLE "public"."orders" TO "authenticated";
 GRANT ALL ON TABLE "public"."orders" TO "service_role";
@@ -1109,6 +1705,18 @@ GRANT ALL ON SEQUENCE "public"."payments_id_seq" TO "service_role";
 
 
 
+GRANT ALL ON TABLE "public"."permissions" TO "anon";
+GRANT ALL ON TABLE "public"."permissions" TO "authenticated";
+GRANT ALL ON TABLE "public"."permissions" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."permissions_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."permissions_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."permissions_id_seq" TO "service_role";
+
+
+
 GRANT ALL ON TABLE "public"."product_specs" TO "anon";
 GRANT ALL ON TABLE "public"."product_specs" TO "authenticated";
 GRANT ALL ON TABLE "public"."product_specs" TO "service_role";
@@ -1145,6 +1753,48 @@ GRANT ALL ON SEQUENCE "public"."products_id_seq" TO "service_role";
 
 
 
+GRANT ALL ON TABLE "public"."refund_items" TO "anon";
+GRANT ALL ON TABLE "public"."refund_items" TO "authenticated";
+GRANT ALL ON TABLE "public"."refund_items" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."refund_items_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."refund_items_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."refund_items_id_seq" TO "service_role";
+
+
+
+GRANT ALL ON TABLE "public"."refunds" TO "anon";
+GRANT ALL ON TABLE "public"."refunds" TO "authenticated";
+GRANT ALL ON TABLE "public"."refunds" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."refunds_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."refunds_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."refunds_id_seq" TO "service_role";
+
+
+
+GRANT ALL ON TABLE "public"."role_permissions" TO "anon";
+GRANT ALL ON TABLE "public"."role_permissions" TO "authenticated";
+GRANT ALL ON TABLE "public"."role_permissions" TO "service_role";
+
+
+
+GRANT ALL ON TABLE "public"."roles" TO "anon";
+GRANT ALL ON TABLE "public"."roles" TO "authenticated";
+GRANT ALL ON TABLE "public"."roles" TO "service_role";
+
+
+
+GRANT ALL ON SEQUENCE "public"."roles_id_seq" TO "anon";
+GRANT ALL ON SEQUENCE "public"."roles_id_seq" TO "authenticated";
+GRANT ALL ON SEQUENCE "public"."roles_id_seq" TO "service_role";
+
+
+
 GRANT ALL ON TABLE "public"."shipments" TO "anon";
 GRANT ALL ON TABLE "public"."shipments" TO "authenticated";
 GRANT ALL ON TABLE "public"."shipments" TO "service_role";
@@ -1157,6 +1807,18 @@ GRANT ALL ON SEQUENCE "public"."shipments_id_seq" TO "service_role";
 
 
 
+GRANT ALL ON TABLE "public"."telegram_user_preferences" TO "anon";
+GRANT ALL ON TABLE "public"."telegram_user_preferences" TO "authenticated";
+GRANT ALL ON TABLE "public"."telegram_user_preferences" TO "service_role";
+
+
+
+GRANT ALL ON TABLE "public"."user_roles" TO "anon";
+GRANT ALL ON TABLE "public"."user_roles" TO "authenticated";
+GRANT ALL ON TABLE "public"."user_roles" TO "service_role";
+
+
+
 GRANT ALL ON TABLE "public"."user_sessions" TO "anon";
 GRANT ALL ON TABLE "public"."user_sessions" TO "authenticated";
 GRANT ALL ON TABLE "public"."user_sessions" TO "service_role";